@@ -2,9 +2,13 @@ package rest
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"yadro.com/course/api/adapters/rest/middleware"
 	"yadro.com/course/api/core"
@@ -68,6 +72,315 @@ func NewUpdateHandler(log *slog.Logger, updater core.Updater, verifier core.Toke
 	return middleware.Auth(handler, verifier)
 }
 
+func NewReindexHandler(log *slog.Logger, updater core.Updater, verifier core.TokenVerifier) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		err := updater.Reindex(r.Context())
+		switch {
+		case err == nil:
+		case errors.Is(err, core.ErrAlreadyExists):
+			http.Error(w, "update or reindex is already running", http.StatusAccepted)
+		default:
+			log.Error("failed to reindex", "error", err)
+			http.Error(w, "failed to reindex", http.StatusInternalServerError)
+		}
+	}
+
+	return middleware.Auth(handler, verifier)
+}
+
+func NewExportHandler(log *slog.Logger, updater core.Updater, verifier core.TokenVerifier) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="comics.jsonl"`)
+
+		enc := json.NewEncoder(w)
+		written := false
+		err := updater.Export(r.Context(), func(comic core.ComicRecord) error {
+			written = true
+			return enc.Encode(comic)
+		})
+		if err != nil {
+			log.Error("failed to export", "error", err)
+			// the status line is already sent once the first comic is written
+			if !written {
+				w.Header().Del("Content-Disposition")
+				http.Error(w, "failed to export", http.StatusInternalServerError)
+			}
+		}
+	}
+
+	return middleware.Auth(handler, verifier)
+}
+
+func NewImportHandler(log *slog.Logger, updater core.Updater, verifier core.TokenVerifier) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		dec := json.NewDecoder(r.Body)
+		imported, err := updater.Import(r.Context(), func() (core.ComicRecord, error) {
+			var comic core.ComicRecord
+			if err := dec.Decode(&comic); err != nil {
+				if errors.Is(err, io.EOF) {
+					return comic, io.EOF
+				}
+				return comic, fmt.Errorf("%w: %v", core.ErrBadArguments, err)
+			}
+			return comic, nil
+		})
+		if err != nil {
+			log.Error("failed to import", "error", err)
+			switch {
+			case errors.Is(err, core.ErrBadArguments):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			case errors.Is(err, core.ErrAlreadyExists):
+				http.Error(w, "update or import is already running", http.StatusConflict)
+			default:
+				http.Error(w, "failed to import", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]int{"imported": imported}); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+
+	return middleware.Auth(handler, verifier)
+}
+
+func NewVerifyHandler(log *slog.Logger, updater core.Updater, verifier core.TokenVerifier) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		repair := false
+		if value := r.URL.Query().Get("repair"); value != "" {
+			var err error
+			if repair, err = strconv.ParseBool(value); err != nil {
+				http.Error(w, "bad repair", http.StatusBadRequest)
+				return
+			}
+		}
+
+		reports, err := updater.Verify(r.Context(), repair)
+		if err != nil {
+			log.Error("failed to verify", "error", err)
+			if errors.Is(err, core.ErrAlreadyExists) {
+				http.Error(w, "update is already running", http.StatusConflict)
+				return
+			}
+			http.Error(w, "failed to verify", http.StatusInternalServerError)
+			return
+		}
+
+		sources := make([]map[string]interface{}, 0, len(reports))
+		for _, report := range reports {
+			sources = append(sources, map[string]interface{}{
+				"source":   report.Source,
+				"total":    report.Total,
+				"missing":  report.Missing,
+				"empty":    report.Empty,
+				"repaired": report.Repaired,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{"sources": sources}); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+
+	return middleware.Auth(handler, verifier)
+}
+
+func NewRefreshHandler(log *slog.Logger, updater core.Updater, verifier core.TokenVerifier) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		changed, err := updater.Refresh(r.Context())
+		if err != nil {
+			if errors.Is(err, core.ErrAlreadyExists) {
+				http.Error(w, "update is already running", http.StatusConflict)
+				return
+			}
+			log.Error("failed to refresh", "error", err)
+			http.Error(w, "failed to refresh", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]int{"changed": changed}); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+
+	return middleware.Auth(handler, verifier)
+}
+
+func NewHistoryHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id < 1 {
+			http.Error(w, "bad id", http.StatusBadRequest)
+			return
+		}
+		source := r.URL.Query().Get("source")
+		if source == "" {
+			source = core.DefaultSource
+		}
+
+		versions, err := updater.History(r.Context(), source, id)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				http.Error(w, "comic not found", http.StatusNotFound)
+				return
+			}
+			log.Error("failed to get history", "error", err)
+			http.Error(w, "failed to get history", http.StatusInternalServerError)
+			return
+		}
+
+		resp := map[string]interface{}{
+			"id":       id,
+			"source":   source,
+			"versions": make([]map[string]interface{}, 0, len(versions)),
+		}
+		for _, v := range versions {
+			resp["versions"] = append(resp["versions"].([]map[string]interface{}), map[string]interface{}{
+				"url":          v.URL,
+				"title":        v.Title,
+				"safe_title":   v.SafeTitle,
+				"alt":          v.Alt,
+				"transcript":   v.Transcript,
+				"content_hash": v.ContentHash,
+				"replaced_at":  v.ReplacedAt,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+}
+
+// NewTagsHandler lists the tags of the comic.
+func NewTagsHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := comicID(w, r)
+		if !ok {
+			return
+		}
+
+		tags, err := updater.Tags(r.Context(), id.Source, id.ID)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				http.Error(w, "comic not found", http.StatusNotFound)
+				return
+			}
+			log.Error("failed to get tags", "error", err)
+			http.Error(w, "failed to get tags", http.StatusInternalServerError)
+			return
+		}
+
+		resp := map[string]interface{}{
+			"id":     id.ID,
+			"source": id.Source,
+			"tags":   make([]map[string]interface{}, 0, len(tags)),
+		}
+		for _, tag := range tags {
+			resp["tags"] = append(resp["tags"].([]map[string]interface{}), tagResult(tag))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+}
+
+// NewAddTagHandler attaches the tag of the body, {"tag": "black hat"}, to
+// the comic.
+func NewAddTagHandler(log *slog.Logger, updater core.Updater, verifier core.TokenVerifier) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		id, ok := comicID(w, r)
+		if !ok {
+			return
+		}
+		var req struct {
+			Tag string `json:"tag"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad tag", http.StatusBadRequest)
+			return
+		}
+
+		tag, err := updater.AddTag(r.Context(), id.Source, id.ID, req.Tag)
+		if err != nil {
+			tagError(w, log, "failed to add tag", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(tagResult(tag)); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+
+	return middleware.Auth(handler, verifier)
+}
+
+func NewRemoveTagHandler(log *slog.Logger, updater core.Updater, verifier core.TokenVerifier) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		id, ok := comicID(w, r)
+		if !ok {
+			return
+		}
+		if err := updater.RemoveTag(r.Context(), id.Source, id.ID, r.PathValue("tag")); err != nil {
+			tagError(w, log, "failed to remove tag", err)
+		}
+	}
+
+	return middleware.Auth(handler, verifier)
+}
+
+// NewTagCountsHandler lists every tag with the number of its comics, the
+// most used first.
+func NewTagCountsHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		counts, err := updater.TagCounts(r.Context())
+		if err != nil {
+			log.Error("failed to count tags", "error", err)
+			http.Error(w, "failed to count tags", http.StatusInternalServerError)
+			return
+		}
+
+		tags := make([]map[string]interface{}, 0, len(counts))
+		for _, c := range counts {
+			tags = append(tags, map[string]interface{}{"tag": c.Tag, "comics": c.Comics})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{"tags": tags}); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+}
+
+func tagResult(tag core.Tag) map[string]interface{} {
+	return map[string]interface{}{
+		"name":     tag.Name,
+		"words":    tag.Words,
+		"added_at": tag.AddedAt,
+	}
+}
+
+func tagError(w http.ResponseWriter, log *slog.Logger, msg string, err error) {
+	switch {
+	case errors.Is(err, core.ErrNotFound):
+		http.Error(w, "comic or tag not found", http.StatusNotFound)
+	case errors.Is(err, core.ErrBadArguments):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Error(msg, "error", err)
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
+
 func NewUpdateStatsHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		stats, err := updater.Stats(r.Context())
@@ -77,11 +390,22 @@ func NewUpdateStatsHandler(log *slog.Logger, updater core.Updater) http.HandlerF
 			return
 		}
 
-		resp := map[string]int{
+		pipeline := make([]map[string]interface{}, 0, len(stats.Pipeline))
+		for _, m := range stats.Pipeline {
+			pipeline = append(pipeline, map[string]interface{}{
+				"stage":      m.Stage,
+				"items":      m.Items,
+				"errors":     m.Errors,
+				"seconds":    m.Seconds,
+				"per_second": m.PerSecond,
+			})
+		}
+		resp := map[string]interface{}{
 			"words_total":    stats.WordsTotal,
 			"words_unique":   stats.WordsUnique,
 			"comics_fetched": stats.ComicsFetched,
 			"comics_total":   stats.ComicsTotal,
+			"pipeline":       pipeline,
 		}
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
@@ -90,6 +414,31 @@ func NewUpdateStatsHandler(log *slog.Logger, updater core.Updater) http.HandlerF
 	}
 }
 
+// NewCacheStatsHandler reports the lookups of the search result cache.
+func NewCacheStatsHandler(log *slog.Logger, searcher core.Searcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats, err := searcher.CacheStats(r.Context())
+		if err != nil {
+			log.Error("failed to get cache stats", "error", err)
+			http.Error(w, "failed to get cache stats", http.StatusInternalServerError)
+			return
+		}
+
+		resp := map[string]interface{}{
+			"hits":      stats.Hits,
+			"misses":    stats.Misses,
+			"evictions": stats.Evictions,
+			"purges":    stats.Purges,
+			"size":      stats.Size,
+			"capacity":  stats.Capacity,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("failed to encode cache stats", "error", err)
+		}
+	}
+}
+
 func NewUpdateStatusHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -138,7 +487,13 @@ func NewSearchHandler(log *slog.Logger, searcher core.Searcher, concurrencyLimit
 			return
 		}
 
-		comics, err := searcher.Search(r.Context(), num, phrase)
+		opts, err := searchOptions(r)
+		if err != nil {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		found, err := searcher.Search(r.Context(), num, phrase, opts)
 		if err != nil {
 			log.Error("arguments are not acceptable", "error", err)
 			http.Error(w, "Bad arguments", http.StatusBadRequest)
@@ -146,15 +501,18 @@ func NewSearchHandler(log *slog.Logger, searcher core.Searcher, concurrencyLimit
 		}
 
 		resp := map[string]interface{}{
-			"comics": make([]map[string]interface{}, 0, len(comics)),
-			"total":  len(comics),
+			"comics": make([]map[string]interface{}, 0, len(found.Comics)),
+			"total":  len(found.Comics),
+		}
+		if found.Relaxation != nil {
+			resp["relaxation"] = map[string]interface{}{
+				"relax":  found.Relaxation.Relax,
+				"phrase": found.Relaxation.Phrase(),
+			}
 		}
 
-		for _, comic := range comics {
-			resp["comics"] = append(resp["comics"].([]map[string]interface{}), map[string]interface{}{
-				"id":  comic.ID,
-				"url": comic.URL,
-			})
+		for _, comic := range found.Comics {
+			resp["comics"] = append(resp["comics"].([]map[string]interface{}), searchResult(comic))
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -166,6 +524,89 @@ func NewSearchHandler(log *slog.Logger, searcher core.Searcher, concurrencyLimit
 	return middleware.Concurrency(handler, concurrencyLimit)
 }
 
+// searchOptions reads the optional bounds and order of a search: from and
+// to as YYYY-MM-DD dates, min_id, max_id, sort, match (all or any words,
+// the default) and no_cache. The client comes from the Client middleware.
+func searchOptions(r *http.Request) (core.SearchOptions, error) {
+	opts := core.SearchOptions{Client: middleware.ClientFrom(r.Context())}
+	q := r.URL.Query()
+
+	for _, bound := range []struct {
+		name string
+		date *time.Time
+	}{{"from", &opts.From}, {"to", &opts.To}} {
+		if v := q.Get(bound.name); v != "" {
+			date, err := time.Parse(time.DateOnly, v)
+			if err != nil {
+				return core.SearchOptions{}, fmt.Errorf("%w: %s: %v", core.ErrBadArguments, bound.name, err)
+			}
+			*bound.date = date
+		}
+	}
+	for _, bound := range []struct {
+		name string
+		id   *int
+	}{{"min_id", &opts.MinID}, {"max_id", &opts.MaxID}} {
+		if v := q.Get(bound.name); v != "" {
+			id, err := strconv.Atoi(v)
+			if err != nil || id < 1 {
+				return core.SearchOptions{}, fmt.Errorf("%w: %s must be a positive number", core.ErrBadArguments, bound.name)
+			}
+			*bound.id = id
+		}
+	}
+
+	switch opts.Sort = q.Get("sort"); opts.Sort {
+	case "", core.SortRelevance, core.SortNewest, core.SortOldest:
+	default:
+		return core.SearchOptions{}, fmt.Errorf("%w: unknown sort %q", core.ErrBadArguments, opts.Sort)
+	}
+
+	switch match := q.Get("match"); match {
+	case "", "any":
+	case "all":
+		opts.All = true
+	default:
+		return core.SearchOptions{}, fmt.Errorf("%w: unknown match %q", core.ErrBadArguments, match)
+	}
+
+	if v := q.Get("no_cache"); v != "" {
+		noCache, err := strconv.ParseBool(v)
+		if err != nil {
+			return core.SearchOptions{}, fmt.Errorf("%w: no_cache: %v", core.ErrBadArguments, err)
+		}
+		opts.NoCache = noCache
+	}
+	return opts, nil
+}
+
+// searchResult is one found comic of a search response. The publication
+// date and the snippet are left out when the comic has none.
+func searchResult(comic core.Comics) map[string]interface{} {
+	result := map[string]interface{}{
+		"id":     comic.ID,
+		"source": comic.Source,
+		"url":    comic.URL,
+	}
+	if comic.Published != nil {
+		result["published"] = comic.Published.Format(time.DateOnly)
+	}
+	if comic.Snippet.Field == "" {
+		return result
+	}
+
+	highlights := make([][2]int, 0, len(comic.Snippet.Highlights))
+	for _, h := range comic.Snippet.Highlights {
+		highlights = append(highlights, [2]int{h.Start, h.End})
+	}
+	result["snippet"] = map[string]interface{}{
+		"field":      comic.Snippet.Field,
+		"text":       comic.Snippet.Text,
+		"highlights": highlights,
+	}
+	return result
+}
+
 func NewIndexSearchHandler(log *slog.Logger, searcher core.Searcher, rateLimit int) http.HandlerFunc {
 	handler := func(w http.ResponseWriter, r *http.Request) {
 		phrase := r.URL.Query().Get("phrase")
@@ -185,9 +626,15 @@ func NewIndexSearchHandler(log *slog.Logger, searcher core.Searcher, rateLimit i
 			return
 		}
 
-		comics, err := searcher.IndexSearch(r.Context(), num, phrase)
+		opts, err := searchOptions(r)
+		if err != nil {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		found, err := searcher.IndexSearch(r.Context(), num, phrase, opts)
 		if err != nil {
-			if len(comics) == 0 {
+			if len(found.Comics) == 0 {
 				return
 			}
 			log.Error("arguments are not acceptable", "error", err)
@@ -196,15 +643,18 @@ func NewIndexSearchHandler(log *slog.Logger, searcher core.Searcher, rateLimit i
 		}
 
 		resp := map[string]interface{}{
-			"comics": make([]map[string]interface{}, 0, len(comics)),
-			"total":  len(comics),
+			"comics": make([]map[string]interface{}, 0, len(found.Comics)),
+			"total":  len(found.Comics),
+		}
+		if found.Relaxation != nil {
+			resp["relaxation"] = map[string]interface{}{
+				"relax":  found.Relaxation.Relax,
+				"phrase": found.Relaxation.Phrase(),
+			}
 		}
 
-		for _, comic := range comics {
-			resp["comics"] = append(resp["comics"].([]map[string]interface{}), map[string]interface{}{
-				"id":  comic.ID,
-				"url": comic.URL,
-			})
+		for _, comic := range found.Comics {
+			resp["comics"] = append(resp["comics"].([]map[string]interface{}), searchResult(comic))
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -215,3 +665,668 @@ func NewIndexSearchHandler(log *slog.Logger, searcher core.Searcher, rateLimit i
 
 	return middleware.Rate(handler, rateLimit)
 }
+
+// NewFTSSearchHandler searches with the PostgreSQL full-text search. The
+// phrase may use the web search syntax: quotes, "or" and "-".
+func NewFTSSearchHandler(log *slog.Logger, searcher core.Searcher, rateLimit int) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		phrase := r.URL.Query().Get("phrase")
+		if phrase == "" {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		limit := r.URL.Query().Get("limit")
+		if limit == "" {
+			limit = "10"
+		}
+
+		num, err := strconv.Atoi(limit)
+		if err != nil {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		opts, err := searchOptions(r)
+		if err != nil {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		comics, err := searcher.FTSSearch(r.Context(), num, phrase, opts)
+		if err != nil {
+			log.Error("failed to full-text search", "error", err)
+			http.Error(w, "failed to search", http.StatusInternalServerError)
+			return
+		}
+
+		resp := map[string]interface{}{
+			"comics": make([]map[string]interface{}, 0, len(comics)),
+			"total":  len(comics),
+		}
+
+		for _, comic := range comics {
+			resp["comics"] = append(resp["comics"].([]map[string]interface{}), searchResult(comic))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+
+	return middleware.Rate(handler, rateLimit)
+}
+
+// NewHybridSearchHandler searches with all the engines at once, fusing
+// their results. The engines that failed or timed out are listed in
+// degraded, the comics are found by the others.
+func NewHybridSearchHandler(log *slog.Logger, searcher core.Searcher, rateLimit int) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		phrase := r.URL.Query().Get("phrase")
+		if phrase == "" {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		limit := r.URL.Query().Get("limit")
+		if limit == "" {
+			limit = "10"
+		}
+
+		num, err := strconv.Atoi(limit)
+		if err != nil {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		opts, err := searchOptions(r)
+		if err != nil {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		hybrid, err := searcher.HybridSearch(r.Context(), num, phrase, opts)
+		if err != nil {
+			if errors.Is(err, core.ErrBadArguments) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Error("failed to hybrid search", "error", err)
+			http.Error(w, "failed to search", http.StatusInternalServerError)
+			return
+		}
+
+		resp := map[string]interface{}{
+			"comics":   make([]map[string]interface{}, 0, len(hybrid.Comics)),
+			"total":    len(hybrid.Comics),
+			"degraded": append([]string{}, hybrid.Degraded...),
+		}
+
+		for _, comic := range hybrid.Comics {
+			resp["comics"] = append(resp["comics"].([]map[string]interface{}), searchResult(comic))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+
+	return middleware.Rate(handler, rateLimit)
+}
+
+// NewSemanticSearchHandler searches by meaning, so the comics found need not
+// have the words of the phrase.
+func NewSemanticSearchHandler(log *slog.Logger, searcher core.Searcher, rateLimit int) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		phrase := r.URL.Query().Get("phrase")
+		if phrase == "" {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		limit := r.URL.Query().Get("limit")
+		if limit == "" {
+			limit = "10"
+		}
+
+		num, err := strconv.Atoi(limit)
+		if err != nil {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		opts, err := searchOptions(r)
+		if err != nil {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		comics, err := searcher.SemanticSearch(r.Context(), num, phrase, opts)
+		if err != nil {
+			if errors.Is(err, core.ErrBadArguments) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Error("failed to semantic search", "error", err)
+			http.Error(w, "failed to search", http.StatusInternalServerError)
+			return
+		}
+
+		resp := map[string]interface{}{
+			"comics": make([]map[string]interface{}, 0, len(comics)),
+			"total":  len(comics),
+		}
+
+		for _, comic := range comics {
+			resp["comics"] = append(resp["comics"].([]map[string]interface{}), searchResult(comic))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+
+	return middleware.Rate(handler, rateLimit)
+}
+
+// Explainable serves the requests with explain=true by explain and the
+// rest by search.
+func Explainable(search, explain http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		value := r.URL.Query().Get("explain")
+		if value == "" {
+			search(w, r)
+			return
+		}
+		on, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "bad explain", http.StatusBadRequest)
+			return
+		}
+		if on {
+			explain(w, r)
+			return
+		}
+		search(w, r)
+	}
+}
+
+// NewExplainHandler runs a search of the engine telling how every comic
+// found was scored. It is meant for admins.
+func NewExplainHandler(log *slog.Logger, searcher core.Searcher, engine string, verifier core.TokenVerifier) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		phrase := r.URL.Query().Get("phrase")
+		if phrase == "" {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		limit := r.URL.Query().Get("limit")
+		if limit == "" {
+			limit = "10"
+		}
+
+		num, err := strconv.Atoi(limit)
+		if err != nil {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		opts, err := searchOptions(r)
+		if err != nil {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		explain, err := searcher.Explain(r.Context(), engine, num, phrase, opts)
+		if errors.Is(err, core.ErrBadArguments) {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Error("failed to explain search", "engine", engine, "error", err)
+			http.Error(w, "failed to explain search", http.StatusInternalServerError)
+			return
+		}
+
+		terms := make([]map[string]interface{}, 0, len(explain.Terms))
+		for _, term := range explain.Terms {
+			terms = append(terms, map[string]interface{}{"field": term.Field, "word": term.Word})
+		}
+		resp := map[string]interface{}{
+			"engine": engine,
+			"terms":  terms,
+			"comics": make([]map[string]interface{}, 0, len(explain.Comics)),
+			"total":  len(explain.Comics),
+		}
+		if explain.TSQuery != "" {
+			resp["tsquery"] = explain.TSQuery
+		}
+		if len(explain.Plan) > 0 {
+			resp["plan"] = explain.Plan
+		}
+		if explain.Formula != "" {
+			resp["formula"] = explain.Formula
+		}
+
+		for _, comic := range explain.Comics {
+			result := searchResult(comic)
+			if comic.Explanation != nil {
+				result["explanation"] = explanation(*comic.Explanation)
+			}
+			resp["comics"] = append(resp["comics"].([]map[string]interface{}), result)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+
+	return middleware.Auth(handler, verifier)
+}
+
+func explanation(e core.Explanation) map[string]interface{} {
+	matches := make([]map[string]interface{}, 0, len(e.Matches))
+	for _, m := range e.Matches {
+		matches = append(matches, map[string]interface{}{
+			"term":  map[string]interface{}{"field": m.Term.Field, "word": m.Term.Word},
+			"field": m.Field,
+			"tf":    m.TF,
+			"boost": m.Boost,
+			"score": m.Score,
+		})
+	}
+	return map[string]interface{}{
+		"score":   e.Score,
+		"matches": matches,
+	}
+}
+
+// NewAnalyticsHandler sums up the searches of the last window, 24h by
+// default: the most frequent queries, the ones that found nothing and the
+// latency percentiles of every search mode.
+func NewAnalyticsHandler(log *slog.Logger, searcher core.Searcher, verifier core.TokenVerifier) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		window := 24 * time.Hour
+		if value := r.URL.Query().Get("window"); value != "" {
+			var err error
+			if window, err = time.ParseDuration(value); err != nil || window <= 0 {
+				http.Error(w, "bad window", http.StatusBadRequest)
+				return
+			}
+		}
+
+		limit := 10
+		if value := r.URL.Query().Get("limit"); value != "" {
+			var err error
+			if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+				http.Error(w, "bad limit", http.StatusBadRequest)
+				return
+			}
+		}
+
+		analytics, err := searcher.Analytics(r.Context(), time.Now().Add(-window), limit)
+		if err != nil {
+			log.Error("failed to get search analytics", "error", err)
+			http.Error(w, "failed to get search analytics", http.StatusInternalServerError)
+			return
+		}
+
+		latency := make([]map[string]interface{}, 0, len(analytics.Latency))
+		for _, l := range analytics.Latency {
+			latency = append(latency, map[string]interface{}{
+				"mode":     l.Mode,
+				"searches": l.Searches,
+				"p50_ms":   l.P50.Seconds() * 1000,
+				"p90_ms":   l.P90.Seconds() * 1000,
+				"p99_ms":   l.P99.Seconds() * 1000,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"window":       window.String(),
+			"top":          queryCounts(analytics.Top),
+			"zero_results": queryCounts(analytics.ZeroResults),
+			"latency":      latency,
+		}); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+
+	return middleware.Auth(handler, verifier)
+}
+
+func queryCounts(counts []core.QueryCount) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(counts))
+	for _, c := range counts {
+		out = append(out, map[string]interface{}{
+			"query":    c.Query,
+			"searches": c.Searches,
+			"clients":  c.Clients,
+		})
+	}
+	return out
+}
+
+// NewSimilarHandler finds the comics most like the given one.
+func NewSimilarHandler(log *slog.Logger, searcher core.Searcher, rateLimit int) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id < 1 {
+			http.Error(w, "bad id", http.StatusBadRequest)
+			return
+		}
+		source := r.URL.Query().Get("source")
+		if source == "" {
+			source = core.DefaultSource
+		}
+
+		limit := r.URL.Query().Get("limit")
+		if limit == "" {
+			limit = "10"
+		}
+
+		num, err := strconv.Atoi(limit)
+		if err != nil {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		comics, err := searcher.Similar(r.Context(), source, id, num)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				http.Error(w, "comic not found", http.StatusNotFound)
+				return
+			}
+			log.Error("failed to find similar comics", "error", err)
+			http.Error(w, "failed to find similar comics", http.StatusInternalServerError)
+			return
+		}
+
+		resp := map[string]interface{}{
+			"comics": make([]map[string]interface{}, 0, len(comics)),
+			"total":  len(comics),
+		}
+
+		for _, comic := range comics {
+			resp["comics"] = append(resp["comics"].([]map[string]interface{}), searchResult(comic))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+
+	return middleware.Rate(handler, rateLimit)
+}
+
+// NewRandomHandler draws a comic, from the ones found by the optional phrase
+// and within the optional from and to dates.
+func NewRandomHandler(log *slog.Logger, searcher core.Searcher, rateLimit int) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		opts, err := searchOptions(r)
+		if err != nil {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		comic, err := searcher.Random(r.Context(), r.URL.Query().Get("phrase"), opts)
+		if err != nil {
+			curationError(w, log, "failed to draw comic", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(searchResult(comic)); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+
+	return middleware.Rate(handler, rateLimit)
+}
+
+// NewOnThisDayHandler finds the comics published on today's month and day
+// in the past years, or on those of the optional date (YYYY-MM-DD) for the
+// clients in other time zones.
+func NewOnThisDayHandler(log *slog.Logger, searcher core.Searcher, rateLimit int) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		date := time.Now()
+		if v := r.URL.Query().Get("date"); v != "" {
+			var err error
+			if date, err = time.Parse(time.DateOnly, v); err != nil {
+				http.Error(w, "bad date", http.StatusBadRequest)
+				return
+			}
+		}
+
+		limit := r.URL.Query().Get("limit")
+		if limit == "" {
+			limit = "10"
+		}
+
+		num, err := strconv.Atoi(limit)
+		if err != nil {
+			http.Error(w, "Bad arguments", http.StatusBadRequest)
+			return
+		}
+
+		comics, err := searcher.OnThisDay(r.Context(), date, num)
+		if err != nil {
+			curationError(w, log, "failed to find comics of the day", err)
+			return
+		}
+
+		resp := map[string]interface{}{
+			"comics": make([]map[string]interface{}, 0, len(comics)),
+			"total":  len(comics),
+		}
+
+		for _, comic := range comics {
+			resp["comics"] = append(resp["comics"].([]map[string]interface{}), searchResult(comic))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+
+	return middleware.Rate(handler, rateLimit)
+}
+
+// NewCurationHandler lists the pins, the rewrites and the blocklist.
+func NewCurationHandler(log *slog.Logger, curator core.Curator, verifier core.TokenVerifier) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		curation, err := curator.Curation(r.Context())
+		if err != nil {
+			log.Error("failed to get curation", "error", err)
+			http.Error(w, "failed to get curation", http.StatusInternalServerError)
+			return
+		}
+
+		pins := make([]map[string]interface{}, 0, len(curation.Pins))
+		for _, pin := range curation.Pins {
+			pins = append(pins, pinResult(pin))
+		}
+		rewrites := make([]map[string]interface{}, 0, len(curation.Rewrites))
+		for _, rewrite := range curation.Rewrites {
+			rewrites = append(rewrites, map[string]interface{}{"query": rewrite.Query, "phrase": rewrite.Phrase})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"pins":     pins,
+			"rewrites": rewrites,
+			"blocked":  comicRefs(curation.Blocked),
+		}); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+
+	return middleware.Auth(handler, verifier)
+}
+
+// NewPutPinHandler pins the comics for the query, in the order given. The
+// comics are of the default source unless they name one.
+func NewPutPinHandler(log *slog.Logger, curator core.Curator, verifier core.TokenVerifier) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query  string `json:"query"`
+			Comics []struct {
+				ID     int    `json:"id"`
+				Source string `json:"source"`
+			} `json:"comics"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad pin", http.StatusBadRequest)
+			return
+		}
+		comics := make([]core.ComicID, 0, len(req.Comics))
+		for _, comic := range req.Comics {
+			if comic.Source == "" {
+				comic.Source = core.DefaultSource
+			}
+			comics = append(comics, core.ComicID{Source: comic.Source, ID: comic.ID})
+		}
+
+		pin, err := curator.PutPin(r.Context(), req.Query, comics)
+		if err != nil {
+			curationError(w, log, "failed to put pin", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(pinResult(pin)); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+
+	return middleware.Auth(handler, verifier)
+}
+
+func NewDeletePinHandler(log *slog.Logger, curator core.Curator, verifier core.TokenVerifier) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if err := curator.DeletePin(r.Context(), r.URL.Query().Get("query")); err != nil {
+			curationError(w, log, "failed to delete pin", err)
+		}
+	}
+
+	return middleware.Auth(handler, verifier)
+}
+
+// NewPutRewriteHandler makes the searches of the query search the phrase.
+func NewPutRewriteHandler(log *slog.Logger, curator core.Curator, verifier core.TokenVerifier) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query  string `json:"query"`
+			Phrase string `json:"phrase"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad rewrite", http.StatusBadRequest)
+			return
+		}
+
+		rewrite, err := curator.PutRewrite(r.Context(), req.Query, req.Phrase)
+		if err != nil {
+			curationError(w, log, "failed to put rewrite", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"query":  rewrite.Query,
+			"phrase": rewrite.Phrase,
+		}); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+
+	return middleware.Auth(handler, verifier)
+}
+
+func NewDeleteRewriteHandler(log *slog.Logger, curator core.Curator, verifier core.TokenVerifier) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if err := curator.DeleteRewrite(r.Context(), r.URL.Query().Get("query")); err != nil {
+			curationError(w, log, "failed to delete rewrite", err)
+		}
+	}
+
+	return middleware.Auth(handler, verifier)
+}
+
+// NewBlockHandler hides the comic from all the searches.
+func NewBlockHandler(log *slog.Logger, curator core.Curator, verifier core.TokenVerifier) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		id, ok := comicID(w, r)
+		if !ok {
+			return
+		}
+		if err := curator.Block(r.Context(), id); err != nil {
+			curationError(w, log, "failed to block comic", err)
+		}
+	}
+
+	return middleware.Auth(handler, verifier)
+}
+
+func NewUnblockHandler(log *slog.Logger, curator core.Curator, verifier core.TokenVerifier) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		id, ok := comicID(w, r)
+		if !ok {
+			return
+		}
+		if err := curator.Unblock(r.Context(), id); err != nil {
+			curationError(w, log, "failed to unblock comic", err)
+		}
+	}
+
+	return middleware.Auth(handler, verifier)
+}
+
+// comicID parses the comic of the path and the source of the query,
+// replying bad request if the id is bad.
+func comicID(w http.ResponseWriter, r *http.Request) (core.ComicID, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		http.Error(w, "bad id", http.StatusBadRequest)
+		return core.ComicID{}, false
+	}
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		source = core.DefaultSource
+	}
+	return core.ComicID{Source: source, ID: id}, true
+}
+
+func curationError(w http.ResponseWriter, log *slog.Logger, msg string, err error) {
+	switch {
+	case errors.Is(err, core.ErrNotFound):
+		http.Error(w, "not found", http.StatusNotFound)
+	case errors.Is(err, core.ErrBadArguments):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Error(msg, "error", err)
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
+
+func pinResult(pin core.Pin) map[string]interface{} {
+	return map[string]interface{}{
+		"query":  pin.Query,
+		"comics": comicRefs(pin.Comics),
+	}
+}
+
+func comicRefs(ids []core.ComicID) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, map[string]interface{}{"id": id.ID, "source": id.Source})
+	}
+	return out
+}
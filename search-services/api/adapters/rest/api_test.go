@@ -5,12 +5,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -46,18 +50,149 @@ func (m *MockUpdater) Status(ctx context.Context) (core.UpdateStatus, error) {
 func (m *MockUpdater) Drop(ctx context.Context) error {
 	return m.Called(ctx).Error(0)
 }
+func (m *MockUpdater) Reindex(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+func (m *MockUpdater) Verify(ctx context.Context, repair bool) ([]core.VerifyReport, error) {
+	args := m.Called(ctx, repair)
+	return args.Get(0).([]core.VerifyReport), args.Error(1)
+}
+func (m *MockUpdater) Refresh(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+func (m *MockUpdater) History(ctx context.Context, source string, id int) ([]core.ComicVersion, error) {
+	args := m.Called(ctx, source, id)
+	return args.Get(0).([]core.ComicVersion), args.Error(1)
+}
+func (m *MockUpdater) Tags(ctx context.Context, source string, id int) ([]core.Tag, error) {
+	args := m.Called(ctx, source, id)
+	return args.Get(0).([]core.Tag), args.Error(1)
+}
+func (m *MockUpdater) AddTag(ctx context.Context, source string, id int, tag string) (core.Tag, error) {
+	args := m.Called(ctx, source, id, tag)
+	return args.Get(0).(core.Tag), args.Error(1)
+}
+func (m *MockUpdater) RemoveTag(ctx context.Context, source string, id int, tag string) error {
+	return m.Called(ctx, source, id, tag).Error(0)
+}
+func (m *MockUpdater) TagCounts(ctx context.Context) ([]core.TagCount, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]core.TagCount), args.Error(1)
+}
+func (m *MockUpdater) Export(ctx context.Context, send func(core.ComicRecord) error) error {
+	args := m.Called(ctx)
+	for _, comic := range args.Get(0).([]core.ComicRecord) {
+		if err := send(comic); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+func (m *MockUpdater) Import(ctx context.Context, recv func() (core.ComicRecord, error)) (int, error) {
+	args := m.Called(ctx)
+	imported := 0
+	for {
+		comic, err := recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return imported, err
+		}
+		if comic.Source == "" {
+			return imported, core.ErrBadArguments
+		}
+		imported++
+	}
+	return imported, args.Error(0)
+}
 
 type MockSearcher struct{ mock.Mock }
 
-func (m *MockSearcher) Search(ctx context.Context, limit int, phrase string) ([]core.Comics, error) {
-	args := m.Called(ctx, limit, phrase)
+func (m *MockSearcher) Search(ctx context.Context, limit int, phrase string, opts core.SearchOptions) (core.Found, error) {
+	args := m.Called(ctx, limit, phrase, opts)
+	return args.Get(0).(core.Found), args.Error(1)
+}
+func (m *MockSearcher) IndexSearch(ctx context.Context, limit int, phrase string, opts core.SearchOptions) (core.Found, error) {
+	args := m.Called(ctx, limit, phrase, opts)
+	return args.Get(0).(core.Found), args.Error(1)
+}
+func (m *MockSearcher) FTSSearch(ctx context.Context, limit int, phrase string, opts core.SearchOptions) ([]core.Comics, error) {
+	args := m.Called(ctx, limit, phrase, opts)
+	return args.Get(0).([]core.Comics), args.Error(1)
+}
+func (m *MockSearcher) HybridSearch(ctx context.Context, limit int, phrase string, opts core.SearchOptions) (core.Hybrid, error) {
+	args := m.Called(ctx, limit, phrase, opts)
+	return args.Get(0).(core.Hybrid), args.Error(1)
+}
+func (m *MockSearcher) SemanticSearch(ctx context.Context, limit int, phrase string, opts core.SearchOptions) ([]core.Comics, error) {
+	args := m.Called(ctx, limit, phrase, opts)
+	return args.Get(0).([]core.Comics), args.Error(1)
+}
+func (m *MockSearcher) Similar(ctx context.Context, source string, id, limit int) ([]core.Comics, error) {
+	args := m.Called(ctx, source, id, limit)
 	return args.Get(0).([]core.Comics), args.Error(1)
 }
-func (m *MockSearcher) IndexSearch(ctx context.Context, limit int, phrase string) ([]core.Comics, error) {
-	args := m.Called(ctx, limit, phrase)
+
+func (m *MockSearcher) Random(ctx context.Context, phrase string, opts core.SearchOptions) (core.Comics, error) {
+	args := m.Called(ctx, phrase, opts)
+	return args.Get(0).(core.Comics), args.Error(1)
+}
+
+func (m *MockSearcher) OnThisDay(ctx context.Context, date time.Time, limit int) ([]core.Comics, error) {
+	args := m.Called(ctx, date, limit)
 	return args.Get(0).([]core.Comics), args.Error(1)
 }
 
+func (m *MockSearcher) Explain(ctx context.Context, engine string, limit int, phrase string, opts core.SearchOptions) (core.Explain, error) {
+	args := m.Called(ctx, engine, limit, phrase, opts)
+	return args.Get(0).(core.Explain), args.Error(1)
+}
+
+func (m *MockSearcher) Analytics(ctx context.Context, since time.Time, limit int) (core.SearchAnalytics, error) {
+	args := m.Called(ctx, since, limit)
+	return args.Get(0).(core.SearchAnalytics), args.Error(1)
+}
+
+func (m *MockSearcher) CacheStats(ctx context.Context) (core.CacheStats, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(core.CacheStats), args.Error(1)
+}
+
+type MockCurator struct{ mock.Mock }
+
+func (m *MockCurator) Curation(ctx context.Context) (core.Curation, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(core.Curation), args.Error(1)
+}
+
+func (m *MockCurator) PutPin(ctx context.Context, phrase string, comics []core.ComicID) (core.Pin, error) {
+	args := m.Called(ctx, phrase, comics)
+	return args.Get(0).(core.Pin), args.Error(1)
+}
+
+func (m *MockCurator) DeletePin(ctx context.Context, phrase string) error {
+	return m.Called(ctx, phrase).Error(0)
+}
+
+func (m *MockCurator) PutRewrite(ctx context.Context, phrase, rewrite string) (core.Rewrite, error) {
+	args := m.Called(ctx, phrase, rewrite)
+	return args.Get(0).(core.Rewrite), args.Error(1)
+}
+
+func (m *MockCurator) DeleteRewrite(ctx context.Context, phrase string) error {
+	return m.Called(ctx, phrase).Error(0)
+}
+
+func (m *MockCurator) Block(ctx context.Context, id core.ComicID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockCurator) Unblock(ctx context.Context, id core.ComicID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
 type MockTokenVerifier struct{ mock.Mock }
 
 func (m *MockTokenVerifier) Verify(token string) error {
@@ -213,206 +348,1574 @@ func TestNewUpdateHandler(t *testing.T) {
 	}
 }
 
-func TestNewDropHandler(t *testing.T) {
+func TestNewCacheStatsHandler(t *testing.T) {
 	tests := []struct {
 		name       string
-		mockDrop   error
+		mockResult core.CacheStats
+		mockErr    error
 		wantStatus int
-		authHeader string
-		mockVerify error
+		wantBody   string
 	}{
 		{
-			name:       "successful drop",
-			mockDrop:   nil,
+			name:       "stats",
+			mockResult: core.CacheStats{Hits: 7, Misses: 3, Evictions: 1, Purges: 2, Size: 2, Capacity: 1000},
 			wantStatus: http.StatusOK,
-			authHeader: "Token valid",
-			mockVerify: nil,
+			wantBody:   `{"capacity":1000,"evictions":1,"hits":7,"misses":3,"purges":2,"size":2}` + "\n",
 		},
 		{
-			name:       "drop error",
-			mockDrop:   errors.New("drop failed"),
+			name:       "error",
+			mockErr:    errors.New("search error"),
 			wantStatus: http.StatusInternalServerError,
-			authHeader: "Token valid",
-			mockVerify: nil,
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSearcher := &MockSearcher{}
+			mockSearcher.On("CacheStats", mock.Anything).Return(tt.mockResult, tt.mockErr)
+
+			handler := NewCacheStatsHandler(slog.Default(), mockSearcher)
+
+			req := httptest.NewRequest("GET", "/api/search/cache", nil)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+			mockSearcher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewUpdateStatsHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		mockResult core.UpdateStats
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
 		{
-			name:       "Unauthorized",
-			mockDrop:   nil,
-			wantStatus: http.StatusUnauthorized,
-			authHeader: "Token invalid",
-			mockVerify: errors.New("invalid token"),
+			name: "with pipeline",
+			mockResult: core.UpdateStats{
+				WordsTotal: 10, WordsUnique: 5, ComicsFetched: 2, ComicsTotal: 3,
+				Pipeline: []core.StageMetrics{{Stage: "fetch", Items: 2, Errors: 1, Seconds: 0.5, PerSecond: 4}},
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"comics_fetched":2,"comics_total":3,"pipeline":[{"errors":1,"items":2,"per_second":4,"seconds":0.5,"stage":"fetch"}],` +
+				`"words_total":10,"words_unique":5}` + "\n",
+		},
+		{
+			name:       "before the first update",
+			mockResult: core.UpdateStats{ComicsTotal: 3},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"comics_fetched":0,"comics_total":3,"pipeline":[],"words_total":0,"words_unique":0}` + "\n",
+		},
+		{
+			name:       "error",
+			mockErr:    errors.New("update error"),
+			wantStatus: http.StatusInternalServerError,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockUpdater := &MockUpdater{}
-			if tt.authHeader == "Token valid" {
-				mockUpdater.On("Drop", mock.Anything).Return(tt.mockDrop)
-			}
-
-			mockVerifier := &MockTokenVerifier{}
-			if tt.authHeader != "" {
-				token := tt.authHeader[len("Token "):]
-				mockVerifier.On("Verify", token).Return(tt.mockVerify)
-			}
+			mockUpdater.On("Stats", mock.Anything).Return(tt.mockResult, tt.mockErr)
 
-			handler := NewDropHandler(slog.Default(), mockUpdater, mockVerifier)
+			handler := NewUpdateStatsHandler(slog.Default(), mockUpdater)
 
-			req := httptest.NewRequest("DELETE", "/api/db", nil)
-			if tt.authHeader != "" {
-				req.Header.Set("Authorization", tt.authHeader)
-			}
+			req := httptest.NewRequest("GET", "/api/db/stats", nil)
 			w := httptest.NewRecorder()
 
 			handler(w, req)
 
 			assert.Equal(t, tt.wantStatus, w.Code)
-
-			if tt.authHeader == "Token valid" {
-				mockUpdater.AssertExpectations(t)
-			}
-			if tt.authHeader != "" {
-				mockVerifier.AssertExpectations(t)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
 			}
+			mockUpdater.AssertExpectations(t)
 		})
 	}
 }
 
-func TestNewSearchHandler(t *testing.T) {
+func TestNewReindexHandler(t *testing.T) {
 	tests := []struct {
 		name        string
-		queryParams map[string]string
-		mockComics  []core.Comics
-		mockErr     error
+		mockReindex error
 		wantStatus  int
+		authHeader  string
+		mockVerify  error
 	}{
 		{
-			name: "successful search",
-			queryParams: map[string]string{
-				"phrase": "Binary Christmas Tree",
-				"limit":  "1",
-			},
-			mockComics: []core.Comics{
-				{ID: 1, URL: "https://imgs.xkcd.com/comics/tree.png"},
-			},
-			mockErr:    nil,
-			wantStatus: http.StatusOK,
+			name:        "successful reindex",
+			mockReindex: nil,
+			wantStatus:  http.StatusOK,
+			authHeader:  "Token valid",
+			mockVerify:  nil,
 		},
 		{
-			name: "miss phrase",
-			queryParams: map[string]string{
-				"limit": "1",
-			},
-			wantStatus: http.StatusBadRequest,
+			name:        "update in progress",
+			mockReindex: core.ErrAlreadyExists,
+			wantStatus:  http.StatusAccepted,
+			authHeader:  "Token valid",
+			mockVerify:  nil,
 		},
 		{
-			name: "invalid limit",
-			queryParams: map[string]string{
-				"phrase": "test",
-				"limit":  "invalid",
-			},
-			wantStatus: http.StatusBadRequest,
+			name:        "reindex failed",
+			mockReindex: errors.New("words service is down"),
+			wantStatus:  http.StatusInternalServerError,
+			authHeader:  "Token valid",
+			mockVerify:  nil,
 		},
 		{
-			name: "search error",
-			queryParams: map[string]string{
-				"phrase": "test",
-				"limit":  "1",
-			},
-			mockComics: nil,
-			mockErr:    errors.New("search error"),
-			wantStatus: http.StatusBadRequest,
+			name:        "Unauthorized",
+			mockReindex: nil,
+			wantStatus:  http.StatusUnauthorized,
+			authHeader:  "Token invalid",
+			mockVerify:  errors.New("invalid token"),
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockSearcher := &MockSearcher{}
-			if phrase, ok := tt.queryParams["phrase"]; ok && phrase != "" {
-				limit := 10
-				if l, ok := tt.queryParams["limit"]; ok {
-					if num, err := strconv.Atoi(l); err == nil {
-						limit = num
-					}
-				}
-				if _, err := strconv.Atoi(tt.queryParams["limit"]); err == nil || tt.queryParams["limit"] == "" {
-					mockSearcher.On("Search", mock.Anything, limit, phrase).Return(tt.mockComics, tt.mockErr)
-				}
+			mockUpdater := &MockUpdater{}
+			if tt.authHeader == "Token valid" {
+				mockUpdater.On("Reindex", mock.Anything).Return(tt.mockReindex)
 			}
 
-			handler := NewSearchHandler(slog.Default(), mockSearcher, 10)
+			mockVerifier := &MockTokenVerifier{}
+			token := tt.authHeader[len("Token "):]
+			mockVerifier.On("Verify", token).Return(tt.mockVerify)
 
-			req := httptest.NewRequest("GET", "/api/search", nil)
-			q := req.URL.Query()
-			for k, v := range tt.queryParams {
-				q.Add(k, v)
-			}
-			req.URL.RawQuery = q.Encode()
+			handler := NewReindexHandler(slog.Default(), mockUpdater, mockVerifier)
 
+			req := httptest.NewRequest("POST", "/api/db/reindex", nil)
+			req.Header.Set("Authorization", tt.authHeader)
 			w := httptest.NewRecorder()
 
 			handler(w, req)
 
 			assert.Equal(t, tt.wantStatus, w.Code)
-			if tt.wantStatus == http.StatusOK {
-				var response map[string]interface{}
-				err := json.NewDecoder(w.Body).Decode(&response)
-				assert.NoError(t, err)
-				assert.Equal(t, len(tt.mockComics), int(response["total"].(float64)))
-			}
-
-			if phrase, ok := tt.queryParams["phrase"]; ok && phrase != "" {
-				if _, err := strconv.Atoi(tt.queryParams["limit"]); err == nil || tt.queryParams["limit"] == "" {
-					mockSearcher.AssertExpectations(t)
-				}
-			}
+			mockUpdater.AssertExpectations(t)
+			mockVerifier.AssertExpectations(t)
 		})
 	}
 }
 
-func TestNewIndexSearchHandler(t *testing.T) {
+func TestNewExportHandler(t *testing.T) {
 	tests := []struct {
-		name        string
-		queryParams map[string]string
-		mockComics  []core.Comics
-		mockErr     error
-		wantStatus  int
+		name       string
+		comics     []core.ComicRecord
+		mockErr    error
+		wantStatus int
+		wantBody   string
 	}{
 		{
-			name: "successful index search",
-			queryParams: map[string]string{
-				"phrase": "Binary Christmas Tree",
-				"limit":  "1",
-			},
-			mockComics: []core.Comics{
-				{ID: 1, URL: "https://imgs.xkcd.com/comics/tree.png"},
+			name: "successful export",
+			comics: []core.ComicRecord{
+				{Source: "xkcd", ID: 1, URL: "url1", Keywords: []string{"barrel"}, Title: "Barrel", AnalyzerVersion: 1},
+				{Source: "xkcd", ID: 2, URL: "url2"},
 			},
-			mockErr:    nil,
 			wantStatus: http.StatusOK,
+			wantBody: `{"source":"xkcd","id":1,"url":"url1","keywords":["barrel"],"title":"Barrel","safe_title":"","alt":"","transcript":"","analyzer_version":1}
+{"source":"xkcd","id":2,"url":"url2","keywords":null,"title":"","safe_title":"","alt":"","transcript":"","analyzer_version":0}
+`,
+		},
+		{
+			name:       "failed before first comic",
+			comics:     []core.ComicRecord{},
+			mockErr:    errors.New("db error"),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "failed to export\n",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockSearcher := &MockSearcher{}
-			mockSearcher.On("IndexSearch", mock.Anything, 1, "Binary Christmas Tree").Return(tt.mockComics, tt.mockErr)
+			mockUpdater := &MockUpdater{}
+			mockUpdater.On("Export", mock.Anything).Return(tt.comics, tt.mockErr)
 
-			handler := NewIndexSearchHandler(slog.Default(), mockSearcher, 10)
+			mockVerifier := &MockTokenVerifier{}
+			mockVerifier.On("Verify", "valid").Return(nil)
 
-			req := httptest.NewRequest("GET", "/api/isearch", nil)
-			q := req.URL.Query()
-			for k, v := range tt.queryParams {
-				q.Add(k, v)
-			}
-			req.URL.RawQuery = q.Encode()
+			handler := NewExportHandler(slog.Default(), mockUpdater, mockVerifier)
 
+			req := httptest.NewRequest("GET", "/api/db/export", nil)
+			req.Header.Set("Authorization", "Token valid")
 			w := httptest.NewRecorder()
 
 			handler(w, req)
 
 			assert.Equal(t, tt.wantStatus, w.Code)
-			mockSearcher.AssertExpectations(t)
+			assert.Equal(t, tt.wantBody, w.Body.String())
+			mockUpdater.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewImportHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "successful import",
+			body: `{"source":"xkcd","id":1,"url":"url1","keywords":["barrel"]}
+{"source":"xkcd","id":2}
+`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"imported":2}` + "\n",
+		},
+		{
+			name:       "malformed line",
+			body:       `{"source":"xkcd","id":1}` + "\n{oops\n",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "import in progress",
+			body:       `{"source":"xkcd","id":1}`,
+			mockErr:    core.ErrAlreadyExists,
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUpdater := &MockUpdater{}
+			mockUpdater.On("Import", mock.Anything).Return(tt.mockErr)
+
+			mockVerifier := &MockTokenVerifier{}
+			mockVerifier.On("Verify", "valid").Return(nil)
+
+			handler := NewImportHandler(slog.Default(), mockUpdater, mockVerifier)
+
+			req := httptest.NewRequest("POST", "/api/db/import", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Token valid")
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+			mockUpdater.AssertExpectations(t)
 		})
 	}
 }
+
+func TestNewVerifyHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		repair     bool
+		mockResult []core.VerifyReport
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:   "report",
+			query:  "",
+			repair: false,
+			mockResult: []core.VerifyReport{
+				{Source: "xkcd", Total: 5, Missing: []int{3}, Empty: []int{404}},
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"sources":[{"empty":[404],"missing":[3],"repaired":0,"source":"xkcd","total":5}]}` + "\n",
+		},
+		{
+			name:       "repair",
+			query:      "?repair=true",
+			repair:     true,
+			mockResult: []core.VerifyReport{},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"sources":[]}` + "\n",
+		},
+		{
+			name:       "bad repair",
+			query:      "?repair=maybe",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "update in progress",
+			query:      "?repair=1",
+			repair:     true,
+			mockResult: []core.VerifyReport(nil),
+			mockErr:    core.ErrAlreadyExists,
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUpdater := &MockUpdater{}
+			if tt.wantStatus != http.StatusBadRequest {
+				mockUpdater.On("Verify", mock.Anything, tt.repair).Return(tt.mockResult, tt.mockErr)
+			}
+
+			mockVerifier := &MockTokenVerifier{}
+			mockVerifier.On("Verify", "valid").Return(nil)
+
+			handler := NewVerifyHandler(slog.Default(), mockUpdater, mockVerifier)
+
+			req := httptest.NewRequest("GET", "/api/db/verify"+tt.query, nil)
+			req.Header.Set("Authorization", "Token valid")
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+			mockUpdater.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewRefreshHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		mockResult int
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "refreshed",
+			mockResult: 3,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"changed":3}` + "\n",
+		},
+		{
+			name:       "update in progress",
+			mockErr:    core.ErrAlreadyExists,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "refresh failed",
+			mockErr:    errors.New("db error"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUpdater := &MockUpdater{}
+			mockUpdater.On("Refresh", mock.Anything).Return(tt.mockResult, tt.mockErr)
+
+			mockVerifier := &MockTokenVerifier{}
+			mockVerifier.On("Verify", "valid").Return(nil)
+
+			handler := NewRefreshHandler(slog.Default(), mockUpdater, mockVerifier)
+
+			req := httptest.NewRequest("POST", "/api/db/refresh", nil)
+			req.Header.Set("Authorization", "Token valid")
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+			mockUpdater.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewHistoryHandler(t *testing.T) {
+	replaced := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		path       string
+		source     string
+		id         int
+		mockResult []core.ComicVersion
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:   "default source",
+			path:   "/api/comics/1/history",
+			source: "xkcd",
+			id:     1,
+			mockResult: []core.ComicVersion{
+				{URL: "url1", Title: "Barrel", Transcript: "old", ContentHash: "abc", ReplacedAt: replaced},
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"id":1,"source":"xkcd","versions":[{"alt":"","content_hash":"abc","replaced_at":"2024-03-01T12:00:00Z",` +
+				`"safe_title":"","title":"Barrel","transcript":"old","url":"url1"}]}` + "\n",
+		},
+		{
+			name:       "other source without history",
+			path:       "/api/comics/7/history?source=smbc",
+			source:     "smbc",
+			id:         7,
+			mockResult: []core.ComicVersion{},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id":7,"source":"smbc","versions":[]}` + "\n",
+		},
+		{
+			name:       "unknown comic",
+			path:       "/api/comics/9999/history",
+			source:     "xkcd",
+			id:         9999,
+			mockResult: []core.ComicVersion(nil),
+			mockErr:    core.ErrNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "bad id",
+			path:       "/api/comics/abc/history",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUpdater := &MockUpdater{}
+			if tt.id != 0 {
+				mockUpdater.On("History", mock.Anything, tt.source, tt.id).Return(tt.mockResult, tt.mockErr)
+			}
+
+			mux := http.NewServeMux()
+			mux.Handle("GET /api/comics/{id}/history", NewHistoryHandler(slog.Default(), mockUpdater))
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+			mockUpdater.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewDropHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		mockDrop   error
+		wantStatus int
+		authHeader string
+		mockVerify error
+	}{
+		{
+			name:       "successful drop",
+			mockDrop:   nil,
+			wantStatus: http.StatusOK,
+			authHeader: "Token valid",
+			mockVerify: nil,
+		},
+		{
+			name:       "drop error",
+			mockDrop:   errors.New("drop failed"),
+			wantStatus: http.StatusInternalServerError,
+			authHeader: "Token valid",
+			mockVerify: nil,
+		},
+		{
+			name:       "Unauthorized",
+			mockDrop:   nil,
+			wantStatus: http.StatusUnauthorized,
+			authHeader: "Token invalid",
+			mockVerify: errors.New("invalid token"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUpdater := &MockUpdater{}
+			if tt.authHeader == "Token valid" {
+				mockUpdater.On("Drop", mock.Anything).Return(tt.mockDrop)
+			}
+
+			mockVerifier := &MockTokenVerifier{}
+			if tt.authHeader != "" {
+				token := tt.authHeader[len("Token "):]
+				mockVerifier.On("Verify", token).Return(tt.mockVerify)
+			}
+
+			handler := NewDropHandler(slog.Default(), mockUpdater, mockVerifier)
+
+			req := httptest.NewRequest("DELETE", "/api/db", nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			if tt.authHeader == "Token valid" {
+				mockUpdater.AssertExpectations(t)
+			}
+			if tt.authHeader != "" {
+				mockVerifier.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestNewSearchHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		queryParams map[string]string
+		mockComics  []core.Comics
+		mockErr     error
+		wantStatus  int
+	}{
+		{
+			name: "successful search",
+			queryParams: map[string]string{
+				"phrase": "Binary Christmas Tree",
+				"limit":  "1",
+			},
+			mockComics: []core.Comics{
+				{ID: 1, URL: "https://imgs.xkcd.com/comics/tree.png"},
+			},
+			mockErr:    nil,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "miss phrase",
+			queryParams: map[string]string{
+				"limit": "1",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid limit",
+			queryParams: map[string]string{
+				"phrase": "test",
+				"limit":  "invalid",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "search error",
+			queryParams: map[string]string{
+				"phrase": "test",
+				"limit":  "1",
+			},
+			mockComics: nil,
+			mockErr:    errors.New("search error"),
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSearcher := &MockSearcher{}
+			if phrase, ok := tt.queryParams["phrase"]; ok && phrase != "" {
+				limit := 10
+				if l, ok := tt.queryParams["limit"]; ok {
+					if num, err := strconv.Atoi(l); err == nil {
+						limit = num
+					}
+				}
+				if _, err := strconv.Atoi(tt.queryParams["limit"]); err == nil || tt.queryParams["limit"] == "" {
+					mockSearcher.On("Search", mock.Anything, limit, phrase, core.SearchOptions{}).Return(core.Found{Comics: tt.mockComics}, tt.mockErr)
+				}
+			}
+
+			handler := NewSearchHandler(slog.Default(), mockSearcher, 10)
+
+			req := httptest.NewRequest("GET", "/api/search", nil)
+			q := req.URL.Query()
+			for k, v := range tt.queryParams {
+				q.Add(k, v)
+			}
+			req.URL.RawQuery = q.Encode()
+
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				var response map[string]interface{}
+				err := json.NewDecoder(w.Body).Decode(&response)
+				assert.NoError(t, err)
+				assert.Equal(t, len(tt.mockComics), int(response["total"].(float64)))
+			}
+
+			if phrase, ok := tt.queryParams["phrase"]; ok && phrase != "" {
+				if _, err := strconv.Atoi(tt.queryParams["limit"]); err == nil || tt.queryParams["limit"] == "" {
+					mockSearcher.AssertExpectations(t)
+				}
+			}
+		})
+	}
+}
+
+func TestNewIndexSearchHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		queryParams map[string]string
+		opts        core.SearchOptions
+		mockFound   core.Found
+		mockErr     error
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name: "successful index search",
+			queryParams: map[string]string{
+				"phrase": "Binary Christmas Tree",
+				"limit":  "1",
+			},
+			mockFound: core.Found{Comics: []core.Comics{
+				{ID: 1, URL: "https://imgs.xkcd.com/comics/tree.png"},
+			}},
+			mockErr:    nil,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "relaxed search",
+			queryParams: map[string]string{
+				"phrase": "Binary Christmas Tree",
+				"limit":  "1",
+				"match":  "all",
+			},
+			opts: core.SearchOptions{All: true},
+			mockFound: core.Found{
+				Comics: []core.Comics{{ID: 835, Source: "xkcd", URL: "url835"}},
+				Relaxation: &core.Relaxation{Relax: "drop_term", Terms: []core.Term{
+					{Word: "binari"}, {Field: "title", Word: "tree"},
+				}},
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"comics":[{"id":835,"source":"xkcd","url":"url835"}],` +
+				`"relaxation":{"phrase":"binari title:tree","relax":"drop_term"},"total":1}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSearcher := &MockSearcher{}
+			mockSearcher.On("IndexSearch", mock.Anything, 1, "Binary Christmas Tree", tt.opts).Return(tt.mockFound, tt.mockErr)
+
+			handler := NewIndexSearchHandler(slog.Default(), mockSearcher, 10)
+
+			req := httptest.NewRequest("GET", "/api/isearch", nil)
+			q := req.URL.Query()
+			for k, v := range tt.queryParams {
+				q.Add(k, v)
+			}
+			req.URL.RawQuery = q.Encode()
+
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+			mockSearcher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewFTSSearchHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		phrase     string
+		limit      int
+		opts       core.SearchOptions
+		mockComics []core.Comics
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "web search syntax",
+			query:      `?phrase=%22christmas+tree%22+-binary&limit=1`,
+			phrase:     `"christmas tree" -binary`,
+			limit:      1,
+			mockComics: []core.Comics{{ID: 835, Source: "xkcd", URL: "https://imgs.xkcd.com/comics/tree.png"}},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"comics":[{"id":835,"source":"xkcd","url":"https://imgs.xkcd.com/comics/tree.png"}],"total":1}` + "\n",
+		},
+		{
+			name:   "bounds and sort",
+			query:  "?phrase=tree&from=2010-01-01&to=2012-12-31&min_id=100&max_id=900&sort=newest",
+			phrase: "tree",
+			limit:  10,
+			opts: core.SearchOptions{
+				From:  time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:    time.Date(2012, 12, 31, 0, 0, 0, 0, time.UTC),
+				MinID: 100,
+				MaxID: 900,
+				Sort:  core.SortNewest,
+			},
+			mockComics: []core.Comics{},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"comics":[],"total":0}` + "\n",
+		},
+		{
+			name:       "bad date",
+			query:      "?phrase=tree&from=01.01.2010",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "bad id bound",
+			query:      "?phrase=tree&min_id=-1",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "bad sort",
+			query:      "?phrase=tree&sort=random",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "no cache",
+			query:      "?phrase=tree&no_cache=true",
+			phrase:     "tree",
+			limit:      10,
+			opts:       core.SearchOptions{NoCache: true},
+			mockComics: []core.Comics{},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"comics":[],"total":0}` + "\n",
+		},
+		{
+			name:       "bad no_cache",
+			query:      "?phrase=tree&no_cache=please",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "no phrase",
+			query:      "",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "bad limit",
+			query:      "?phrase=tree&limit=many",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "search error",
+			query:      "?phrase=tree",
+			phrase:     "tree",
+			limit:      10,
+			mockComics: []core.Comics(nil),
+			mockErr:    errors.New("search error"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSearcher := &MockSearcher{}
+			if tt.phrase != "" {
+				mockSearcher.On("FTSSearch", mock.Anything, tt.limit, tt.phrase, tt.opts).Return(tt.mockComics, tt.mockErr)
+			}
+
+			handler := NewFTSSearchHandler(slog.Default(), mockSearcher, 10)
+
+			req := httptest.NewRequest("GET", "/api/fsearch"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+			mockSearcher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewHybridSearchHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		phrase     string
+		opts       core.SearchOptions
+		mockHybrid core.Hybrid
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "fused",
+			query:      "?phrase=tree",
+			phrase:     "tree",
+			mockHybrid: core.Hybrid{Comics: []core.Comics{{ID: 835, Source: "xkcd", URL: "url835"}}},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"comics":[{"id":835,"source":"xkcd","url":"url835"}],"degraded":[],"total":1}` + "\n",
+		},
+		{
+			name:       "degraded",
+			query:      "?phrase=tree&no_cache=1",
+			phrase:     "tree",
+			opts:       core.SearchOptions{NoCache: true},
+			mockHybrid: core.Hybrid{Comics: []core.Comics{}, Degraded: []string{"fts"}},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"comics":[],"degraded":["fts"],"total":0}` + "\n",
+		},
+		{
+			name:       "date sort",
+			query:      "?phrase=tree&sort=newest",
+			phrase:     "tree",
+			opts:       core.SearchOptions{Sort: core.SortNewest},
+			mockErr:    fmt.Errorf("%w: hybrid search sorts by relevance only", core.ErrBadArguments),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "no phrase",
+			query:      "",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "search error",
+			query:      "?phrase=tree",
+			phrase:     "tree",
+			mockErr:    errors.New("search error"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSearcher := &MockSearcher{}
+			if tt.phrase != "" {
+				mockSearcher.On("HybridSearch", mock.Anything, 10, tt.phrase, tt.opts).Return(tt.mockHybrid, tt.mockErr)
+			}
+
+			handler := NewHybridSearchHandler(slog.Default(), mockSearcher, 10)
+
+			req := httptest.NewRequest("GET", "/api/hsearch"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+			mockSearcher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewSemanticSearchHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		phrase     string
+		opts       core.SearchOptions
+		mockComics []core.Comics
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "found",
+			query:      "?phrase=spaceship",
+			phrase:     "spaceship",
+			mockComics: []core.Comics{{ID: 1356, Source: "xkcd", URL: "url1356"}},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"comics":[{"id":1356,"source":"xkcd","url":"url1356"}],"total":1}` + "\n",
+		},
+		{
+			name:       "date sort",
+			query:      "?phrase=spaceship&sort=oldest",
+			phrase:     "spaceship",
+			opts:       core.SearchOptions{Sort: core.SortOldest},
+			mockErr:    fmt.Errorf("%w: semantic search sorts by relevance only", core.ErrBadArguments),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "no phrase",
+			query:      "",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "search error",
+			query:      "?phrase=spaceship",
+			phrase:     "spaceship",
+			mockErr:    errors.New("search error"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSearcher := &MockSearcher{}
+			if tt.phrase != "" {
+				mockSearcher.On("SemanticSearch", mock.Anything, 10, tt.phrase, tt.opts).Return(tt.mockComics, tt.mockErr)
+			}
+
+			handler := NewSemanticSearchHandler(slog.Default(), mockSearcher, 10)
+
+			req := httptest.NewRequest("GET", "/api/ssearch"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+			mockSearcher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSearchResult(t *testing.T) {
+	tests := []struct {
+		name  string
+		comic core.Comics
+		want  string
+	}{
+		{
+			name:  "without snippet",
+			comic: core.Comics{ID: 1, Source: "xkcd", URL: "url1"},
+			want:  `{"id":1,"source":"xkcd","url":"url1"}`,
+		},
+		{
+			name: "with snippet",
+			comic: core.Comics{ID: 2, Source: "xkcd", URL: "url2", Snippet: core.Snippet{
+				Field:      "alt",
+				Text:       "A python and a python",
+				Highlights: []core.Highlight{{Start: 2, End: 8}, {Start: 15, End: 21}},
+			}},
+			want: `{"id":2,"source":"xkcd","url":"url2","snippet":{"field":"alt","text":"A python and a python","highlights":[[2,8],[15,21]]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(searchResult(tt.comic))
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestNewSimilarHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		source     string
+		id         int
+		limit      int
+		mockResult []core.Comics
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:   "default source and limit",
+			path:   "/api/comics/1/similar",
+			source: "xkcd",
+			id:     1,
+			limit:  10,
+			mockResult: []core.Comics{
+				{ID: 2, Source: "xkcd", URL: "url2"},
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"comics":[{"id":2,"source":"xkcd","url":"url2"}],"total":1}` + "\n",
+		},
+		{
+			name:       "other source",
+			path:       "/api/comics/7/similar?source=smbc&limit=3",
+			source:     "smbc",
+			id:         7,
+			limit:      3,
+			mockResult: []core.Comics{},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"comics":[],"total":0}` + "\n",
+		},
+		{
+			name:       "unknown comic",
+			path:       "/api/comics/9999/similar",
+			source:     "xkcd",
+			id:         9999,
+			limit:      10,
+			mockResult: []core.Comics(nil),
+			mockErr:    core.ErrNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "search error",
+			path:       "/api/comics/1/similar",
+			source:     "xkcd",
+			id:         1,
+			limit:      10,
+			mockResult: []core.Comics(nil),
+			mockErr:    errors.New("search error"),
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "bad id",
+			path:       "/api/comics/abc/similar",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "bad limit",
+			path:       "/api/comics/1/similar?limit=many",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSearcher := &MockSearcher{}
+			if tt.id != 0 {
+				mockSearcher.On("Similar", mock.Anything, tt.source, tt.id, tt.limit).Return(tt.mockResult, tt.mockErr)
+			}
+
+			mux := http.NewServeMux()
+			mux.Handle("GET /api/comics/{id}/similar", NewSimilarHandler(slog.Default(), mockSearcher, 10))
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+			mockSearcher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewRandomHandler(t *testing.T) {
+	published := time.Date(2011, 2, 14, 0, 0, 0, 0, time.UTC)
+	from := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		path       string
+		phrase     string
+		opts       core.SearchOptions
+		mock       bool
+		mockResult core.Comics
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "any comic",
+			path:       "/api/comics/random",
+			mock:       true,
+			mockResult: core.Comics{ID: 844, Source: "xkcd", URL: "url844", Published: &published},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id":844,"published":"2011-02-14","source":"xkcd","url":"url844"}` + "\n",
+		},
+		{
+			name:       "by phrase and date",
+			path:       "/api/comics/random?phrase=romance&from=2010-01-01",
+			phrase:     "romance",
+			opts:       core.SearchOptions{From: from},
+			mock:       true,
+			mockResult: core.Comics{ID: 162, Source: "xkcd", URL: "url162"},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id":162,"source":"xkcd","url":"url162"}` + "\n",
+		},
+		{
+			name:       "nothing to draw",
+			path:       "/api/comics/random?phrase=zzz",
+			phrase:     "zzz",
+			mock:       true,
+			mockErr:    core.ErrNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "bad date",
+			path:       "/api/comics/random?from=yesterday",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSearcher := &MockSearcher{}
+			if tt.mock {
+				mockSearcher.On("Random", mock.Anything, tt.phrase, tt.opts).Return(tt.mockResult, tt.mockErr)
+			}
+
+			w := httptest.NewRecorder()
+			NewRandomHandler(slog.Default(), mockSearcher, 10).ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+			mockSearcher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewOnThisDayHandler(t *testing.T) {
+	published := time.Date(2009, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		path       string
+		date       time.Time
+		limit      int
+		mockResult []core.Comics
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "given date",
+			path:       "/api/comics/on-this-day?date=2026-04-01&limit=3",
+			date:       time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+			limit:      3,
+			mockResult: []core.Comics{{ID: 565, Source: "xkcd", URL: "url565", Published: &published}},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"comics":[{"id":565,"published":"2009-04-01","source":"xkcd","url":"url565"}],"total":1}` + "\n",
+		},
+		{
+			name:       "bad limit",
+			path:       "/api/comics/on-this-day?date=2026-04-01&limit=0",
+			date:       time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+			limit:      0,
+			mockResult: []core.Comics(nil),
+			mockErr:    fmt.Errorf("%w: limit must be positive", core.ErrBadArguments),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "bad date",
+			path:       "/api/comics/on-this-day?date=04-01",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSearcher := &MockSearcher{}
+			if !tt.date.IsZero() {
+				mockSearcher.On("OnThisDay", mock.Anything, tt.date, tt.limit).Return(tt.mockResult, tt.mockErr)
+			}
+
+			w := httptest.NewRecorder()
+			NewOnThisDayHandler(slog.Default(), mockSearcher, 10).ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+			mockSearcher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewExplainHandler(t *testing.T) {
+	explain := core.Explain{
+		Terms:   []core.Term{{Word: "python"}},
+		Plan:    []string{"Limit  (cost=16.52..16.53 rows=5 width=52)"},
+		Formula: "score = sum over the matches of tf * boost",
+		Comics: []core.Comics{{ID: 353, Source: "xkcd", URL: "url353", Explanation: &core.Explanation{
+			Score: 3,
+			Matches: []core.Match{
+				{Term: core.Term{Word: "python"}, Field: "title", TF: 1, Boost: 3, Score: 3},
+			},
+		}}},
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		auth       string
+		mockResult core.Explain
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "explained search",
+			query:      "?phrase=python&explain=true",
+			auth:       "Token valid",
+			mockResult: explain,
+			wantStatus: http.StatusOK,
+			wantBody: `{"comics":[{"explanation":{"matches":[{"boost":3,"field":"title","score":3,"term":{"field":"","word":"python"},"tf":1}],"score":3},` +
+				`"id":353,"source":"xkcd","url":"url353"}],"engine":"db","formula":"score = sum over the matches of tf * boost","plan":["Limit  (cost=16.52..16.53 rows=5 width=52)"],` +
+				`"terms":[{"field":"","word":"python"}],"total":1}` + "\n",
+		},
+		{
+			name:       "admins only",
+			query:      "?phrase=python&explain=true",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "plain search",
+			query:      "?phrase=python&explain=false",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"comics":[],"total":0}` + "\n",
+		},
+		{
+			name:       "bad explain",
+			query:      "?phrase=python&explain=maybe",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unexplainable search",
+			query:      "?phrase=python&explain=1",
+			auth:       "Token valid",
+			mockErr:    core.ErrBadArguments,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "search error",
+			query:      "?phrase=python&explain=1",
+			auth:       "Token valid",
+			mockErr:    errors.New("search error"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSearcher := &MockSearcher{}
+			mockSearcher.On("Explain", mock.Anything, core.EngineDB, 10, "python", core.SearchOptions{}).
+				Return(tt.mockResult, tt.mockErr).Maybe()
+			mockSearcher.On("Search", mock.Anything, 10, "python", core.SearchOptions{}).
+				Return(core.Found{Comics: []core.Comics{}}, nil).Maybe()
+
+			mockVerifier := &MockTokenVerifier{}
+			mockVerifier.On("Verify", "valid").Return(nil)
+
+			handler := Explainable(
+				NewSearchHandler(slog.Default(), mockSearcher, 10),
+				NewExplainHandler(slog.Default(), mockSearcher, core.EngineDB, mockVerifier))
+
+			req := httptest.NewRequest("GET", "/api/search"+tt.query, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusUnauthorized {
+				mockSearcher.AssertNotCalled(t, "Explain", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestNewAnalyticsHandler(t *testing.T) {
+	analytics := core.SearchAnalytics{
+		Top:         []core.QueryCount{{Query: "cat", Searches: 12, Clients: 4}},
+		ZeroResults: []core.QueryCount{{Query: "unicorn", Searches: 2, Clients: 1}},
+		Latency: []core.LatencyStats{
+			{Mode: "db", Searches: 14, P50: 4500 * time.Microsecond, P90: 12 * time.Millisecond, P99: 30 * time.Millisecond},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		window     time.Duration
+		limit      int
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "default window",
+			window:     24 * time.Hour,
+			limit:      10,
+			wantStatus: http.StatusOK,
+			wantBody: `{"latency":[{"mode":"db","p50_ms":4.5,"p90_ms":12,"p99_ms":30,"searches":14}],` +
+				`"top":[{"clients":4,"query":"cat","searches":12}],"window":"24h0m0s",` +
+				`"zero_results":[{"clients":1,"query":"unicorn","searches":2}]}` + "\n",
+		},
+		{
+			name:       "chosen window and limit",
+			query:      "?window=1h&limit=3",
+			window:     time.Hour,
+			limit:      3,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "bad window",
+			query:      "?window=week",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "bad limit",
+			query:      "?limit=0",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "search error",
+			window:     24 * time.Hour,
+			limit:      10,
+			mockErr:    errors.New("db error"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSearcher := &MockSearcher{}
+			if tt.window != 0 {
+				start := time.Now()
+				mockSearcher.On("Analytics", mock.Anything, mock.MatchedBy(func(since time.Time) bool {
+					return !since.Before(start.Add(-tt.window)) && since.Before(start.Add(-tt.window+time.Minute))
+				}), tt.limit).Return(analytics, tt.mockErr)
+			}
+
+			mockVerifier := &MockTokenVerifier{}
+			mockVerifier.On("Verify", "valid").Return(nil)
+
+			handler := NewAnalyticsHandler(slog.Default(), mockSearcher, mockVerifier)
+
+			req := httptest.NewRequest("GET", "/api/analytics"+tt.query, nil)
+			req.Header.Set("Authorization", "Token valid")
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+			mockSearcher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewCurationHandler(t *testing.T) {
+	mockCurator := &MockCurator{}
+	mockCurator.On("Curation", mock.Anything).Return(core.Curation{
+		Pins:     []core.Pin{{Query: "password", Comics: []core.ComicID{{Source: "xkcd", ID: 936}}}},
+		Rewrites: []core.Rewrite{{Query: "passwd", Phrase: "password"}},
+		Blocked:  []core.ComicID{{Source: "xkcd", ID: 404}},
+	}, nil)
+	mockVerifier := &MockTokenVerifier{}
+	mockVerifier.On("Verify", "valid").Return(nil)
+
+	req := httptest.NewRequest("GET", "/api/curation", nil)
+	req.Header.Set("Authorization", "Token valid")
+	w := httptest.NewRecorder()
+	NewCurationHandler(slog.Default(), mockCurator, mockVerifier)(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"blocked":[{"id":404,"source":"xkcd"}],`+
+		`"pins":[{"comics":[{"id":936,"source":"xkcd"}],"query":"password"}],`+
+		`"rewrites":[{"phrase":"password","query":"passwd"}]}`+"\n", w.Body.String())
+}
+
+func TestNewPutPinHandler(t *testing.T) {
+	pinned := []core.ComicID{{Source: "xkcd", ID: 936}, {Source: "local", ID: 3}}
+
+	tests := []struct {
+		name       string
+		body       string
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "pinned",
+			body:       `{"query":"Passwords","comics":[{"id":936},{"id":3,"source":"local"}]}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"comics":[{"id":936,"source":"xkcd"},{"id":3,"source":"local"}],"query":"password"}` + "\n",
+		},
+		{
+			name:       "bad json",
+			body:       `{"query":`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "no words to pin",
+			body:       `{"query":"Passwords","comics":[{"id":936},{"id":3,"source":"local"}]}`,
+			mockErr:    fmt.Errorf("%w: no words", core.ErrBadArguments),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "search error",
+			body:       `{"query":"Passwords","comics":[{"id":936},{"id":3,"source":"local"}]}`,
+			mockErr:    errors.New("search is down"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCurator := &MockCurator{}
+			mockCurator.On("PutPin", mock.Anything, "Passwords", pinned).
+				Return(core.Pin{Query: "password", Comics: pinned}, tt.mockErr).Maybe()
+			mockVerifier := &MockTokenVerifier{}
+			mockVerifier.On("Verify", "valid").Return(nil)
+
+			req := httptest.NewRequest("PUT", "/api/curation/pins", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Token valid")
+			w := httptest.NewRecorder()
+			NewPutPinHandler(slog.Default(), mockCurator, mockVerifier)(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewUnblockHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		query      string
+		mockErr    error
+		wantStatus int
+	}{
+		{name: "unblocked", id: "404", wantStatus: http.StatusOK},
+		{name: "not blocked", id: "404", mockErr: core.ErrNotFound, wantStatus: http.StatusNotFound},
+		{name: "other source", id: "404", query: "?source=local", wantStatus: http.StatusOK},
+		{name: "bad id", id: "0", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := core.DefaultSource
+			if tt.query != "" {
+				source = "local"
+			}
+			mockCurator := &MockCurator{}
+			mockCurator.On("Unblock", mock.Anything, core.ComicID{Source: source, ID: 404}).Return(tt.mockErr).Maybe()
+			mockVerifier := &MockTokenVerifier{}
+			mockVerifier.On("Verify", "valid").Return(nil)
+
+			req := httptest.NewRequest("DELETE", "/api/curation/blocklist/"+tt.id+tt.query, nil)
+			req.SetPathValue("id", tt.id)
+			req.Header.Set("Authorization", "Token valid")
+			w := httptest.NewRecorder()
+			NewUnblockHandler(slog.Default(), mockCurator, mockVerifier)(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestNewAddTagHandler(t *testing.T) {
+	added := time.Date(2025, 2, 14, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "tagged",
+			path:       "/api/comics/1/tags",
+			body:       `{"tag":"Romance"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"added_at":"2025-02-14T00:00:00Z","name":"romance","words":["romanc"]}` + "\n",
+		},
+		{
+			name:       "bad id",
+			path:       "/api/comics/x/tags",
+			body:       `{"tag":"Romance"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "bad body",
+			path:       "/api/comics/1/tags",
+			body:       `"Romance"`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "stop words",
+			path:       "/api/comics/1/tags",
+			body:       `{"tag":"Romance"}`,
+			mockErr:    fmt.Errorf("%w: no words", core.ErrBadArguments),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown comic",
+			path:       "/api/comics/1/tags",
+			body:       `{"tag":"Romance"}`,
+			mockErr:    core.ErrNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUpdater := &MockUpdater{}
+			mockUpdater.On("AddTag", mock.Anything, core.DefaultSource, 1, "Romance").
+				Return(core.Tag{Name: "romance", Words: []string{"romanc"}, AddedAt: added}, tt.mockErr).Maybe()
+			mockVerifier := &MockTokenVerifier{}
+			mockVerifier.On("Verify", "valid").Return(nil)
+
+			mux := http.NewServeMux()
+			mux.Handle("POST /api/comics/{id}/tags", NewAddTagHandler(slog.Default(), mockUpdater, mockVerifier))
+
+			req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Token valid")
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewRemoveTagHandler(t *testing.T) {
+	mockUpdater := &MockUpdater{}
+	mockUpdater.On("RemoveTag", mock.Anything, "local", 3, "black hat").Return(nil)
+	mockUpdater.On("RemoveTag", mock.Anything, core.DefaultSource, 3, "math").Return(core.ErrNotFound)
+	mockVerifier := &MockTokenVerifier{}
+	mockVerifier.On("Verify", "valid").Return(nil)
+
+	mux := http.NewServeMux()
+	mux.Handle("DELETE /api/comics/{id}/tags/{tag}", NewRemoveTagHandler(slog.Default(), mockUpdater, mockVerifier))
+
+	for path, want := range map[string]int{
+		"/api/comics/3/tags/black%20hat?source=local": http.StatusOK,
+		"/api/comics/3/tags/math":                     http.StatusNotFound,
+	} {
+		req := httptest.NewRequest("DELETE", path, nil)
+		req.Header.Set("Authorization", "Token valid")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code, path)
+	}
+	mockUpdater.AssertExpectations(t)
+}
+
+func TestNewTagCountsHandler(t *testing.T) {
+	mockUpdater := &MockUpdater{}
+	mockUpdater.On("TagCounts", mock.Anything).Return([]core.TagCount{{Tag: "math", Comics: 12}}, nil)
+
+	w := httptest.NewRecorder()
+	NewTagCountsHandler(slog.Default(), mockUpdater)(w, httptest.NewRequest("GET", "/api/tags", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"tags":[{"comics":12,"tag":"math"}]}`+"\n", w.Body.String())
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type clientKey struct{}
+
+// Client puts a hash of the client address into the request context, so
+// that the searches can be told apart by client without keeping addresses.
+// The frontend passes its users' addresses in X-Forwarded-For.
+func Client(next http.HandlerFunc, salt string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(salt + clientAddress(r)))
+		ctx := context.WithValue(r.Context(), clientKey{}, hex.EncodeToString(sum[:8]))
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// ClientFrom returns the client hash put by Client, empty if there is none.
+func ClientFrom(ctx context.Context) string {
+	client, _ := ctx.Value(clientKey{}).(string)
+	return client
+}
+
+func clientAddress(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		first, _, _ := strings.Cut(forwarded, ",")
+		return strings.TrimSpace(first)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
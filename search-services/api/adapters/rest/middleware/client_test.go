@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		sameAs     string
+	}{
+		{
+			name:       "remote address without port",
+			remoteAddr: "192.0.2.1:1234",
+			sameAs:     "192.0.2.1:4321",
+		},
+		{
+			name:       "first forwarded address",
+			remoteAddr: "10.0.0.5:80",
+			forwarded:  "198.51.100.7, 10.0.0.5",
+			sameAs:     "198.51.100.7:1",
+		},
+	}
+
+	hash := func(remoteAddr, forwarded, salt string) string {
+		var client string
+		next := func(w http.ResponseWriter, r *http.Request) {
+			client = ClientFrom(r.Context())
+		}
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		if forwarded != "" {
+			req.Header.Set("X-Forwarded-For", forwarded)
+		}
+		Client(next, salt).ServeHTTP(httptest.NewRecorder(), req)
+		return client
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hash(tt.remoteAddr, tt.forwarded, "salt")
+			assert.Len(t, got, 16)
+			assert.Equal(t, hash(tt.sameAs, "", "salt"), got)
+			assert.NotEqual(t, hash(tt.sameAs, "", "pepper"), got)
+			assert.NotEqual(t, hash("203.0.113.9:1", "", "salt"), got)
+		})
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	assert.Empty(t, ClientFrom(req.Context()))
+}
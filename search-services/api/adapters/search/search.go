@@ -4,9 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"yadro.com/course/api/core"
 	searchpb "yadro.com/course/proto/search"
 )
@@ -37,48 +42,458 @@ func (c Client) Ping(ctx context.Context) error {
 	return nil
 }
 
-func (c Client) Search(ctx context.Context, limit int, phrase string) ([]core.Comics, error) {
-	req := &searchpb.SearchRequest{
-		Phrase: phrase,
-		Limit:  int64(limit),
-	}
+func (c Client) Search(ctx context.Context, limit int, phrase string, opts core.SearchOptions) (core.Found, error) {
+	req := searchRequest(limit, phrase, opts)
 
 	resp, err := c.client.Search(ctx, req)
 	if err != nil {
 		c.log.Error("failed to search comics", "error", err)
+		return core.Found{}, err
+	}
+
+	found := core.Found{
+		Comics:     make([]core.Comics, len(resp.GetComics())),
+		Relaxation: relaxation(resp.GetRelaxation()),
+	}
+	for i, comic := range resp.GetComics() {
+		found.Comics[i] = core.Comics{
+			ID:      int(comic.GetId()),
+			Source:  comic.GetSource(),
+			URL:     comic.GetUrl(),
+			Snippet: snippet(comic.GetSnippet()),
+		}
+	}
+
+	return found, nil
+}
+
+func (c Client) IndexSearch(ctx context.Context, limit int, phrase string, opts core.SearchOptions) (core.Found, error) {
+	req := searchRequest(limit, phrase, opts)
+
+	resp, err := c.client.IndexSearch(ctx, req)
+	if err != nil {
+		c.log.Error("failed to search comics", "error", err)
+		return core.Found{}, err
+	}
+
+	found := core.Found{
+		Comics:     make([]core.Comics, len(resp.GetComics())),
+		Relaxation: relaxation(resp.GetRelaxation()),
+	}
+	for i, comic := range resp.GetComics() {
+		found.Comics[i] = core.Comics{
+			ID:      int(comic.GetId()),
+			Source:  comic.GetSource(),
+			URL:     comic.GetUrl(),
+			Snippet: snippet(comic.GetSnippet()),
+		}
+	}
+
+	return found, nil
+}
+
+func (c Client) FTSSearch(ctx context.Context, limit int, phrase string, opts core.SearchOptions) ([]core.Comics, error) {
+	req := searchRequest(limit, phrase, opts)
+
+	resp, err := c.client.FTSSearch(ctx, req)
+	if err != nil {
+		c.log.Error("failed to full-text search comics", "error", err)
 		return nil, err
 	}
 
 	comics := make([]core.Comics, len(resp.GetComics()))
 	for i, comic := range resp.GetComics() {
 		comics[i] = core.Comics{
-			ID:  int(comic.GetId()),
-			URL: comic.GetUrl(),
+			ID:      int(comic.GetId()),
+			Source:  comic.GetSource(),
+			URL:     comic.GetUrl(),
+			Snippet: snippet(comic.GetSnippet()),
 		}
 	}
 
 	return comics, nil
 }
 
-func (c Client) IndexSearch(ctx context.Context, limit int, phrase string) ([]core.Comics, error) {
-	req := &searchpb.SearchRequest{
-		Phrase: phrase,
+// HybridSearch returns core.ErrBadArguments for a search the service cannot
+// fuse, such as one sorted by date.
+func (c Client) HybridSearch(ctx context.Context, limit int, phrase string, opts core.SearchOptions) (core.Hybrid, error) {
+	req := searchRequest(limit, phrase, opts)
+
+	resp, err := c.client.HybridSearch(ctx, req)
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			return core.Hybrid{}, fmt.Errorf("%w: %s", core.ErrBadArguments, status.Convert(err).Message())
+		}
+		c.log.Error("failed to hybrid search comics", "error", err)
+		return core.Hybrid{}, err
+	}
+
+	hybrid := core.Hybrid{
+		Comics:   make([]core.Comics, len(resp.GetComics())),
+		Degraded: resp.GetDegraded(),
+	}
+	for i, comic := range resp.GetComics() {
+		hybrid.Comics[i] = core.Comics{
+			ID:      int(comic.GetId()),
+			Source:  comic.GetSource(),
+			URL:     comic.GetUrl(),
+			Snippet: snippet(comic.GetSnippet()),
+		}
+	}
+
+	return hybrid, nil
+}
+
+// SemanticSearch returns core.ErrBadArguments for a search the service cannot
+// run, such as one sorted by date or with the semantic search disabled.
+func (c Client) SemanticSearch(ctx context.Context, limit int, phrase string, opts core.SearchOptions) ([]core.Comics, error) {
+	req := searchRequest(limit, phrase, opts)
+
+	resp, err := c.client.SemanticSearch(ctx, req)
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			return nil, fmt.Errorf("%w: %s", core.ErrBadArguments, status.Convert(err).Message())
+		}
+		c.log.Error("failed to semantic search comics", "error", err)
+		return nil, err
+	}
+
+	comics := make([]core.Comics, len(resp.GetComics()))
+	for i, comic := range resp.GetComics() {
+		comics[i] = core.Comics{
+			ID:      int(comic.GetId()),
+			Source:  comic.GetSource(),
+			URL:     comic.GetUrl(),
+			Snippet: snippet(comic.GetSnippet()),
+		}
+	}
+
+	return comics, nil
+}
+
+func (c Client) Similar(ctx context.Context, source string, id, limit int) ([]core.Comics, error) {
+	req := &searchpb.SimilarRequest{
+		Id:     int64(id),
 		Limit:  int64(limit),
+		Source: source,
 	}
 
-	resp, err := c.client.IndexSearch(ctx, req)
+	resp, err := c.client.Similar(ctx, req)
 	if err != nil {
-		c.log.Error("failed to search comics", "error", err)
+		if status.Code(err) == codes.NotFound {
+			return nil, core.ErrNotFound
+		}
+		c.log.Error("failed to find similar comics", "error", err)
 		return nil, err
 	}
 
 	comics := make([]core.Comics, len(resp.GetComics()))
 	for i, comic := range resp.GetComics() {
 		comics[i] = core.Comics{
-			ID:  int(comic.GetId()),
-			URL: comic.GetUrl(),
+			ID:     int(comic.GetId()),
+			Source: comic.GetSource(),
+			URL:    comic.GetUrl(),
 		}
 	}
 
 	return comics, nil
 }
+
+func (c Client) Random(ctx context.Context, phrase string, opts core.SearchOptions) (core.Comics, error) {
+	req := &searchpb.RandomRequest{Phrase: phrase, Client: opts.Client}
+	if !opts.From.IsZero() {
+		req.From = timestamppb.New(opts.From)
+	}
+	if !opts.To.IsZero() {
+		req.To = timestamppb.New(opts.To)
+	}
+
+	resp, err := c.client.Random(ctx, req)
+	if err != nil {
+		return core.Comics{}, c.curationError("failed to draw comic", err)
+	}
+	return dated(resp), nil
+}
+
+func (c Client) OnThisDay(ctx context.Context, date time.Time, limit int) ([]core.Comics, error) {
+	resp, err := c.client.OnThisDay(ctx, &searchpb.OnThisDayRequest{
+		Date:  timestamppb.New(date),
+		Limit: int64(limit),
+	})
+	if err != nil {
+		return nil, c.curationError("failed to find comics of the day", err)
+	}
+
+	comics := make([]core.Comics, len(resp.GetComics()))
+	for i, comic := range resp.GetComics() {
+		comics[i] = dated(comic)
+	}
+	return comics, nil
+}
+
+// Explain runs the search of the engine asking to explain it.
+func (c Client) Explain(ctx context.Context, engine string, limit int, phrase string, opts core.SearchOptions) (core.Explain, error) {
+	var search func(context.Context, *searchpb.SearchRequest, ...grpc.CallOption) (*searchpb.SearchReply, error)
+	switch engine {
+	case core.EngineDB:
+		search = c.client.Search
+	case core.EngineIndex:
+		search = c.client.IndexSearch
+	case core.EngineFTS:
+		search = c.client.FTSSearch
+	default:
+		return core.Explain{}, fmt.Errorf("%w: unknown engine %q", core.ErrBadArguments, engine)
+	}
+
+	req := searchRequest(limit, phrase, opts)
+	req.Explain = true
+
+	resp, err := search(ctx, req)
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			return core.Explain{}, fmt.Errorf("%w: %s", core.ErrBadArguments, status.Convert(err).Message())
+		}
+		c.log.Error("failed to explain search", "engine", engine, "error", err)
+		return core.Explain{}, err
+	}
+
+	explain := core.Explain{
+		TSQuery: resp.GetExplain().GetTsquery(),
+		Plan:    resp.GetExplain().GetPlan(),
+		Formula: resp.GetExplain().GetFormula(),
+		Comics:  make([]core.Comics, len(resp.GetComics())),
+	}
+	for _, term := range resp.GetExplain().GetTerms() {
+		explain.Terms = append(explain.Terms, core.Term{Field: term.GetField(), Word: term.GetWord()})
+	}
+	for i, comic := range resp.GetComics() {
+		explain.Comics[i] = core.Comics{
+			ID:          int(comic.GetId()),
+			Source:      comic.GetSource(),
+			URL:         comic.GetUrl(),
+			Snippet:     snippet(comic.GetSnippet()),
+			Explanation: explanation(comic.GetExplanation()),
+		}
+	}
+	return explain, nil
+}
+
+func (c Client) Analytics(ctx context.Context, since time.Time, limit int) (core.SearchAnalytics, error) {
+	reply, err := c.client.Analytics(ctx, &searchpb.AnalyticsRequest{
+		Since: timestamppb.New(since),
+		Limit: int64(limit),
+	})
+	if err != nil {
+		c.log.Error("failed to get search analytics", "error", err)
+		return core.SearchAnalytics{}, err
+	}
+
+	analytics := core.SearchAnalytics{
+		Top:         queryCounts(reply.GetTop()),
+		ZeroResults: queryCounts(reply.GetZeroResults()),
+		Latency:     make([]core.LatencyStats, 0, len(reply.GetLatency())),
+	}
+	for _, l := range reply.GetLatency() {
+		analytics.Latency = append(analytics.Latency, core.LatencyStats{
+			Mode:     l.GetMode(),
+			Searches: int(l.GetSearches()),
+			P50:      milliseconds(l.GetP50Ms()),
+			P90:      milliseconds(l.GetP90Ms()),
+			P99:      milliseconds(l.GetP99Ms()),
+		})
+	}
+	return analytics, nil
+}
+
+func (c Client) CacheStats(ctx context.Context) (core.CacheStats, error) {
+	reply, err := c.client.CacheStats(ctx, &emptypb.Empty{})
+	if err != nil {
+		c.log.Error("failed to get cache stats", "error", err)
+		return core.CacheStats{}, err
+	}
+	return core.CacheStats{
+		Hits:      int(reply.GetHits()),
+		Misses:    int(reply.GetMisses()),
+		Evictions: int(reply.GetEvictions()),
+		Purges:    int(reply.GetPurges()),
+		Size:      int(reply.GetSize()),
+		Capacity:  int(reply.GetCapacity()),
+	}, nil
+}
+
+func (c Client) Curation(ctx context.Context) (core.Curation, error) {
+	reply, err := c.client.GetCuration(ctx, &emptypb.Empty{})
+	if err != nil {
+		c.log.Error("failed to get curation", "error", err)
+		return core.Curation{}, err
+	}
+
+	curation := core.Curation{
+		Pins:     make([]core.Pin, 0, len(reply.GetPins())),
+		Rewrites: make([]core.Rewrite, 0, len(reply.GetRewrites())),
+		Blocked:  comicIDs(reply.GetBlocked()),
+	}
+	for _, pin := range reply.GetPins() {
+		curation.Pins = append(curation.Pins, core.Pin{Query: pin.GetQuery(), Comics: comicIDs(pin.GetComics())})
+	}
+	for _, rewrite := range reply.GetRewrites() {
+		curation.Rewrites = append(curation.Rewrites, core.Rewrite{Query: rewrite.GetQuery(), Phrase: rewrite.GetPhrase()})
+	}
+	return curation, nil
+}
+
+func (c Client) PutPin(ctx context.Context, phrase string, comics []core.ComicID) (core.Pin, error) {
+	reply, err := c.client.PutPin(ctx, &searchpb.Pin{Query: phrase, Comics: comicRefs(comics)})
+	if err != nil {
+		return core.Pin{}, c.curationError("failed to put pin", err)
+	}
+	return core.Pin{Query: reply.GetQuery(), Comics: comicIDs(reply.GetComics())}, nil
+}
+
+func (c Client) DeletePin(ctx context.Context, phrase string) error {
+	_, err := c.client.DeletePin(ctx, &searchpb.QueryRequest{Query: phrase})
+	return c.curationError("failed to delete pin", err)
+}
+
+func (c Client) PutRewrite(ctx context.Context, phrase, rewrite string) (core.Rewrite, error) {
+	reply, err := c.client.PutRewrite(ctx, &searchpb.Rewrite{Query: phrase, Phrase: rewrite})
+	if err != nil {
+		return core.Rewrite{}, c.curationError("failed to put rewrite", err)
+	}
+	return core.Rewrite{Query: reply.GetQuery(), Phrase: reply.GetPhrase()}, nil
+}
+
+func (c Client) DeleteRewrite(ctx context.Context, phrase string) error {
+	_, err := c.client.DeleteRewrite(ctx, &searchpb.QueryRequest{Query: phrase})
+	return c.curationError("failed to delete rewrite", err)
+}
+
+func (c Client) Block(ctx context.Context, id core.ComicID) error {
+	_, err := c.client.Block(ctx, &searchpb.ComicRef{Id: int64(id.ID), Source: id.Source})
+	return c.curationError("failed to block comic", err)
+}
+
+func (c Client) Unblock(ctx context.Context, id core.ComicID) error {
+	_, err := c.client.Unblock(ctx, &searchpb.ComicRef{Id: int64(id.ID), Source: id.Source})
+	return c.curationError("failed to unblock comic", err)
+}
+
+// curationError maps the statuses of the curation edits, and of the random
+// draws, to the core errors, logging the unexpected ones.
+func (c Client) curationError(msg string, err error) error {
+	switch status.Code(err) {
+	case codes.OK:
+		return nil
+	case codes.NotFound:
+		return core.ErrNotFound
+	case codes.InvalidArgument:
+		return fmt.Errorf("%w: %s", core.ErrBadArguments, status.Convert(err).Message())
+	}
+	c.log.Error(msg, "error", err)
+	return err
+}
+
+func comicIDs(in []*searchpb.ComicRef) []core.ComicID {
+	out := make([]core.ComicID, 0, len(in))
+	for _, ref := range in {
+		out = append(out, core.ComicID{Source: ref.GetSource(), ID: int(ref.GetId())})
+	}
+	return out
+}
+
+func comicRefs(in []core.ComicID) []*searchpb.ComicRef {
+	out := make([]*searchpb.ComicRef, 0, len(in))
+	for _, id := range in {
+		out = append(out, &searchpb.ComicRef{Id: int64(id.ID), Source: id.Source})
+	}
+	return out
+}
+
+func queryCounts(in []*searchpb.QueryCount) []core.QueryCount {
+	out := make([]core.QueryCount, 0, len(in))
+	for _, c := range in {
+		out = append(out, core.QueryCount{Query: c.GetQuery(), Searches: int(c.GetSearches()), Clients: int(c.GetClients())})
+	}
+	return out
+}
+
+func milliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+func searchRequest(limit int, phrase string, opts core.SearchOptions) *searchpb.SearchRequest {
+	req := &searchpb.SearchRequest{
+		Phrase:  phrase,
+		Limit:   int64(limit),
+		MinId:   int64(opts.MinID),
+		MaxId:   int64(opts.MaxID),
+		Sort:    opts.Sort,
+		All:     opts.All,
+		Client:  opts.Client,
+		NoCache: opts.NoCache,
+	}
+	if !opts.From.IsZero() {
+		req.From = timestamppb.New(opts.From)
+	}
+	if !opts.To.IsZero() {
+		req.To = timestamppb.New(opts.To)
+	}
+	return req
+}
+
+// dated is the comic with its publication date, if it has one.
+func dated(in *searchpb.Comics) core.Comics {
+	out := core.Comics{
+		ID:      int(in.GetId()),
+		Source:  in.GetSource(),
+		URL:     in.GetUrl(),
+		Snippet: snippet(in.GetSnippet()),
+	}
+	if in.GetPublished() != nil {
+		published := in.GetPublished().AsTime()
+		out.Published = &published
+	}
+	return out
+}
+
+func relaxation(in *searchpb.Relaxation) *core.Relaxation {
+	if in == nil {
+		return nil
+	}
+
+	out := &core.Relaxation{Relax: in.GetRelax()}
+	for _, term := range in.GetTerms() {
+		out.Terms = append(out.Terms, core.Term{Field: term.GetField(), Word: term.GetWord()})
+	}
+	return out
+}
+
+func snippet(in *searchpb.Snippet) core.Snippet {
+	if in == nil {
+		return core.Snippet{}
+	}
+
+	out := core.Snippet{Field: in.GetField(), Text: in.GetText()}
+	for _, h := range in.GetHighlights() {
+		out.Highlights = append(out.Highlights, core.Highlight{Start: int(h.GetStart()), End: int(h.GetEnd())})
+	}
+	return out
+}
+
+func explanation(in *searchpb.Explanation) *core.Explanation {
+	if in == nil {
+		return nil
+	}
+
+	out := &core.Explanation{Score: in.GetScore()}
+	for _, m := range in.GetMatches() {
+		out.Matches = append(out.Matches, core.Match{
+			Term:  core.Term{Field: m.GetTerm().GetField(), Word: m.GetTerm().GetWord()},
+			Field: m.GetField(),
+			TF:    int(m.GetTf()),
+			Boost: m.GetBoost(),
+			Score: m.GetScore(),
+		})
+	}
+	return out
+}
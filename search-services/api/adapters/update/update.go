@@ -2,10 +2,17 @@ package update
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"yadro.com/course/api/core"
 	updatepb "yadro.com/course/proto/update"
 )
@@ -60,11 +67,22 @@ func (c Client) Stats(ctx context.Context) (core.UpdateStats, error) {
 		c.log.Error("failed to get stats", "error", err)
 		return core.UpdateStats{}, err
 	}
+	pipeline := make([]core.StageMetrics, 0, len(stats.Pipeline))
+	for _, m := range stats.Pipeline {
+		pipeline = append(pipeline, core.StageMetrics{
+			Stage:     m.Stage,
+			Items:     int(m.Items),
+			Errors:    int(m.Errors),
+			Seconds:   m.Seconds,
+			PerSecond: m.PerSecond,
+		})
+	}
 	return core.UpdateStats{
 		WordsTotal:    int(stats.WordsTotal),
 		WordsUnique:   int(stats.WordsUnique),
 		ComicsFetched: int(stats.ComicsFetched),
 		ComicsTotal:   int(stats.ComicsTotal),
+		Pipeline:      pipeline,
 	}, nil
 }
 
@@ -85,3 +103,258 @@ func (c Client) Drop(ctx context.Context) error {
 	}
 	return nil
 }
+
+func (c Client) Reindex(ctx context.Context) error {
+	_, err := c.client.Reindex(ctx, nil)
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return core.ErrAlreadyExists
+		}
+		c.log.Error("failed to reindex db", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (c Client) Export(ctx context.Context, send func(core.ComicRecord) error) error {
+	stream, err := c.client.Export(ctx, nil)
+	if err != nil {
+		c.log.Error("failed to export db", "error", err)
+		return err
+	}
+
+	for {
+		comic, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			c.log.Error("failed to receive comic", "error", err)
+			return err
+		}
+		err = send(core.ComicRecord{
+			Source:             comic.GetSource(),
+			ID:                 int(comic.GetId()),
+			URL:                comic.GetUrl(),
+			Keywords:           comic.GetKeywords(),
+			TitleKeywords:      comic.GetTitleKeywords(),
+			AltKeywords:        comic.GetAltKeywords(),
+			TranscriptKeywords: comic.GetTranscriptKeywords(),
+			Title:              comic.GetTitle(),
+			SafeTitle:          comic.GetSafeTitle(),
+			Alt:                comic.GetAlt(),
+			Transcript:         comic.GetTranscript(),
+			AnalyzerVersion:    int(comic.GetAnalyzerVersion()),
+			Published:          date(comic.GetPublished()),
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Import streams comics returned by recv until it reports io.EOF. Any other
+// error from recv aborts the call.
+func (c Client) Import(ctx context.Context, recv func() (core.ComicRecord, error)) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.client.Import(ctx)
+	if err != nil {
+		c.log.Error("failed to import db", "error", err)
+		return 0, err
+	}
+
+	for {
+		comic, err := recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		err = stream.Send(&updatepb.Comic{
+			Source:             comic.Source,
+			Id:                 int64(comic.ID),
+			Url:                comic.URL,
+			Keywords:           comic.Keywords,
+			TitleKeywords:      comic.TitleKeywords,
+			AltKeywords:        comic.AltKeywords,
+			TranscriptKeywords: comic.TranscriptKeywords,
+			Title:              comic.Title,
+			SafeTitle:          comic.SafeTitle,
+			Alt:                comic.Alt,
+			Transcript:         comic.Transcript,
+			AnalyzerVersion:    int64(comic.AnalyzerVersion),
+			Published:          timestamp(comic.Published),
+		})
+		// io.EOF means the server has already failed, CloseAndRecv reports why.
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			c.log.Error("failed to send comic", "error", err)
+			return 0, err
+		}
+	}
+
+	reply, err := stream.CloseAndRecv()
+	if err != nil {
+		c.log.Error("failed to import db", "error", err)
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			return 0, fmt.Errorf("%w: %s", core.ErrBadArguments, status.Convert(err).Message())
+		case codes.AlreadyExists:
+			return 0, core.ErrAlreadyExists
+		}
+		return 0, err
+	}
+	return int(reply.GetImported()), nil
+}
+
+func (c Client) Verify(ctx context.Context, repair bool) ([]core.VerifyReport, error) {
+	reply, err := c.client.Verify(ctx, &updatepb.VerifyRequest{Repair: repair})
+	if err != nil {
+		c.log.Error("failed to verify db", "error", err)
+		if status.Code(err) == codes.AlreadyExists {
+			return nil, core.ErrAlreadyExists
+		}
+		return nil, err
+	}
+
+	reports := make([]core.VerifyReport, 0, len(reply.GetSources()))
+	for _, r := range reply.GetSources() {
+		reports = append(reports, core.VerifyReport{
+			Source:   r.GetSource(),
+			Total:    int(r.GetTotal()),
+			Missing:  toInt(r.GetMissing()),
+			Empty:    toInt(r.GetEmpty()),
+			Repaired: int(r.GetRepaired()),
+		})
+	}
+	return reports, nil
+}
+
+func (c Client) Refresh(ctx context.Context) (int, error) {
+	reply, err := c.client.Refresh(ctx, nil)
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return 0, core.ErrAlreadyExists
+		}
+		c.log.Error("failed to refresh db", "error", err)
+		return 0, err
+	}
+	return int(reply.GetChanged()), nil
+}
+
+func (c Client) History(ctx context.Context, source string, id int) ([]core.ComicVersion, error) {
+	reply, err := c.client.History(ctx, &updatepb.HistoryRequest{Source: source, Id: int64(id)})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, core.ErrNotFound
+		}
+		c.log.Error("failed to get comic history", "error", err)
+		return nil, err
+	}
+
+	versions := make([]core.ComicVersion, 0, len(reply.GetVersions()))
+	for _, v := range reply.GetVersions() {
+		versions = append(versions, core.ComicVersion{
+			URL:         v.GetUrl(),
+			Title:       v.GetTitle(),
+			SafeTitle:   v.GetSafeTitle(),
+			Alt:         v.GetAlt(),
+			Transcript:  v.GetTranscript(),
+			ContentHash: v.GetContentHash(),
+			ReplacedAt:  v.GetReplacedAt().AsTime(),
+		})
+	}
+	return versions, nil
+}
+
+func (c Client) Tags(ctx context.Context, source string, id int) ([]core.Tag, error) {
+	reply, err := c.client.Tags(ctx, &updatepb.TagsRequest{Source: source, Id: int64(id)})
+	if err != nil {
+		return nil, c.tagError("failed to get tags", err)
+	}
+
+	tags := make([]core.Tag, 0, len(reply.GetTags()))
+	for _, tag := range reply.GetTags() {
+		tags = append(tags, newTag(tag))
+	}
+	return tags, nil
+}
+
+func (c Client) AddTag(ctx context.Context, source string, id int, tag string) (core.Tag, error) {
+	reply, err := c.client.AddTag(ctx, &updatepb.TagRequest{Source: source, Id: int64(id), Tag: tag})
+	if err != nil {
+		return core.Tag{}, c.tagError("failed to add tag", err)
+	}
+	return newTag(reply), nil
+}
+
+func (c Client) RemoveTag(ctx context.Context, source string, id int, tag string) error {
+	_, err := c.client.RemoveTag(ctx, &updatepb.TagRequest{Source: source, Id: int64(id), Tag: tag})
+	if err != nil {
+		return c.tagError("failed to remove tag", err)
+	}
+	return nil
+}
+
+func (c Client) TagCounts(ctx context.Context) ([]core.TagCount, error) {
+	reply, err := c.client.TagCounts(ctx, nil)
+	if err != nil {
+		c.log.Error("failed to count tags", "error", err)
+		return nil, err
+	}
+
+	counts := make([]core.TagCount, 0, len(reply.GetTags()))
+	for _, tc := range reply.GetTags() {
+		counts = append(counts, core.TagCount{Tag: tc.GetTag(), Comics: int(tc.GetComics())})
+	}
+	return counts, nil
+}
+
+// tagError maps the statuses of the tag requests to the core errors,
+// logging the unexpected ones.
+func (c Client) tagError(msg string, err error) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return core.ErrNotFound
+	case codes.InvalidArgument:
+		return fmt.Errorf("%w: %s", core.ErrBadArguments, status.Convert(err).Message())
+	}
+	c.log.Error(msg, "error", err)
+	return err
+}
+
+func newTag(in *updatepb.Tag) core.Tag {
+	tag := core.Tag{Name: in.GetName(), Words: in.GetWords()}
+	if in.GetAddedAt() != nil {
+		tag.AddedAt = in.GetAddedAt().AsTime()
+	}
+	return tag
+}
+
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func date(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func toInt(ids []int64) []int {
+	out := make([]int, len(ids))
+	for i, id := range ids {
+		out[i] = int(id)
+	}
+	return out
+}
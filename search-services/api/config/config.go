@@ -21,6 +21,9 @@ type Config struct {
 	UpdateAddress     string        `yaml:"update_address" env:"UPDATE_ADDRESS" env-default:"update:82"`
 	SearchAddress     string        `yaml:"search_address" env:"SEARCH_ADDRESS" env-default:"search:83"`
 	TokenTTL          time.Duration `yaml:"token_ttl" env:"TOKEN_TTL" env-default:"24h"`
+	// ClientSalt is mixed into the hashes of client addresses kept in the
+	// search log.
+	ClientSalt string `yaml:"client_salt" env:"CLIENT_SALT"`
 }
 
 func MustLoad(configPath string) Config {
@@ -1,5 +1,10 @@
 package core
 
+import (
+	"strings"
+	"time"
+)
+
 type UpdateStatus string
 
 const (
@@ -13,9 +18,245 @@ type UpdateStats struct {
 	WordsUnique   int
 	ComicsFetched int
 	ComicsTotal   int
+	Pipeline      []StageMetrics
+}
+
+// StageMetrics describes one stage of the last update pipeline run.
+type StageMetrics struct {
+	Stage     string
+	Items     int
+	Errors    int
+	Seconds   float64
+	PerSecond float64
 }
 
 type Comics struct {
-	ID  int
-	URL string
+	ID      int
+	Source  string
+	URL     string
+	Snippet Snippet
+	// Explanation is set by explained searches only.
+	Explanation *Explanation
+	// Published is set by the random draws and the comics of the day only,
+	// nil for an undated comic.
+	Published *time.Time
+}
+
+// Hybrid is a search fused from all the engines. Degraded names the engines
+// that failed or timed out, their comics are missing.
+type Hybrid struct {
+	Comics   []Comics
+	Degraded []string
+}
+
+// Found is the comics a search found. Relaxation is set if the phrase
+// required all its words and found nothing, so it was loosened.
+type Found struct {
+	Comics     []Comics
+	Relaxation *Relaxation
+}
+
+// Relaxation tells how a phrase was loosened: drop_term, any_term or fuzzy,
+// and the terms the comics were found by.
+type Relaxation struct {
+	Relax string
+	Terms []Term
+}
+
+// Phrase writes the terms as a search phrase, the scoped ones as field:word.
+func (r Relaxation) Phrase() string {
+	words := make([]string, len(r.Terms))
+	for i, term := range r.Terms {
+		words[i] = term.Word
+		if term.Field != "" {
+			words[i] = term.Field + ":" + term.Word
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// Engines a search may be run and explained with.
+const (
+	EngineDB    = "db"
+	EngineIndex = "index"
+	EngineFTS   = "fts"
+)
+
+// Explain is a search run with the explanation of every comic found. A
+// full-text search has the TSQuery the database made of the phrase instead
+// of the Terms, the index search has no SQL Plan.
+type Explain struct {
+	Terms   []Term
+	TSQuery string
+	Plan    []string
+	// Formula is how the engine scores the comics.
+	Formula string
+	Comics  []Comics
+}
+
+// Term is a normalized query word, matching any field if Field is empty.
+type Term struct {
+	Field string
+	Word  string
+}
+
+// Explanation breaks the score of a comic down into the matched terms.
+type Explanation struct {
+	Score   float64
+	Matches []Match
+}
+
+// Match is a query term found in a field, Score = TF * Boost.
+type Match struct {
+	Term  Term
+	Field string
+	TF    int
+	Boost float64
+	Score float64
+}
+
+// Sort orders of the found comics.
+const (
+	SortRelevance = "relevance"
+	SortNewest    = "newest"
+	SortOldest    = "oldest"
+)
+
+// SearchOptions narrow a search down and order it. Zero bounds are open,
+// the dates are inclusive. An empty sort means relevance.
+type SearchOptions struct {
+	From  time.Time
+	To    time.Time
+	MinID int
+	MaxID int
+	Sort  string
+	// All makes a comic match every word of the phrase, not just any.
+	All bool
+	// Client is a hash identifying who searched, for the search log only.
+	Client string
+	// NoCache makes the search skip the result cache, for debugging.
+	NoCache bool
+}
+
+// CacheStats count the lookups of the search result cache.
+type CacheStats struct {
+	Hits      int
+	Misses    int
+	Evictions int
+	Purges    int
+	Size      int
+	Capacity  int
+}
+
+// SearchAnalytics sum up the searches made since a moment.
+type SearchAnalytics struct {
+	Top         []QueryCount
+	ZeroResults []QueryCount
+	Latency     []LatencyStats
+}
+
+// QueryCount is how many searches of a normalized query were made and by
+// how many distinct clients.
+type QueryCount struct {
+	Query    string
+	Searches int
+	Clients  int
+}
+
+// LatencyStats are the latency percentiles of a search mode.
+type LatencyStats struct {
+	Mode     string
+	Searches int
+	P50      time.Duration
+	P90      time.Duration
+	P99      time.Duration
+}
+
+// Snippet is a piece of a comic field around the matched words.
+type Snippet struct {
+	Field      string
+	Text       string
+	Highlights []Highlight
+}
+
+// Highlight is a matched word of a snippet, as byte offsets into its text.
+type Highlight struct {
+	Start int
+	End   int
+}
+
+type VerifyReport struct {
+	Source   string
+	Total    int
+	Missing  []int
+	Empty    []int
+	Repaired int
+}
+
+// DefaultSource is the comic source assumed when a request names none.
+const DefaultSource = "xkcd"
+
+type ComicVersion struct {
+	URL         string
+	Title       string
+	SafeTitle   string
+	Alt         string
+	Transcript  string
+	ContentHash string
+	ReplacedAt  time.Time
+}
+
+// Tag is a theme attached to a comic by hand, Words are it normalized.
+type Tag struct {
+	Name    string
+	Words   []string
+	AddedAt time.Time
+}
+
+// TagCount is a tag with the number of comics it is attached to.
+type TagCount struct {
+	Tag    string
+	Comics int
+}
+
+// ComicRecord is a stored comic with all its metadata, one line of a dump.
+type ComicRecord struct {
+	Source             string     `json:"source"`
+	ID                 int        `json:"id"`
+	URL                string     `json:"url"`
+	Keywords           []string   `json:"keywords"`
+	TitleKeywords      []string   `json:"title_keywords,omitempty"`
+	AltKeywords        []string   `json:"alt_keywords,omitempty"`
+	TranscriptKeywords []string   `json:"transcript_keywords,omitempty"`
+	Title              string     `json:"title"`
+	SafeTitle          string     `json:"safe_title"`
+	Alt                string     `json:"alt"`
+	Transcript         string     `json:"transcript"`
+	AnalyzerVersion    int        `json:"analyzer_version"`
+	Published          *time.Time `json:"published,omitempty"`
+}
+
+// ComicID identifies a comic among the sources.
+type ComicID struct {
+	Source string
+	ID     int
+}
+
+// Pin puts the comics first in the results of the normalized query.
+type Pin struct {
+	Query  string
+	Comics []ComicID
+}
+
+// Rewrite makes the searches of the normalized query search the phrase.
+type Rewrite struct {
+	Query  string
+	Phrase string
+}
+
+// Curation is how the editors set the searches up.
+type Curation struct {
+	Pins     []Pin
+	Rewrites []Rewrite
+	Blocked  []ComicID
 }
@@ -1,6 +1,9 @@
 package core
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Normalizer interface {
 	Norm(context.Context, string) ([]string, error)
@@ -15,11 +18,51 @@ type Updater interface {
 	Stats(context.Context) (UpdateStats, error)
 	Status(context.Context) (UpdateStatus, error)
 	Drop(context.Context) error
+	Reindex(context.Context) error
+	Export(ctx context.Context, send func(ComicRecord) error) error
+	Import(ctx context.Context, recv func() (ComicRecord, error)) (int, error)
+	Verify(ctx context.Context, repair bool) ([]VerifyReport, error)
+	Refresh(context.Context) (int, error)
+	History(ctx context.Context, source string, id int) ([]ComicVersion, error)
+	// Tags, AddTag and RemoveTag return ErrNotFound if there is no such
+	// comic or, to remove, no such tag of it, and AddTag ErrBadArguments
+	// for a tag that cannot be searched.
+	Tags(ctx context.Context, source string, id int) ([]Tag, error)
+	AddTag(ctx context.Context, source string, id int, tag string) (Tag, error)
+	RemoveTag(ctx context.Context, source string, id int, tag string) error
+	TagCounts(ctx context.Context) ([]TagCount, error)
 }
 
 type Searcher interface {
-	Search(context.Context, int, string) ([]Comics, error)
-	IndexSearch(context.Context, int, string) ([]Comics, error)
+	Search(context.Context, int, string, SearchOptions) (Found, error)
+	IndexSearch(context.Context, int, string, SearchOptions) (Found, error)
+	FTSSearch(context.Context, int, string, SearchOptions) ([]Comics, error)
+	HybridSearch(context.Context, int, string, SearchOptions) (Hybrid, error)
+	SemanticSearch(context.Context, int, string, SearchOptions) ([]Comics, error)
+	Similar(ctx context.Context, source string, id, limit int) ([]Comics, error)
+	// Random draws a comic within the date bounds of the options, from the
+	// comics found by the phrase if it is not empty. It returns ErrNotFound
+	// if there is nothing to draw from.
+	Random(ctx context.Context, phrase string, opts SearchOptions) (Comics, error)
+	// OnThisDay finds the comics published on the month and day of the date
+	// in the past years, newest first.
+	OnThisDay(ctx context.Context, date time.Time, limit int) ([]Comics, error)
+	Explain(ctx context.Context, engine string, limit int, phrase string, opts SearchOptions) (Explain, error)
+	Analytics(ctx context.Context, since time.Time, limit int) (SearchAnalytics, error)
+	CacheStats(ctx context.Context) (CacheStats, error)
+}
+
+// Curator edits the curation of the searches. The phrases are normalized
+// into the queries, the deletes return ErrNotFound if there is nothing to
+// delete and the edits ErrBadArguments if they are not acceptable.
+type Curator interface {
+	Curation(ctx context.Context) (Curation, error)
+	PutPin(ctx context.Context, phrase string, comics []ComicID) (Pin, error)
+	DeletePin(ctx context.Context, phrase string) error
+	PutRewrite(ctx context.Context, phrase, rewrite string) (Rewrite, error)
+	DeleteRewrite(ctx context.Context, phrase string) error
+	Block(ctx context.Context, id ComicID) error
+	Unblock(ctx context.Context, id ComicID) error
 }
 
 type Loginer interface {
@@ -11,6 +11,7 @@ import (
 
 	"yadro.com/course/api/adapters/aaa"
 	"yadro.com/course/api/adapters/rest"
+	"yadro.com/course/api/adapters/rest/middleware"
 	"yadro.com/course/api/adapters/search"
 	"yadro.com/course/api/adapters/update"
 	"yadro.com/course/api/adapters/words"
@@ -57,10 +58,44 @@ func main() {
 	mux := http.NewServeMux()
 	mux.Handle("POST /api/login", rest.NewLoginHandler(log, aaa))
 	mux.Handle("GET /api/ping", rest.NewPingHandler(log, map[string]core.Pinger{"words": wordsClient, "update": updateClient, "search": searchClient}))
-	mux.Handle("GET /api/search", rest.NewSearchHandler(log, searchClient, cfg.SearchConcurrency))
-	mux.Handle("GET /api/isearch", rest.NewIndexSearchHandler(log, searchClient, cfg.SearchRate))
+	mux.Handle("GET /api/search", middleware.Client(rest.Explainable(
+		rest.NewSearchHandler(log, searchClient, cfg.SearchConcurrency),
+		rest.NewExplainHandler(log, searchClient, core.EngineDB, aaa)), cfg.ClientSalt))
+	mux.Handle("GET /api/isearch", middleware.Client(rest.Explainable(
+		rest.NewIndexSearchHandler(log, searchClient, cfg.SearchRate),
+		rest.NewExplainHandler(log, searchClient, core.EngineIndex, aaa)), cfg.ClientSalt))
+	mux.Handle("GET /api/fsearch", middleware.Client(rest.Explainable(
+		rest.NewFTSSearchHandler(log, searchClient, cfg.SearchRate),
+		rest.NewExplainHandler(log, searchClient, core.EngineFTS, aaa)), cfg.ClientSalt))
+	mux.Handle("GET /api/hsearch", middleware.Client(
+		rest.NewHybridSearchHandler(log, searchClient, cfg.SearchRate), cfg.ClientSalt))
+	mux.Handle("GET /api/ssearch", middleware.Client(
+		rest.NewSemanticSearchHandler(log, searchClient, cfg.SearchRate), cfg.ClientSalt))
+	mux.Handle("GET /api/analytics", rest.NewAnalyticsHandler(log, searchClient, aaa))
+	mux.Handle("GET /api/curation", rest.NewCurationHandler(log, searchClient, aaa))
+	mux.Handle("PUT /api/curation/pins", rest.NewPutPinHandler(log, searchClient, aaa))
+	mux.Handle("DELETE /api/curation/pins", rest.NewDeletePinHandler(log, searchClient, aaa))
+	mux.Handle("PUT /api/curation/rewrites", rest.NewPutRewriteHandler(log, searchClient, aaa))
+	mux.Handle("DELETE /api/curation/rewrites", rest.NewDeleteRewriteHandler(log, searchClient, aaa))
+	mux.Handle("PUT /api/curation/blocklist/{id}", rest.NewBlockHandler(log, searchClient, aaa))
+	mux.Handle("DELETE /api/curation/blocklist/{id}", rest.NewUnblockHandler(log, searchClient, aaa))
 	mux.Handle("POST /api/db/update", rest.NewUpdateHandler(log, updateClient, aaa))
+	mux.Handle("POST /api/db/reindex", rest.NewReindexHandler(log, updateClient, aaa))
+	mux.Handle("GET /api/db/export", rest.NewExportHandler(log, updateClient, aaa))
+	mux.Handle("POST /api/db/import", rest.NewImportHandler(log, updateClient, aaa))
+	mux.Handle("GET /api/db/verify", rest.NewVerifyHandler(log, updateClient, aaa))
+	mux.Handle("POST /api/db/refresh", rest.NewRefreshHandler(log, updateClient, aaa))
+	mux.Handle("GET /api/comics/random", middleware.Client(
+		rest.NewRandomHandler(log, searchClient, cfg.SearchRate), cfg.ClientSalt))
+	mux.Handle("GET /api/comics/on-this-day", rest.NewOnThisDayHandler(log, searchClient, cfg.SearchRate))
+	mux.Handle("GET /api/comics/{id}/similar", rest.NewSimilarHandler(log, searchClient, cfg.SearchRate))
+	mux.Handle("GET /api/comics/{id}/history", rest.NewHistoryHandler(log, updateClient))
+	mux.Handle("GET /api/comics/{id}/tags", rest.NewTagsHandler(log, updateClient))
+	mux.Handle("POST /api/comics/{id}/tags", rest.NewAddTagHandler(log, updateClient, aaa))
+	mux.Handle("DELETE /api/comics/{id}/tags/{tag}", rest.NewRemoveTagHandler(log, updateClient, aaa))
+	mux.Handle("GET /api/tags", rest.NewTagCountsHandler(log, updateClient))
 	mux.Handle("GET /api/db/stats", rest.NewUpdateStatsHandler(log, updateClient))
+	mux.Handle("GET /api/search/cache", rest.NewCacheStatsHandler(log, searchClient))
 	mux.Handle("GET /api/db/status", rest.NewUpdateStatusHandler(log, updateClient))
 	mux.Handle("DELETE /api/db", rest.NewDropHandler(log, updateClient, aaa))
 
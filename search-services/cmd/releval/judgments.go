@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Judgment lists the comics relevant to a query. Grades start at 1, the
+// higher the more relevant.
+type Judgment struct {
+	Query    string      `json:"query"`
+	Relevant []Relevance `json:"relevant"`
+}
+
+type Relevance struct {
+	ID    int `json:"id"`
+	Grade int `json:"grade"`
+}
+
+// Grades maps the relevant comics to their grades.
+func (j Judgment) Grades() map[int]int {
+	grades := make(map[int]int, len(j.Relevant))
+	for _, r := range j.Relevant {
+		grades[r.ID] = r.Grade
+	}
+	return grades
+}
+
+// LoadJudgments reads a JSON array of judgments. Every query must be judged
+// once and have a relevant comic.
+func LoadJudgments(path string) ([]Judgment, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var judgments []Judgment
+	if err := json.Unmarshal(raw, &judgments); err != nil {
+		return nil, fmt.Errorf("bad judgments %s: %w", path, err)
+	}
+
+	seen := make(map[string]struct{}, len(judgments))
+	for _, j := range judgments {
+		if j.Query == "" {
+			return nil, fmt.Errorf("bad judgments %s: empty query", path)
+		}
+		if _, ok := seen[j.Query]; ok {
+			return nil, fmt.Errorf("bad judgments %s: query %q is judged twice", path, j.Query)
+		}
+		seen[j.Query] = struct{}{}
+		if len(j.Relevant) == 0 {
+			return nil, fmt.Errorf("bad judgments %s: query %q has no relevant comics", path, j.Query)
+		}
+	}
+	return judgments, nil
+}
@@ -0,0 +1,136 @@
+// Command releval rates the rankings of the search modes against relevance
+// judgments and compares them with a saved baseline.
+//
+//	releval -judgments judgments.json -baseline baseline.json
+//
+// runs every judged query through the API in every mode, prints precision@k,
+// recall@k, MRR and nDCG@k and exits with 1 if a query got worse than in the
+// baseline. With -update the baseline is rewritten instead.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// apiModes are the search endpoints of the API by mode.
+var apiModes = map[string]string{
+	"db":       "/api/search",
+	"index":    "/api/isearch",
+	"fts":      "/api/fsearch",
+	"hybrid":   "/api/hsearch",
+	"semantic": "/api/ssearch",
+}
+
+func main() {
+	address := flag.String("api", "localhost:80", "address of the API")
+	judgmentsPath := flag.String("judgments", "", "path to the relevance judgments")
+	baselinePath := flag.String("baseline", "", "path to the baseline report")
+	update := flag.Bool("update", false, "rewrite the baseline instead of comparing with it")
+	modesFlag := flag.String("modes", "db,index,fts", "modes to evaluate, known ones or name=/api/path")
+	k := flag.Int("k", 10, "number of comics rated per query")
+	tolerance := flag.Float64("tolerance", 0.001, "drop of a metric not taken for a regression")
+	verbose := flag.Bool("v", false, "print the metrics of every query")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of a search")
+	flag.Parse()
+
+	if *judgmentsPath == "" || *k < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	judgments, err := LoadJudgments(*judgmentsPath)
+	if err != nil {
+		log.Fatalf("failed to load judgments: %v", err)
+	}
+
+	client := &http.Client{Timeout: *timeout}
+	modes := make(map[string]Searcher)
+	for _, mode := range strings.Split(*modesFlag, ",") {
+		name, path, custom := strings.Cut(strings.TrimSpace(mode), "=")
+		if !custom {
+			var ok bool
+			if path, ok = apiModes[name]; !ok {
+				log.Fatalf("unknown mode %q", name)
+			}
+		}
+		modes[name] = apiSearcher(client, *address, path)
+	}
+
+	report, err := Run(context.Background(), modes, judgments, *k)
+	if err != nil {
+		log.Fatalf("failed to evaluate: %v", err)
+	}
+	if err := report.Print(os.Stdout, *verbose); err != nil {
+		log.Fatalf("failed to print report: %v", err)
+	}
+
+	if *baselinePath == "" {
+		return
+	}
+	if *update {
+		if err := report.Save(*baselinePath); err != nil {
+			log.Fatalf("failed to save baseline: %v", err)
+		}
+		return
+	}
+
+	baseline, err := LoadReport(*baselinePath)
+	if err != nil {
+		log.Fatalf("failed to load baseline: %v", err)
+	}
+	if baseline.K != report.K {
+		log.Fatalf("baseline rates %d comics per query, not %d", baseline.K, report.K)
+	}
+	regressions := Compare(baseline, report, *tolerance)
+	for _, r := range regressions {
+		fmt.Println("regression:", r)
+	}
+	if len(regressions) > 0 {
+		os.Exit(1)
+	}
+}
+
+// apiSearcher searches with the API endpoint at path.
+func apiSearcher(client *http.Client, address, path string) Searcher {
+	return func(ctx context.Context, phrase string, limit int) ([]int, error) {
+		params := url.Values{"phrase": {phrase}, "limit": {fmt.Sprint(limit)}}
+		req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("http://%s%s?%s", address, path, params.Encode()), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+
+		var result struct {
+			Comics []struct {
+				ID int `json:"id"`
+			} `json:"comics"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return nil, err
+		}
+
+		ids := make([]int, len(result.Comics))
+		for i, comic := range result.Comics {
+			ids[i] = comic.ID
+		}
+		return ids, nil
+	}
+}
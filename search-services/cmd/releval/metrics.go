@@ -0,0 +1,92 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Metrics rate the ranking of one query, or the mean of several.
+type Metrics struct {
+	// Precision is the share of relevant comics among the first k.
+	Precision float64 `json:"precision"`
+	// Recall is the share of the relevant comics found among the first k.
+	Recall float64 `json:"recall"`
+	// MRR is the reciprocal rank of the first relevant comic, 0 if none is
+	// in the first k.
+	MRR float64 `json:"mrr"`
+	// NDCG is the graded gain of the first k against the ideal ranking.
+	NDCG float64 `json:"ndcg"`
+}
+
+// Evaluate rates the comics found, best first, against the relevant ones
+// mapped to their grades. Comics graded 0 or not graded are not relevant.
+func Evaluate(found []int, relevant map[int]int, k int) Metrics {
+	if k < len(found) {
+		found = found[:k]
+	}
+
+	total := 0
+	grades := make([]int, 0, len(relevant))
+	for _, grade := range relevant {
+		if grade > 0 {
+			total++
+			grades = append(grades, grade)
+		}
+	}
+
+	var m Metrics
+	hits := 0
+	dcg := 0.0
+	for i, id := range found {
+		grade := relevant[id]
+		if grade <= 0 {
+			continue
+		}
+		hits++
+		if m.MRR == 0 {
+			m.MRR = 1 / float64(i+1)
+		}
+		dcg += gain(grade, i)
+	}
+
+	if k > 0 {
+		m.Precision = float64(hits) / float64(k)
+	}
+	if total > 0 {
+		m.Recall = float64(hits) / float64(total)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(grades)))
+	ideal := 0.0
+	for i, grade := range grades {
+		if i == k {
+			break
+		}
+		ideal += gain(grade, i)
+	}
+	if ideal > 0 {
+		m.NDCG = dcg / ideal
+	}
+	return m
+}
+
+// gain of a comic of the grade found at the zero-based rank.
+func gain(grade, rank int) float64 {
+	return (math.Pow(2, float64(grade)) - 1) / math.Log2(float64(rank+2))
+}
+
+// Mean averages the metrics, zero for none.
+func Mean(all []Metrics) Metrics {
+	var m Metrics
+	if len(all) == 0 {
+		return m
+	}
+	for _, one := range all {
+		m.Precision += one.Precision
+		m.Recall += one.Recall
+		m.MRR += one.MRR
+		m.NDCG += one.NDCG
+	}
+	n := float64(len(all))
+	return Metrics{Precision: m.Precision / n, Recall: m.Recall / n, MRR: m.MRR / n, NDCG: m.NDCG / n}
+}
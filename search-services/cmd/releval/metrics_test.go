@@ -0,0 +1,91 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		found    []int
+		relevant map[int]int
+		k        int
+		want     Metrics
+	}{
+		{
+			name:     "perfect ranking",
+			found:    []int{1, 2},
+			relevant: map[int]int{1: 3, 2: 1},
+			k:        2,
+			want:     Metrics{Precision: 1, Recall: 1, MRR: 1, NDCG: 1},
+		},
+		{
+			name:     "nothing found",
+			found:    []int{},
+			relevant: map[int]int{1: 1},
+			k:        10,
+			want:     Metrics{},
+		},
+		{
+			name:     "relevant comic second",
+			found:    []int{5, 1},
+			relevant: map[int]int{1: 1},
+			k:        2,
+			want:     Metrics{Precision: 0.5, Recall: 1, MRR: 0.5, NDCG: 1 / math.Log2(3)},
+		},
+		{
+			name:     "relevant comic past k",
+			found:    []int{5, 6, 1},
+			relevant: map[int]int{1: 1},
+			k:        2,
+			want:     Metrics{},
+		},
+		{
+			name:     "grades swapped",
+			found:    []int{2, 1},
+			relevant: map[int]int{1: 2, 2: 1},
+			k:        2,
+			want: Metrics{
+				Precision: 1,
+				Recall:    1,
+				MRR:       1,
+				NDCG:      (1 + 3/math.Log2(3)) / (3 + 1/math.Log2(3)),
+			},
+		},
+		{
+			name:     "zero grade is not relevant",
+			found:    []int{2, 1},
+			relevant: map[int]int{1: 1, 2: 0},
+			k:        2,
+			want:     Metrics{Precision: 0.5, Recall: 1, MRR: 0.5, NDCG: 1 / math.Log2(3)},
+		},
+		{
+			name:     "more relevant comics than k",
+			found:    []int{1},
+			relevant: map[int]int{1: 1, 2: 1, 3: 1},
+			k:        1,
+			want:     Metrics{Precision: 1, Recall: 1.0 / 3, MRR: 1, NDCG: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Evaluate(tt.found, tt.relevant, tt.k)
+			assert.InDelta(t, tt.want.Precision, got.Precision, 1e-9)
+			assert.InDelta(t, tt.want.Recall, got.Recall, 1e-9)
+			assert.InDelta(t, tt.want.MRR, got.MRR, 1e-9)
+			assert.InDelta(t, tt.want.NDCG, got.NDCG, 1e-9)
+		})
+	}
+}
+
+func TestMean(t *testing.T) {
+	assert.Equal(t, Metrics{}, Mean(nil))
+	assert.Equal(t,
+		Metrics{Precision: 0.5, Recall: 0.75, MRR: 0.25, NDCG: 1},
+		Mean([]Metrics{{Precision: 1, Recall: 1, MRR: 0.5, NDCG: 1}, {Precision: 0, Recall: 0.5, MRR: 0, NDCG: 1}}),
+	)
+}
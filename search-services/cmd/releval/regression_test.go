@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"yadro.com/course/search/adapters/index"
+	"yadro.com/course/search/core"
+	"yadro.com/course/words/words"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/baseline.json")
+
+// corpusDir holds the comics fakexkcd serves by default.
+const corpusDir = "../../fakexkcd/corpus/data"
+
+// TestRegression ranks the bundled corpus with our normalization and the
+// index, as the search service does, and fails if a judged query ranks
+// worse than in testdata/baseline.json. Run with -update to accept a new
+// ranking.
+func TestRegression(t *testing.T) {
+	db := loadCorpus(t)
+	idx := index.NewIndex(slog.New(slog.NewTextHandler(io.Discard, nil)), db, time.Hour, 4, 0)
+	require.NoError(t, idx.BuildIndex(db.comics))
+
+	service, err := core.NewService(slog.New(slog.NewTextHandler(io.Discard, nil)), db, normalizer{}, idx, nil, nil, nil, nil,
+		core.Boosts{Title: 3, Alt: 2, Transcript: 1}, 0)
+	require.NoError(t, err)
+
+	judgments, err := LoadJudgments("testdata/judgments.json")
+	require.NoError(t, err)
+
+	report, err := Run(context.Background(), map[string]Searcher{
+		string(core.EngineIndex): func(ctx context.Context, phrase string, limit int) ([]int, error) {
+			found, err := service.IndexSearch(ctx, limit, phrase, core.Options{})
+			if err != nil {
+				return nil, err
+			}
+			ids := make([]int, len(found.Comics))
+			for i, comic := range found.Comics {
+				ids[i] = comic.ID
+			}
+			return ids, nil
+		},
+	}, judgments, 10)
+	require.NoError(t, err)
+
+	if *update {
+		require.NoError(t, report.Save("testdata/baseline.json"))
+		return
+	}
+
+	baseline, err := LoadReport("testdata/baseline.json")
+	require.NoError(t, err)
+	require.Equal(t, baseline.K, report.K)
+	for _, r := range Compare(baseline, report, 1e-9) {
+		t.Error(r)
+	}
+	// queries judged after the baseline was saved are not compared
+	for query := range report.Modes[string(core.EngineIndex)].Queries {
+		_, ok := baseline.Modes[string(core.EngineIndex)].Queries[query]
+		assert.True(t, ok, "query %q is missing from the baseline, run with -update", query)
+	}
+}
+
+// corpus stands in for the database, keeping the comics the way the update
+// service stores them.
+type corpus struct {
+	comics []core.Comics
+	texts  map[int]core.Text
+}
+
+func loadCorpus(t *testing.T) corpus {
+	entries, err := os.ReadDir(corpusDir)
+	require.NoError(t, err)
+
+	c := corpus{texts: make(map[int]core.Text)}
+	for _, entry := range entries {
+		if path.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		raw, err := os.ReadFile(path.Join(corpusDir, entry.Name()))
+		require.NoError(t, err)
+
+		var info struct {
+			Num        int    `json:"num"`
+			Title      string `json:"title"`
+			SafeTitle  string `json:"safe_title"`
+			Alt        string `json:"alt"`
+			Transcript string `json:"transcript"`
+		}
+		require.NoError(t, json.Unmarshal(raw, &info))
+
+		title := strings.TrimSpace(info.Title + " " + info.SafeTitle)
+		c.texts[info.Num] = core.Text{Title: info.Title, Alt: info.Alt, Transcript: info.Transcript}
+		c.comics = append(c.comics, core.Comics{
+			ID:                 info.Num,
+			Source:             "xkcd",
+			Keywords:           keywords(t, title+" "+info.Alt+" "+info.Transcript),
+			TitleKeywords:      keywords(t, title),
+			AltKeywords:        keywords(t, info.Alt),
+			TranscriptKeywords: keywords(t, info.Transcript),
+		})
+	}
+	return c
+}
+
+func keywords(t *testing.T, text string) string {
+	raw, err := json.Marshal(words.NormalizedString(text))
+	require.NoError(t, err)
+	return string(raw)
+}
+
+func (c corpus) SearchComics(context.Context, int, core.Query) ([]core.Comics, error) {
+	return nil, nil
+}
+
+func (c corpus) GetImageURL(context.Context, string, int) (string, error) {
+	return "", nil
+}
+
+func (c corpus) GetComics(context.Context) ([]core.Comics, error) {
+	return c.comics, nil
+}
+
+func (c corpus) GetTexts(_ context.Context, ids []core.ComicID) (map[core.ComicID]core.Text, error) {
+	texts := make(map[core.ComicID]core.Text, len(ids))
+	for _, id := range ids {
+		texts[id] = c.texts[id.ID]
+	}
+	return texts, nil
+}
+
+func (c corpus) ExplainSearch(context.Context, int, core.Query) (core.Explain, error) {
+	return core.Explain{}, nil
+}
+
+func (c corpus) RandomComics(context.Context, int, core.Options) ([]core.Comics, error) {
+	return nil, nil
+}
+
+func (c corpus) OnThisDay(context.Context, time.Month, int, int, int) ([]core.Comics, error) {
+	return nil, nil
+}
+
+// normalizer is the words service without the network.
+type normalizer struct{}
+
+func (normalizer) Norm(_ context.Context, phrase string) ([]string, error) {
+	return words.NormalizedString(phrase), nil
+}
+
+func (normalizer) Tokens(_ context.Context, texts []string) ([][]core.Token, error) {
+	out := make([][]core.Token, len(texts))
+	for i, text := range texts {
+		tokens := words.Tokens(text)
+		out[i] = make([]core.Token, len(tokens))
+		for j, token := range tokens {
+			out[i][j] = core.Token{Stem: token.Stem, Start: token.Start, End: token.End}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+// Searcher runs a query in a search mode, returning the IDs of the comics
+// found, best first.
+type Searcher func(ctx context.Context, phrase string, limit int) ([]int, error)
+
+// Report holds the metrics of every mode evaluated. It doubles as the
+// baseline later reports are compared against.
+type Report struct {
+	K     int                   `json:"k"`
+	Modes map[string]ModeReport `json:"modes"`
+}
+
+type ModeReport struct {
+	Mean    Metrics            `json:"mean"`
+	Queries map[string]Metrics `json:"queries"`
+}
+
+// Run searches every judged query in every mode and rates the first k
+// comics found.
+func Run(ctx context.Context, modes map[string]Searcher, judgments []Judgment, k int) (Report, error) {
+	report := Report{K: k, Modes: make(map[string]ModeReport, len(modes))}
+	for mode, search := range modes {
+		mr := ModeReport{Queries: make(map[string]Metrics, len(judgments))}
+		all := make([]Metrics, 0, len(judgments))
+		for _, j := range judgments {
+			found, err := search(ctx, j.Query, k)
+			if err != nil {
+				return Report{}, fmt.Errorf("%s: query %q: %w", mode, j.Query, err)
+			}
+			m := Evaluate(found, j.Grades(), k)
+			mr.Queries[j.Query] = m
+			all = append(all, m)
+		}
+		mr.Mean = Mean(all)
+		report.Modes[mode] = mr
+	}
+	return report, nil
+}
+
+// Print writes the mean metrics of every mode, with those of every query
+// if verbose is set.
+func (r Report) Print(w io.Writer, verbose bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "mode\tquery\tP@%d\tR@%d\tMRR\tnDCG@%d\n", r.K, r.K, r.K)
+	for _, mode := range sortedKeys(r.Modes) {
+		mr := r.Modes[mode]
+		if verbose {
+			for _, query := range sortedKeys(mr.Queries) {
+				printMetrics(tw, mode, query, mr.Queries[query])
+			}
+		}
+		printMetrics(tw, mode, "(mean)", mr.Mean)
+	}
+	return tw.Flush()
+}
+
+func printMetrics(w io.Writer, mode, query string, m Metrics) {
+	fmt.Fprintf(w, "%s\t%s\t%.3f\t%.3f\t%.3f\t%.3f\n", mode, query, m.Precision, m.Recall, m.MRR, m.NDCG)
+}
+
+// Regression is a metric of a query worse than in the baseline.
+type Regression struct {
+	Mode     string
+	Query    string
+	Metric   string
+	Baseline float64
+	Current  float64
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s %q: %s dropped from %.3f to %.3f", r.Mode, r.Query, r.Metric, r.Baseline, r.Current)
+}
+
+// Compare finds the metrics of the report lower than in the baseline by
+// more than tolerance. Only the modes and queries of both are compared,
+// so that new ones can be evaluated before they get into the baseline.
+func Compare(baseline, current Report, tolerance float64) []Regression {
+	var regressions []Regression
+	for _, mode := range sortedKeys(baseline.Modes) {
+		cur, ok := current.Modes[mode]
+		if !ok {
+			continue
+		}
+		for _, query := range sortedKeys(baseline.Modes[mode].Queries) {
+			now, ok := cur.Queries[query]
+			if !ok {
+				continue
+			}
+			was := baseline.Modes[mode].Queries[query]
+			for _, metric := range []struct {
+				name     string
+				was, now float64
+			}{
+				{"precision", was.Precision, now.Precision},
+				{"recall", was.Recall, now.Recall},
+				{"mrr", was.MRR, now.MRR},
+				{"ndcg", was.NDCG, now.NDCG},
+			} {
+				if metric.now < metric.was-tolerance {
+					regressions = append(regressions, Regression{
+						Mode:     mode,
+						Query:    query,
+						Metric:   metric.name,
+						Baseline: metric.was,
+						Current:  metric.now,
+					})
+				}
+			}
+		}
+	}
+	return regressions
+}
+
+// LoadReport reads a report saved by Save.
+func LoadReport(path string) (Report, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Report{}, err
+	}
+	var report Report
+	if err := json.Unmarshal(raw, &report); err != nil {
+		return Report{}, fmt.Errorf("bad report %s: %w", path, err)
+	}
+	return report, nil
+}
+
+// Save writes the report as indented JSON to diff it in reviews.
+func (r Report) Save(path string) error {
+	raw, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(raw, '\n'), 0o644)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	judgments := []Judgment{
+		{Query: "a", Relevant: []Relevance{{ID: 1, Grade: 1}}},
+	}
+
+	report, err := Run(context.Background(), map[string]Searcher{
+		"good": func(context.Context, string, int) ([]int, error) { return []int{1}, nil },
+		"bad":  func(context.Context, string, int) ([]int, error) { return []int{2}, nil },
+	}, judgments, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.K)
+	assert.Equal(t, Metrics{Precision: 1, Recall: 1, MRR: 1, NDCG: 1}, report.Modes["good"].Mean)
+	assert.Equal(t, Metrics{}, report.Modes["bad"].Queries["a"])
+
+	_, err = Run(context.Background(), map[string]Searcher{
+		"broken": func(context.Context, string, int) ([]int, error) { return nil, errors.New("down") },
+	}, judgments, 1)
+	assert.Error(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	baseline := Report{K: 10, Modes: map[string]ModeReport{
+		"index": {Queries: map[string]Metrics{
+			"a": {Precision: 0.5, Recall: 1, MRR: 1, NDCG: 0.9},
+			"b": {Precision: 0.1, Recall: 1, MRR: 0.5, NDCG: 0.5},
+		}},
+		"gone": {Queries: map[string]Metrics{"a": {NDCG: 1}}},
+	}}
+
+	tests := []struct {
+		name    string
+		current Report
+		want    []Regression
+	}{
+		{
+			name:    "same",
+			current: baseline,
+			want:    nil,
+		},
+		{
+			name: "better and within tolerance",
+			current: Report{K: 10, Modes: map[string]ModeReport{
+				"index": {Queries: map[string]Metrics{
+					"a": {Precision: 0.6, Recall: 1, MRR: 1, NDCG: 0.8995},
+					"b": {Precision: 0.1, Recall: 1, MRR: 1, NDCG: 0.7},
+				}},
+			}},
+			want: nil,
+		},
+		{
+			name: "worse",
+			current: Report{K: 10, Modes: map[string]ModeReport{
+				"index": {Queries: map[string]Metrics{
+					"a": {Precision: 0.5, Recall: 1, MRR: 0.5, NDCG: 0.7},
+					"c": {},
+				}},
+			}},
+			want: []Regression{
+				{Mode: "index", Query: "a", Metric: "mrr", Baseline: 1, Current: 0.5},
+				{Mode: "index", Query: "a", Metric: "ndcg", Baseline: 0.9, Current: 0.7},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Compare(baseline, tt.current, 0.001))
+		})
+	}
+}
+
+func TestReport_Save(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	report := Report{K: 5, Modes: map[string]ModeReport{
+		"db": {Mean: Metrics{NDCG: 0.5}, Queries: map[string]Metrics{"a": {NDCG: 0.5}}},
+	}}
+
+	require.NoError(t, report.Save(path))
+	loaded, err := LoadReport(path)
+	require.NoError(t, err)
+	assert.Equal(t, report, loaded)
+}
+
+func TestLoadJudgments(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "valid", content: `[{"query": "a", "relevant": [{"id": 1, "grade": 2}]}]`},
+		{name: "not json", content: `query a`, wantErr: true},
+		{name: "empty query", content: `[{"query": "", "relevant": [{"id": 1, "grade": 2}]}]`, wantErr: true},
+		{name: "no relevant comics", content: `[{"query": "a", "relevant": []}]`, wantErr: true},
+		{
+			name:    "query judged twice",
+			content: `[{"query": "a", "relevant": [{"id": 1, "grade": 1}]}, {"query": "a", "relevant": [{"id": 2, "grade": 1}]}]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "judgments.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+
+			judgments, err := LoadJudgments(path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, map[int]int{1: 2}, judgments[0].Grades())
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package corpus
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+//go:embed data/*.json
+var bundled embed.FS
+
+// Corpus is a set of comics in the xkcd info.0.json format keyed by number.
+type Corpus struct {
+	comics map[int][]byte
+	last   int
+}
+
+// Bundled returns the small corpus compiled into the binary.
+func Bundled() (*Corpus, error) {
+	data, err := fs.Sub(bundled, "data")
+	if err != nil {
+		return nil, err
+	}
+	return Load(data)
+}
+
+// Load reads every {num}.json file in the root of fsys. The number in the
+// file name must match the num field of the comic.
+func Load(fsys fs.FS) (*Corpus, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Corpus{comics: make(map[int][]byte)}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || path.Ext(name) != ".json" {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			continue
+		}
+
+		raw, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		var info struct {
+			Num int `json:"num"`
+		}
+		if err := json.Unmarshal(raw, &info); err != nil {
+			return nil, fmt.Errorf("bad comic %s: %w", name, err)
+		}
+		if info.Num != id {
+			return nil, fmt.Errorf("bad comic %s: num is %d", name, info.Num)
+		}
+
+		c.comics[id] = raw
+		c.last = max(c.last, id)
+	}
+
+	if len(c.comics) == 0 {
+		return nil, fmt.Errorf("no comics found")
+	}
+	return c, nil
+}
+
+func (c *Corpus) Len() int {
+	return len(c.comics)
+}
+
+// Handler serves the corpus the way xkcd.com does: the latest comic at
+// /info.0.json and every comic at /{num}/info.0.json.
+func (c *Corpus) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /info.0.json", func(w http.ResponseWriter, r *http.Request) {
+		c.write(w, c.last)
+	})
+	mux.HandleFunc("GET /{id}/info.0.json", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		c.write(w, id)
+	})
+	return mux
+}
+
+func (c *Corpus) write(w http.ResponseWriter, id int) {
+	raw, ok := c.comics[id]
+	if !ok {
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(raw)
+}
@@ -0,0 +1,120 @@
+package corpus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name: "comics and other files",
+			fsys: fstest.MapFS{
+				"1.json":      {Data: []byte(`{"num":1}`)},
+				"3.json":      {Data: []byte(`{"num":3}`)},
+				"README.json": {Data: []byte(`{}`)},
+				"1.png":       {Data: []byte{}},
+			},
+			wantLen: 2,
+		},
+		{
+			name:    "empty",
+			fsys:    fstest.MapFS{},
+			wantErr: true,
+		},
+		{
+			name: "num mismatch",
+			fsys: fstest.MapFS{
+				"1.json": {Data: []byte(`{"num":2}`)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid JSON",
+			fsys: fstest.MapFS{
+				"1.json": {Data: []byte(`{`)},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.fsys)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil {
+				assert.Equal(t, tt.wantLen, got.Len())
+			}
+		})
+	}
+}
+
+func TestCorpus_Handler(t *testing.T) {
+	c, err := Load(fstest.MapFS{
+		"1.json": {Data: []byte(`{"num":1,"title":"one"}`)},
+		"3.json": {Data: []byte(`{"num":3,"title":"three"}`)},
+	})
+	if err != nil {
+		t.Fatalf("failed to load corpus: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "latest",
+			path:       "/info.0.json",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"num":3,"title":"three"}`,
+		},
+		{
+			name:       "by number",
+			path:       "/1/info.0.json",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"num":1,"title":"one"}`,
+		},
+		{
+			name:       "missing",
+			path:       "/2/info.0.json",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "not a number",
+			path:       "/abc/info.0.json",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c.Handler().ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBundled(t *testing.T) {
+	c, err := Bundled()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, c.Len())
+}
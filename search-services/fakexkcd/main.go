@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"yadro.com/course/fakexkcd/corpus"
+)
+
+// config of the offline stand-in for xkcd.com. Without a corpus directory
+// the comics bundled into the binary are served.
+type config struct {
+	Address string `yaml:"address" env:"FAKEXKCD_ADDRESS" env-default:":80"`
+	Corpus  string `yaml:"corpus" env:"FAKEXKCD_CORPUS"`
+}
+
+func main() {
+	var cfg config
+	configPath := flag.String("config", "", "path to config file")
+	flag.Parse()
+
+	if *configPath != "" {
+		if err := cleanenv.ReadConfig(*configPath, &cfg); err != nil {
+			log.Printf("error reading config file: %v\n", err)
+		}
+	}
+
+	if err := cleanenv.ReadEnv(&cfg); err != nil {
+		log.Printf("error reading env: %v\n", err)
+	}
+
+	var (
+		comics *corpus.Corpus
+		err    error
+	)
+	if cfg.Corpus != "" {
+		comics, err = corpus.Load(os.DirFS(cfg.Corpus))
+	} else {
+		comics, err = corpus.Bundled()
+	}
+	if err != nil {
+		log.Fatalf("failed to load corpus: %v", err)
+	}
+
+	server := http.Server{
+		Addr:    cfg.Address,
+		Handler: comics.Handler(),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		if err := server.Shutdown(context.Background()); err != nil {
+			log.Printf("erroneous shutdown: %v", err)
+		}
+	}()
+
+	log.Printf("serving %d comics on %s", comics.Len(), cfg.Address)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("server closed unexpectedly: %v", err)
+	}
+}
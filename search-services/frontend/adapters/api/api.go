@@ -6,6 +6,7 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"yadro.com/course/frontend/core"
@@ -25,11 +26,36 @@ func NewClient(apiAddress string, log *slog.Logger) *Client {
 	}
 }
 
-func (c Client) Search(phrase string) (core.SearchResponse, error) {
-	searchURL := fmt.Sprintf("http://%s/api/search?phrase=%s", c.apiAddress, phrase)
+// Search runs the hybrid search of all the engines if the comics are ranked
+// by relevance by any of the words. It neither sorts by date nor relaxes a
+// search of all the words finding nothing, the database search does.
+func (c Client) Search(phrase string, opts core.SearchOptions) (core.SearchResponse, error) {
+	params := url.Values{"phrase": {phrase}}
+	for key, value := range map[string]string{
+		"from":   opts.From,
+		"to":     opts.To,
+		"min_id": opts.MinID,
+		"max_id": opts.MaxID,
+		"sort":   opts.Sort,
+		"match":  opts.Match,
+	} {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	path := "/api/hsearch"
+	if opts.Sort != "" && opts.Sort != "relevance" || opts.Match == "all" {
+		path = "/api/search"
+	}
+	searchURL := fmt.Sprintf("http://%s%s?%s", c.apiAddress, path, params.Encode())
 	c.log.Debug("API request", "url", searchURL)
 
-	resp, err := c.client.Get(searchURL)
+	req, _ := http.NewRequest("GET", searchURL, nil)
+	if opts.Client != "" {
+		req.Header.Set("X-Forwarded-For", opts.Client)
+	}
+
+	resp, err := c.client.Do(req)
 	if err != nil {
 		c.log.Error("failed to search", "error", err)
 		return core.SearchResponse{}, err
@@ -51,6 +77,102 @@ func (c Client) Search(phrase string) (core.SearchResponse, error) {
 	return result, nil
 }
 
+func (c Client) Similar(source string, id int) (core.SearchResponse, error) {
+	similarURL := fmt.Sprintf("http://%s/api/comics/%d/similar?source=%s", c.apiAddress, id, url.QueryEscape(source))
+	c.log.Debug("API request", "url", similarURL)
+
+	resp, err := c.client.Get(similarURL)
+	if err != nil {
+		c.log.Error("failed to find similar comics", "error", err)
+		return core.SearchResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		c.log.Error("failed to find similar comics", "status", resp.StatusCode)
+		return core.SearchResponse{}, fmt.Errorf("similar comics: unexpected status %d", resp.StatusCode)
+	}
+
+	var result core.SearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		c.log.Error("failed to decode API response", "error", err)
+		return core.SearchResponse{}, err
+	}
+
+	return result, nil
+}
+
+// Random draws a comic by the phrase, if any, within the dates of the
+// options; the other options do not apply to a draw.
+func (c Client) Random(phrase string, opts core.SearchOptions) (core.SearchResponse, error) {
+	params := url.Values{}
+	for key, value := range map[string]string{
+		"phrase": phrase,
+		"from":   opts.From,
+		"to":     opts.To,
+	} {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	randomURL := fmt.Sprintf("http://%s/api/comics/random?%s", c.apiAddress, params.Encode())
+	c.log.Debug("API request", "url", randomURL)
+
+	req, _ := http.NewRequest("GET", randomURL, nil)
+	if opts.Client != "" {
+		req.Header.Set("X-Forwarded-For", opts.Client)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.log.Error("failed to draw comic", "error", err)
+		return core.SearchResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return core.SearchResponse{}, nil
+	default:
+		c.log.Error("failed to draw comic", "status", resp.StatusCode)
+		return core.SearchResponse{}, fmt.Errorf("random comic: unexpected status %d", resp.StatusCode)
+	}
+
+	var comic core.Comic
+	if err := json.NewDecoder(resp.Body).Decode(&comic); err != nil {
+		c.log.Error("failed to decode API response", "error", err)
+		return core.SearchResponse{}, err
+	}
+
+	return core.SearchResponse{Comics: []core.Comic{comic}}, nil
+}
+
+func (c Client) OnThisDay() (core.SearchResponse, error) {
+	dayURL := fmt.Sprintf("http://%s/api/comics/on-this-day", c.apiAddress)
+	c.log.Debug("API request", "url", dayURL)
+
+	resp, err := c.client.Get(dayURL)
+	if err != nil {
+		c.log.Error("failed to find comics of the day", "error", err)
+		return core.SearchResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		c.log.Error("failed to find comics of the day", "status", resp.StatusCode)
+		return core.SearchResponse{}, fmt.Errorf("comics of the day: unexpected status %d", resp.StatusCode)
+	}
+
+	var result core.SearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		c.log.Error("failed to decode API response", "error", err)
+		return core.SearchResponse{}, err
+	}
+
+	return result, nil
+}
+
 func (c Client) Update(token string) error {
 	req, _ := http.NewRequest("POST", fmt.Sprintf("http://%s/api/db/update", c.apiAddress), nil)
 	req.Header.Set("Authorization", "Token "+token)
@@ -89,6 +211,25 @@ func (c Client) Drop(token string) error {
 	return nil
 }
 
+func (c Client) Reindex(token string) error {
+	req, _ := http.NewRequest("POST", fmt.Sprintf("http://%s/api/db/reindex", c.apiAddress), nil)
+	req.Header.Set("Authorization", "Token "+token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.log.Error("reindex failed", "error", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		c.log.Error("reindex failed", "status", resp.StatusCode)
+		return err
+	}
+
+	return nil
+}
+
 func (c Client) Login(username, password string) (string, error) {
 	jsonBody := map[string]string{
 		"name":     username,
@@ -155,3 +296,28 @@ func (c Client) GetStats() (core.Stats, error) {
 
 	return stats, nil
 }
+
+func (c Client) GetAnalytics(token, window string) (core.Analytics, error) {
+	analyticsURL := fmt.Sprintf("http://%s/api/analytics?window=%s", c.apiAddress, url.QueryEscape(window))
+	req, _ := http.NewRequest("GET", analyticsURL, nil)
+	req.Header.Set("Authorization", "Token "+token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.log.Error("failed to get analytics", "error", err)
+		return core.Analytics{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		c.log.Error("failed to get analytics", "status", resp.StatusCode)
+		return core.Analytics{}, fmt.Errorf("analytics: unexpected status %d", resp.StatusCode)
+	}
+
+	var analytics core.Analytics
+	if err := json.NewDecoder(resp.Body).Decode(&analytics); err != nil {
+		c.log.Error("failed to decode analytics", "error", err)
+		return core.Analytics{}, err
+	}
+	return analytics, nil
+}
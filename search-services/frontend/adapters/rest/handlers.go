@@ -3,8 +3,10 @@ package rest
 import (
 	"html/template"
 	"log/slog"
+	"net"
 	"net/http"
 	"path/filepath"
+	"strconv"
 
 	"yadro.com/course/frontend/core"
 )
@@ -17,6 +19,18 @@ func getToken(r *http.Request) string {
 	return cookie.Value
 }
 
+// resultsPage is the data of results.html. The heading tells a search from
+// the similar comics, a random draw and the comics of the day.
+type resultsPage struct {
+	Query      string
+	Similar    int
+	Lucky      bool
+	OnThisDay  bool
+	Comics     []core.Comic
+	Degraded   []string
+	Relaxation *core.Relaxation
+}
+
 func SearchHandler(templatePath string, log *slog.Logger, api core.API) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		query := r.URL.Query().Get("query")
@@ -25,7 +39,19 @@ func SearchHandler(templatePath string, log *slog.Logger, api core.API) http.Han
 			return
 		}
 
-		result, err := api.Search(query)
+		opts := core.SearchOptions{
+			From:  r.URL.Query().Get("from"),
+			To:    r.URL.Query().Get("to"),
+			MinID: r.URL.Query().Get("min_id"),
+			MaxID: r.URL.Query().Get("max_id"),
+			Sort:  r.URL.Query().Get("sort"),
+			Match: r.URL.Query().Get("match"),
+		}
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			opts.Client = host
+		}
+
+		result, err := api.Search(query, opts)
 		if err != nil {
 			log.Error("failed to search", "error", err)
 			http.Error(w, "search error", http.StatusInternalServerError)
@@ -37,24 +63,113 @@ func SearchHandler(templatePath string, log *slog.Logger, api core.API) http.Han
 			filepath.Join(templatePath, "results.html"),
 		))
 
-		data := struct {
-			Query  string
-			Comics []struct {
-				ID       int
-				ImageURL string
-			}
-		}{
-			Query: query,
+		data := resultsPage{
+			Query:      query,
+			Comics:     result.Comics,
+			Degraded:   result.Degraded,
+			Relaxation: result.Relaxation,
+		}
+
+		if err := tmpl.ExecuteTemplate(w, "results.html", data); err != nil {
+			log.Error("template error", "error", err)
+			http.Error(w, "template error", http.StatusInternalServerError)
+		}
+	}
+}
+
+// SimilarHandler shows the comics like the one given by id and source.
+func SimilarHandler(templatePath string, log *slog.Logger, api core.API) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil || id < 1 {
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+		source := r.URL.Query().Get("source")
+		if source == "" {
+			source = "xkcd"
+		}
+
+		result, err := api.Similar(source, id)
+		if err != nil {
+			log.Error("failed to find similar comics", "error", err)
+			http.Error(w, "search error", http.StatusInternalServerError)
+			return
+		}
+
+		tmpl := template.Must(template.ParseFiles(
+			filepath.Join(templatePath, "index.html"),
+			filepath.Join(templatePath, "results.html"),
+		))
+
+		data := resultsPage{
+			Similar: id,
+			Comics:  result.Comics,
+		}
+
+		if err := tmpl.ExecuteTemplate(w, "results.html", data); err != nil {
+			log.Error("template error", "error", err)
+			http.Error(w, "template error", http.StatusInternalServerError)
+		}
+	}
+}
+
+// LuckyHandler shows a random comic, found by the query if it is typed and
+// within the dates of the search form.
+func LuckyHandler(templatePath string, log *slog.Logger, api core.API) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		opts := core.SearchOptions{
+			From: r.URL.Query().Get("from"),
+			To:   r.URL.Query().Get("to"),
+		}
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			opts.Client = host
+		}
+
+		result, err := api.Random(query, opts)
+		if err != nil {
+			log.Error("failed to draw comic", "error", err)
+			http.Error(w, "search error", http.StatusInternalServerError)
+			return
 		}
 
-		for _, comic := range result.Comics {
-			data.Comics = append(data.Comics, struct {
-				ID       int
-				ImageURL string
-			}{
-				ID:       comic.ID,
-				ImageURL: comic.ImageURL,
-			})
+		tmpl := template.Must(template.ParseFiles(
+			filepath.Join(templatePath, "index.html"),
+			filepath.Join(templatePath, "results.html"),
+		))
+
+		data := resultsPage{
+			Query:  query,
+			Lucky:  true,
+			Comics: result.Comics,
+		}
+
+		if err := tmpl.ExecuteTemplate(w, "results.html", data); err != nil {
+			log.Error("template error", "error", err)
+			http.Error(w, "template error", http.StatusInternalServerError)
+		}
+	}
+}
+
+// OnThisDayHandler shows the comics published on this day in the past years.
+func OnThisDayHandler(templatePath string, log *slog.Logger, api core.API) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := api.OnThisDay()
+		if err != nil {
+			log.Error("failed to find comics of the day", "error", err)
+			http.Error(w, "search error", http.StatusInternalServerError)
+			return
+		}
+
+		tmpl := template.Must(template.ParseFiles(
+			filepath.Join(templatePath, "index.html"),
+			filepath.Join(templatePath, "results.html"),
+		))
+
+		data := resultsPage{
+			OnThisDay: true,
+			Comics:    result.Comics,
 		}
 
 		if err := tmpl.ExecuteTemplate(w, "results.html", data); err != nil {
@@ -124,12 +239,29 @@ func DashboardHandler(templatePath string, log *slog.Logger, api core.API) http.
 			return
 		}
 
+		window := r.URL.Query().Get("window")
+		if window == "" {
+			window = "24h"
+		}
+
+		// the dashboard is still of use without the search log
+		var analytics *core.Analytics
+		if result, err := api.GetAnalytics(getToken(r), window); err != nil {
+			log.Error("failed to get analytics", "error", err)
+		} else {
+			analytics = &result
+		}
+
 		data := struct {
-			Stats  core.Stats
-			Status core.Status
+			Stats     core.Stats
+			Status    core.Status
+			Window    string
+			Analytics *core.Analytics
 		}{
-			Stats:  stats,
-			Status: status,
+			Stats:     stats,
+			Status:    status,
+			Window:    window,
+			Analytics: analytics,
 		}
 
 		tmpl := template.Must(template.ParseFiles(
@@ -178,3 +310,21 @@ func AdminDropHandler(log *slog.Logger, api core.API) http.HandlerFunc {
 		http.Redirect(w, r, "/admin/dashboard", http.StatusSeeOther)
 	}
 }
+
+func AdminReindexHandler(log *slog.Logger, api core.API) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := getToken(r)
+		if token == "" {
+			http.Redirect(w, r, "/admin/login", http.StatusUnauthorized)
+			return
+		}
+
+		err := api.Reindex(token)
+		if err != nil {
+			log.Error("failed to reindex", "error", err)
+			http.Redirect(w, r, "/admin", http.StatusUnauthorized)
+		}
+
+		http.Redirect(w, r, "/admin/dashboard", http.StatusSeeOther)
+	}
+}
@@ -2,11 +2,78 @@ package core
 
 type SearchResponse struct {
 	Comics []Comic `json:"comics"`
+	// Degraded names the engines a hybrid search had to do without.
+	Degraded []string `json:"degraded"`
+	// Relaxation is set if a search of all the words found nothing and was
+	// loosened.
+	Relaxation *Relaxation `json:"relaxation"`
+}
+
+// Relaxation tells how the phrase was loosened: drop_term, any_term or fuzzy,
+// and the phrase the comics were found by.
+type Relaxation struct {
+	Relax  string `json:"relax"`
+	Phrase string `json:"phrase"`
+}
+
+// SearchOptions are the search filters as typed in the form: dates are
+// YYYY-MM-DD, empty fields are not sent.
+type SearchOptions struct {
+	From  string
+	To    string
+	MinID string
+	MaxID string
+	Sort  string
+	// Match is "all" if a comic is to have every word of the phrase.
+	Match string
+	// Client is the address of the user searching, passed on for the
+	// search log.
+	Client string
 }
 
 type Comic struct {
-	ID       int    `json:"id"`
-	ImageURL string `json:"url"`
+	ID       int      `json:"id"`
+	Source   string   `json:"source"`
+	ImageURL string   `json:"url"`
+	Snippet  *Snippet `json:"snippet"`
+	// Published is YYYY-MM-DD, set for the random comics and the comics of
+	// the day only.
+	Published string `json:"published"`
+}
+
+// Snippet is a piece of a comic field; highlights are byte offsets of the
+// matched words in its text.
+type Snippet struct {
+	Field      string   `json:"field"`
+	Text       string   `json:"text"`
+	Highlights [][2]int `json:"highlights"`
+}
+
+type SnippetPart struct {
+	Text  string
+	Match bool
+}
+
+// Parts splits the snippet text into matched and plain parts for rendering.
+// Highlights out of order or out of the text are ignored.
+func (s Snippet) Parts() []SnippetPart {
+	var parts []SnippetPart
+	pos := 0
+	for _, h := range s.Highlights {
+		start, end := h[0], h[1]
+		if start < pos || end <= start || end > len(s.Text) {
+			continue
+		}
+		if start > pos {
+			parts = append(parts, SnippetPart{Text: s.Text[pos:start]})
+		}
+		parts = append(parts, SnippetPart{Text: s.Text[start:end], Match: true})
+		pos = end
+	}
+	if pos < len(s.Text) {
+		parts = append(parts, SnippetPart{Text: s.Text[pos:]})
+	}
+	return parts
 }
 
 type Stats struct {
@@ -16,6 +83,28 @@ type Stats struct {
 	WordsUnique   int `json:"words_unique"`
 }
 
+// Analytics sum up the searches made over the window.
+type Analytics struct {
+	Window      string         `json:"window"`
+	Top         []QueryCount   `json:"top"`
+	ZeroResults []QueryCount   `json:"zero_results"`
+	Latency     []LatencyStats `json:"latency"`
+}
+
+type QueryCount struct {
+	Query    string `json:"query"`
+	Searches int    `json:"searches"`
+	Clients  int    `json:"clients"`
+}
+
+type LatencyStats struct {
+	Mode     string  `json:"mode"`
+	Searches int     `json:"searches"`
+	P50      float64 `json:"p50_ms"`
+	P90      float64 `json:"p90_ms"`
+	P99      float64 `json:"p99_ms"`
+}
+
 type Status struct {
 	Status string `json:"status"`
 }
@@ -1,10 +1,17 @@
 package core
 
 type API interface {
-	Search(string) (SearchResponse, error)
+	Search(phrase string, opts SearchOptions) (SearchResponse, error)
+	Similar(source string, id int) (SearchResponse, error)
+	// Random draws a comic, found by the phrase if it is not empty; the
+	// response has no comics if there is none to draw.
+	Random(phrase string, opts SearchOptions) (SearchResponse, error)
+	OnThisDay() (SearchResponse, error)
 	Update(string) error
 	Drop(string) error
+	Reindex(string) error
 	GetStatus() (Status, error)
 	GetStats() (Stats, error)
+	GetAnalytics(token, window string) (Analytics, error)
 	Login(string, string) (string, error)
 }
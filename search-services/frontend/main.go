@@ -32,9 +32,13 @@ func main() {
 	mux.HandleFunc("GET /admin/dashboard", rest.DashboardHandler(cfg.TemplatePath, log, apiClient))
 	mux.HandleFunc("POST /admin/update", rest.AdminUpdateHandler(log, apiClient))
 	mux.HandleFunc("POST /admin/drop", rest.AdminDropHandler(log, apiClient))
+	mux.HandleFunc("POST /admin/reindex", rest.AdminReindexHandler(log, apiClient))
 
 	mux.HandleFunc("GET /", rest.MainPageHandler(cfg.TemplatePath, log))
 	mux.HandleFunc("GET /search", rest.SearchHandler(cfg.TemplatePath, log, apiClient))
+	mux.HandleFunc("GET /similar", rest.SimilarHandler(cfg.TemplatePath, log, apiClient))
+	mux.HandleFunc("GET /lucky", rest.LuckyHandler(cfg.TemplatePath, log, apiClient))
+	mux.HandleFunc("GET /on-this-day", rest.OnThisDayHandler(cfg.TemplatePath, log, apiClient))
 
 	srv := &http.Server{
 		Addr:    cfg.HTTPAddress,
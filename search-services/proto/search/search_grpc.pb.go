@@ -20,9 +20,24 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Search_Ping_FullMethodName        = "/search.Search/Ping"
-	Search_Search_FullMethodName      = "/search.Search/Search"
-	Search_IndexSearch_FullMethodName = "/search.Search/IndexSearch"
+	Search_Ping_FullMethodName           = "/search.Search/Ping"
+	Search_Search_FullMethodName         = "/search.Search/Search"
+	Search_IndexSearch_FullMethodName    = "/search.Search/IndexSearch"
+	Search_FTSSearch_FullMethodName      = "/search.Search/FTSSearch"
+	Search_HybridSearch_FullMethodName   = "/search.Search/HybridSearch"
+	Search_SemanticSearch_FullMethodName = "/search.Search/SemanticSearch"
+	Search_Similar_FullMethodName        = "/search.Search/Similar"
+	Search_Random_FullMethodName         = "/search.Search/Random"
+	Search_OnThisDay_FullMethodName      = "/search.Search/OnThisDay"
+	Search_Analytics_FullMethodName      = "/search.Search/Analytics"
+	Search_CacheStats_FullMethodName     = "/search.Search/CacheStats"
+	Search_GetCuration_FullMethodName    = "/search.Search/GetCuration"
+	Search_PutPin_FullMethodName         = "/search.Search/PutPin"
+	Search_DeletePin_FullMethodName      = "/search.Search/DeletePin"
+	Search_PutRewrite_FullMethodName     = "/search.Search/PutRewrite"
+	Search_DeleteRewrite_FullMethodName  = "/search.Search/DeleteRewrite"
+	Search_Block_FullMethodName          = "/search.Search/Block"
+	Search_Unblock_FullMethodName        = "/search.Search/Unblock"
 )
 
 // SearchClient is the client API for Search service.
@@ -32,6 +47,27 @@ type SearchClient interface {
 	Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchReply, error)
 	IndexSearch(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchReply, error)
+	FTSSearch(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchReply, error)
+	// HybridSearch fuses the results of all the engines, relevance order only
+	HybridSearch(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchReply, error)
+	// SemanticSearch finds comics by meaning, relevance order only
+	SemanticSearch(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchReply, error)
+	Similar(ctx context.Context, in *SimilarRequest, opts ...grpc.CallOption) (*SearchReply, error)
+	// Random draws a comic, NotFound if there is none to draw from
+	Random(ctx context.Context, in *RandomRequest, opts ...grpc.CallOption) (*Comics, error)
+	// OnThisDay finds the comics of the day in the past years, newest first
+	OnThisDay(ctx context.Context, in *OnThisDayRequest, opts ...grpc.CallOption) (*SearchReply, error)
+	Analytics(ctx context.Context, in *AnalyticsRequest, opts ...grpc.CallOption) (*AnalyticsReply, error)
+	CacheStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CacheStatsReply, error)
+	GetCuration(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Curation, error)
+	// PutPin replaces the comics pinned for the query, Pin.query is a phrase
+	PutPin(ctx context.Context, in *Pin, opts ...grpc.CallOption) (*Pin, error)
+	DeletePin(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// PutRewrite replaces the rewrite of the query, Rewrite.query is a phrase
+	PutRewrite(ctx context.Context, in *Rewrite, opts ...grpc.CallOption) (*Rewrite, error)
+	DeleteRewrite(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Block(ctx context.Context, in *ComicRef, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Unblock(ctx context.Context, in *ComicRef, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type searchClient struct {
@@ -72,6 +108,156 @@ func (c *searchClient) IndexSearch(ctx context.Context, in *SearchRequest, opts
 	return out, nil
 }
 
+func (c *searchClient) FTSSearch(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchReply)
+	err := c.cc.Invoke(ctx, Search_FTSSearch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchClient) HybridSearch(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchReply)
+	err := c.cc.Invoke(ctx, Search_HybridSearch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchClient) SemanticSearch(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchReply)
+	err := c.cc.Invoke(ctx, Search_SemanticSearch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchClient) Similar(ctx context.Context, in *SimilarRequest, opts ...grpc.CallOption) (*SearchReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchReply)
+	err := c.cc.Invoke(ctx, Search_Similar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchClient) Random(ctx context.Context, in *RandomRequest, opts ...grpc.CallOption) (*Comics, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Comics)
+	err := c.cc.Invoke(ctx, Search_Random_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchClient) OnThisDay(ctx context.Context, in *OnThisDayRequest, opts ...grpc.CallOption) (*SearchReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchReply)
+	err := c.cc.Invoke(ctx, Search_OnThisDay_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchClient) Analytics(ctx context.Context, in *AnalyticsRequest, opts ...grpc.CallOption) (*AnalyticsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AnalyticsReply)
+	err := c.cc.Invoke(ctx, Search_Analytics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchClient) CacheStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CacheStatsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CacheStatsReply)
+	err := c.cc.Invoke(ctx, Search_CacheStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchClient) GetCuration(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Curation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Curation)
+	err := c.cc.Invoke(ctx, Search_GetCuration_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchClient) PutPin(ctx context.Context, in *Pin, opts ...grpc.CallOption) (*Pin, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Pin)
+	err := c.cc.Invoke(ctx, Search_PutPin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchClient) DeletePin(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Search_DeletePin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchClient) PutRewrite(ctx context.Context, in *Rewrite, opts ...grpc.CallOption) (*Rewrite, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Rewrite)
+	err := c.cc.Invoke(ctx, Search_PutRewrite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchClient) DeleteRewrite(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Search_DeleteRewrite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchClient) Block(ctx context.Context, in *ComicRef, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Search_Block_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchClient) Unblock(ctx context.Context, in *ComicRef, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Search_Unblock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SearchServer is the server API for Search service.
 // All implementations must embed UnimplementedSearchServer
 // for forward compatibility.
@@ -79,6 +265,27 @@ type SearchServer interface {
 	Ping(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	Search(context.Context, *SearchRequest) (*SearchReply, error)
 	IndexSearch(context.Context, *SearchRequest) (*SearchReply, error)
+	FTSSearch(context.Context, *SearchRequest) (*SearchReply, error)
+	// HybridSearch fuses the results of all the engines, relevance order only
+	HybridSearch(context.Context, *SearchRequest) (*SearchReply, error)
+	// SemanticSearch finds comics by meaning, relevance order only
+	SemanticSearch(context.Context, *SearchRequest) (*SearchReply, error)
+	Similar(context.Context, *SimilarRequest) (*SearchReply, error)
+	// Random draws a comic, NotFound if there is none to draw from
+	Random(context.Context, *RandomRequest) (*Comics, error)
+	// OnThisDay finds the comics of the day in the past years, newest first
+	OnThisDay(context.Context, *OnThisDayRequest) (*SearchReply, error)
+	Analytics(context.Context, *AnalyticsRequest) (*AnalyticsReply, error)
+	CacheStats(context.Context, *emptypb.Empty) (*CacheStatsReply, error)
+	GetCuration(context.Context, *emptypb.Empty) (*Curation, error)
+	// PutPin replaces the comics pinned for the query, Pin.query is a phrase
+	PutPin(context.Context, *Pin) (*Pin, error)
+	DeletePin(context.Context, *QueryRequest) (*emptypb.Empty, error)
+	// PutRewrite replaces the rewrite of the query, Rewrite.query is a phrase
+	PutRewrite(context.Context, *Rewrite) (*Rewrite, error)
+	DeleteRewrite(context.Context, *QueryRequest) (*emptypb.Empty, error)
+	Block(context.Context, *ComicRef) (*emptypb.Empty, error)
+	Unblock(context.Context, *ComicRef) (*emptypb.Empty, error)
 	mustEmbedUnimplementedSearchServer()
 }
 
@@ -98,6 +305,51 @@ func (UnimplementedSearchServer) Search(context.Context, *SearchRequest) (*Searc
 func (UnimplementedSearchServer) IndexSearch(context.Context, *SearchRequest) (*SearchReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method IndexSearch not implemented")
 }
+func (UnimplementedSearchServer) FTSSearch(context.Context, *SearchRequest) (*SearchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FTSSearch not implemented")
+}
+func (UnimplementedSearchServer) HybridSearch(context.Context, *SearchRequest) (*SearchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HybridSearch not implemented")
+}
+func (UnimplementedSearchServer) SemanticSearch(context.Context, *SearchRequest) (*SearchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SemanticSearch not implemented")
+}
+func (UnimplementedSearchServer) Similar(context.Context, *SimilarRequest) (*SearchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Similar not implemented")
+}
+func (UnimplementedSearchServer) Random(context.Context, *RandomRequest) (*Comics, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Random not implemented")
+}
+func (UnimplementedSearchServer) OnThisDay(context.Context, *OnThisDayRequest) (*SearchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OnThisDay not implemented")
+}
+func (UnimplementedSearchServer) Analytics(context.Context, *AnalyticsRequest) (*AnalyticsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Analytics not implemented")
+}
+func (UnimplementedSearchServer) CacheStats(context.Context, *emptypb.Empty) (*CacheStatsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CacheStats not implemented")
+}
+func (UnimplementedSearchServer) GetCuration(context.Context, *emptypb.Empty) (*Curation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCuration not implemented")
+}
+func (UnimplementedSearchServer) PutPin(context.Context, *Pin) (*Pin, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutPin not implemented")
+}
+func (UnimplementedSearchServer) DeletePin(context.Context, *QueryRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePin not implemented")
+}
+func (UnimplementedSearchServer) PutRewrite(context.Context, *Rewrite) (*Rewrite, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutRewrite not implemented")
+}
+func (UnimplementedSearchServer) DeleteRewrite(context.Context, *QueryRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteRewrite not implemented")
+}
+func (UnimplementedSearchServer) Block(context.Context, *ComicRef) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Block not implemented")
+}
+func (UnimplementedSearchServer) Unblock(context.Context, *ComicRef) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unblock not implemented")
+}
 func (UnimplementedSearchServer) mustEmbedUnimplementedSearchServer() {}
 func (UnimplementedSearchServer) testEmbeddedByValue()                {}
 
@@ -173,6 +425,276 @@ func _Search_IndexSearch_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Search_FTSSearch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).FTSSearch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_FTSSearch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).FTSSearch(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_HybridSearch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).HybridSearch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_HybridSearch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).HybridSearch(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_SemanticSearch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).SemanticSearch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_SemanticSearch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).SemanticSearch(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_Similar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SimilarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).Similar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_Similar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).Similar(ctx, req.(*SimilarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_Random_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RandomRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).Random(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_Random_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).Random(ctx, req.(*RandomRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_OnThisDay_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OnThisDayRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).OnThisDay(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_OnThisDay_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).OnThisDay(ctx, req.(*OnThisDayRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_Analytics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnalyticsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).Analytics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_Analytics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).Analytics(ctx, req.(*AnalyticsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_CacheStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).CacheStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_CacheStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).CacheStats(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_GetCuration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).GetCuration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_GetCuration_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).GetCuration(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_PutPin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Pin)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).PutPin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_PutPin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).PutPin(ctx, req.(*Pin))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_DeletePin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).DeletePin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_DeletePin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).DeletePin(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_PutRewrite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Rewrite)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).PutRewrite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_PutRewrite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).PutRewrite(ctx, req.(*Rewrite))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_DeleteRewrite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).DeleteRewrite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_DeleteRewrite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).DeleteRewrite(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_Block_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ComicRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).Block(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_Block_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).Block(ctx, req.(*ComicRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_Unblock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ComicRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).Unblock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_Unblock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).Unblock(ctx, req.(*ComicRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Search_ServiceDesc is the grpc.ServiceDesc for Search service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -192,6 +714,66 @@ var Search_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "IndexSearch",
 			Handler:    _Search_IndexSearch_Handler,
 		},
+		{
+			MethodName: "FTSSearch",
+			Handler:    _Search_FTSSearch_Handler,
+		},
+		{
+			MethodName: "HybridSearch",
+			Handler:    _Search_HybridSearch_Handler,
+		},
+		{
+			MethodName: "SemanticSearch",
+			Handler:    _Search_SemanticSearch_Handler,
+		},
+		{
+			MethodName: "Similar",
+			Handler:    _Search_Similar_Handler,
+		},
+		{
+			MethodName: "Random",
+			Handler:    _Search_Random_Handler,
+		},
+		{
+			MethodName: "OnThisDay",
+			Handler:    _Search_OnThisDay_Handler,
+		},
+		{
+			MethodName: "Analytics",
+			Handler:    _Search_Analytics_Handler,
+		},
+		{
+			MethodName: "CacheStats",
+			Handler:    _Search_CacheStats_Handler,
+		},
+		{
+			MethodName: "GetCuration",
+			Handler:    _Search_GetCuration_Handler,
+		},
+		{
+			MethodName: "PutPin",
+			Handler:    _Search_PutPin_Handler,
+		},
+		{
+			MethodName: "DeletePin",
+			Handler:    _Search_DeletePin_Handler,
+		},
+		{
+			MethodName: "PutRewrite",
+			Handler:    _Search_PutRewrite_Handler,
+		},
+		{
+			MethodName: "DeleteRewrite",
+			Handler:    _Search_DeleteRewrite_Handler,
+		},
+		{
+			MethodName: "Block",
+			Handler:    _Search_Block_Handler,
+		},
+		{
+			MethodName: "Unblock",
+			Handler:    _Search_Unblock_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "search.proto",
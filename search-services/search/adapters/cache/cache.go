@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"yadro.com/course/search/core"
+)
+
+// Cache is an LRU cache of search results whose entries expire after a TTL.
+type Cache struct {
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// order holds the entries, the most recently used first.
+	order *list.List
+	stats core.CacheStats
+}
+
+type entry struct {
+	key     string
+	comics  []core.Comics
+	expires time.Time
+}
+
+// New makes a cache of up to capacity searches kept for ttl at most.
+func New(capacity int, ttl time.Duration) *Cache {
+	return &Cache{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]*list.Element, capacity),
+		order:    list.New(),
+		stats:    core.CacheStats{Capacity: capacity},
+	}
+}
+
+// Get returns a copy of the cached comics, missing an expired entry.
+func (c *Cache) Get(key string) ([]core.Comics, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if !c.now().Before(e.expires) {
+		c.remove(el)
+		c.stats.Misses++
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+	c.stats.Hits++
+	return append([]core.Comics(nil), e.comics...), true
+}
+
+// Put caches the comics, evicting the least recently used entry if the
+// cache is full.
+func (c *Cache) Put(key string, comics []core.Comics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := &entry{key: key, comics: append([]core.Comics(nil), comics...), expires: c.now().Add(c.ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return
+	}
+
+	if c.order.Len() >= c.capacity {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+	c.entries[key] = c.order.PushFront(e)
+}
+
+// Purge drops every entry.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element, c.capacity)
+	c.order.Init()
+	c.stats.Purges++
+}
+
+func (c *Cache) Stats() core.CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.order.Len()
+	return stats
+}
+
+func (c *Cache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*entry).key)
+}
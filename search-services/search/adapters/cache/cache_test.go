@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"yadro.com/course/search/core"
+)
+
+func comics(ids ...int) []core.Comics {
+	out := make([]core.Comics, len(ids))
+	for i, id := range ids {
+		out[i] = core.Comics{ID: id}
+	}
+	return out
+}
+
+func TestCache_Get(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New(2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	got, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Nil(t, got)
+
+	c.Put("a", comics(1, 2))
+	got, ok = c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, comics(1, 2), got)
+
+	// the comics returned are a copy
+	got[0].ID = 5
+	got, _ = c.Get("a")
+	assert.Equal(t, comics(1, 2), got)
+
+	now = now.Add(time.Minute)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+
+	assert.Equal(t, core.CacheStats{Hits: 2, Misses: 2, Capacity: 2}, c.Stats())
+}
+
+func TestCache_Put(t *testing.T) {
+	c := New(2, time.Minute)
+
+	c.Put("a", comics(1))
+	c.Put("b", comics(2))
+	_, _ = c.Get("a")
+	c.Put("c", comics(3))
+
+	_, ok := c.Get("b")
+	assert.False(t, ok, "the least recently used entry is evicted")
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+
+	c.Put("a", comics(4))
+	got, _ := c.Get("a")
+	assert.Equal(t, comics(4), got)
+	assert.Equal(t, core.CacheStats{Hits: 3, Misses: 1, Evictions: 1, Size: 2, Capacity: 2}, c.Stats())
+}
+
+func TestCache_Purge(t *testing.T) {
+	c := New(2, time.Minute)
+	c.Put("a", comics(1))
+	c.Put("b", comics(2))
+
+	c.Purge()
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, core.CacheStats{Misses: 1, Purges: 1, Capacity: 2}, c.Stats())
+
+	c.Put("a", comics(1))
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+}
@@ -0,0 +1,158 @@
+package curation
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"yadro.com/course/search/core"
+)
+
+// Store keeps the curation in the curation_pins, curation_rewrites and
+// curation_blocklist tables.
+type Store struct {
+	log  *slog.Logger
+	conn *sqlx.DB
+}
+
+func New(log *slog.Logger, address string) (*Store, error) {
+	db, err := sqlx.Connect("pgx", address)
+	if err != nil {
+		log.Error("connection problem", "address", address, "error", err)
+		return nil, err
+	}
+	return newStore(log, db), nil
+}
+
+func newStore(log *slog.Logger, db *sqlx.DB) *Store {
+	return &Store{log: log, conn: db}
+}
+
+type pinRow struct {
+	Query   string `db:"query"`
+	Source  string `db:"source"`
+	ComicID int    `db:"comic_id"`
+}
+
+type rewriteRow struct {
+	Query  string `db:"query"`
+	Phrase string `db:"phrase"`
+}
+
+type comicRow struct {
+	Source  string `db:"source"`
+	ComicID int    `db:"comic_id"`
+}
+
+// Load returns the whole curation, the pins and the rewrites ordered by
+// their queries and the pinned comics by their positions.
+func (s *Store) Load(ctx context.Context) (core.Curation, error) {
+	var pins []pinRow
+	if err := s.conn.SelectContext(ctx, &pins, `
+	SELECT query, source, comic_id FROM curation_pins ORDER BY query, position
+	`); err != nil {
+		return core.Curation{}, fmt.Errorf("failed to load pins: %w", err)
+	}
+	var rewrites []rewriteRow
+	if err := s.conn.SelectContext(ctx, &rewrites, `
+	SELECT query, phrase FROM curation_rewrites ORDER BY query
+	`); err != nil {
+		return core.Curation{}, fmt.Errorf("failed to load rewrites: %w", err)
+	}
+	var blocked []comicRow
+	if err := s.conn.SelectContext(ctx, &blocked, `
+	SELECT source, comic_id FROM curation_blocklist ORDER BY source, comic_id
+	`); err != nil {
+		return core.Curation{}, fmt.Errorf("failed to load blocklist: %w", err)
+	}
+
+	curation := core.Curation{
+		Pins:     []core.Pin{},
+		Rewrites: make([]core.Rewrite, len(rewrites)),
+		Blocked:  make([]core.ComicID, len(blocked)),
+	}
+	for _, r := range pins {
+		if n := len(curation.Pins); n == 0 || curation.Pins[n-1].Query != r.Query {
+			curation.Pins = append(curation.Pins, core.Pin{Query: r.Query})
+		}
+		pin := &curation.Pins[len(curation.Pins)-1]
+		pin.Comics = append(pin.Comics, core.ComicID{Source: r.Source, ID: r.ComicID})
+	}
+	for i, r := range rewrites {
+		curation.Rewrites[i] = core.Rewrite{Query: r.Query, Phrase: r.Phrase}
+	}
+	for i, r := range blocked {
+		curation.Blocked[i] = core.ComicID{Source: r.Source, ID: r.ComicID}
+	}
+	return curation, nil
+}
+
+// PutPin replaces the comics pinned for the query in one transaction, so
+// that a reload never sees them half written.
+func (s *Store) PutPin(ctx context.Context, pin core.Pin) error {
+	tx, err := s.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM curation_pins WHERE query = $1`, pin.Query); err != nil {
+		return err
+	}
+	for position, id := range pin.Comics {
+		if _, err := tx.ExecContext(ctx, `
+		INSERT INTO curation_pins (query, position, source, comic_id) VALUES ($1, $2, $3, $4)
+		`, pin.Query, position, id.Source, id.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) DeletePin(ctx context.Context, query string) error {
+	return s.delete(ctx, `DELETE FROM curation_pins WHERE query = $1`, query)
+}
+
+func (s *Store) PutRewrite(ctx context.Context, rewrite core.Rewrite) error {
+	_, err := s.conn.ExecContext(ctx, `
+	INSERT INTO curation_rewrites (query, phrase) VALUES ($1, $2)
+	ON CONFLICT (query) DO UPDATE SET phrase = EXCLUDED.phrase
+	`, rewrite.Query, rewrite.Phrase)
+	return err
+}
+
+func (s *Store) DeleteRewrite(ctx context.Context, query string) error {
+	return s.delete(ctx, `DELETE FROM curation_rewrites WHERE query = $1`, query)
+}
+
+// Block does nothing if the comic is blocked already.
+func (s *Store) Block(ctx context.Context, id core.ComicID) error {
+	_, err := s.conn.ExecContext(ctx, `
+	INSERT INTO curation_blocklist (source, comic_id) VALUES ($1, $2)
+	ON CONFLICT DO NOTHING
+	`, id.Source, id.ID)
+	return err
+}
+
+func (s *Store) Unblock(ctx context.Context, id core.ComicID) error {
+	return s.delete(ctx, `DELETE FROM curation_blocklist WHERE source = $1 AND comic_id = $2`, id.Source, id.ID)
+}
+
+// delete returns core.ErrNotFound if no row is deleted.
+func (s *Store) delete(ctx context.Context, query string, args ...any) error {
+	res, err := s.conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		s.log.Error("failed to delete curation", "error", err)
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return core.ErrNotFound
+	}
+	return nil
+}
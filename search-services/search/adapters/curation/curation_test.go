@@ -0,0 +1,91 @@
+package curation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+	"yadro.com/course/search/core"
+)
+
+func TestStore_Load(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT query, source, comic_id FROM curation_pins ORDER BY query, position`).
+		WillReturnRows(sqlxmock.NewRows([]string{"query", "source", "comic_id"}).
+			AddRow("password", "xkcd", 936).
+			AddRow("password", "xkcd", 792).
+			AddRow("standard", "xkcd", 927))
+	mock.ExpectQuery(`SELECT query, phrase FROM curation_rewrites ORDER BY query`).
+		WillReturnRows(sqlxmock.NewRows([]string{"query", "phrase"}).AddRow("passwd", "password"))
+	mock.ExpectQuery(`SELECT source, comic_id FROM curation_blocklist ORDER BY source, comic_id`).
+		WillReturnRows(sqlxmock.NewRows([]string{"source", "comic_id"}).AddRow("xkcd", 404))
+
+	got, err := newStore(slog.Default(), db).Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, core.Curation{
+		Pins: []core.Pin{
+			{Query: "password", Comics: []core.ComicID{{Source: "xkcd", ID: 936}, {Source: "xkcd", ID: 792}}},
+			{Query: "standard", Comics: []core.ComicID{{Source: "xkcd", ID: 927}}},
+		},
+		Rewrites: []core.Rewrite{{Query: "passwd", Phrase: "password"}},
+		Blocked:  []core.ComicID{{Source: "xkcd", ID: 404}},
+	}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_PutPin(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM curation_pins WHERE query = \$1`).
+		WithArgs("password").
+		WillReturnResult(sqlxmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO curation_pins \(query, position, source, comic_id\) VALUES \(\$1, \$2, \$3, \$4\)`).
+		WithArgs("password", 0, "xkcd", 936).
+		WillReturnResult(sqlxmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO curation_pins`).
+		WithArgs("password", 1, "xkcd", 792).
+		WillReturnError(errors.New("db down"))
+	mock.ExpectRollback()
+
+	err = newStore(slog.Default(), db).PutPin(context.Background(), core.Pin{
+		Query:  "password",
+		Comics: []core.ComicID{{Source: "xkcd", ID: 936}, {Source: "xkcd", ID: 792}},
+	})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "a failed insert rolls the pin back")
+}
+
+func TestStore_Delete(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+	store := newStore(slog.Default(), db)
+	ctx := context.Background()
+
+	mock.ExpectExec(`DELETE FROM curation_rewrites WHERE query = \$1`).
+		WithArgs("passwd").
+		WillReturnResult(sqlxmock.NewResult(0, 1))
+	assert.NoError(t, store.DeleteRewrite(ctx, "passwd"))
+
+	mock.ExpectExec(`DELETE FROM curation_blocklist WHERE source = \$1 AND comic_id = \$2`).
+		WithArgs("xkcd", 404).
+		WillReturnResult(sqlxmock.NewResult(0, 0))
+	assert.ErrorIs(t, store.Unblock(ctx, core.ComicID{Source: "xkcd", ID: 404}), core.ErrNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"log/slog"
+	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
@@ -31,41 +32,240 @@ func New(log *slog.Logger, address string) (*DB, error) {
 	}, nil
 }
 
-func (db *DB) SearchComics(ctx context.Context, limit int, keywords []string) ([]core.Comics, error) {
+func (db *DB) SearchComics(ctx context.Context, limit int, query core.Query) ([]core.Comics, error) {
+	sqlQuery := ranked("c.comic_id, c.source, c.image_url", query.Options.Sort)
+
+	var dbComics []core.DbComics
+	err := db.conn.SelectContext(ctx, &dbComics, sqlQuery, rankArgs(limit, query)...)
+	if err != nil {
+		db.log.Error("failed to do query", "error", err)
+		return nil, err
+	}
+
+	comics := make([]core.Comics, len(dbComics))
+	for i, c := range dbComics {
+		comics[i] = core.Comics{
+			ID:     c.ID,
+			Source: c.Source,
+			URL:    c.URL,
+		}
+	}
+	return comics, nil
+}
+
+// ExplainSearch runs the query of SearchComics along with its plan and the
+// terms each comic found matched.
+func (db *DB) ExplainSearch(ctx context.Context, limit int, query core.Query) (core.Explain, error) {
+	sqlQuery := ranked("c.comic_id, c.source, c.image_url, t.score", query.Options.Sort)
+	args := rankArgs(limit, query)
+
+	var plan []string
+	if err := db.conn.SelectContext(ctx, &plan, "EXPLAIN "+sqlQuery, args...); err != nil {
+		db.log.Error("failed to explain query", "error", err)
+		return core.Explain{}, err
+	}
+
+	var scored []struct {
+		core.DbComics
+		Score float64 `db:"score"`
+	}
+	if err := db.conn.SelectContext(ctx, &scored, sqlQuery, args...); err != nil {
+		db.log.Error("failed to do query", "error", err)
+		return core.Explain{}, err
+	}
+
+	sources := make([]string, len(scored))
+	ids := make([]int, len(scored))
+	for i, c := range scored {
+		sources[i], ids[i] = c.Source, c.ID
+	}
+
+	// term is the position of the query term, counted from 1
+	matchQuery := `
+	SELECT t.source, t.comic_id, q.n AS term, t.field, t.tf
+	FROM comic_terms AS t
+	JOIN unnest($1::text[], $2::text[]) WITH ORDINALITY AS q(field, term, n)
+		ON t.term = q.term AND (q.field = '' OR q.field = t.field)
+	JOIN unnest($3::text[], $4::int[]) AS hit(source, comic_id)
+		ON t.source = hit.source AND t.comic_id = hit.comic_id
+	ORDER BY q.n, t.field
+	`
+	fields, words := query.Columns()
+	var rows []struct {
+		Source string `db:"source"`
+		ID     int    `db:"comic_id"`
+		Term   int    `db:"term"`
+		Field  string `db:"field"`
+		TF     int    `db:"tf"`
+	}
+	err := db.conn.SelectContext(ctx, &rows, matchQuery,
+		pq.Array(fields), pq.Array(words), pq.Array(sources), pq.Array(ids))
+	if err != nil {
+		db.log.Error("failed to get matched terms", "error", err)
+		return core.Explain{}, err
+	}
+
+	type key struct {
+		source string
+		id     int
+	}
+	matches := make(map[key][]core.Match)
+	for _, row := range rows {
+		if row.Term < 1 || row.Term > len(query.Terms) {
+			continue
+		}
+		k := key{row.Source, row.ID}
+		matches[k] = append(matches[k],
+			core.NewMatch(query.Terms[row.Term-1], row.Field, row.TF, query.Boosts.Of(row.Field)))
+	}
+
+	explain := core.Explain{Plan: plan, Comics: make([]core.Comics, len(scored))}
+	for i, c := range scored {
+		explain.Comics[i] = core.Comics{
+			ID:     c.ID,
+			Source: c.Source,
+			URL:    c.URL,
+			Explanation: &core.Explanation{
+				Score:   c.Score,
+				Matches: matches[key{c.Source, c.ID}],
+			},
+		}
+	}
+	return explain, nil
+}
+
+// ranked is the query of SearchComics selecting the given columns of the
+// ranked comics t joined with comics c.
+func ranked(columns string, sort core.Sort) string {
+	// comic_terms holds how many times every keyword occurs in each field of
+	// a comic, so the rank is summed up straight from its primary key index.
+	// A comic must match every scoped term, and every term at all if $12 is
+	// their number rather than zero. The bounds are applied before the limit
+	// so that it counts matching comics only.
+	return `
+	SELECT ` + columns + `
+	FROM (
+		SELECT t.source, t.comic_id,
+			SUM(t.tf * CASE t.field
+				WHEN 'title' THEN $3::float8
+				WHEN 'alt' THEN $4::float8
+				WHEN 'transcript' THEN $5::float8
+				WHEN 'tag' THEN $13::float8
+				ELSE 1 END) AS score
+		FROM comic_terms AS t
+		JOIN unnest($1::text[], $2::text[]) AS q(field, term)
+			ON t.term = q.term AND (q.field = '' OR q.field = t.field)
+		GROUP BY t.source, t.comic_id
+		HAVING COUNT(*) FILTER (WHERE q.field <> '') = $6
+			AND ($12 = 0 OR COUNT(DISTINCT (q.field, q.term)) = $12)
+	) AS t
+	JOIN comics AS c USING (source, comic_id)
+	WHERE ($8::date IS NULL OR c.published >= $8::date)
+		AND ($9::date IS NULL OR c.published <= $9::date)
+		AND ($10::int IS NULL OR c.comic_id >= $10::int)
+		AND ($11::int IS NULL OR c.comic_id <= $11::int)
+	ORDER BY ` + orderBy(sort) + `
+	LIMIT $7
+	`
+}
+
+// rankArgs are the arguments of the ranked query.
+func rankArgs(limit int, query core.Query) []any {
+	fields, words := query.Columns()
+	all := 0
+	if query.Options.All {
+		all = len(query.Terms)
+	}
+	args := append([]any{pq.Array(fields), pq.Array(words),
+		query.Boosts.Title, query.Boosts.Alt, query.Boosts.Transcript, query.Scoped(), limit},
+		bounds(query.Options)...)
+	return append(args, all, query.Boosts.Tag)
+}
+
+// orderBy sorts the ranked comics t joined with comics c. Undated comics go
+// last in either date order.
+func orderBy(sort core.Sort) string {
+	switch sort {
+	case core.SortNewest:
+		return "c.published DESC NULLS LAST, t.score DESC, c.comic_id DESC"
+	case core.SortOldest:
+		return "c.published ASC NULLS LAST, t.score DESC, c.comic_id DESC"
+	}
+	return "t.score DESC, c.comic_id DESC"
+}
+
+// bounds are the query arguments of the options, NULL for open bounds.
+func bounds(opts core.Options) []any {
+	return []any{
+		sql.NullTime{Time: opts.From, Valid: !opts.From.IsZero()},
+		sql.NullTime{Time: opts.To, Valid: !opts.To.IsZero()},
+		sql.NullInt64{Int64: int64(opts.MinID), Valid: opts.MinID != 0},
+		sql.NullInt64{Int64: int64(opts.MaxID), Valid: opts.MaxID != 0},
+	}
+}
+
+func (db *DB) RandomComics(ctx context.Context, limit int, opts core.Options) ([]core.Comics, error) {
 	query := `
-	SELECT comic_id, image_url
+	SELECT comic_id, source, image_url, published
 	FROM comics
-	WHERE keywords && $1
-	ORDER BY (
-		SELECT COUNT(*) 
-		FROM unnest(keywords) AS kw
-		WHERE kw = ANY($1)
-	) DESC
-	LIMIT $2
+	WHERE image_url <> ''
+		AND ($2::date IS NULL OR published >= $2::date)
+		AND ($3::date IS NULL OR published <= $3::date)
+		AND ($4::int IS NULL OR comic_id >= $4::int)
+		AND ($5::int IS NULL OR comic_id <= $5::int)
+	ORDER BY random()
+	LIMIT $1
 	`
 
 	var dbComics []core.DbComics
-	err := db.conn.SelectContext(ctx, &dbComics, query, pq.Array(keywords), limit)
+	err := db.conn.SelectContext(ctx, &dbComics, query, append([]any{limit}, bounds(opts)...)...)
 	if err != nil {
-		db.log.Error("failed to do query", "error", err)
+		db.log.Error("failed to draw comics", "error", err)
 		return nil, err
 	}
+	return dated(dbComics), nil
+}
 
+func (db *DB) OnThisDay(ctx context.Context, month time.Month, day, year, limit int) ([]core.Comics, error) {
+	query := `
+	SELECT comic_id, source, image_url, published
+	FROM comics
+	WHERE image_url <> ''
+		AND EXTRACT(MONTH FROM published) = $1
+		AND EXTRACT(DAY FROM published) = $2
+		AND EXTRACT(YEAR FROM published) < $3
+	ORDER BY published DESC, comic_id DESC
+	LIMIT $4
+	`
+
+	var dbComics []core.DbComics
+	err := db.conn.SelectContext(ctx, &dbComics, query, int(month), day, year, limit)
+	if err != nil {
+		db.log.Error("failed to find comics of the day", "error", err)
+		return nil, err
+	}
+	return dated(dbComics), nil
+}
+
+// dated converts the comics selected with their publication dates.
+func dated(dbComics []core.DbComics) []core.Comics {
 	comics := make([]core.Comics, len(dbComics))
 	for i, c := range dbComics {
 		comics[i] = core.Comics{
-			ID:  c.ID,
-			URL: c.URL,
+			ID:        c.ID,
+			Source:    c.Source,
+			URL:       c.URL,
+			Published: c.Published,
 		}
 	}
-	return comics, nil
+	return comics
 }
 
-func (db *DB) GetImageURL(ctx context.Context, id int) (string, error) {
-	query := `SELECT image_url FROM comics WHERE comic_id = $1`
+func (db *DB) GetImageURL(ctx context.Context, source string, id int) (string, error) {
+	query := `SELECT image_url FROM comics WHERE source = $1 AND comic_id = $2`
 
 	var imageURL string
-	err := db.conn.GetContext(ctx, &imageURL, query, id)
+	err := db.conn.GetContext(ctx, &imageURL, query, source, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return "", nil
@@ -78,11 +278,50 @@ func (db *DB) GetImageURL(ctx context.Context, id int) (string, error) {
 
 }
 
+// GetTexts gets the text of all the comics in one query.
+func (db *DB) GetTexts(ctx context.Context, ids []core.ComicID) (map[core.ComicID]core.Text, error) {
+	query := `
+	SELECT source, comic_id, title, alt, transcript FROM comics
+	WHERE (source, comic_id) IN (SELECT * FROM unnest($1::text[], $2::int[]))
+	`
+
+	sources := make([]string, len(ids))
+	comicIDs := make([]int, len(ids))
+	for i, id := range ids {
+		sources[i], comicIDs[i] = id.Source, id.ID
+	}
+
+	var rows []struct {
+		Source string `db:"source"`
+		ID     int    `db:"comic_id"`
+		core.Text
+	}
+	if err := db.conn.SelectContext(ctx, &rows, query, pq.Array(sources), pq.Array(comicIDs)); err != nil {
+		db.log.Error("failed to get texts", "error", err)
+		return nil, err
+	}
+
+	texts := make(map[core.ComicID]core.Text, len(rows))
+	for _, row := range rows {
+		texts[core.ComicID{Source: row.Source, ID: row.ID}] = row.Text
+	}
+	return texts, nil
+}
+
 func (db *DB) GetComics(ctx context.Context) ([]core.Comics, error) {
 	query := `
         SELECT 
             comic_id, 
-            ARRAY_TO_JSON(COALESCE(keywords, ARRAY[]::TEXT[])) AS keywords
+            source,
+            ARRAY_TO_JSON(COALESCE(keywords, ARRAY[]::TEXT[])) AS keywords,
+            ARRAY_TO_JSON(COALESCE(title_keywords, ARRAY[]::TEXT[])) AS title_keywords,
+            ARRAY_TO_JSON(COALESCE(alt_keywords, ARRAY[]::TEXT[])) AS alt_keywords,
+            ARRAY_TO_JSON(COALESCE(transcript_keywords, ARRAY[]::TEXT[])) AS transcript_keywords,
+            ARRAY_TO_JSON(COALESCE((
+                SELECT array_agg(kw) FROM comic_tags AS t, unnest(t.keywords) AS kw
+                WHERE t.source = comics.source AND t.comic_id = comics.comic_id
+            ), ARRAY[]::TEXT[])) AS tag_keywords,
+            published
         FROM comics
     `
 
@@ -96,8 +335,14 @@ func (db *DB) GetComics(ctx context.Context) ([]core.Comics, error) {
 	out := make([]core.Comics, len(comics))
 	for i, c := range comics {
 		out[i] = core.Comics{
-			ID:       c.ID,
-			Keywords: c.Keywords,
+			ID:                 c.ID,
+			Source:             c.Source,
+			Keywords:           c.Keywords,
+			TitleKeywords:      c.TitleKeywords,
+			AltKeywords:        c.AltKeywords,
+			TranscriptKeywords: c.TranscriptKeywords,
+			TagKeywords:        c.TagKeywords,
+			Published:          c.Published,
 		}
 	}
 
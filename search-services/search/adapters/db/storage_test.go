@@ -3,10 +3,13 @@ package db
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"log/slog"
 	"testing"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	sqlxmock "github.com/zhashkevych/go-sqlxmock"
 	"yadro.com/course/search/core"
@@ -24,60 +27,315 @@ func TestDB_SearchComics(t *testing.T) {
 		conn: db,
 	}
 
+	boosts := core.Boosts{Title: 3, Alt: 2, Transcript: 1, Tag: 4}
+
 	tests := []struct {
-		name     string
-		limit    int
-		keywords []string
-		mock     func()
-		want     []core.Comics
-		wantErr  bool
+		name    string
+		limit   int
+		query   core.Query
+		mock    func()
+		want    []core.Comics
+		wantErr bool
 	}{
 		{
-			name:     "successful search",
-			limit:    10,
-			keywords: []string{"keyword1", "keyword2"},
+			name:  "successful search",
+			limit: 10,
+			query: core.Query{Terms: []core.Term{{Word: "keyword1"}, {Word: "keyword2"}}, Boosts: boosts},
 			mock: func() {
-				rows := sqlxmock.NewRows([]string{"comic_id", "image_url", "keywords"}).
-					AddRow(1, "https://imgs.xkcd.com/comics/barrel_cropped_(1).jpg", "keywords1").
-					AddRow(2, "https://imgs.xkcd.com/comics/tree_cropped_(1).jpg", "keywords2")
-				mock.ExpectQuery(`SELECT comic_id, image_url FROM comics WHERE keywords && \$1.*LIMIT \$2`).
-					WithArgs(sqlxmock.AnyArg(), 10).
+				rows := sqlxmock.NewRows([]string{"comic_id", "source", "image_url"}).
+					AddRow(1, "xkcd", "https://imgs.xkcd.com/comics/barrel_cropped_(1).jpg").
+					AddRow(2, "xkcd", "https://imgs.xkcd.com/comics/tree_cropped_(1).jpg")
+				mock.ExpectQuery(`SELECT c.comic_id, c.source, c.image_url FROM \(.*FROM comic_terms AS t JOIN unnest\(\$1::text\[\], \$2::text\[\]\).*\) AS t JOIN comics AS c .* ORDER BY t.score DESC, c.comic_id DESC LIMIT \$7`).
+					WithArgs(pq.Array([]string{"", ""}), pq.Array([]string{"keyword1", "keyword2"}), 3.0, 2.0, 1.0, 0, 10, nil, nil, nil, nil, 0, 4.0).
 					WillReturnRows(rows)
 			},
 			want: []core.Comics{
-				{ID: 1, URL: "https://imgs.xkcd.com/comics/barrel_cropped_(1).jpg"},
-				{ID: 2, URL: "https://imgs.xkcd.com/comics/tree_cropped_(1).jpg"},
+				{ID: 1, Source: "xkcd", URL: "https://imgs.xkcd.com/comics/barrel_cropped_(1).jpg"},
+				{ID: 2, Source: "xkcd", URL: "https://imgs.xkcd.com/comics/tree_cropped_(1).jpg"},
 			},
 			wantErr: false,
 		},
 		{
-			name:     "empty",
-			limit:    10,
-			keywords: []string{"test"},
+			name:  "scoped terms are required",
+			limit: 5,
+			query: core.Query{Terms: []core.Term{{Field: core.FieldTitle, Word: "python"}, {Word: "snake"}}, Boosts: boosts},
 			mock: func() {
-				rows := sqlxmock.NewRows([]string{"comic_id", "image_url"})
-				mock.ExpectQuery(`SELECT comic_id, image_url FROM comics WHERE keywords && \$1.*LIMIT \$2`).
-					WithArgs(sqlxmock.AnyArg(), 10).
+				rows := sqlxmock.NewRows([]string{"comic_id", "source", "image_url"}).
+					AddRow(353, "xkcd", "https://imgs.xkcd.com/comics/python.png")
+				mock.ExpectQuery(`HAVING COUNT\(\*\) FILTER \(WHERE q.field <> ''\) = \$6`).
+					WithArgs(pq.Array([]string{"title", ""}), pq.Array([]string{"python", "snake"}), 3.0, 2.0, 1.0, 1, 5, nil, nil, nil, nil, 0, 4.0).
+					WillReturnRows(rows)
+			},
+			want: []core.Comics{
+				{ID: 353, Source: "xkcd", URL: "https://imgs.xkcd.com/comics/python.png"},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "all terms are required",
+			limit: 5,
+			query: core.Query{Terms: []core.Term{{Word: "python"}, {Word: "snake"}}, Boosts: boosts, Options: core.Options{All: true}},
+			mock: func() {
+				rows := sqlxmock.NewRows([]string{"comic_id", "source", "image_url"}).
+					AddRow(353, "xkcd", "https://imgs.xkcd.com/comics/python.png")
+				mock.ExpectQuery(`AND \(\$12 = 0 OR COUNT\(DISTINCT \(q.field, q.term\)\) = \$12\)`).
+					WithArgs(pq.Array([]string{"", ""}), pq.Array([]string{"python", "snake"}), 3.0, 2.0, 1.0, 0, 5, nil, nil, nil, nil, 2, 4.0).
+					WillReturnRows(rows)
+			},
+			want: []core.Comics{
+				{ID: 353, Source: "xkcd", URL: "https://imgs.xkcd.com/comics/python.png"},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "bounds before the limit, oldest first",
+			limit: 5,
+			query: core.Query{Terms: []core.Term{{Word: "python"}}, Boosts: boosts, Options: core.Options{
+				From:  time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC),
+				MaxID: 1000,
+				Sort:  core.SortOldest,
+			}},
+			mock: func() {
+				rows := sqlxmock.NewRows([]string{"comic_id", "source", "image_url"}).
+					AddRow(353, "xkcd", "https://imgs.xkcd.com/comics/python.png")
+				mock.ExpectQuery(`JOIN comics AS c USING \(source, comic_id\) WHERE \(\$8::date IS NULL OR c.published >= \$8::date\) .* `+
+					`ORDER BY c.published ASC NULLS LAST, t.score DESC, c.comic_id DESC LIMIT \$7`).
+					WithArgs(pq.Array([]string{""}), pq.Array([]string{"python"}), 3.0, 2.0, 1.0, 0, 5,
+						time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), nil, nil, int64(1000), 0, 4.0).
+					WillReturnRows(rows)
+			},
+			want: []core.Comics{
+				{ID: 353, Source: "xkcd", URL: "https://imgs.xkcd.com/comics/python.png"},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "empty",
+			limit: 10,
+			query: core.Query{Terms: []core.Term{{Word: "test"}}, Boosts: boosts},
+			mock: func() {
+				rows := sqlxmock.NewRows([]string{"comic_id", "source", "image_url"})
+				mock.ExpectQuery(`FROM comic_terms`).
 					WillReturnRows(rows)
 			},
 			want:    []core.Comics{},
 			wantErr: false,
 		},
+		{
+			name:  "db error",
+			limit: 10,
+			query: core.Query{Terms: []core.Term{{Word: "test"}}, Boosts: boosts},
+			mock: func() {
+				mock.ExpectQuery(`FROM comic_terms`).
+					WillReturnError(errors.New("db error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			got, err := storage.SearchComics(context.Background(), tt.limit, tt.keywords)
+			got, err := storage.SearchComics(context.Background(), tt.limit, tt.query)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("SearchComics error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDB_ExplainSearch(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &DB{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	python := core.Term{Word: "python"}
+	titleSnake := core.Term{Field: core.FieldTitle, Word: "snake"}
+	query := core.Query{Terms: []core.Term{python, titleSnake}, Boosts: core.Boosts{Title: 3, Alt: 2, Transcript: 1, Tag: 4}}
+	args := []driver.Value{pq.Array([]string{"", "title"}), pq.Array([]string{"python", "snake"}), 3.0, 2.0, 1.0, 1, 10, nil, nil, nil, nil, 0, 4.0}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    core.Explain
+		wantErr bool
+	}{
+		{
+			name: "plan and matches",
+			mock: func() {
+				mock.ExpectQuery(`EXPLAIN SELECT c.comic_id, c.source, c.image_url, t.score FROM \(`).
+					WithArgs(args...).
+					WillReturnRows(sqlxmock.NewRows([]string{"QUERY PLAN"}).
+						AddRow("Limit  (cost=16.52..16.53 rows=5 width=52)").
+						AddRow("  ->  Sort  (cost=16.52..16.53 rows=5 width=52)"))
+				mock.ExpectQuery(`^SELECT c.comic_id, c.source, c.image_url, t.score FROM \(`).
+					WithArgs(args...).
+					WillReturnRows(sqlxmock.NewRows([]string{"comic_id", "source", "image_url", "score"}).
+						AddRow(1, "xkcd", "url1", 8.0).
+						AddRow(2, "xkcd", "url2", 3.0))
+				mock.ExpectQuery(`SELECT t.source, t.comic_id, q.n AS term, t.field, t.tf FROM comic_terms AS t `+
+					`JOIN unnest\(\$1::text\[\], \$2::text\[\]\) WITH ORDINALITY .* ORDER BY q.n, t.field`).
+					WithArgs(pq.Array([]string{"", "title"}), pq.Array([]string{"python", "snake"}),
+						pq.Array([]string{"xkcd", "xkcd"}), pq.Array([]int{1, 2})).
+					WillReturnRows(sqlxmock.NewRows([]string{"source", "comic_id", "term", "field", "tf"}).
+						AddRow("xkcd", 1, 1, "title", 1).
+						AddRow("xkcd", 1, 1, "transcript", 2).
+						AddRow("xkcd", 1, 2, "title", 1).
+						AddRow("xkcd", 2, 2, "title", 1))
+			},
+			want: core.Explain{
+				Plan: []string{
+					"Limit  (cost=16.52..16.53 rows=5 width=52)",
+					"  ->  Sort  (cost=16.52..16.53 rows=5 width=52)",
+				},
+				Comics: []core.Comics{
+					{ID: 1, Source: "xkcd", URL: "url1", Explanation: &core.Explanation{Score: 8, Matches: []core.Match{
+						{Term: python, Field: core.FieldTitle, TF: 1, Boost: 3, Score: 3},
+						{Term: python, Field: core.FieldTranscript, TF: 2, Boost: 1, Score: 2},
+						{Term: titleSnake, Field: core.FieldTitle, TF: 1, Boost: 3, Score: 3},
+					}}},
+					{ID: 2, Source: "xkcd", URL: "url2", Explanation: &core.Explanation{Score: 3, Matches: []core.Match{
+						{Term: titleSnake, Field: core.FieldTitle, TF: 1, Boost: 3, Score: 3},
+					}}},
+				},
+			},
+		},
+		{
+			name: "explain error",
+			mock: func() {
+				mock.ExpectQuery(`EXPLAIN`).WillReturnError(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "matches error",
+			mock: func() {
+				mock.ExpectQuery(`EXPLAIN`).
+					WillReturnRows(sqlxmock.NewRows([]string{"QUERY PLAN"}).AddRow("Result"))
+				mock.ExpectQuery(`^SELECT c.comic_id`).
+					WillReturnRows(sqlxmock.NewRows([]string{"comic_id", "source", "image_url", "score"}))
+				mock.ExpectQuery(`WITH ORDINALITY`).WillReturnError(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := storage.ExplainSearch(context.Background(), 10, query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExplainSearch error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
 
+func TestDB_GetComics(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &DB{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	published := time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlxmock.NewRows([]string{"comic_id", "source", "keywords", "title_keywords", "alt_keywords", "transcript_keywords", "tag_keywords", "published"}).
+		AddRow(1, "xkcd", `["barrel","us"]`, `["barrel"]`, `["us"]`, `[]`, `["romanc"]`, published).
+		AddRow(2, "smbc", `["tree"]`, `[]`, `[]`, `[]`, `[]`, nil)
+	mock.ExpectQuery(`SELECT comic_id, source, .* AS keywords, .* AS title_keywords, .* AS alt_keywords, .* AS transcript_keywords, .* AS tag_keywords, published FROM comics`).
+		WillReturnRows(rows)
+
+	got, err := storage.GetComics(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []core.Comics{{
+		ID: 1, Source: "xkcd", Keywords: `["barrel","us"]`,
+		TitleKeywords: `["barrel"]`, AltKeywords: `["us"]`, TranscriptKeywords: `[]`, TagKeywords: `["romanc"]`,
+		Published: &published,
+	}, {
+		ID: 2, Source: "smbc", Keywords: `["tree"]`, TitleKeywords: `[]`, AltKeywords: `[]`, TranscriptKeywords: `[]`,
+		TagKeywords: `[]`,
+	}}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_RandomComics(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &DB{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	from := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+	published := time.Date(2011, 2, 14, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT comic_id, source, image_url, published FROM comics WHERE image_url <> '' .* ORDER BY random\(\) LIMIT \$1`).
+		WithArgs(2, from, nil, nil, nil).
+		WillReturnRows(sqlxmock.NewRows([]string{"comic_id", "source", "image_url", "published"}).
+			AddRow(844, "xkcd", "url844", published).
+			AddRow(162, "xkcd", "url162", published))
+
+	got, err := storage.RandomComics(context.Background(), 2, core.Options{From: from})
+	assert.NoError(t, err)
+	assert.Equal(t, []core.Comics{
+		{ID: 844, Source: "xkcd", URL: "url844", Published: &published},
+		{ID: 162, Source: "xkcd", URL: "url162", Published: &published},
+	}, got)
+
+	mock.ExpectQuery(`ORDER BY random\(\)`).WillReturnError(errors.New("db error"))
+	_, err = storage.RandomComics(context.Background(), 1, core.Options{})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_OnThisDay(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &DB{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	published := time.Date(2009, 4, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT comic_id, source, image_url, published FROM comics WHERE .* `+
+		`EXTRACT\(MONTH FROM published\) = \$1 AND EXTRACT\(DAY FROM published\) = \$2 AND EXTRACT\(YEAR FROM published\) < \$3 `+
+		`ORDER BY published DESC, comic_id DESC LIMIT \$4`).
+		WithArgs(4, 1, 2026, 10).
+		WillReturnRows(sqlxmock.NewRows([]string{"comic_id", "source", "image_url", "published"}).
+			AddRow(565, "xkcd", "url565", published))
+
+	got, err := storage.OnThisDay(context.Background(), time.April, 1, 2026, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []core.Comics{{ID: 565, Source: "xkcd", URL: "url565", Published: &published}}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestDB_GetImageURL(t *testing.T) {
 	db, mock, err := sqlxmock.Newx()
 	if err != nil {
@@ -103,8 +361,8 @@ func TestDB_GetImageURL(t *testing.T) {
 			mock: func() {
 				row := mock.NewRows([]string{"image_url"}).
 					AddRow("https://imgs.xkcd.com/comics/barrel_cropped_(1).jpg")
-				mock.ExpectQuery(`SELECT image_url FROM comics WHERE comic_id = \$1`).
-					WithArgs(1).
+				mock.ExpectQuery(`SELECT image_url FROM comics WHERE source = \$1 AND comic_id = \$2`).
+					WithArgs("xkcd", 1).
 					WillReturnRows(row)
 			},
 			want:    "https://imgs.xkcd.com/comics/barrel_cropped_(1).jpg",
@@ -114,8 +372,8 @@ func TestDB_GetImageURL(t *testing.T) {
 			name: "not found",
 			id:   3069,
 			mock: func() {
-				mock.ExpectQuery(`SELECT image_url FROM comics WHERE comic_id = \$1`).
-					WithArgs(3069).
+				mock.ExpectQuery(`SELECT image_url FROM comics WHERE source = \$1 AND comic_id = \$2`).
+					WithArgs("xkcd", 3069).
 					WillReturnError(sql.ErrNoRows)
 			},
 			want:    "",
@@ -125,8 +383,8 @@ func TestDB_GetImageURL(t *testing.T) {
 			name: "database error",
 			id:   1,
 			mock: func() {
-				mock.ExpectQuery(`SELECT image_url FROM comics WHERE comic_id = \$1`).
-					WithArgs(1).
+				mock.ExpectQuery(`SELECT image_url FROM comics WHERE source = \$1 AND comic_id = \$2`).
+					WithArgs("xkcd", 1).
 					WillReturnError(errors.New("database error"))
 			},
 			want:    "",
@@ -137,7 +395,7 @@ func TestDB_GetImageURL(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			got, err := storage.GetImageURL(context.Background(), tt.id)
+			got, err := storage.GetImageURL(context.Background(), "xkcd", tt.id)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetImageURL error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -146,3 +404,63 @@ func TestDB_GetImageURL(t *testing.T) {
 		})
 	}
 }
+
+func TestDB_GetTexts(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &DB{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	ids := []core.ComicID{{Source: "xkcd", ID: 1}, {Source: "xkcd", ID: 2}, {Source: "smbc", ID: 1}}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    map[core.ComicID]core.Text
+		wantErr bool
+	}{
+		{
+			name: "found in one query",
+			mock: func() {
+				rows := mock.NewRows([]string{"source", "comic_id", "title", "alt", "transcript"}).
+					AddRow("xkcd", 1, "Barrel - Part 1", "Don't we all.", "[[A boy sits in a barrel]]").
+					AddRow("smbc", 1, "Trees", "", "")
+				mock.ExpectQuery(`SELECT source, comic_id, title, alt, transcript FROM comics `+
+					`WHERE \(source, comic_id\) IN \(SELECT \* FROM unnest\(\$1::text\[\], \$2::int\[\]\)\)`).
+					WithArgs(pq.Array([]string{"xkcd", "xkcd", "smbc"}), pq.Array([]int{1, 2, 1})).
+					WillReturnRows(rows)
+			},
+			want: map[core.ComicID]core.Text{
+				{Source: "xkcd", ID: 1}: {Title: "Barrel - Part 1", Alt: "Don't we all.", Transcript: "[[A boy sits in a barrel]]"},
+				{Source: "smbc", ID: 1}: {Title: "Trees"},
+			},
+		},
+		{
+			name: "database error",
+			mock: func() {
+				mock.ExpectQuery(`SELECT source, comic_id, title, alt, transcript FROM comics`).
+					WillReturnError(errors.New("database error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := storage.GetTexts(context.Background(), ids)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetTexts error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,127 @@
+package fts
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"yadro.com/course/search/core"
+)
+
+// FTS searches comics with the PostgreSQL full-text search over the
+// weighted fts column: title, then alt, then transcript.
+type FTS struct {
+	log  *slog.Logger
+	conn *sqlx.DB
+}
+
+func New(log *slog.Logger, address string) (*FTS, error) {
+	db, err := sqlx.Connect("pgx", address)
+	if err != nil {
+		log.Error("connection problem", "address", address, "error", err)
+		return nil, err
+	}
+
+	return &FTS{
+		log:  log,
+		conn: db,
+	}, nil
+}
+
+func (f *FTS) Search(ctx context.Context, limit int, phrase string, opts core.Options) ([]core.Comics, error) {
+	var dbComics []core.DbComics
+	err := f.conn.SelectContext(ctx, &dbComics, matched("comic_id, source, image_url", opts.Sort),
+		matchArgs(limit, phrase, opts)...)
+	if err != nil {
+		f.log.Error("failed to do full-text query", "error", err)
+		return nil, err
+	}
+
+	comics := make([]core.Comics, len(dbComics))
+	for i, c := range dbComics {
+		comics[i] = core.Comics{
+			ID:     c.ID,
+			Source: c.Source,
+			URL:    c.URL,
+		}
+	}
+	return comics, nil
+}
+
+// Explain runs the query of Search along with its plan, the tsquery the
+// phrase was turned into and the rank of every comic found.
+func (f *FTS) Explain(ctx context.Context, limit int, phrase string, opts core.Options) (core.Explain, error) {
+	query := matched("comic_id, source, image_url, ts_rank_cd(fts, q) AS score", opts.Sort)
+	args := matchArgs(limit, phrase, opts)
+
+	var explain core.Explain
+	err := f.conn.GetContext(ctx, &explain.TSQuery, `SELECT websearch_to_tsquery('english', $1)::text`, phrase)
+	if err != nil {
+		f.log.Error("failed to parse full-text query", "error", err)
+		return core.Explain{}, err
+	}
+
+	if err := f.conn.SelectContext(ctx, &explain.Plan, "EXPLAIN "+query, args...); err != nil {
+		f.log.Error("failed to explain full-text query", "error", err)
+		return core.Explain{}, err
+	}
+
+	var scored []struct {
+		core.DbComics
+		Score float64 `db:"score"`
+	}
+	if err := f.conn.SelectContext(ctx, &scored, query, args...); err != nil {
+		f.log.Error("failed to do full-text query", "error", err)
+		return core.Explain{}, err
+	}
+
+	explain.Comics = make([]core.Comics, len(scored))
+	for i, c := range scored {
+		explain.Comics[i] = core.Comics{
+			ID:          c.ID,
+			Source:      c.Source,
+			URL:         c.URL,
+			Explanation: &core.Explanation{Score: c.Score},
+		}
+	}
+	return explain, nil
+}
+
+// matched is the query of Search selecting the given columns of the
+// matching comics.
+func matched(columns string, sort core.Sort) string {
+	return `
+	SELECT ` + columns + `
+	FROM comics, websearch_to_tsquery('english', $1) AS q
+	WHERE fts @@ q
+		AND ($3::date IS NULL OR published >= $3::date)
+		AND ($4::date IS NULL OR published <= $4::date)
+		AND ($5::int IS NULL OR comic_id >= $5::int)
+		AND ($6::int IS NULL OR comic_id <= $6::int)
+	ORDER BY ` + orderBy(sort) + `
+	LIMIT $2
+	`
+}
+
+// matchArgs are the arguments of the matched query, NULL for open bounds.
+func matchArgs(limit int, phrase string, opts core.Options) []any {
+	return []any{phrase, limit,
+		sql.NullTime{Time: opts.From, Valid: !opts.From.IsZero()},
+		sql.NullTime{Time: opts.To, Valid: !opts.To.IsZero()},
+		sql.NullInt64{Int64: int64(opts.MinID), Valid: opts.MinID != 0},
+		sql.NullInt64{Int64: int64(opts.MaxID), Valid: opts.MaxID != 0},
+	}
+}
+
+// orderBy sorts the matched comics, undated ones last in either date order.
+func orderBy(sort core.Sort) string {
+	switch sort {
+	case core.SortNewest:
+		return "published DESC NULLS LAST, ts_rank_cd(fts, q) DESC, comic_id DESC"
+	case core.SortOldest:
+		return "published ASC NULLS LAST, ts_rank_cd(fts, q) DESC, comic_id DESC"
+	}
+	return "ts_rank_cd(fts, q) DESC, comic_id DESC"
+}
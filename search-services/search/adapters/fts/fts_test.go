@@ -0,0 +1,180 @@
+package fts
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+	"yadro.com/course/search/core"
+)
+
+func TestFTS_Search(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &FTS{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	tests := []struct {
+		name    string
+		phrase  string
+		limit   int
+		opts    core.Options
+		mock    func()
+		want    []core.Comics
+		wantErr bool
+	}{
+		{
+			name:   "successful search",
+			phrase: `"hidden treasure" -map`,
+			limit:  10,
+			mock: func() {
+				rows := sqlxmock.NewRows([]string{"comic_id", "source", "image_url"}).
+					AddRow(2, "xkcd", "url2").
+					AddRow(1, "xkcd", "url1")
+				mock.ExpectQuery(`FROM comics, websearch_to_tsquery\('english', \$1\) AS q WHERE fts @@ q .* ORDER BY ts_rank_cd\(fts, q\) DESC.*LIMIT \$2`).
+					WithArgs(`"hidden treasure" -map`, 10, nil, nil, nil, nil).
+					WillReturnRows(rows)
+			},
+			want: []core.Comics{
+				{ID: 2, Source: "xkcd", URL: "url2"},
+				{ID: 1, Source: "xkcd", URL: "url1"},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "bounds and newest first",
+			phrase: "python",
+			limit:  5,
+			opts: core.Options{
+				From:  time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:    time.Date(2012, 12, 31, 0, 0, 0, 0, time.UTC),
+				MinID: 100,
+				Sort:  core.SortNewest,
+			},
+			mock: func() {
+				rows := sqlxmock.NewRows([]string{"comic_id", "source", "image_url"}).
+					AddRow(1000, "xkcd", "url1000")
+				mock.ExpectQuery(`published >= \$3::date.* comic_id <= \$6::int\) ORDER BY published DESC NULLS LAST, ts_rank_cd\(fts, q\) DESC`).
+					WithArgs("python", 5, time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC),
+						time.Date(2012, 12, 31, 0, 0, 0, 0, time.UTC), int64(100), nil).
+					WillReturnRows(rows)
+			},
+			want: []core.Comics{
+				{ID: 1000, Source: "xkcd", URL: "url1000"},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "empty",
+			phrase: "nothing",
+			limit:  10,
+			mock: func() {
+				rows := sqlxmock.NewRows([]string{"comic_id", "source", "image_url"})
+				mock.ExpectQuery(`websearch_to_tsquery`).
+					WithArgs("nothing", 10, nil, nil, nil, nil).
+					WillReturnRows(rows)
+			},
+			want:    []core.Comics{},
+			wantErr: false,
+		},
+		{
+			name:   "db error",
+			phrase: "fail",
+			limit:  10,
+			mock: func() {
+				mock.ExpectQuery(`websearch_to_tsquery`).
+					WillReturnError(errors.New("db error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := storage.Search(context.Background(), tt.limit, tt.phrase, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Search error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestFTS_Explain(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &FTS{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    core.Explain
+		wantErr bool
+	}{
+		{
+			name: "plan and ranks",
+			mock: func() {
+				mock.ExpectQuery(`SELECT websearch_to_tsquery\('english', \$1\)::text`).
+					WithArgs("hidden treasures").
+					WillReturnRows(sqlxmock.NewRows([]string{"websearch_to_tsquery"}).AddRow("'hidden' & 'treasur'"))
+				mock.ExpectQuery(`EXPLAIN SELECT comic_id, source, image_url, ts_rank_cd\(fts, q\) AS score FROM comics`).
+					WithArgs("hidden treasures", 10, nil, nil, nil, nil).
+					WillReturnRows(sqlxmock.NewRows([]string{"QUERY PLAN"}).
+						AddRow("Limit  (cost=8.02..8.03 rows=1 width=44)"))
+				mock.ExpectQuery(`^SELECT comic_id, source, image_url, ts_rank_cd\(fts, q\) AS score FROM comics`).
+					WithArgs("hidden treasures", 10, nil, nil, nil, nil).
+					WillReturnRows(sqlxmock.NewRows([]string{"comic_id", "source", "image_url", "score"}).
+						AddRow(2, "xkcd", "url2", 0.2))
+			},
+			want: core.Explain{
+				TSQuery: "'hidden' & 'treasur'",
+				Plan:    []string{"Limit  (cost=8.02..8.03 rows=1 width=44)"},
+				Comics: []core.Comics{
+					{ID: 2, Source: "xkcd", URL: "url2", Explanation: &core.Explanation{Score: 0.2}},
+				},
+			},
+		},
+		{
+			name: "db error",
+			mock: func() {
+				mock.ExpectQuery(`websearch_to_tsquery`).
+					WillReturnError(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := storage.Explain(context.Background(), 10, "hidden treasures", core.Options{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Explain error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -2,8 +2,13 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	searchpb "yadro.com/course/proto/search"
 	"yadro.com/course/search/core"
 )
@@ -22,7 +27,77 @@ func (s *Server) Ping(_ context.Context, _ *emptypb.Empty) (*emptypb.Empty, erro
 }
 
 func (s *Server) Search(ctx context.Context, in *searchpb.SearchRequest) (*searchpb.SearchReply, error) {
-	comics, err := s.service.Search(ctx, int(in.Limit), in.Phrase)
+	opts, err := options(in)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if in.GetExplain() {
+		return s.explain(ctx, core.EngineDB, in, opts)
+	}
+
+	found, err := s.service.Search(ctx, int(in.Limit), in.Phrase, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	searchReply := &searchpb.SearchReply{
+		Comics:     make([]*searchpb.Comics, 0, len(found.Comics)),
+		Total:      int64(len(found.Comics)),
+		Relaxation: relaxation(found.Relaxation),
+	}
+
+	for _, comic := range found.Comics {
+		searchReply.Comics = append(searchReply.Comics, &searchpb.Comics{
+			Id:      int64(comic.ID),
+			Url:     comic.URL,
+			Source:  comic.Source,
+			Snippet: snippet(comic.Snippet),
+		})
+	}
+	return searchReply, nil
+}
+
+func (s *Server) IndexSearch(ctx context.Context, in *searchpb.SearchRequest) (*searchpb.SearchReply, error) {
+	opts, err := options(in)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if in.GetExplain() {
+		return s.explain(ctx, core.EngineIndex, in, opts)
+	}
+
+	found, err := s.service.IndexSearch(ctx, int(in.Limit), in.Phrase, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	searchReply := &searchpb.SearchReply{
+		Comics:     make([]*searchpb.Comics, 0, len(found.Comics)),
+		Total:      int64(len(found.Comics)),
+		Relaxation: relaxation(found.Relaxation),
+	}
+
+	for _, comic := range found.Comics {
+		searchReply.Comics = append(searchReply.Comics, &searchpb.Comics{
+			Id:      int64(comic.ID),
+			Url:     comic.URL,
+			Source:  comic.Source,
+			Snippet: snippet(comic.Snippet),
+		})
+	}
+	return searchReply, nil
+}
+
+func (s *Server) FTSSearch(ctx context.Context, in *searchpb.SearchRequest) (*searchpb.SearchReply, error) {
+	opts, err := options(in)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if in.GetExplain() {
+		return s.explain(ctx, core.EngineFTS, in, opts)
+	}
+
+	comics, err := s.service.FTSSearch(ctx, int(in.Limit), in.Phrase, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -34,16 +109,94 @@ func (s *Server) Search(ctx context.Context, in *searchpb.SearchRequest) (*searc
 
 	for _, comic := range comics {
 		searchReply.Comics = append(searchReply.Comics, &searchpb.Comics{
-			Id:  int64(comic.ID),
-			Url: comic.URL,
+			Id:      int64(comic.ID),
+			Url:     comic.URL,
+			Source:  comic.Source,
+			Snippet: snippet(comic.Snippet),
 		})
 	}
 	return searchReply, nil
 }
 
-func (s *Server) IndexSearch(ctx context.Context, in *searchpb.SearchRequest) (*searchpb.SearchReply, error) {
-	comics, err := s.service.IndexSearch(ctx, int(in.Limit), in.Phrase)
+// HybridSearch cannot be explained, the engines fused score differently.
+func (s *Server) HybridSearch(ctx context.Context, in *searchpb.SearchRequest) (*searchpb.SearchReply, error) {
+	opts, err := options(in)
 	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if in.GetExplain() {
+		return nil, status.Error(codes.InvalidArgument, "hybrid search cannot be explained")
+	}
+
+	hybrid, err := s.service.HybridSearch(ctx, int(in.Limit), in.Phrase, opts)
+	if err != nil {
+		if errors.Is(err, core.ErrBadArguments) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, err
+	}
+
+	searchReply := &searchpb.SearchReply{
+		Comics:   make([]*searchpb.Comics, 0, len(hybrid.Comics)),
+		Total:    int64(len(hybrid.Comics)),
+		Degraded: make([]string, 0, len(hybrid.Degraded)),
+	}
+
+	for _, comic := range hybrid.Comics {
+		searchReply.Comics = append(searchReply.Comics, &searchpb.Comics{
+			Id:      int64(comic.ID),
+			Url:     comic.URL,
+			Source:  comic.Source,
+			Snippet: snippet(comic.Snippet),
+		})
+	}
+	for _, engine := range hybrid.Degraded {
+		searchReply.Degraded = append(searchReply.Degraded, string(engine))
+	}
+	return searchReply, nil
+}
+
+// SemanticSearch cannot be explained, the similarity has no terms to break
+// down into.
+func (s *Server) SemanticSearch(ctx context.Context, in *searchpb.SearchRequest) (*searchpb.SearchReply, error) {
+	opts, err := options(in)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if in.GetExplain() {
+		return nil, status.Error(codes.InvalidArgument, "semantic search cannot be explained")
+	}
+
+	comics, err := s.service.SemanticSearch(ctx, int(in.Limit), in.Phrase, opts)
+	if err != nil {
+		if errors.Is(err, core.ErrBadArguments) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, err
+	}
+
+	searchReply := &searchpb.SearchReply{
+		Comics: make([]*searchpb.Comics, 0, len(comics)),
+		Total:  int64(len(comics)),
+	}
+
+	for _, comic := range comics {
+		searchReply.Comics = append(searchReply.Comics, &searchpb.Comics{
+			Id:      int64(comic.ID),
+			Url:     comic.URL,
+			Source:  comic.Source,
+			Snippet: snippet(comic.Snippet),
+		})
+	}
+	return searchReply, nil
+}
+
+func (s *Server) Similar(ctx context.Context, in *searchpb.SimilarRequest) (*searchpb.SearchReply, error) {
+	comics, err := s.service.Similar(ctx, in.GetSource(), int(in.GetId()), int(in.GetLimit()))
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
 		return nil, err
 	}
 
@@ -54,9 +207,313 @@ func (s *Server) IndexSearch(ctx context.Context, in *searchpb.SearchRequest) (*
 
 	for _, comic := range comics {
 		searchReply.Comics = append(searchReply.Comics, &searchpb.Comics{
-			Id:  int64(comic.ID),
-			Url: comic.URL,
+			Id:     int64(comic.ID),
+			Url:    comic.URL,
+			Source: comic.Source,
 		})
 	}
 	return searchReply, nil
 }
+
+func (s *Server) Random(ctx context.Context, in *searchpb.RandomRequest) (*searchpb.Comics, error) {
+	opts := core.Options{Client: in.GetClient()}
+	if in.GetFrom() != nil {
+		opts.From = in.GetFrom().AsTime()
+	}
+	if in.GetTo() != nil {
+		opts.To = in.GetTo().AsTime()
+	}
+
+	comic, err := s.service.Random(ctx, in.GetPhrase(), opts)
+	if err != nil {
+		return nil, curationError(err)
+	}
+	return dated(comic), nil
+}
+
+func (s *Server) OnThisDay(ctx context.Context, in *searchpb.OnThisDayRequest) (*searchpb.SearchReply, error) {
+	date := time.Now()
+	if in.GetDate() != nil {
+		date = in.GetDate().AsTime()
+	}
+
+	comics, err := s.service.OnThisDay(ctx, date, int(in.GetLimit()))
+	if err != nil {
+		return nil, curationError(err)
+	}
+
+	searchReply := &searchpb.SearchReply{
+		Comics: make([]*searchpb.Comics, 0, len(comics)),
+		Total:  int64(len(comics)),
+	}
+	for _, comic := range comics {
+		searchReply.Comics = append(searchReply.Comics, dated(comic))
+	}
+	return searchReply, nil
+}
+
+// dated is the comic with its publication date, if it has one.
+func dated(comic core.Comics) *searchpb.Comics {
+	out := &searchpb.Comics{
+		Id:      int64(comic.ID),
+		Url:     comic.URL,
+		Source:  comic.Source,
+		Snippet: snippet(comic.Snippet),
+	}
+	if comic.Published != nil {
+		out.Published = timestamppb.New(*comic.Published)
+	}
+	return out
+}
+
+func (s *Server) Analytics(ctx context.Context, in *searchpb.AnalyticsRequest) (*searchpb.AnalyticsReply, error) {
+	analytics, err := s.service.Analytics(ctx, in.GetSince().AsTime(), int(in.GetLimit()))
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &searchpb.AnalyticsReply{
+		Top:         queryCounts(analytics.Top),
+		ZeroResults: queryCounts(analytics.ZeroResults),
+		Latency:     make([]*searchpb.LatencyStats, 0, len(analytics.Latency)),
+	}
+	for _, l := range analytics.Latency {
+		reply.Latency = append(reply.Latency, &searchpb.LatencyStats{
+			Mode:     string(l.Mode),
+			Searches: int64(l.Searches),
+			P50Ms:    milliseconds(l.P50),
+			P90Ms:    milliseconds(l.P90),
+			P99Ms:    milliseconds(l.P99),
+		})
+	}
+	return reply, nil
+}
+
+func (s *Server) CacheStats(ctx context.Context, _ *emptypb.Empty) (*searchpb.CacheStatsReply, error) {
+	stats, err := s.service.CacheStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &searchpb.CacheStatsReply{
+		Hits:      int64(stats.Hits),
+		Misses:    int64(stats.Misses),
+		Evictions: int64(stats.Evictions),
+		Purges:    int64(stats.Purges),
+		Size:      int64(stats.Size),
+		Capacity:  int64(stats.Capacity),
+	}, nil
+}
+
+func queryCounts(counts []core.QueryCount) []*searchpb.QueryCount {
+	out := make([]*searchpb.QueryCount, 0, len(counts))
+	for _, c := range counts {
+		out = append(out, &searchpb.QueryCount{Query: c.Query, Searches: int64(c.Searches), Clients: int64(c.Clients)})
+	}
+	return out
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func (s *Server) explain(ctx context.Context, engine core.Engine, in *searchpb.SearchRequest, opts core.Options) (*searchpb.SearchReply, error) {
+	explain, err := s.service.Explain(ctx, engine, int(in.GetLimit()), in.GetPhrase(), opts)
+	if err != nil {
+		if errors.Is(err, core.ErrBadArguments) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, err
+	}
+
+	searchReply := &searchpb.SearchReply{
+		Comics: make([]*searchpb.Comics, 0, len(explain.Comics)),
+		Total:  int64(len(explain.Comics)),
+		Explain: &searchpb.Explain{
+			Terms:   make([]*searchpb.Term, 0, len(explain.Terms)),
+			Tsquery: explain.TSQuery,
+			Plan:    explain.Plan,
+			Formula: explain.Formula,
+		},
+	}
+	for _, term := range explain.Terms {
+		searchReply.Explain.Terms = append(searchReply.Explain.Terms, &searchpb.Term{Field: term.Field, Word: term.Word})
+	}
+
+	for _, comic := range explain.Comics {
+		searchReply.Comics = append(searchReply.Comics, &searchpb.Comics{
+			Id:          int64(comic.ID),
+			Url:         comic.URL,
+			Source:      comic.Source,
+			Snippet:     snippet(comic.Snippet),
+			Explanation: explanation(comic.Explanation),
+		})
+	}
+	return searchReply, nil
+}
+
+func options(in *searchpb.SearchRequest) (core.Options, error) {
+	sort, err := core.ParseSort(in.GetSort())
+	if err != nil {
+		return core.Options{}, err
+	}
+
+	opts := core.Options{
+		MinID:   int(in.GetMinId()),
+		MaxID:   int(in.GetMaxId()),
+		Sort:    sort,
+		All:     in.GetAll(),
+		Client:  in.GetClient(),
+		NoCache: in.GetNoCache(),
+	}
+	if in.GetFrom() != nil {
+		opts.From = in.GetFrom().AsTime()
+	}
+	if in.GetTo() != nil {
+		opts.To = in.GetTo().AsTime()
+	}
+	return opts, nil
+}
+
+func snippet(in core.Snippet) *searchpb.Snippet {
+	if in.Field == "" {
+		return nil
+	}
+
+	out := &searchpb.Snippet{
+		Field:      in.Field,
+		Text:       in.Text,
+		Highlights: make([]*searchpb.Highlight, 0, len(in.Highlights)),
+	}
+	for _, h := range in.Highlights {
+		out.Highlights = append(out.Highlights, &searchpb.Highlight{Start: int64(h.Start), End: int64(h.End)})
+	}
+	return out
+}
+
+func relaxation(in *core.Relaxation) *searchpb.Relaxation {
+	if in == nil {
+		return nil
+	}
+
+	out := &searchpb.Relaxation{
+		Relax: string(in.Relax),
+		Terms: make([]*searchpb.Term, 0, len(in.Terms)),
+	}
+	for _, term := range in.Terms {
+		out.Terms = append(out.Terms, &searchpb.Term{Field: term.Field, Word: term.Word})
+	}
+	return out
+}
+
+func explanation(in *core.Explanation) *searchpb.Explanation {
+	if in == nil {
+		return nil
+	}
+
+	out := &searchpb.Explanation{
+		Score:   in.Score,
+		Matches: make([]*searchpb.Match, 0, len(in.Matches)),
+	}
+	for _, m := range in.Matches {
+		out.Matches = append(out.Matches, &searchpb.Match{
+			Term:  &searchpb.Term{Field: m.Term.Field, Word: m.Term.Word},
+			Field: m.Field,
+			Tf:    int64(m.TF),
+			Boost: m.Boost,
+			Score: m.Score,
+		})
+	}
+	return out
+}
+
+func (s *Server) GetCuration(ctx context.Context, _ *emptypb.Empty) (*searchpb.Curation, error) {
+	curation, err := s.service.Curation(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &searchpb.Curation{
+		Pins:     make([]*searchpb.Pin, 0, len(curation.Pins)),
+		Rewrites: make([]*searchpb.Rewrite, 0, len(curation.Rewrites)),
+		Blocked:  comicRefs(curation.Blocked),
+	}
+	for _, pin := range curation.Pins {
+		reply.Pins = append(reply.Pins, &searchpb.Pin{Query: pin.Query, Comics: comicRefs(pin.Comics)})
+	}
+	for _, rewrite := range curation.Rewrites {
+		reply.Rewrites = append(reply.Rewrites, &searchpb.Rewrite{Query: rewrite.Query, Phrase: rewrite.Phrase})
+	}
+	return reply, nil
+}
+
+func (s *Server) PutPin(ctx context.Context, in *searchpb.Pin) (*searchpb.Pin, error) {
+	comics := make([]core.ComicID, 0, len(in.GetComics()))
+	for _, ref := range in.GetComics() {
+		comics = append(comics, comicID(ref))
+	}
+	pin, err := s.service.PutPin(ctx, in.GetQuery(), comics)
+	if err != nil {
+		return nil, curationError(err)
+	}
+	return &searchpb.Pin{Query: pin.Query, Comics: comicRefs(pin.Comics)}, nil
+}
+
+func (s *Server) DeletePin(ctx context.Context, in *searchpb.QueryRequest) (*emptypb.Empty, error) {
+	if err := s.service.DeletePin(ctx, in.GetQuery()); err != nil {
+		return nil, curationError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) PutRewrite(ctx context.Context, in *searchpb.Rewrite) (*searchpb.Rewrite, error) {
+	rewrite, err := s.service.PutRewrite(ctx, in.GetQuery(), in.GetPhrase())
+	if err != nil {
+		return nil, curationError(err)
+	}
+	return &searchpb.Rewrite{Query: rewrite.Query, Phrase: rewrite.Phrase}, nil
+}
+
+func (s *Server) DeleteRewrite(ctx context.Context, in *searchpb.QueryRequest) (*emptypb.Empty, error) {
+	if err := s.service.DeleteRewrite(ctx, in.GetQuery()); err != nil {
+		return nil, curationError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) Block(ctx context.Context, in *searchpb.ComicRef) (*emptypb.Empty, error) {
+	if err := s.service.Block(ctx, comicID(in)); err != nil {
+		return nil, curationError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) Unblock(ctx context.Context, in *searchpb.ComicRef) (*emptypb.Empty, error) {
+	if err := s.service.Unblock(ctx, comicID(in)); err != nil {
+		return nil, curationError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// curationError maps the errors of the curation edits, and of the random
+// draws, to the statuses.
+func curationError(err error) error {
+	switch {
+	case errors.Is(err, core.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, core.ErrBadArguments):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return err
+}
+
+func comicID(in *searchpb.ComicRef) core.ComicID {
+	return core.ComicID{Source: in.GetSource(), ID: int(in.GetId())}
+}
+
+func comicRefs(ids []core.ComicID) []*searchpb.ComicRef {
+	out := make([]*searchpb.ComicRef, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, &searchpb.ComicRef{Id: int64(id.ID), Source: id.Source})
+	}
+	return out
+}
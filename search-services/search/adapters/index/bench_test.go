@@ -0,0 +1,203 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"runtime"
+	"sort"
+	"sync"
+	"testing"
+
+	"yadro.com/course/search/core"
+)
+
+const (
+	benchDocs  = 100_000
+	benchWords = 50_000
+)
+
+var (
+	benchOnce   sync.Once
+	benchComics []core.Comics
+)
+
+// syntheticComics makes a corpus of benchDocs comics, the same on every
+// run, with the words of their fields drawn from a Zipf distribution as in
+// a natural language.
+func syntheticComics() []core.Comics {
+	benchOnce.Do(func() {
+		r := rand.New(rand.NewPCG(1, 2))
+		zipf := rand.NewZipf(r, 1.1, 2, benchWords-1)
+		words := func(n int) string {
+			keywords := make([]string, n)
+			for i := range keywords {
+				keywords[i] = fmt.Sprintf("w%d", zipf.Uint64())
+			}
+			raw, _ := json.Marshal(keywords)
+			return string(raw)
+		}
+
+		benchComics = make([]core.Comics, benchDocs)
+		for i := range benchComics {
+			benchComics[i] = core.Comics{
+				ID:                 i + 1,
+				Source:             "xkcd",
+				TitleKeywords:      words(2 + r.IntN(3)),
+				AltKeywords:        words(5 + r.IntN(10)),
+				TranscriptKeywords: words(20 + r.IntN(80)),
+			}
+		}
+	})
+	return benchComics
+}
+
+// benchQueries mix frequent and rare words, the frequent ones matching most
+// of the corpus.
+var benchQueries = map[string]core.Query{
+	"frequent": {Terms: []core.Term{{Word: "w0"}, {Word: "w1"}, {Word: "w2"}}},
+	"mixed":    {Terms: []core.Term{{Word: "w3"}, {Word: "w100"}, {Word: "w5000"}}},
+	"rare":     {Terms: []core.Term{{Word: "w20000"}, {Word: "w30000"}}},
+	"scoped":   {Terms: []core.Term{{Field: core.FieldTitle, Word: "w1"}, {Word: "w10"}}},
+}
+
+// sliceIndex is the index as it was before the posting lists: a doc is
+// repeated in the list of a word once per occurrence, the docs found are
+// scored in maps and all of them are sorted to take the top.
+type sliceIndex struct {
+	storage map[string]map[string][]int
+	docs    []core.Comics
+}
+
+func newSliceIndex(comics []core.Comics) *sliceIndex {
+	index := &sliceIndex{storage: make(map[string]map[string][]int)}
+	for _, comic := range comics {
+		fields, err := fieldKeywords(comic)
+		if err != nil {
+			continue
+		}
+		doc := len(index.docs)
+		index.docs = append(index.docs, core.Comics{ID: comic.ID, Source: comic.Source, Published: comic.Published})
+		for field, keywords := range fields {
+			if index.storage[field] == nil {
+				index.storage[field] = make(map[string][]int)
+			}
+			for _, word := range keywords {
+				index.storage[field][word] = append(index.storage[field][word], doc)
+			}
+		}
+	}
+	return index
+}
+
+func (index *sliceIndex) rate(query core.Query, limit int) []comicRate {
+	scores := make(map[int]float64)
+	scoped := make(map[int]int)
+	count := func(term core.Term, field string, postings []int) {
+		for _, doc := range postings {
+			scores[doc] += query.Boosts.Of(field)
+			if term.Field != "" {
+				scoped[doc]++
+			}
+		}
+	}
+	for _, term := range query.Terms {
+		if term.Field != "" {
+			count(term, term.Field, index.storage[term.Field][term.Word])
+			continue
+		}
+		for _, field := range fieldOrder {
+			count(term, field, index.storage[field][term.Word])
+		}
+	}
+
+	required := query.Scoped()
+	var rated []comicRate
+	for doc, score := range scores {
+		if scoped[doc] != required || !query.Options.Match(index.docs[doc].ID, index.docs[doc].Published) {
+			continue
+		}
+		rated = append(rated, comicRate{comic: index.docs[doc], score: score})
+	}
+
+	less := better(query.Options.Sort)
+	sort.Slice(rated, func(i, j int) bool { return less(rated[i], rated[j]) })
+	return rated[:min(limit, len(rated))]
+}
+
+// BenchmarkRate ranks the synthetic corpus with the posting lists and the
+// top-k heap, in one shard and in four searched in parallel, against the
+// slices sorted in full. The images are left out as they come from the
+// database.
+func BenchmarkRate(b *testing.B) {
+	comics := syntheticComics()
+	indexes := make(map[string]*Index)
+	for _, shards := range []int{1, 4} {
+		index := &Index{log: slog.Default(), shards: newShards(shards)}
+		if err := index.BuildIndex(comics); err != nil {
+			b.Fatal(err)
+		}
+		indexes[fmt.Sprintf("postings-%d", shards)] = index
+	}
+	slices := newSliceIndex(comics)
+	boosts := core.Boosts{Title: 3, Alt: 2, Transcript: 1}
+
+	for name, query := range benchQueries {
+		query.Boosts = boosts
+		for _, kind := range []string{"postings-1", "postings-4"} {
+			b.Run(name+"/"+kind, func(b *testing.B) {
+				b.ReportAllocs()
+				for range b.N {
+					indexes[kind].rate(query, 10, false)
+				}
+			})
+		}
+		b.Run(name+"/slices", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				slices.rate(query, 10)
+			}
+		})
+	}
+}
+
+// BenchmarkStorage reports the heap taken by the storage of the synthetic
+// corpus as posting lists and as slices.
+func BenchmarkStorage(b *testing.B) {
+	comics := syntheticComics()
+
+	b.Run("postings", func(b *testing.B) {
+		for range b.N {
+			var storage map[string]map[string]postings
+			b.ReportMetric(float64(heapGrowth(func() {
+				storage, _ = buildPostings(slog.Default(), comics)
+			})), "heap-bytes")
+			runtime.KeepAlive(storage)
+		}
+	})
+	b.Run("slices", func(b *testing.B) {
+		for range b.N {
+			var storage map[string]map[string][]int
+			b.ReportMetric(float64(heapGrowth(func() {
+				storage = newSliceIndex(comics).storage
+			})), "heap-bytes")
+			runtime.KeepAlive(storage)
+		}
+	})
+}
+
+// heapGrowth tells how much the heap in use grew after build, the garbage
+// it left collected.
+func heapGrowth(build func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	build()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	if after.HeapAlloc < before.HeapAlloc {
+		return 0
+	}
+	return after.HeapAlloc - before.HeapAlloc
+}
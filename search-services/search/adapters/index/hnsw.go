@@ -0,0 +1,186 @@
+package index
+
+import (
+	"container/heap"
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+// hnsw is a hierarchical navigable small world graph of unit vectors, an
+// approximate nearest neighbour structure (Malkov and Yashunin): every
+// vector is linked to its nearest ones on its level and all the levels
+// below, the upper levels being ever sparser, so a search descends greedily
+// from the top and widens its walk on the bottom level only.
+type hnsw struct {
+	// m is the number of links of a node on a level, twice that on the
+	// bottom one.
+	m              int
+	efConstruction int
+	levelFactor    float64
+	rand           *rand.Rand
+
+	vectors [][]float32
+	// links holds the neighbours of every node on every level it is on.
+	links    [][][]int
+	entry    int
+	topLevel int
+}
+
+// neighbor is a node with its similarity to the vector searched.
+type neighbor struct {
+	node int
+	sim  float32
+}
+
+func newHNSW(m, efConstruction int, r *rand.Rand) *hnsw {
+	return &hnsw{
+		m:              m,
+		efConstruction: efConstruction,
+		levelFactor:    1 / math.Log(float64(m)),
+		rand:           r,
+		entry:          -1,
+	}
+}
+
+func dot(a, b []float32) float32 {
+	var s float32
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+func (h *hnsw) maxLinks(level int) int {
+	if level == 0 {
+		return 2 * h.m
+	}
+	return h.m
+}
+
+// add inserts the vector as the next node.
+func (h *hnsw) add(vector []float32) {
+	node := len(h.vectors)
+	level := int(-math.Log(1-h.rand.Float64()) * h.levelFactor)
+	h.vectors = append(h.vectors, vector)
+	h.links = append(h.links, make([][]int, level+1))
+
+	if h.entry == -1 {
+		h.entry, h.topLevel = node, level
+		return
+	}
+
+	entries := []neighbor{{h.entry, dot(vector, h.vectors[h.entry])}}
+	for l := h.topLevel; l > level; l-- {
+		entries = h.searchLevel(vector, entries, 1, l)
+	}
+	for l := min(level, h.topLevel); l >= 0; l-- {
+		found := h.searchLevel(vector, entries, h.efConstruction, l)
+		nearest := found[:min(h.m, len(found))]
+		h.links[node][l] = make([]int, 0, len(nearest))
+		for _, n := range nearest {
+			h.links[node][l] = append(h.links[node][l], n.node)
+			h.link(n.node, node, l)
+		}
+		entries = found
+	}
+
+	if level > h.topLevel {
+		h.entry, h.topLevel = node, level
+	}
+}
+
+// link adds the link from node to other on the level, dropping the
+// farthest one if the node has too many.
+func (h *hnsw) link(node, other, level int) {
+	links := append(h.links[node][level], other)
+	if len(links) > h.maxLinks(level) {
+		vector := h.vectors[node]
+		sort.Slice(links, func(i, j int) bool {
+			return dot(vector, h.vectors[links[i]]) > dot(vector, h.vectors[links[j]])
+		})
+		links = links[:h.maxLinks(level)]
+	}
+	h.links[node][level] = links
+}
+
+// search returns up to k nodes nearest to the vector, the nearest first,
+// walking ef nodes at most on the bottom level.
+func (h *hnsw) search(vector []float32, k, ef int) []neighbor {
+	if h.entry == -1 || k <= 0 {
+		return nil
+	}
+
+	entries := []neighbor{{h.entry, dot(vector, h.vectors[h.entry])}}
+	for l := h.topLevel; l > 0; l-- {
+		entries = h.searchLevel(vector, entries, 1, l)
+	}
+	found := h.searchLevel(vector, entries, max(ef, k), 0)
+	return found[:min(k, len(found))]
+}
+
+// searchLevel walks the level from the entries to the ef nodes nearest to
+// the vector it finds, returned the nearest first.
+func (h *hnsw) searchLevel(vector []float32, entries []neighbor, ef, level int) []neighbor {
+	visited := make(map[int]struct{}, ef*4)
+	candidates := &neighborHeap{nearest: true}
+	found := &neighborHeap{}
+	for _, e := range entries {
+		visited[e.node] = struct{}{}
+		heap.Push(candidates, e)
+		heap.Push(found, e)
+		if found.Len() > ef {
+			heap.Pop(found)
+		}
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(neighbor)
+		if found.Len() >= ef && c.sim < found.items[0].sim {
+			break
+		}
+		for _, n := range h.links[c.node][level] {
+			if _, ok := visited[n]; ok {
+				continue
+			}
+			visited[n] = struct{}{}
+
+			sim := dot(vector, h.vectors[n])
+			if found.Len() < ef || sim > found.items[0].sim {
+				heap.Push(candidates, neighbor{n, sim})
+				heap.Push(found, neighbor{n, sim})
+				if found.Len() > ef {
+					heap.Pop(found)
+				}
+			}
+		}
+	}
+
+	nearest := make([]neighbor, found.Len())
+	for i := len(nearest) - 1; i >= 0; i-- {
+		nearest[i] = heap.Pop(found).(neighbor)
+	}
+	return nearest
+}
+
+// neighborHeap keeps the nearest neighbor at the root if nearest is set,
+// the farthest one otherwise.
+type neighborHeap struct {
+	nearest bool
+	items   []neighbor
+}
+
+func (h *neighborHeap) Len() int { return len(h.items) }
+func (h *neighborHeap) Less(i, j int) bool {
+	if h.nearest {
+		return h.items[i].sim > h.items[j].sim
+	}
+	return h.items[i].sim < h.items[j].sim
+}
+func (h *neighborHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *neighborHeap) Push(x any)    { h.items = append(h.items, x.(neighbor)) }
+func (h *neighborHeap) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
@@ -0,0 +1,79 @@
+package index
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func randomUnit(r *rand.Rand, dimensions int) []float32 {
+	v := make([]float32, dimensions)
+	var norm float64
+	for i := range v {
+		v[i] = float32(r.NormFloat64())
+		norm += float64(v[i] * v[i])
+	}
+	for i := range v {
+		v[i] /= float32(math.Sqrt(norm))
+	}
+	return v
+}
+
+func TestHNSW_Recall(t *testing.T) {
+	const (
+		nodes      = 2000
+		dimensions = 16
+		queries    = 50
+		k          = 10
+	)
+	r := rand.New(rand.NewPCG(3, 4))
+	graph := newHNSW(hnswLinks, hnswEF, r)
+	for range nodes {
+		graph.add(randomUnit(r, dimensions))
+	}
+
+	var hits int
+	for range queries {
+		query := randomUnit(r, dimensions)
+
+		exact := make([]neighbor, nodes)
+		for n, v := range graph.vectors {
+			exact[n] = neighbor{n, dot(query, v)}
+		}
+		sort.Slice(exact, func(i, j int) bool { return exact[i].sim > exact[j].sim })
+		want := make(map[int]bool, k)
+		for _, n := range exact[:k] {
+			want[n.node] = true
+		}
+
+		found := graph.search(query, k, hnswEF)
+		assert.Len(t, found, k)
+		for i, n := range found {
+			if want[n.node] {
+				hits++
+			}
+			if i > 0 {
+				assert.GreaterOrEqual(t, found[i-1].sim, n.sim)
+			}
+		}
+	}
+
+	recall := float64(hits) / (queries * k)
+	assert.Greater(t, recall, 0.95, "recall@%d", k)
+}
+
+func TestHNSW_Small(t *testing.T) {
+	graph := newHNSW(hnswLinks, hnswEF, rand.New(rand.NewPCG(1, 2)))
+	assert.Empty(t, graph.search([]float32{1, 0}, 5, hnswEF))
+
+	graph.add([]float32{1, 0})
+	graph.add([]float32{0, 1})
+	graph.add([]float32{0.6, 0.8})
+
+	found := graph.search([]float32{0.8, 0.6}, 5, hnswEF)
+	assert.Equal(t, []int{2, 0, 1}, []int{found[0].node, found[1].node, found[2].node})
+	assert.Len(t, graph.search([]float32{0.8, 0.6}, 1, hnswEF), 1)
+}
@@ -3,8 +3,13 @@ package index
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"hash/fnv"
 	"log/slog"
+	"slices"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"yadro.com/course/search/core"
@@ -14,14 +19,31 @@ type Index struct {
 	log      *slog.Logger
 	indexTTL time.Duration
 	db       core.DB
-	storage  map[string][]int
+
+	// shards partition the comics by ID, see shardOf.
+	shards []*shard
+	// semantic is the space of all the comics for the semantic search, of
+	// the given number of dimensions; zero dimensions disable it.
+	dimensions int
+	semantic   atomic.Pointer[semantic]
+	// generation counts the builds published, of the index or a shard.
+	generation atomic.Uint64
+
+	// build serializes the builds; sums are the checksums of the comics of
+	// every shard as last built, nil before the first build.
+	build sync.Mutex
+	sums  []uint64
 }
 
-func NewIndex(log *slog.Logger, db core.DB, indexTTL time.Duration) *Index {
+// NewIndex makes an index of the given number of shards, one at least,
+// with a semantic space of the given number of dimensions.
+func NewIndex(log *slog.Logger, db core.DB, indexTTL time.Duration, shards, dimensions int) *Index {
 	index := &Index{
-		log:      log,
-		indexTTL: indexTTL,
-		db:       db,
+		log:        log,
+		indexTTL:   indexTTL,
+		db:         db,
+		shards:     newShards(max(shards, 1)),
+		dimensions: dimensions,
 	}
 
 	go index.UpdateIndex()
@@ -38,75 +60,311 @@ func (index *Index) UpdateIndex() {
 		comics, err := index.db.GetComics(ctx)
 		if err != nil {
 			index.log.Error("failed to get comics from db", "error", err)
+			continue
 		}
 
-		if err = index.BuildIndex(comics); err != nil {
+		if err = index.refresh(comics); err != nil {
 			index.log.Error("failed to build index", "error", err)
 		}
 	}
 }
 
-func (index *Index) BuildIndex(comics []core.Comics) error {
-	newIndex := make(map[string][]int)
-	for _, comic := range comics {
-		var keywords []string
-		if err := json.Unmarshal([]byte(comic.Keywords), &keywords); err != nil {
-			index.log.Error("failed to unmarshal keywords", "error", err)
+// refresh rebuilds the shards whose comics changed since they were last
+// built, see BuildShard, and the semantic space if any did, as it spans all
+// the shards. Nothing is rebuilt if nothing changed.
+func (index *Index) refresh(comics []core.Comics) error {
+	index.build.Lock()
+	sums := slices.Clone(index.sums)
+	index.build.Unlock()
+	if sums == nil {
+		return index.BuildIndex(comics)
+	}
+
+	changed := false
+	for n, part := range index.partition(comics) {
+		if checksum(part) == sums[n] {
 			continue
 		}
+		index.log.Info("rebuilding shard", "shard", n, "comics", len(part))
+		if err := index.BuildShard(n, part); err != nil {
+			return err
+		}
+		changed = true
+	}
+	if changed && index.dimensions > 0 {
+		index.semantic.Store(buildSemantic(index.log, comics, index.dimensions))
+		index.generation.Add(1)
+	}
+	return nil
+}
+
+// BuildIndex rebuilds all the shards and the semantic space in parallel.
+func (index *Index) BuildIndex(comics []core.Comics) error {
+	index.build.Lock()
+	defer index.build.Unlock()
+
+	parts := index.partition(comics)
+	index.sums = make([]uint64, len(parts))
+	for n, part := range parts {
+		index.sums[n] = checksum(part)
+	}
+
+	var wg sync.WaitGroup
+	for n, shard := range index.shards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shard.build(index.log, parts[n])
+		}()
+	}
+	if index.dimensions > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			index.semantic.Store(buildSemantic(index.log, comics, index.dimensions))
+		}()
+	}
+	wg.Wait()
+
+	index.generation.Add(1)
+	return nil
+}
+
+// BuildShard rebuilds the shard n alone from the comics, those of the other
+// shards being skipped. The semantic space spans all the shards, it is left
+// for the caller to rebuild, see refresh.
+func (index *Index) BuildShard(n int, comics []core.Comics) error {
+	if n < 0 || n >= len(index.shards) {
+		return fmt.Errorf("%w: shard %d of %d", core.ErrBadArguments, n, len(index.shards))
+	}
+
+	index.build.Lock()
+	defer index.build.Unlock()
 
-		for _, word := range keywords {
-			newIndex[word] = append(newIndex[word], comic.ID)
+	part := make([]core.Comics, 0, len(comics)/len(index.shards)+1)
+	for _, comic := range comics {
+		if index.shardOf(comic.ID) == n {
+			part = append(part, comic)
 		}
 	}
+	index.shards[n].build(index.log, part)
+	if index.sums != nil {
+		index.sums[n] = checksum(part)
+	}
 
-	index.storage = newIndex
+	index.generation.Add(1)
 	return nil
 }
 
-func (index Index) SearchByIndex(ctx context.Context, limit int, keywords []string) ([]core.Comics, error) {
-	comicCount := make(map[int]int)
+// partition splits the comics by their shards.
+func (index *Index) partition(comics []core.Comics) [][]core.Comics {
+	parts := make([][]core.Comics, len(index.shards))
+	for _, comic := range comics {
+		n := index.shardOf(comic.ID)
+		parts[n] = append(parts[n], comic)
+	}
+	return parts
+}
 
-	for _, keyword := range keywords {
-		if ids, exists := index.storage[keyword]; exists {
-			for _, id := range ids {
-				comicCount[id]++
-			}
+// checksum sums up the indexed fields of the comics, in any order as the
+// database returns them unordered.
+func checksum(comics []core.Comics) uint64 {
+	var sum uint64
+	for _, comic := range comics {
+		h := fnv.New64a()
+		fmt.Fprintf(h, "%s\x00%d\x00%s\x00%s\x00%s\x00%s\x00%s",
+			comic.Source, comic.ID, comic.Keywords,
+			comic.TitleKeywords, comic.AltKeywords, comic.TranscriptKeywords, comic.TagKeywords)
+		if comic.Published != nil {
+			fmt.Fprintf(h, "\x00%d", comic.Published.Unix())
 		}
+		sum += h.Sum64()
 	}
+	return sum
+}
 
-	if len(comicCount) == 0 {
-		return []core.Comics{}, nil
+// shardOf returns the shard of the comic ID.
+func (index *Index) shardOf(id int) int {
+	return int(uint(id) % uint(len(index.shards)))
+}
+
+// Generation tells the builds apart, it grows with every one.
+func (index *Index) Generation() uint64 {
+	return index.generation.Load()
+}
+
+// fields are summed up in a fixed order for the scores to be reproducible.
+var fieldOrder = []string{"", core.FieldTitle, core.FieldAlt, core.FieldTranscript, core.FieldTag}
+
+// fieldKeywords decodes the keywords of every field of the comic. A comic
+// without them contributes its keywords to the empty field; the tags are
+// attached by hand, apart from the text, so they do not count.
+func fieldKeywords(comic core.Comics) (map[string][]string, error) {
+	fields := make(map[string][]string)
+	for field, raw := range map[string]string{
+		core.FieldTitle:      comic.TitleKeywords,
+		core.FieldAlt:        comic.AltKeywords,
+		core.FieldTranscript: comic.TranscriptKeywords,
+	} {
+		keywords, err := decodeKeywords(raw)
+		if err != nil {
+			return nil, err
+		}
+		if len(keywords) > 0 {
+			fields[field] = keywords
+		}
+	}
+	if len(fields) == 0 {
+		keywords, err := decodeKeywords(comic.Keywords)
+		if err != nil {
+			return nil, err
+		}
+		fields[""] = keywords
 	}
 
-	type comicRate struct {
-		id            int
-		keywordsCount int
+	tags, err := decodeKeywords(comic.TagKeywords)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) > 0 {
+		fields[core.FieldTag] = tags
+	}
+	return fields, nil
+}
+
+// decodeKeywords decodes a JSON array of keywords, none if it is empty.
+func decodeKeywords(raw string) ([]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var keywords []string
+	if err := json.Unmarshal([]byte(raw), &keywords); err != nil {
+		return nil, err
 	}
+	return keywords, nil
+}
+
+func (index *Index) SearchByIndex(ctx context.Context, limit int, query core.Query) ([]core.Comics, error) {
+	return index.withImages(ctx, index.rate(query, limit, false))
+}
+
+// ExplainSearch is SearchByIndex with the matches of every comic found.
+func (index *Index) ExplainSearch(ctx context.Context, limit int, query core.Query) ([]core.Comics, error) {
+	return index.withImages(ctx, index.rate(query, limit, true))
+}
 
-	var sortedComics []comicRate
-	for id, score := range comicCount {
-		sortedComics = append(sortedComics, comicRate{id, score})
+func (index *Index) rate(query core.Query, limit int, explain bool) []comicRate {
+	return index.fanOut(limit, better(query.Options.Sort), func(s *shard) []comicRate {
+		return s.rate(query, limit, explain)
+	})
+}
+
+// fanOut runs the search on all the shards in parallel and merges the tops
+// they found into the limit best in the order.
+func (index *Index) fanOut(limit int, order func(a, b comicRate) bool, search func(*shard) []comicRate) []comicRate {
+	found := make([][]comicRate, len(index.shards))
+	var wg sync.WaitGroup
+	for n, shard := range index.shards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found[n] = search(shard)
+		}()
 	}
+	wg.Wait()
 
-	sort.Slice(sortedComics, func(i, j int) bool {
-		if sortedComics[i].keywordsCount == sortedComics[j].keywordsCount {
-			return sortedComics[i].id > sortedComics[j].id
+	top := newTopK(limit, order)
+	for _, rated := range found {
+		for _, rate := range rated {
+			top.offer(rate)
 		}
-		return sortedComics[i].keywordsCount > sortedComics[j].keywordsCount
+	}
+	return top.sorted()
+}
+
+// SemanticSearch finds the comics closest in meaning to the query words,
+// those without any of them included. It fails with core.ErrBadArguments if
+// the semantic space is disabled and finds nothing until it is built.
+func (index *Index) SemanticSearch(ctx context.Context, limit int, query core.Query) ([]core.Comics, error) {
+	if index.dimensions <= 0 {
+		return []core.Comics{}, fmt.Errorf("%w: semantic search is disabled", core.ErrBadArguments)
+	}
+	space := index.semantic.Load()
+	if space == nil {
+		return []core.Comics{}, nil
+	}
+	return index.withImages(ctx, space.search(query, limit))
+}
+
+// Similar finds the comics closest to the given one by cosine similarity of
+// their term vectors. The comic itself is left out.
+func (index *Index) Similar(ctx context.Context, source string, id, limit int) ([]core.Comics, error) {
+	origin, ok := index.shards[index.shardOf(id)].vector(source, id)
+	if !ok {
+		return []core.Comics{}, fmt.Errorf("%w: comic %d of %q is not indexed", core.ErrNotFound, id, source)
+	}
+
+	// words are summed up in a fixed order for the scores to be reproducible
+	words := make([]string, 0, len(origin))
+	for word := range origin {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	rated := index.fanOut(limit, better(core.SortRelevance), func(s *shard) []comicRate {
+		return s.similar(source, id, origin, words, limit)
 	})
+	return index.withImages(ctx, rated)
+}
+
+type comicRate struct {
+	comic core.Comics
+	score float64
+}
 
-	resultCount := min(limit, len(sortedComics))
-	result := make([]core.Comics, 0, resultCount)
+// better reports whether comic a goes before comic b in the given order.
+// Undated comics go last in either date order; ties are broken by the
+// score, then by the ID, the newer first, then by the source.
+func better(order core.Sort) func(a, b comicRate) bool {
+	return func(a, b comicRate) bool {
+		if order != core.SortRelevance && order != "" {
+			x, y := a.comic.Published, b.comic.Published
+			switch {
+			case x == nil && y != nil:
+				return false
+			case x != nil && y == nil:
+				return true
+			case x != nil && !x.Equal(*y):
+				if order == core.SortNewest {
+					return x.After(*y)
+				}
+				return x.Before(*y)
+			}
+		}
+		if a.score == b.score {
+			if a.comic.ID == b.comic.ID {
+				return a.comic.Source < b.comic.Source
+			}
+			return a.comic.ID > b.comic.ID
+		}
+		return a.score > b.score
+	}
+}
 
-	for i := 0; i < resultCount; i++ {
-		imageUrl, err := index.db.GetImageURL(ctx, sortedComics[i].id)
+// withImages returns the rated comics with their images.
+func (index *Index) withImages(ctx context.Context, rated []comicRate) ([]core.Comics, error) {
+	result := make([]core.Comics, 0, len(rated))
+	for _, rate := range rated {
+		comic := rate.comic
+		imageUrl, err := index.db.GetImageURL(ctx, comic.Source, comic.ID)
 		if err != nil {
 			index.log.Error("failed to get image from db", "error", err)
 			return []core.Comics{}, err
 		}
 
-		result = append(result, core.Comics{ID: sortedComics[i].id, URL: imageUrl})
+		result = append(result, core.Comics{
+			ID: comic.ID, Source: comic.Source, URL: imageUrl, Published: comic.Published, Explanation: comic.Explanation,
+		})
 	}
 
 	return result, nil
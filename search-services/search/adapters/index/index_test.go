@@ -3,7 +3,9 @@ package index
 import (
 	"context"
 	"log/slog"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -14,13 +16,13 @@ type MockDB struct {
 	mock.Mock
 }
 
-func (m *MockDB) SearchComics(ctx context.Context, limit int, keywords []string) ([]core.Comics, error) {
-	args := m.Called(ctx, limit, keywords)
+func (m *MockDB) SearchComics(ctx context.Context, limit int, query core.Query) ([]core.Comics, error) {
+	args := m.Called(ctx, limit, query)
 	return args.Get(0).([]core.Comics), args.Error(1)
 }
 
-func (m *MockDB) GetImageURL(ctx context.Context, id int) (string, error) {
-	args := m.Called(ctx, id)
+func (m *MockDB) GetImageURL(ctx context.Context, source string, id int) (string, error) {
+	args := m.Called(ctx, source, id)
 	return args.String(0), args.Error(1)
 }
 
@@ -29,22 +31,100 @@ func (m *MockDB) GetComics(ctx context.Context) ([]core.Comics, error) {
 	return args.Get(0).([]core.Comics), args.Error(1)
 }
 
+func (m *MockDB) GetTexts(ctx context.Context, ids []core.ComicID) (map[core.ComicID]core.Text, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).(map[core.ComicID]core.Text), args.Error(1)
+}
+
+func (m *MockDB) RandomComics(ctx context.Context, limit int, opts core.Options) ([]core.Comics, error) {
+	args := m.Called(ctx, limit, opts)
+	return args.Get(0).([]core.Comics), args.Error(1)
+}
+
+func (m *MockDB) OnThisDay(ctx context.Context, month time.Month, day, year, limit int) ([]core.Comics, error) {
+	args := m.Called(ctx, month, day, year, limit)
+	return args.Get(0).([]core.Comics), args.Error(1)
+}
+
+func (m *MockDB) ExplainSearch(ctx context.Context, limit int, query core.Query) (core.Explain, error) {
+	args := m.Called(ctx, limit, query)
+	return args.Get(0).(core.Explain), args.Error(1)
+}
+
 func TestBuildIndex(t *testing.T) {
 	tests := []struct {
-		name    string
-		comics  []core.Comics
-		want    map[string][]int
-		wantErr bool
+		name     string
+		comics   []core.Comics
+		want     map[string]map[string][]int
+		wantDocs []core.Comics
+		wantErr  bool
 	}{
 		{
 			name: "valid keywords",
 			comics: []core.Comics{
-				{ID: 1, Keywords: `["cat","dog"]`},
-				{ID: 2, Keywords: `["cat"]`},
+				{ID: 1, Source: "xkcd", Keywords: `["cat","dog"]`},
+				{ID: 2, Source: "xkcd", Keywords: `["cat"]`},
+			},
+			want: map[string]map[string][]int{
+				"": {
+					"cat": {0, 1},
+					"dog": {0},
+				},
+			},
+			wantDocs: []core.Comics{
+				{ID: 1, Source: "xkcd"},
+				{ID: 2, Source: "xkcd"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "keywords by field",
+			comics: []core.Comics{
+				{ID: 1, Source: "xkcd", Keywords: `["cat","dog"]`,
+					TitleKeywords: `["cat"]`, AltKeywords: `["dog"]`, TranscriptKeywords: `["cat","dog"]`},
+				{ID: 2, Source: "xkcd", Keywords: `["cat"]`, TitleKeywords: `[]`, AltKeywords: `[]`, TranscriptKeywords: `[]`},
+			},
+			want: map[string]map[string][]int{
+				"title":      {"cat": {0}},
+				"alt":        {"dog": {0}},
+				"transcript": {"cat": {0}, "dog": {0}},
+				"":           {"cat": {1}},
+			},
+			wantDocs: []core.Comics{
+				{ID: 1, Source: "xkcd"},
+				{ID: 2, Source: "xkcd"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "tags beside the keywords",
+			comics: []core.Comics{
+				{ID: 1, Source: "xkcd", Keywords: `["cat"]`, TitleKeywords: `["cat"]`, TagKeywords: `["romanc"]`},
+				{ID: 2, Source: "xkcd", Keywords: `["dog"]`, TagKeywords: `["math"]`},
+			},
+			want: map[string]map[string][]int{
+				"title": {"cat": {0}},
+				"":      {"dog": {1}},
+				"tag":   {"romanc": {0}, "math": {1}},
 			},
-			want: map[string][]int{
-				"cat": {1, 2},
-				"dog": {1},
+			wantDocs: []core.Comics{
+				{ID: 1, Source: "xkcd"},
+				{ID: 2, Source: "xkcd"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "same id in different sources",
+			comics: []core.Comics{
+				{ID: 1, Source: "xkcd", Keywords: `["cat"]`},
+				{ID: 1, Source: "smbc", Keywords: `["cat"]`},
+			},
+			want: map[string]map[string][]int{
+				"": {"cat": {0, 1}},
+			},
+			wantDocs: []core.Comics{
+				{ID: 1, Source: "xkcd"},
+				{ID: 1, Source: "smbc"},
 			},
 			wantErr: false,
 		},
@@ -52,18 +132,17 @@ func TestBuildIndex(t *testing.T) {
 			name: "invalid JSON",
 			comics: []core.Comics{
 				{ID: 1, Keywords: "invalid json"},
+				{ID: 2, Keywords: `["cat"]`, TitleKeywords: "invalid json"},
 			},
-			want:    map[string][]int{},
-			wantErr: false,
+			want:     map[string]map[string][]int{},
+			wantDocs: []core.Comics{},
+			wantErr:  false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			idx := &Index{
-				log:     slog.Default(),
-				storage: make(map[string][]int),
-			}
+			idx := &Index{log: slog.Default(), shards: newShards(1)}
 
 			err := idx.BuildIndex(tt.comics)
 			if (err != nil) != tt.wantErr {
@@ -71,18 +150,68 @@ func TestBuildIndex(t *testing.T) {
 				return
 			}
 
-			assert.Equal(t, tt.want, idx.storage)
+			assert.Equal(t, tt.want, decode(idx.shards[0].storage))
+			assert.Equal(t, tt.wantDocs, idx.shards[0].docs)
+			assert.Equal(t, uint64(1), idx.Generation())
 		})
 	}
 }
 
+// encode builds posting lists from the docs holding every word, a doc
+// repeated once per occurrence.
+func encode(storage map[string]map[string][]int) map[string]map[string]postings {
+	encoded := make(map[string]map[string]postings, len(storage))
+	for field, words := range storage {
+		encoded[field] = make(map[string]postings, len(words))
+		for word, docs := range words {
+			var b postingsBuilder
+			for _, doc := range docs {
+				b.add(doc)
+			}
+			encoded[field][word] = b.postings()
+		}
+	}
+	return encoded
+}
+
+// decode is the reverse of encode.
+func decode(storage map[string]map[string]postings) map[string]map[string][]int {
+	decoded := make(map[string]map[string][]int, len(storage))
+	for field, words := range storage {
+		decoded[field] = make(map[string][]int, len(words))
+		for word, list := range words {
+			for c := list.cursor(); c.next(); {
+				for range c.tf {
+					decoded[field][word] = append(decoded[field][word], c.doc)
+				}
+			}
+		}
+	}
+	return decoded
+}
+
+func date(year, month, day int) *time.Time {
+	d := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	return &d
+}
+
+func terms(words ...string) []core.Term {
+	out := make([]core.Term, len(words))
+	for i, word := range words {
+		out[i] = core.Term{Word: word}
+	}
+	return out
+}
+
 func TestSearchByIndex(t *testing.T) {
 	ctx := context.Background()
+	boosts := core.Boosts{Title: 3, Alt: 2, Transcript: 1}
 
 	tests := []struct {
 		name      string
-		storage   map[string][]int
-		keywords  []string
+		storage   map[string]map[string][]int
+		docs      []core.Comics
+		query     core.Query
 		limit     int
 		mockSetup func(*MockDB)
 		want      []core.Comics
@@ -90,51 +219,221 @@ func TestSearchByIndex(t *testing.T) {
 	}{
 		{
 			name: "one keyword",
-			storage: map[string][]int{
-				"cat": {1, 2},
-				"dog": {2, 3},
+			storage: map[string]map[string][]int{
+				"": {
+					"cat": {0, 1},
+					"dog": {1, 2},
+				},
+			},
+			docs: []core.Comics{
+				{ID: 1, Source: "xkcd"},
+				{ID: 2, Source: "xkcd"},
+				{ID: 3, Source: "xkcd"},
 			},
-			keywords: []string{"cat"},
-			limit:    10,
+			query: core.Query{Terms: terms("cat"), Boosts: boosts},
+			limit: 10,
 			mockSetup: func(m *MockDB) {
-				m.On("GetImageURL", ctx, 1).Return("url1", nil)
-				m.On("GetImageURL", ctx, 2).Return("url2", nil)
+				m.On("GetImageURL", ctx, "xkcd", 1).Return("url1", nil)
+				m.On("GetImageURL", ctx, "xkcd", 2).Return("url2", nil)
 			},
 			want: []core.Comics{
-				{ID: 2, URL: "url2"},
-				{ID: 1, URL: "url1"},
+				{ID: 2, Source: "xkcd", URL: "url2"},
+				{ID: 1, Source: "xkcd", URL: "url1"},
 			},
 			wantErr: false,
 		},
 		{
 			name: "two or more keywords",
-			storage: map[string][]int{
-				"cat": {1, 2},
-				"dog": {2, 3},
+			storage: map[string]map[string][]int{
+				"": {
+					"cat": {0, 1},
+					"dog": {1, 2},
+				},
+			},
+			docs: []core.Comics{
+				{ID: 1, Source: "xkcd"},
+				{ID: 2, Source: "xkcd"},
+				{ID: 3, Source: "xkcd"},
+			},
+			query: core.Query{Terms: terms("cat", "dog"), Boosts: boosts},
+			limit: 10,
+			mockSetup: func(m *MockDB) {
+				m.On("GetImageURL", ctx, "xkcd", 2).Return("url2", nil)
+				m.On("GetImageURL", ctx, "xkcd", 1).Return("url1", nil)
+				m.On("GetImageURL", ctx, "xkcd", 3).Return("url3", nil)
+			},
+			want: []core.Comics{
+				{ID: 2, Source: "xkcd", URL: "url2"},
+				{ID: 3, Source: "xkcd", URL: "url3"},
+				{ID: 1, Source: "xkcd", URL: "url1"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "boosted fields rank first",
+			storage: map[string]map[string][]int{
+				"title":      {"python": {0}},
+				"alt":        {"python": {1}},
+				"transcript": {"python": {1, 2}},
+			},
+			docs: []core.Comics{
+				{ID: 1, Source: "xkcd"},
+				{ID: 2, Source: "xkcd"},
+				{ID: 3, Source: "xkcd"},
+			},
+			query: core.Query{Terms: terms("python"), Boosts: core.Boosts{Title: 4, Alt: 2, Transcript: 1}},
+			limit: 10,
+			mockSetup: func(m *MockDB) {
+				m.On("GetImageURL", ctx, "xkcd", 1).Return("url1", nil)
+				m.On("GetImageURL", ctx, "xkcd", 2).Return("url2", nil)
+				m.On("GetImageURL", ctx, "xkcd", 3).Return("url3", nil)
+			},
+			want: []core.Comics{
+				{ID: 1, Source: "xkcd", URL: "url1"},
+				{ID: 2, Source: "xkcd", URL: "url2"},
+				{ID: 3, Source: "xkcd", URL: "url3"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "scoped term is required",
+			storage: map[string]map[string][]int{
+				"title":      {"python": {0}, "snake": {2}},
+				"transcript": {"python": {1}, "snake": {0, 1}},
+			},
+			docs: []core.Comics{
+				{ID: 1, Source: "xkcd"},
+				{ID: 2, Source: "xkcd"},
+				{ID: 3, Source: "xkcd"},
+			},
+			query: core.Query{
+				Terms:  []core.Term{{Field: core.FieldTitle, Word: "python"}, {Word: "snake"}},
+				Boosts: boosts,
+			},
+			limit: 10,
+			mockSetup: func(m *MockDB) {
+				m.On("GetImageURL", ctx, "xkcd", 1).Return("url1", nil)
+			},
+			want: []core.Comics{
+				{ID: 1, Source: "xkcd", URL: "url1"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "all terms required",
+			storage: map[string]map[string][]int{
+				"title":      {"cat": {0, 1}},
+				"transcript": {"cat": {0}, "dog": {1, 2}},
+			},
+			docs: []core.Comics{
+				{ID: 1, Source: "xkcd"},
+				{ID: 2, Source: "xkcd"},
+				{ID: 3, Source: "xkcd"},
+			},
+			query: core.Query{Terms: terms("cat", "dog"), Boosts: boosts, Options: core.Options{All: true}},
+			limit: 10,
+			mockSetup: func(m *MockDB) {
+				m.On("GetImageURL", ctx, "xkcd", 2).Return("url2", nil)
+			},
+			want: []core.Comics{
+				{ID: 2, Source: "xkcd", URL: "url2"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "bounds apply before the limit",
+			storage: map[string]map[string][]int{
+				"": {"cat": {0, 1, 2, 3}},
+			},
+			docs: []core.Comics{
+				{ID: 1, Source: "xkcd", Published: date(2006, 1, 1)},
+				{ID: 2, Source: "xkcd", Published: date(2010, 6, 1)},
+				{ID: 3, Source: "xkcd", Published: date(2011, 6, 1)},
+				{ID: 4, Source: "smbc"},
 			},
-			keywords: []string{"cat", "dog"},
-			limit:    10,
+			query: core.Query{Terms: terms("cat"), Boosts: boosts, Options: core.Options{
+				From: *date(2010, 1, 1), To: *date(2012, 12, 31), MaxID: 2,
+			}},
+			limit: 1,
 			mockSetup: func(m *MockDB) {
-				m.On("GetImageURL", ctx, 2).Return("url2", nil)
-				m.On("GetImageURL", ctx, 1).Return("url1", nil)
-				m.On("GetImageURL", ctx, 3).Return("url3", nil)
+				m.On("GetImageURL", ctx, "xkcd", 2).Return("url2", nil)
 			},
 			want: []core.Comics{
-				{ID: 2, URL: "url2"},
-				{ID: 3, URL: "url3"},
-				{ID: 1, URL: "url1"},
+				{ID: 2, Source: "xkcd", URL: "url2", Published: date(2010, 6, 1)},
 			},
 			wantErr: false,
 		},
+		{
+			name: "oldest first, undated last",
+			storage: map[string]map[string][]int{
+				"":      {"cat": {0, 1, 2}},
+				"title": {"cat": {2}},
+			},
+			docs: []core.Comics{
+				{ID: 1, Source: "smbc"},
+				{ID: 2, Source: "xkcd", Published: date(2010, 6, 1)},
+				{ID: 3, Source: "xkcd", Published: date(2006, 1, 1)},
+			},
+			query: core.Query{Terms: terms("cat"), Boosts: boosts, Options: core.Options{Sort: core.SortOldest}},
+			limit: 10,
+			mockSetup: func(m *MockDB) {
+				m.On("GetImageURL", ctx, "smbc", 1).Return("url1", nil)
+				m.On("GetImageURL", ctx, "xkcd", 2).Return("url2", nil)
+				m.On("GetImageURL", ctx, "xkcd", 3).Return("url3", nil)
+			},
+			want: []core.Comics{
+				{ID: 3, Source: "xkcd", URL: "url3", Published: date(2006, 1, 1)},
+				{ID: 2, Source: "xkcd", URL: "url2", Published: date(2010, 6, 1)},
+				{ID: 1, Source: "smbc", URL: "url1"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "newest first",
+			storage: map[string]map[string][]int{
+				"": {"cat": {0, 1, 2}},
+			},
+			docs: []core.Comics{
+				{ID: 1, Source: "xkcd", Published: date(2006, 1, 1)},
+				{ID: 2, Source: "smbc"},
+				{ID: 3, Source: "xkcd", Published: date(2010, 6, 1)},
+			},
+			query: core.Query{Terms: terms("cat"), Boosts: boosts, Options: core.Options{Sort: core.SortNewest}},
+			limit: 2,
+			mockSetup: func(m *MockDB) {
+				m.On("GetImageURL", ctx, "xkcd", 3).Return("url3", nil)
+				m.On("GetImageURL", ctx, "xkcd", 1).Return("url1", nil)
+			},
+			want: []core.Comics{
+				{ID: 3, Source: "xkcd", URL: "url3", Published: date(2010, 6, 1)},
+				{ID: 1, Source: "xkcd", URL: "url1", Published: date(2006, 1, 1)},
+			},
+			wantErr: false,
+		},
+		{
+			name: "nothing found",
+			storage: map[string]map[string][]int{
+				"": {"cat": {0}},
+			},
+			docs:      []core.Comics{{ID: 1, Source: "xkcd"}},
+			query:     core.Query{Terms: []core.Term{{Field: core.FieldAlt, Word: "cat"}}, Boosts: boosts},
+			limit:     10,
+			mockSetup: func(m *MockDB) {},
+			want:      []core.Comics{},
+			wantErr:   false,
+		},
 		{
 			name: "failed to get image url",
-			storage: map[string][]int{
-				"cat": {1},
+			storage: map[string]map[string][]int{
+				"": {"cat": {0}},
+			},
+			docs: []core.Comics{
+				{ID: 1, Source: "xkcd"},
 			},
-			keywords: []string{"cat"},
-			limit:    10,
+			query: core.Query{Terms: terms("cat"), Boosts: boosts},
+			limit: 10,
 			mockSetup: func(m *MockDB) {
-				m.On("GetImageURL", ctx, 1).Return("", assert.AnError)
+				m.On("GetImageURL", ctx, "xkcd", 1).Return("", assert.AnError)
 			},
 			want:    []core.Comics{},
 			wantErr: true,
@@ -147,12 +446,12 @@ func TestSearchByIndex(t *testing.T) {
 			tt.mockSetup(mockDB)
 
 			idx := &Index{
-				log:     slog.Default(),
-				db:      mockDB,
-				storage: tt.storage,
+				log:    slog.Default(),
+				db:     mockDB,
+				shards: []*shard{{storage: encode(tt.storage), docs: tt.docs}},
 			}
 
-			got, err := idx.SearchByIndex(ctx, tt.limit, tt.keywords)
+			got, err := idx.SearchByIndex(ctx, tt.limit, tt.query)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("SearchByIndex error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -163,3 +462,199 @@ func TestSearchByIndex(t *testing.T) {
 		})
 	}
 }
+
+func TestExplainSearch(t *testing.T) {
+	ctx := context.Background()
+	boosts := core.Boosts{Title: 3, Alt: 2, Transcript: 1}
+	python := core.Term{Word: "python"}
+	titleSnake := core.Term{Field: core.FieldTitle, Word: "snake"}
+
+	mockDB := new(MockDB)
+	mockDB.On("GetImageURL", ctx, "xkcd", 1).Return("url1", nil)
+	mockDB.On("GetImageURL", ctx, "xkcd", 2).Return("url2", nil)
+
+	idx := &Index{
+		log: slog.Default(),
+		db:  mockDB,
+		shards: []*shard{{
+			storage: encode(map[string]map[string][]int{
+				"title":      {"python": {0}, "snake": {0, 1}},
+				"transcript": {"python": {0, 0, 1, 2}},
+			}),
+			docs: []core.Comics{
+				{ID: 1, Source: "xkcd"},
+				{ID: 2, Source: "xkcd"},
+				{ID: 3, Source: "xkcd"},
+			},
+		}},
+	}
+
+	got, err := idx.ExplainSearch(ctx, 10, core.Query{Terms: []core.Term{python, titleSnake}, Boosts: boosts})
+	assert.NoError(t, err)
+	assert.Equal(t, []core.Comics{
+		{ID: 1, Source: "xkcd", URL: "url1", Explanation: &core.Explanation{Score: 8, Matches: []core.Match{
+			{Term: python, Field: core.FieldTitle, TF: 1, Boost: 3, Score: 3},
+			{Term: python, Field: core.FieldTranscript, TF: 2, Boost: 1, Score: 2},
+			{Term: titleSnake, Field: core.FieldTitle, TF: 1, Boost: 3, Score: 3},
+		}}},
+		{ID: 2, Source: "xkcd", URL: "url2", Explanation: &core.Explanation{Score: 4, Matches: []core.Match{
+			{Term: python, Field: core.FieldTranscript, TF: 1, Boost: 1, Score: 1},
+			{Term: titleSnake, Field: core.FieldTitle, TF: 1, Boost: 3, Score: 3},
+		}}},
+	}, got)
+	mockDB.AssertExpectations(t)
+}
+
+func TestSimilar(t *testing.T) {
+	ctx := context.Background()
+	comics := []core.Comics{
+		{ID: 1, Source: "xkcd", Keywords: `["python","snake","code"]`},
+		{ID: 2, Source: "xkcd", Keywords: `["python","snake","zoo"]`},
+		{ID: 3, Source: "xkcd", Keywords: `["python","code"]`},
+		{ID: 4, Source: "xkcd", Keywords: `["cat"]`},
+	}
+
+	tests := []struct {
+		name      string
+		source    string
+		id        int
+		limit     int
+		mockSetup func(*MockDB)
+		want      []core.Comics
+		wantErr   error
+	}{
+		{
+			name:   "closest first without the comic itself",
+			source: "xkcd",
+			id:     1,
+			limit:  10,
+			mockSetup: func(m *MockDB) {
+				m.On("GetImageURL", ctx, "xkcd", 3).Return("url3", nil)
+				m.On("GetImageURL", ctx, "xkcd", 2).Return("url2", nil)
+			},
+			want: []core.Comics{
+				{ID: 3, Source: "xkcd", URL: "url3"},
+				{ID: 2, Source: "xkcd", URL: "url2"},
+			},
+		},
+		{
+			name:   "limit",
+			source: "xkcd",
+			id:     1,
+			limit:  1,
+			mockSetup: func(m *MockDB) {
+				m.On("GetImageURL", ctx, "xkcd", 3).Return("url3", nil)
+			},
+			want: []core.Comics{
+				{ID: 3, Source: "xkcd", URL: "url3"},
+			},
+		},
+		{
+			name:      "nothing in common",
+			source:    "xkcd",
+			id:        4,
+			limit:     10,
+			mockSetup: func(m *MockDB) {},
+			want:      []core.Comics{},
+		},
+		{
+			name:      "not indexed",
+			source:    "smbc",
+			id:        1,
+			limit:     10,
+			mockSetup: func(m *MockDB) {},
+			want:      []core.Comics{},
+			wantErr:   core.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			tt.mockSetup(mockDB)
+
+			idx := &Index{log: slog.Default(), db: mockDB, shards: newShards(1)}
+			if err := idx.BuildIndex(comics); err != nil {
+				t.Fatalf("BuildIndex error = %v", err)
+			}
+
+			got, err := idx.Similar(ctx, tt.source, tt.id, tt.limit)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestShards(t *testing.T) {
+	ctx := context.Background()
+	comics := []core.Comics{
+		{ID: 1, Source: "xkcd", Keywords: `["python","snake"]`},
+		{ID: 2, Source: "xkcd", Keywords: `["python"]`},
+		{ID: 3, Source: "xkcd", Keywords: `["python","python"]`},
+		{ID: 4, Source: "xkcd", Keywords: `["snake"]`},
+		{ID: 5, Source: "xkcd", Keywords: `["python","snake","snake"]`},
+	}
+	query := core.Query{Terms: terms("python", "snake"), Boosts: core.Boosts{Title: 3, Alt: 2, Transcript: 1}}
+
+	mockDB := new(MockDB)
+	for _, comic := range comics {
+		mockDB.On("GetImageURL", ctx, "xkcd", comic.ID).Return("", nil)
+	}
+
+	single := &Index{log: slog.Default(), db: mockDB, shards: newShards(1)}
+	sharded := &Index{log: slog.Default(), db: mockDB, shards: newShards(3)}
+	assert.NoError(t, single.BuildIndex(comics))
+	assert.NoError(t, sharded.BuildIndex(comics))
+	assert.Equal(t, []core.Comics{{ID: 1, Source: "xkcd"}, {ID: 4, Source: "xkcd"}}, sharded.shards[1].docs)
+
+	want, err := single.SearchByIndex(ctx, 3, query)
+	assert.NoError(t, err)
+	got, err := sharded.SearchByIndex(ctx, 3, query)
+	assert.NoError(t, err)
+	assert.Equal(t, []core.Comics{{ID: 5, Source: "xkcd"}, {ID: 3, Source: "xkcd"}, {ID: 1, Source: "xkcd"}}, got)
+	assert.Equal(t, want, got)
+
+	// comic 5 is rebuilt without snakes, its shard only
+	rebuilt := []core.Comics{{ID: 2, Source: "xkcd", Keywords: `["python"]`}, {ID: 5, Source: "xkcd", Keywords: `["python"]`}}
+	assert.NoError(t, sharded.BuildShard(2, rebuilt))
+	assert.Equal(t, uint64(2), sharded.Generation())
+	assert.Equal(t, []core.Comics{{ID: 2, Source: "xkcd"}, {ID: 5, Source: "xkcd"}}, sharded.shards[2].docs)
+	assert.Len(t, sharded.shards[1].docs, 2)
+
+	got, err = sharded.SearchByIndex(ctx, 3, query)
+	assert.NoError(t, err)
+	assert.Equal(t, []core.Comics{{ID: 3, Source: "xkcd"}, {ID: 1, Source: "xkcd"}, {ID: 5, Source: "xkcd"}}, got)
+
+	assert.ErrorIs(t, sharded.BuildShard(3, rebuilt), core.ErrBadArguments)
+	assert.Equal(t, uint64(2), sharded.Generation())
+}
+
+func TestIndex_Refresh(t *testing.T) {
+	comics := []core.Comics{
+		{ID: 1, Source: "xkcd", Keywords: `["python","snake"]`},
+		{ID: 2, Source: "xkcd", Keywords: `["python"]`},
+		{ID: 4, Source: "xkcd", Keywords: `["snake"]`},
+		{ID: 5, Source: "xkcd", Keywords: `["python","snake","snake"]`},
+	}
+	idx := &Index{log: slog.Default(), shards: newShards(3)}
+
+	// the first refresh builds all the shards
+	assert.NoError(t, idx.refresh(comics))
+	assert.Equal(t, uint64(1), idx.Generation())
+	assert.Len(t, idx.shards[2].docs, 2)
+
+	// the same comics in another order change nothing
+	reordered := []core.Comics{comics[3], comics[2], comics[1], comics[0]}
+	assert.NoError(t, idx.refresh(reordered))
+	assert.Equal(t, uint64(1), idx.Generation())
+
+	// comic 5 changed, its shard only is rebuilt
+	docs := idx.shards[1].docs
+	changed := slices.Clone(comics)
+	changed[3].Keywords = `["python"]`
+	assert.NoError(t, idx.refresh(changed))
+	assert.Equal(t, uint64(2), idx.Generation())
+	assert.NotContains(t, idx.shards[2].storage[""], "snake")
+	assert.Same(t, &docs[0], &idx.shards[1].docs[0])
+}
@@ -0,0 +1,115 @@
+package index
+
+import (
+	"container/heap"
+	"encoding/binary"
+)
+
+// postings is the posting list of a word in a field: the docs holding it in
+// increasing order, each with the number of times it is held. A doc is
+// stored as two uvarints, its delta from the previous doc and the count, so
+// a list of close docs takes a couple of bytes per doc.
+type postings []byte
+
+// postingsBuilder encodes a posting list from the occurrences of a word,
+// which must come in non-decreasing doc order.
+type postingsBuilder struct {
+	data []byte
+	prev int
+	doc  int
+	tf   int
+}
+
+func (b *postingsBuilder) add(doc int) {
+	if b.tf > 0 && doc == b.doc {
+		b.tf++
+		return
+	}
+	b.flush()
+	b.doc, b.tf = doc, 1
+}
+
+func (b *postingsBuilder) flush() {
+	if b.tf == 0 {
+		return
+	}
+	b.data = binary.AppendUvarint(b.data, uint64(b.doc-b.prev))
+	b.data = binary.AppendUvarint(b.data, uint64(b.tf))
+	b.prev = b.doc
+}
+
+// postings returns the list, trimmed not to hold spare capacity.
+func (b *postingsBuilder) postings() postings {
+	b.flush()
+	b.tf = 0
+	return append(postings(nil), b.data...)
+}
+
+// cursor walks a posting list. After next reports true, doc and tf hold
+// the current entry.
+type cursor struct {
+	data postings
+	pos  int
+	doc  int
+	tf   int
+}
+
+func (p postings) cursor() cursor {
+	return cursor{data: p}
+}
+
+func (c *cursor) next() bool {
+	if c.pos >= len(c.data) {
+		return false
+	}
+	delta, n := binary.Uvarint(c.data[c.pos:])
+	c.pos += n
+	tf, n := binary.Uvarint(c.data[c.pos:])
+	c.pos += n
+	c.doc += int(delta)
+	c.tf = int(tf)
+	return true
+}
+
+// topK keeps the k best comics seen, the worst of them at the root of the
+// heap, so that a better comic replaces it in O(log k).
+type topK struct {
+	k      int
+	better func(a, b comicRate) bool
+	rates  []comicRate
+}
+
+func newTopK(k int, better func(a, b comicRate) bool) *topK {
+	return &topK{k: k, better: better, rates: make([]comicRate, 0, min(max(k, 0), 64))}
+}
+
+func (t *topK) Len() int           { return len(t.rates) }
+func (t *topK) Less(i, j int) bool { return t.better(t.rates[j], t.rates[i]) }
+func (t *topK) Swap(i, j int)      { t.rates[i], t.rates[j] = t.rates[j], t.rates[i] }
+func (t *topK) Push(x any)         { t.rates = append(t.rates, x.(comicRate)) }
+func (t *topK) Pop() any {
+	last := t.rates[len(t.rates)-1]
+	t.rates = t.rates[:len(t.rates)-1]
+	return last
+}
+
+// offer keeps the comic if it is among the k best so far.
+func (t *topK) offer(rate comicRate) {
+	switch {
+	case t.k <= 0:
+	case len(t.rates) < t.k:
+		heap.Push(t, rate)
+	case t.better(rate, t.rates[0]):
+		t.rates[0] = rate
+		heap.Fix(t, 0)
+	}
+}
+
+// sorted empties the heap into a slice, the best comic first.
+func (t *topK) sorted() []comicRate {
+	out := make([]comicRate, len(t.rates))
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(t).(comicRate)
+	}
+	return out
+}
@@ -0,0 +1,90 @@
+package index
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"yadro.com/course/search/core"
+)
+
+func TestPostings(t *testing.T) {
+	type entry struct{ doc, tf int }
+	tests := []struct {
+		name     string
+		docs     []int
+		want     []entry
+		wantSize int
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:     "occurrences merged",
+			docs:     []int{0, 0, 3, 5, 5, 5},
+			want:     []entry{{0, 2}, {3, 1}, {5, 3}},
+			wantSize: 6,
+		},
+		{
+			name:     "long gaps",
+			docs:     []int{200, 100_000},
+			want:     []entry{{200, 1}, {100_000, 1}},
+			wantSize: 7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b postingsBuilder
+			for _, doc := range tt.docs {
+				b.add(doc)
+			}
+			list := b.postings()
+
+			var got []entry
+			for c := list.cursor(); c.next(); {
+				got = append(got, entry{c.doc, c.tf})
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Len(t, list, tt.wantSize)
+		})
+	}
+}
+
+func TestTopK(t *testing.T) {
+	rate := func(id int, score float64) comicRate {
+		return comicRate{comic: core.Comics{ID: id}, score: score}
+	}
+	rates := []comicRate{rate(1, 2), rate(2, 5), rate(3, 1), rate(4, 5), rate(5, 3)}
+
+	tests := []struct {
+		name string
+		k    int
+		want []comicRate
+	}{
+		{
+			name: "best first",
+			k:    3,
+			want: []comicRate{rate(4, 5), rate(2, 5), rate(5, 3)},
+		},
+		{
+			name: "fewer than k",
+			k:    10,
+			want: []comicRate{rate(4, 5), rate(2, 5), rate(5, 3), rate(1, 2), rate(3, 1)},
+		},
+		{
+			name: "zero k",
+			k:    0,
+			want: []comicRate{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			top := newTopK(tt.k, better(core.SortRelevance))
+			for _, r := range rates {
+				top.offer(r)
+			}
+			assert.Equal(t, tt.want, top.sorted())
+		})
+	}
+}
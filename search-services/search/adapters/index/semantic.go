@@ -0,0 +1,204 @@
+package index
+
+import (
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"yadro.com/course/search/core"
+)
+
+const (
+	// hnswLinks and hnswEF trade the build time and the memory of the graph
+	// for the recall of the semantic search.
+	hnswLinks = 16
+	hnswEF    = 100
+)
+
+// semantic is the latent semantic space of the comics (LSA): the TF-IDF
+// term-document matrix reduced by a truncated SVD, so that words used in
+// the same comics get close and a query finds comics without its words.
+// The comics are kept in an HNSW graph by their vectors in the space.
+type semantic struct {
+	terms map[string]int
+	idf   []float64
+	// basis maps a term to the space, a row of the left singular vectors.
+	basis [][]float32
+	docs  []core.Comics
+	// vectors are the unit vectors of docs, nil for a doc without a word
+	// of terms; the others are the nodes of graph in turn.
+	vectors [][]float32
+	nodes   []int
+	graph   *hnsw
+}
+
+// buildSemantic makes the space of the given number of dimensions. Words
+// found in a single comic relate it to no other, so they are left out.
+// The builds are reproducible, the random numbers are seeded.
+func buildSemantic(log *slog.Logger, comics []core.Comics, dimensions int) *semantic {
+	s := &semantic{terms: make(map[string]int)}
+
+	counts := make([]map[string]int, 0, len(comics))
+	df := make(map[string]int)
+	for _, comic := range comics {
+		fields, err := fieldKeywords(comic)
+		if err != nil {
+			log.Error("failed to unmarshal keywords", "error", err)
+			continue
+		}
+		count := make(map[string]int)
+		for _, keywords := range fields {
+			for _, word := range keywords {
+				if count[word] == 0 {
+					df[word]++
+				}
+				count[word]++
+			}
+		}
+		s.docs = append(s.docs, core.Comics{ID: comic.ID, Source: comic.Source, Published: comic.Published})
+		counts = append(counts, count)
+	}
+
+	// terms are numbered and summed up in a fixed order for the builds to
+	// be reproducible
+	words := make([]string, 0, len(df))
+	for word, n := range df {
+		if n > 1 {
+			words = append(words, word)
+		}
+	}
+	sort.Strings(words)
+	for t, word := range words {
+		s.terms[word] = t
+	}
+	s.idf = make([]float64, len(words))
+	for t, word := range words {
+		s.idf[t] = math.Log(float64(len(s.docs)) / float64(df[word]))
+	}
+
+	matrix := sparseMatrix{rows: len(words), cols: make([][]weight, len(counts))}
+	for j, count := range counts {
+		for word, tf := range count {
+			if t, ok := s.terms[word]; ok {
+				matrix.cols[j] = append(matrix.cols[j], weight{t, (1 + math.Log(float64(tf))) * s.idf[t]})
+			}
+		}
+		sort.Slice(matrix.cols[j], func(a, b int) bool { return matrix.cols[j][a].row < matrix.cols[j][b].row })
+		matrix.cols[j] = normalize(matrix.cols[j])
+	}
+
+	r := rand.New(rand.NewPCG(1, 2))
+	u := truncatedSVD(matrix, dimensions, r)
+	s.basis = make([][]float32, len(u))
+	for t, row := range u {
+		s.basis[t] = make([]float32, len(row))
+		for i, x := range row {
+			s.basis[t][i] = float32(x)
+		}
+	}
+
+	s.vectors = make([][]float32, len(s.docs))
+	s.graph = newHNSW(hnswLinks, hnswEF, r)
+	for j, col := range matrix.cols {
+		vector := s.project(col)
+		if vector == nil {
+			continue
+		}
+		s.vectors[j] = vector
+		s.nodes = append(s.nodes, j)
+		s.graph.add(vector)
+	}
+	return s
+}
+
+// normalize scales the weights to a unit vector, so that long comics do not
+// outweigh the short ones. Weights all zero, of words found in every comic,
+// are dropped: the comic has no direction in the space, like a query
+// mapping to none in project.
+func normalize(col []weight) []weight {
+	var norm float64
+	for _, w := range col {
+		norm += w.value * w.value
+	}
+	if norm == 0 {
+		return nil
+	}
+	norm = math.Sqrt(norm)
+	for i := range col {
+		col[i].value /= norm
+	}
+	return col
+}
+
+// project maps the weighted terms to a unit vector of the space, nil if
+// they map to none.
+func (s *semantic) project(col []weight) []float32 {
+	if len(col) == 0 || len(s.basis) == 0 || len(s.basis[0]) == 0 {
+		return nil
+	}
+
+	vector := make([]float64, len(s.basis[0]))
+	for _, w := range col {
+		for i, x := range s.basis[w.row] {
+			vector[i] += w.value * float64(x)
+		}
+	}
+
+	var norm float64
+	for _, x := range vector {
+		norm += x * x
+	}
+	if norm == 0 {
+		return nil
+	}
+	norm = math.Sqrt(norm)
+	out := make([]float32, len(vector))
+	for i, x := range vector {
+		out[i] = float32(x / norm)
+	}
+	return out
+}
+
+// search returns the limit comics closest to the query words, scoped or
+// not, by cosine similarity; comics pointing away are left out. A search
+// with bounds compares all the comics within them, as the nearest comics
+// in the graph may well be out of them.
+func (s *semantic) search(query core.Query, limit int) []comicRate {
+	var col []weight
+	seen := make(map[int]struct{})
+	for _, term := range query.Terms {
+		t, ok := s.terms[term.Word]
+		if _, dup := seen[t]; !ok || dup {
+			continue
+		}
+		seen[t] = struct{}{}
+		col = append(col, weight{t, s.idf[t]})
+	}
+	vector := s.project(col)
+	if vector == nil {
+		return nil
+	}
+
+	top := newTopK(limit, better(core.SortRelevance))
+	offer := func(doc int, sim float32) {
+		if sim > 0 {
+			top.offer(comicRate{comic: s.docs[doc], score: float64(sim)})
+		}
+	}
+
+	opts := query.Options
+	if opts.From.IsZero() && opts.To.IsZero() && opts.MinID == 0 && opts.MaxID == 0 {
+		for _, n := range s.graph.search(vector, limit, hnswEF) {
+			offer(s.nodes[n.node], n.sim)
+		}
+		return top.sorted()
+	}
+
+	for doc, comic := range s.docs {
+		if s.vectors[doc] != nil && opts.Match(comic.ID, comic.Published) {
+			offer(doc, dot(vector, s.vectors[doc]))
+		}
+	}
+	return top.sorted()
+}
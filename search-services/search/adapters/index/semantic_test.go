@@ -0,0 +1,105 @@
+package index
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"yadro.com/course/search/core"
+)
+
+// topicComics are about space or about cooking, no comic has all the words
+// of its topic.
+var topicComics = []core.Comics{
+	{ID: 1, Source: "xkcd", Keywords: `["astronaut","rocket","orbit"]`},
+	{ID: 2, Source: "xkcd", Keywords: `["astronaut","orbit","lonely","moon"]`},
+	{ID: 3, Source: "xkcd", Keywords: `["rocket","moon","orbit"]`},
+	{ID: 4, Source: "xkcd", Keywords: `["recipe","oven","flour"]`},
+	{ID: 5, Source: "xkcd", Keywords: `["oven","bake","flour","lonely"]`},
+	{ID: 6, Source: "xkcd", Keywords: `["recipe","bake","oven"]`},
+	{ID: 7, Source: "xkcd", Keywords: `["unique"]`},
+}
+
+func TestSemanticSearch(t *testing.T) {
+	ctx := context.Background()
+	ids := func(comics []core.Comics) []int {
+		out := make([]int, len(comics))
+		for i, comic := range comics {
+			out[i] = comic.ID
+		}
+		return out
+	}
+
+	mockDB := new(MockDB)
+	for _, comic := range topicComics {
+		mockDB.On("GetImageURL", ctx, "xkcd", comic.ID).Return("", nil).Maybe()
+	}
+	idx := &Index{log: slog.Default(), db: mockDB, shards: newShards(2), dimensions: 2}
+
+	// nothing until built
+	got, err := idx.SemanticSearch(ctx, 10, core.Query{Terms: terms("rocket")})
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	assert.NoError(t, idx.BuildIndex(topicComics))
+
+	// comic 2 has no rocket but is about space
+	got, err = idx.SemanticSearch(ctx, 3, core.Query{Terms: terms("rocket")})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int{1, 2, 3}, ids(got))
+
+	got, err = idx.SemanticSearch(ctx, 2, core.Query{Terms: terms("bake", "recipe")})
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Subset(t, []int{4, 5, 6}, ids(got))
+
+	// bounds are compared in full
+	got, err = idx.SemanticSearch(ctx, 2, core.Query{Terms: terms("oven"), Options: core.Options{MaxID: 5}})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int{4, 5}, ids(got))
+
+	// words of a single comic or unknown ones find nothing
+	got, err = idx.SemanticSearch(ctx, 10, core.Query{Terms: terms("unique", "xylophone")})
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	disabled := &Index{log: slog.Default(), db: mockDB, shards: newShards(1)}
+	assert.NoError(t, disabled.BuildIndex(topicComics))
+	_, err = disabled.SemanticSearch(ctx, 10, core.Query{Terms: terms("rocket")})
+	assert.ErrorIs(t, err, core.ErrBadArguments)
+}
+
+func TestBuildSemantic(t *testing.T) {
+	space := buildSemantic(slog.Default(), topicComics, 2)
+
+	assert.NotContains(t, space.terms, "unique")
+	assert.Len(t, space.basis, len(space.terms))
+	assert.Nil(t, space.vectors[6])
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, space.nodes)
+
+	// builds are the same
+	again := buildSemantic(slog.Default(), topicComics, 2)
+	assert.Equal(t, space.basis, again.basis)
+	assert.Equal(t, space.graph.links, again.graph.links)
+}
+
+func TestBuildSemantic_ZeroWeights(t *testing.T) {
+	// "comic" is in every comic and weighs nothing, comic 3 has no other word
+	comics := []core.Comics{
+		{ID: 1, Source: "xkcd", Keywords: `["comic","rocket","orbit"]`},
+		{ID: 2, Source: "xkcd", Keywords: `["comic","rocket","moon"]`},
+		{ID: 3, Source: "xkcd", Keywords: `["comic"]`},
+		{ID: 4, Source: "xkcd", Keywords: `["comic","orbit","moon"]`},
+	}
+	space := buildSemantic(slog.Default(), comics, 2)
+
+	assert.Nil(t, space.vectors[2])
+	assert.Equal(t, []int{0, 1, 3}, space.nodes)
+	for _, vector := range space.vectors {
+		for _, x := range vector {
+			assert.False(t, math.IsNaN(float64(x)))
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package index
+
+import (
+	"log/slog"
+	"math"
+	"sync"
+
+	"yadro.com/course/search/core"
+)
+
+// shard indexes the comics of a part of the IDs. It is built apart from the
+// other shards, so the IDF of its term vectors is its own; with many comics
+// in every shard it is close to the one of the whole corpus.
+type shard struct {
+	mu sync.RWMutex
+	// storage maps a field and a keyword to the posting list of the
+	// positions in docs. Keywords of comics stored without fields are kept
+	// under the empty field.
+	storage map[string]map[string]postings
+	docs    []core.Comics
+	// vectors are unit TF-IDF vectors of docs over all their fields.
+	vectors []map[string]float64
+}
+
+func newShards(n int) []*shard {
+	shards := make([]*shard, n)
+	for i := range shards {
+		shards[i] = &shard{}
+	}
+	return shards
+}
+
+// build replaces the comics of the shard.
+func (s *shard) build(log *slog.Logger, comics []core.Comics) {
+	storage, docs := buildPostings(log, comics)
+	vectors := termVectors(storage, len(docs))
+
+	s.mu.Lock()
+	s.storage = storage
+	s.docs = docs
+	s.vectors = vectors
+	s.mu.Unlock()
+}
+
+// buildPostings encodes the posting lists of the comics, which get their
+// positions in docs in order so that every list is sorted.
+func buildPostings(log *slog.Logger, comics []core.Comics) (map[string]map[string]postings, []core.Comics) {
+	builders := make(map[string]map[string]*postingsBuilder)
+	docs := make([]core.Comics, 0, len(comics))
+	for _, comic := range comics {
+		fields, err := fieldKeywords(comic)
+		if err != nil {
+			log.Error("failed to unmarshal keywords", "error", err)
+			continue
+		}
+
+		doc := len(docs)
+		docs = append(docs, core.Comics{ID: comic.ID, Source: comic.Source, Published: comic.Published})
+		for field, keywords := range fields {
+			if builders[field] == nil {
+				builders[field] = make(map[string]*postingsBuilder)
+			}
+			for _, word := range keywords {
+				b := builders[field][word]
+				if b == nil {
+					b = &postingsBuilder{}
+					builders[field][word] = b
+				}
+				b.add(doc)
+			}
+		}
+	}
+
+	storage := make(map[string]map[string]postings, len(builders))
+	for field, words := range builders {
+		storage[field] = make(map[string]postings, len(words))
+		for word, b := range words {
+			storage[field][word] = b.postings()
+		}
+	}
+	return storage, docs
+}
+
+// termVectors weighs the words of every doc by TF-IDF, a word found in
+// several fields of a doc counting once per field.
+func termVectors(storage map[string]map[string]postings, total int) []map[string]float64 {
+	vectors := make([]map[string]float64, total)
+	for doc := range vectors {
+		vectors[doc] = make(map[string]float64)
+	}
+
+	freqs := make(map[string]map[int]int)
+	for _, words := range storage {
+		for word, list := range words {
+			if freqs[word] == nil {
+				freqs[word] = make(map[int]int)
+			}
+			for c := list.cursor(); c.next(); {
+				freqs[word][c.doc] += c.tf
+			}
+		}
+	}
+
+	for word, docs := range freqs {
+		idf := math.Log(float64(total) / float64(len(docs)))
+		if idf == 0 {
+			continue
+		}
+		for doc, tf := range docs {
+			vectors[doc][word] = float64(tf) * idf
+		}
+	}
+
+	for _, vector := range vectors {
+		var norm float64
+		for _, weight := range vector {
+			norm += weight * weight
+		}
+		norm = math.Sqrt(norm)
+		for word := range vector {
+			vector[word] /= norm
+		}
+	}
+	return vectors
+}
+
+// termCursor walks the posting list of a query term in a field; n is the
+// position of the term in the query.
+type termCursor struct {
+	cursor
+	n     int
+	term  core.Term
+	field string
+	boost float64
+	done  bool
+}
+
+// rate scores the comics of the shard matching the query and returns the
+// limit best, with the matches that make up their scores if explain is set.
+// The posting lists of the terms are merged doc by doc, so a doc is scored
+// once and kept only if it makes the top.
+func (s *shard) rate(query core.Query, limit int, explain bool) []comicRate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// the cursors of a term are added one after another, so the terms a doc
+	// matches are counted as the runs of its cursors
+	var cursors []*termCursor
+	add := func(n int, term core.Term, field string) {
+		list := s.storage[field][term.Word]
+		if len(list) == 0 {
+			return
+		}
+		c := &termCursor{cursor: list.cursor(), n: n, term: term, field: field, boost: query.Boosts.Of(field)}
+		c.next()
+		cursors = append(cursors, c)
+	}
+	for n, term := range query.Terms {
+		if term.Field != "" {
+			add(n, term, term.Field)
+			continue
+		}
+		for _, field := range fieldOrder {
+			add(n, term, field)
+		}
+	}
+
+	required := query.Scoped()
+	all := query.Options.All
+	top := newTopK(limit, better(query.Options.Sort))
+	for {
+		doc := -1
+		for _, c := range cursors {
+			if !c.done && (doc == -1 || c.doc < doc) {
+				doc = c.doc
+			}
+		}
+		if doc == -1 {
+			break
+		}
+
+		var (
+			score   float64
+			scoped  int
+			terms   int
+			last    = -1
+			matches []core.Match
+		)
+		for _, c := range cursors {
+			if c.done || c.doc != doc {
+				continue
+			}
+			score += float64(c.tf) * c.boost
+			if c.term.Field != "" {
+				scoped++
+			}
+			if c.n != last {
+				terms, last = terms+1, c.n
+			}
+			if explain {
+				matches = append(matches, core.NewMatch(c.term, c.field, c.tf, c.boost))
+			}
+			c.done = !c.next()
+		}
+
+		comic := s.docs[doc]
+		if scoped != required || all && terms != len(query.Terms) || !query.Options.Match(comic.ID, comic.Published) {
+			continue
+		}
+		if explain {
+			comic.Explanation = &core.Explanation{Score: score, Matches: matches}
+		}
+		top.offer(comicRate{comic: comic, score: score})
+	}
+	return top.sorted()
+}
+
+// vector returns the term vector of the comic if the shard holds it.
+func (s *shard) vector(source string, id int) (map[string]float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for doc, comic := range s.docs {
+		if comic.ID == id && comic.Source == source {
+			return s.vectors[doc], true
+		}
+	}
+	return nil, false
+}
+
+// similar returns the limit comics of the shard closest to the origin
+// vector over its words, leaving the origin comic out.
+func (s *shard) similar(source string, id int, origin map[string]float64, words []string, limit int) []comicRate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	candidates := make(map[int]struct{})
+	for _, word := range words {
+		for _, field := range fieldOrder {
+			for c := s.storage[field][word].cursor(); c.next(); {
+				candidates[c.doc] = struct{}{}
+			}
+		}
+	}
+
+	top := newTopK(limit, better(core.SortRelevance))
+	for doc := range candidates {
+		comic := s.docs[doc]
+		if comic.ID == id && comic.Source == source {
+			continue
+		}
+		var score float64
+		for _, word := range words {
+			score += origin[word] * s.vectors[doc][word]
+		}
+		top.offer(comicRate{comic: comic, score: score})
+	}
+	return top.sorted()
+}
@@ -0,0 +1,225 @@
+package index
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+// sparseMatrix is a terms × docs matrix kept by columns, every column the
+// weights of the terms of a doc.
+type sparseMatrix struct {
+	rows int
+	cols [][]weight
+}
+
+type weight struct {
+	row   int
+	value float64
+}
+
+// truncatedSVD returns the k leading left singular vectors of a, as a row
+// of k coordinates per row of a, by the randomized range finder of Halko,
+// Martinsson and Tropp: a is multiplied by a random matrix of a few more
+// than k columns, sharpened by power iterations, and the singular vectors
+// are taken from the eigenvectors of the small Gram matrix of its
+// projection. Fewer vectors are returned if a is of a lower size.
+func truncatedSVD(a sparseMatrix, k int, r *rand.Rand) [][]float64 {
+	const (
+		oversample = 10
+		iterations = 2
+	)
+	l := min(k+oversample, a.rows, len(a.cols))
+	k = min(k, l)
+	if k <= 0 {
+		return make([][]float64, a.rows)
+	}
+
+	// the range of a is sampled by y = a × omega
+	ys := make([][]float64, l)
+	for c := range ys {
+		ys[c] = make([]float64, a.rows)
+	}
+	omega := make([]float64, l)
+	for _, col := range a.cols {
+		for c := range omega {
+			omega[c] = r.NormFloat64()
+		}
+		for _, w := range col {
+			for c := range ys {
+				ys[c][w.row] += w.value * omega[c]
+			}
+		}
+	}
+	orthonormalize(ys)
+
+	for range iterations {
+		zs := a.mulT(ys)
+		orthonormalize(zs)
+		ys = a.mul(zs)
+		orthonormalize(ys)
+	}
+
+	// gram = b × bᵀ for b = yᵀ × a, summed up by the columns of b
+	gram := make([][]float64, l)
+	for p := range gram {
+		gram[p] = make([]float64, l)
+	}
+	b := make([]float64, l)
+	for _, col := range a.cols {
+		for c := range b {
+			b[c] = 0
+			for _, w := range col {
+				b[c] += ys[c][w.row] * w.value
+			}
+		}
+		for p := range b {
+			for q := p; q < l; q++ {
+				gram[p][q] += b[p] * b[q]
+			}
+		}
+	}
+	for p := range gram {
+		for q := p + 1; q < l; q++ {
+			gram[q][p] = gram[p][q]
+		}
+	}
+
+	values, vectors := jacobiEigen(gram)
+	order := make([]int, l)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return values[order[i]] > values[order[j]] })
+
+	u := make([][]float64, a.rows)
+	for t := range u {
+		u[t] = make([]float64, k)
+		for i, e := range order[:k] {
+			for c := range ys {
+				u[t][i] += ys[c][t] * vectors[c][e]
+			}
+		}
+	}
+	return u
+}
+
+// mul returns a × x for the columns x of a len(a.cols) × len(xs) matrix.
+func (a sparseMatrix) mul(xs [][]float64) [][]float64 {
+	ys := make([][]float64, len(xs))
+	for c := range ys {
+		ys[c] = make([]float64, a.rows)
+	}
+	for j, col := range a.cols {
+		for _, w := range col {
+			for c, x := range xs {
+				ys[c][w.row] += w.value * x[j]
+			}
+		}
+	}
+	return ys
+}
+
+// mulT returns aᵀ × y for the columns y of an a.rows × len(ys) matrix.
+func (a sparseMatrix) mulT(ys [][]float64) [][]float64 {
+	zs := make([][]float64, len(ys))
+	for c := range zs {
+		zs[c] = make([]float64, len(a.cols))
+	}
+	for j, col := range a.cols {
+		for _, w := range col {
+			for c, y := range ys {
+				zs[c][j] += w.value * y[w.row]
+			}
+		}
+	}
+	return zs
+}
+
+// orthonormalize makes the vectors orthonormal by the modified Gram-Schmidt
+// process. A vector dependent on the previous ones is zeroed.
+func orthonormalize(vs [][]float64) {
+	for i, v := range vs {
+		for _, u := range vs[:i] {
+			var dot float64
+			for t := range v {
+				dot += v[t] * u[t]
+			}
+			for t := range v {
+				v[t] -= dot * u[t]
+			}
+		}
+
+		var norm float64
+		for _, x := range v {
+			norm += x * x
+		}
+		norm = math.Sqrt(norm)
+		for t := range v {
+			if norm < 1e-10 {
+				v[t] = 0
+			} else {
+				v[t] /= norm
+			}
+		}
+	}
+}
+
+// jacobiEigen returns the eigenvalues of the symmetric matrix a and its
+// eigenvectors as the columns of the second matrix, by the cyclic Jacobi
+// method. The matrix a is destroyed.
+func jacobiEigen(a [][]float64) ([]float64, [][]float64) {
+	n := len(a)
+	v := make([][]float64, n)
+	for i := range v {
+		v[i] = make([]float64, n)
+		v[i][i] = 1
+	}
+
+	for range 100 {
+		var off, diag float64
+		for p := range a {
+			diag += a[p][p] * a[p][p]
+			for q := p + 1; q < n; q++ {
+				off += a[p][q] * a[p][q]
+			}
+		}
+		if off <= 1e-24*diag || off == 0 {
+			break
+		}
+
+		for p := range a {
+			for q := p + 1; q < n; q++ {
+				if a[p][q] == 0 {
+					continue
+				}
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+
+				for k := range a {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p], a[k][q] = c*akp-s*akq, s*akp+c*akq
+				}
+				for k := range a {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k], a[q][k] = c*apk-s*aqk, s*apk+c*aqk
+				}
+				for k := range v {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p], v[k][q] = c*vkp-s*vkq, s*vkp+c*vkq
+				}
+			}
+		}
+	}
+
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = a[i][i]
+	}
+	return values, v
+}
@@ -0,0 +1,82 @@
+package index
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJacobiEigen(t *testing.T) {
+	a := [][]float64{
+		{4, 1, 2},
+		{1, 3, 0},
+		{2, 0, 5},
+	}
+	original := [][]float64{{4, 1, 2}, {1, 3, 0}, {2, 0, 5}}
+
+	values, vectors := jacobiEigen(a)
+
+	// a × v = λ × v for every eigenvector, a column of vectors
+	for e, value := range values {
+		for i := range original {
+			var av float64
+			for j := range original {
+				av += original[i][j] * vectors[j][e]
+			}
+			assert.InDelta(t, value*vectors[i][e], av, 1e-9)
+		}
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	assert.InDelta(t, 12, sorted[0]+sorted[1]+sorted[2], 1e-9)
+}
+
+func TestTruncatedSVD(t *testing.T) {
+	// a rank 2 matrix of 6 terms and 5 docs: two topics mixed in the docs
+	topics := [][]float64{{1, 1, 1, 0, 0, 0}, {0, 0, 1, 1, 1, 1}}
+	mix := [][2]float64{{1, 0}, {0, 1}, {1, 1}, {2, 1}, {0, 3}}
+	a := sparseMatrix{rows: 6, cols: make([][]weight, len(mix))}
+	dense := make([][]float64, len(mix))
+	for j, m := range mix {
+		dense[j] = make([]float64, 6)
+		for row := range dense[j] {
+			dense[j][row] = m[0]*topics[0][row] + m[1]*topics[1][row]
+			if dense[j][row] != 0 {
+				a.cols[j] = append(a.cols[j], weight{row, dense[j][row]})
+			}
+		}
+	}
+
+	u := truncatedSVD(a, 2, rand.New(rand.NewPCG(1, 2)))
+	assert.Len(t, u, 6)
+
+	// the columns of u are orthonormal and span every doc: u × uᵀ × doc = doc
+	for p := range 2 {
+		for q := range 2 {
+			var d float64
+			for row := range u {
+				d += u[row][p] * u[row][q]
+			}
+			assert.InDelta(t, map[bool]float64{true: 1, false: 0}[p == q], d, 1e-9)
+		}
+	}
+	for _, doc := range dense {
+		coords := make([]float64, 2)
+		for row, x := range doc {
+			for i := range coords {
+				coords[i] += u[row][i] * x
+			}
+		}
+		for row, x := range doc {
+			assert.InDelta(t, x, u[row][0]*coords[0]+u[row][1]*coords[1], 1e-9)
+		}
+	}
+
+	// more dimensions than the matrix has are cut down
+	u = truncatedSVD(a, 50, rand.New(rand.NewPCG(1, 2)))
+	assert.Len(t, u[0], 5)
+	assert.False(t, math.IsNaN(u[0][4]))
+}
@@ -0,0 +1,110 @@
+package index
+
+import (
+	"sort"
+
+	"yadro.com/course/search/core"
+)
+
+// DocFreq counts the comics with the term in its field, or in any field if
+// it is not scoped. The shards hold apart comics, so their counts add up.
+func (index *Index) DocFreq(term core.Term) int {
+	total := 0
+	for _, s := range index.shards {
+		total += s.docFreq(term)
+	}
+	return total
+}
+
+// Fuzzy returns the words of any field of the index other than the word
+// itself within the edit distance of it, in order.
+func (index *Index) Fuzzy(word string, distance int) []string {
+	target := []rune(word)
+	seen := make(map[string]struct{})
+	words := []string{}
+	for _, s := range index.shards {
+		s.mu.RLock()
+		for _, field := range fieldOrder {
+			for other := range s.storage[field] {
+				if _, ok := seen[other]; ok || other == word {
+					continue
+				}
+				seen[other] = struct{}{}
+				if editDistance(target, []rune(other), distance) <= distance {
+					words = append(words, other)
+				}
+			}
+		}
+		s.mu.RUnlock()
+	}
+	sort.Strings(words)
+	return words
+}
+
+// docFreq counts the docs of the shard with the term, merging the posting
+// lists of its fields so that a doc with the word in several counts once.
+func (s *shard) docFreq(term core.Term) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fields := fieldOrder
+	if term.Field != "" {
+		fields = []string{term.Field}
+	}
+	var cursors []*cursor
+	for _, field := range fields {
+		c := s.storage[field][term.Word].cursor()
+		if c.next() {
+			cursors = append(cursors, &c)
+		}
+	}
+
+	count := 0
+	for len(cursors) > 0 {
+		doc := cursors[0].doc
+		for _, c := range cursors[1:] {
+			doc = min(doc, c.doc)
+		}
+		count++
+
+		active := cursors[:0]
+		for _, c := range cursors {
+			if c.doc != doc || c.next() {
+				active = append(active, c)
+			}
+		}
+		cursors = active
+	}
+	return count
+}
+
+// editDistance returns the Levenshtein distance of the words, or anything
+// over limit once it is sure to exceed it.
+func editDistance(a, b []rune, limit int) int {
+	if d := len(a) - len(b); d > limit || -d > limit {
+		return limit + 1
+	}
+
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		lowest := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			lowest = min(lowest, curr[j])
+		}
+		if lowest > limit {
+			return limit + 1
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,63 @@
+package index
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"yadro.com/course/search/core"
+)
+
+func TestDocFreq(t *testing.T) {
+	idx := &Index{shards: []*shard{
+		{storage: encode(map[string]map[string][]int{
+			"title":      {"rocket": {0, 2}},
+			"transcript": {"rocket": {1, 2, 3}, "moon": {3}},
+		})},
+		{storage: encode(map[string]map[string][]int{
+			"": {"rocket": {0}},
+		})},
+	}}
+
+	assert.Equal(t, 5, idx.DocFreq(core.Term{Word: "rocket"}))
+	assert.Equal(t, 2, idx.DocFreq(core.Term{Field: core.FieldTitle, Word: "rocket"}))
+	assert.Equal(t, 0, idx.DocFreq(core.Term{Field: core.FieldAlt, Word: "rocket"}))
+	assert.Equal(t, 0, idx.DocFreq(core.Term{Word: "banana"}))
+}
+
+func TestFuzzy(t *testing.T) {
+	idx := &Index{shards: []*shard{
+		{storage: encode(map[string]map[string][]int{
+			"title":      {"rocket": {0}, "pocket": {1}},
+			"transcript": {"rocket": {0}, "rock": {1}},
+		})},
+		{storage: encode(map[string]map[string][]int{
+			"": {"rockets": {0}, "locket": {1}, "socks": {2}},
+		})},
+	}}
+
+	assert.Equal(t, []string{"rock", "rocket"}, idx.Fuzzy("rocke", 1))
+	assert.Equal(t, []string{"locket", "pocket", "rock", "rockets"}, idx.Fuzzy("rocket", 2))
+	assert.Equal(t, []string{}, idx.Fuzzy("banana", 2))
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"rocket", "rocket", 2, 0},
+		{"rocket", "rockte", 2, 2},
+		{"rocket", "pocket", 2, 1},
+		{"kitten", "sitting", 3, 3},
+		{"кот", "кит", 1, 1},
+		{"rocket", "banana", 2, 3},
+		{"rock", "rockets", 2, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, editDistance([]rune(tt.a), []rune(tt.b), tt.limit))
+		})
+	}
+}
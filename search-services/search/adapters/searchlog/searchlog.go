@@ -0,0 +1,261 @@
+package searchlog
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"yadro.com/course/search/core"
+)
+
+const (
+	// queueSize is how many records may wait for the writer, the rest are
+	// dropped so that searches never wait for the log.
+	queueSize = 1024
+	// batchSize is the most records written by one insert.
+	batchSize = 100
+	// flushInterval is the longest a record waits for its batch to fill up.
+	flushInterval = time.Second
+	// purgeInterval is how often the rows older than the retention period
+	// are deleted.
+	purgeInterval = time.Hour
+)
+
+// SearchLog writes the searches to the search_log table in the background.
+type SearchLog struct {
+	log       *slog.Logger
+	conn      *sqlx.DB
+	retention time.Duration
+
+	queue chan core.SearchRecord
+	done  chan struct{}
+	once  sync.Once
+	wg    sync.WaitGroup
+}
+
+// New starts the writer. Rows older than retention are purged, a zero
+// retention keeps them forever.
+func New(log *slog.Logger, address string, retention time.Duration) (*SearchLog, error) {
+	db, err := sqlx.Connect("pgx", address)
+	if err != nil {
+		log.Error("connection problem", "address", address, "error", err)
+		return nil, err
+	}
+
+	searchLog := newSearchLog(log, db, retention)
+	searchLog.wg.Add(1)
+	go searchLog.write()
+	if retention > 0 {
+		searchLog.wg.Add(1)
+		go searchLog.purge()
+	}
+	return searchLog, nil
+}
+
+func newSearchLog(log *slog.Logger, db *sqlx.DB, retention time.Duration) *SearchLog {
+	return &SearchLog{
+		log:       log,
+		conn:      db,
+		retention: retention,
+		queue:     make(chan core.SearchRecord, queueSize),
+		done:      make(chan struct{}),
+	}
+}
+
+// Record queues the record for writing, dropping it if the queue is full.
+func (l *SearchLog) Record(record core.SearchRecord) {
+	select {
+	case l.queue <- record:
+	default:
+		l.log.Warn("search log queue is full, record dropped", "query", record.Query)
+	}
+}
+
+// Close writes the queued records and stops the writer.
+func (l *SearchLog) Close() {
+	l.once.Do(func() { close(l.done) })
+	l.wg.Wait()
+}
+
+func (l *SearchLog) write() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]core.SearchRecord, 0, batchSize)
+	for {
+		select {
+		case record := <-l.queue:
+			batch = append(batch, record)
+			if len(batch) < batchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-l.done:
+			for len(l.queue) > 0 {
+				batch = append(batch, <-l.queue)
+			}
+			l.flush(batch)
+			return
+		}
+		l.flush(batch)
+		batch = batch[:0]
+	}
+}
+
+type row struct {
+	LoggedAt  time.Time `db:"logged_at"`
+	Query     string    `db:"query"`
+	Mode      string    `db:"mode"`
+	Results   int       `db:"results"`
+	LatencyMS float64   `db:"latency_ms"`
+	Client    string    `db:"client"`
+}
+
+// flush inserts the records, losing them if the database fails.
+func (l *SearchLog) flush(records []core.SearchRecord) {
+	if len(records) == 0 {
+		return
+	}
+
+	rows := make([]row, len(records))
+	for i, r := range records {
+		rows[i] = row{
+			LoggedAt:  r.Time,
+			Query:     r.Query,
+			Mode:      string(r.Mode),
+			Results:   r.Results,
+			LatencyMS: float64(r.Latency) / float64(time.Millisecond),
+			Client:    r.Client,
+		}
+	}
+
+	_, err := l.conn.NamedExecContext(context.Background(), `
+	INSERT INTO search_log (logged_at, query, mode, results, latency_ms, client)
+	VALUES (:logged_at, :query, :mode, :results, :latency_ms, :client)
+	`, rows)
+	if err != nil {
+		l.log.Error("failed to write search log", "records", len(rows), "error", err)
+	}
+}
+
+func (l *SearchLog) purge() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := l.Purge(context.Background(), time.Now().Add(-l.retention)); err != nil {
+				l.log.Error("failed to purge search log", "error", err)
+			}
+		case <-l.done:
+			return
+		}
+	}
+}
+
+// Purge deletes the rows logged before the given moment.
+func (l *SearchLog) Purge(ctx context.Context, before time.Time) error {
+	res, err := l.conn.ExecContext(ctx, `DELETE FROM search_log WHERE logged_at < $1`, before)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		l.log.Info("search log purged", "rows", n)
+	}
+	return nil
+}
+
+// TopQueries are the queries searched most since the given moment.
+func (l *SearchLog) TopQueries(ctx context.Context, since time.Time, limit int) ([]core.QueryCount, error) {
+	return l.queries(ctx, `
+	SELECT query, COUNT(*) AS searches, COUNT(DISTINCT client) AS clients
+	FROM search_log
+	WHERE logged_at >= $1 AND query <> ''
+	GROUP BY query
+	ORDER BY searches DESC, query
+	LIMIT $2
+	`, since, limit)
+}
+
+// ZeroResultQueries are the queries that found nothing most often since
+// the given moment. Queries of stop words only are left out like in
+// TopQueries, they are logged empty.
+func (l *SearchLog) ZeroResultQueries(ctx context.Context, since time.Time, limit int) ([]core.QueryCount, error) {
+	return l.queries(ctx, `
+	SELECT query, COUNT(*) AS searches, COUNT(DISTINCT client) AS clients
+	FROM search_log
+	WHERE logged_at >= $1 AND results = 0 AND query <> ''
+	GROUP BY query
+	ORDER BY searches DESC, query
+	LIMIT $2
+	`, since, limit)
+}
+
+func (l *SearchLog) queries(ctx context.Context, query string, since time.Time, limit int) ([]core.QueryCount, error) {
+	var rows []struct {
+		Query    string `db:"query"`
+		Searches int    `db:"searches"`
+		Clients  int    `db:"clients"`
+	}
+	if err := l.conn.SelectContext(ctx, &rows, query, since, limit); err != nil {
+		l.log.Error("failed to count queries", "error", err)
+		return nil, err
+	}
+
+	counts := make([]core.QueryCount, len(rows))
+	for i, r := range rows {
+		counts[i] = core.QueryCount{Query: r.Query, Searches: r.Searches, Clients: r.Clients}
+	}
+	return counts, nil
+}
+
+// Latency are the latency percentiles of every search mode since the
+// given moment.
+func (l *SearchLog) Latency(ctx context.Context, since time.Time) ([]core.LatencyStats, error) {
+	query := `
+	SELECT mode, COUNT(*) AS searches,
+		percentile_cont(0.5) WITHIN GROUP (ORDER BY latency_ms) AS p50,
+		percentile_cont(0.9) WITHIN GROUP (ORDER BY latency_ms) AS p90,
+		percentile_cont(0.99) WITHIN GROUP (ORDER BY latency_ms) AS p99
+	FROM search_log
+	WHERE logged_at >= $1
+	GROUP BY mode
+	ORDER BY mode
+	`
+
+	var rows []struct {
+		Mode     string  `db:"mode"`
+		Searches int     `db:"searches"`
+		P50      float64 `db:"p50"`
+		P90      float64 `db:"p90"`
+		P99      float64 `db:"p99"`
+	}
+	if err := l.conn.SelectContext(ctx, &rows, query, since); err != nil {
+		l.log.Error("failed to get latency percentiles", "error", err)
+		return nil, err
+	}
+
+	stats := make([]core.LatencyStats, len(rows))
+	for i, r := range rows {
+		stats[i] = core.LatencyStats{
+			Mode:     core.Engine(r.Mode),
+			Searches: r.Searches,
+			P50:      milliseconds(r.P50),
+			P90:      milliseconds(r.P90),
+			P99:      milliseconds(r.P99),
+		}
+	}
+	return stats, nil
+}
+
+func milliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
@@ -0,0 +1,160 @@
+package searchlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+	"yadro.com/course/search/core"
+)
+
+func TestSearchLog_Close(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectExec(`INSERT INTO search_log \(logged_at, query, mode, results, latency_ms, client\) VALUES \(\?, \?, \?, \?, \?, \?\),\(\?, \?, \?, \?, \?, \?\)`).
+		WithArgs(at, "cat", "db", 3, 1.5, "3f2a", at, "unicorn", "index", 0, 0.25, "").
+		WillReturnResult(sqlxmock.NewResult(0, 2))
+
+	searchLog := newSearchLog(slog.Default(), db, 0)
+	searchLog.wg.Add(1)
+	go searchLog.write()
+
+	searchLog.Record(core.SearchRecord{Time: at, Query: "cat", Mode: core.EngineDB, Results: 3,
+		Latency: 1500 * time.Microsecond, Client: "3f2a"})
+	searchLog.Record(core.SearchRecord{Time: at, Query: "unicorn", Mode: core.EngineIndex,
+		Latency: 250 * time.Microsecond})
+	searchLog.Close()
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchLog_Record(t *testing.T) {
+	searchLog := newSearchLog(slog.Default(), nil, 0)
+	for i := 0; i < queueSize+1; i++ {
+		searchLog.Record(core.SearchRecord{Query: "cat"})
+	}
+	assert.Len(t, searchLog.queue, queueSize, "records beyond the queue are dropped")
+}
+
+func TestSearchLog_Purge(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	before := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec(`DELETE FROM search_log WHERE logged_at < \$1`).
+		WithArgs(before).
+		WillReturnResult(sqlxmock.NewResult(0, 7))
+
+	searchLog := newSearchLog(slog.Default(), db, 24*time.Hour)
+	assert.NoError(t, searchLog.Purge(context.Background(), before))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchLog_Queries(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	searchLog := newSearchLog(slog.Default(), db, 0)
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		query   func() ([]core.QueryCount, error)
+		mock    func()
+		want    []core.QueryCount
+		wantErr bool
+	}{
+		{
+			name: "top queries",
+			query: func() ([]core.QueryCount, error) {
+				return searchLog.TopQueries(context.Background(), since, 10)
+			},
+			mock: func() {
+				mock.ExpectQuery(`SELECT query, COUNT\(\*\) AS searches, COUNT\(DISTINCT client\) AS clients FROM search_log WHERE logged_at >= \$1 AND query <> '' GROUP BY query ORDER BY searches DESC, query LIMIT \$2`).
+					WithArgs(since, 10).
+					WillReturnRows(sqlxmock.NewRows([]string{"query", "searches", "clients"}).
+						AddRow("cat", 12, 4).
+						AddRow("christma tree", 3, 3))
+			},
+			want: []core.QueryCount{
+				{Query: "cat", Searches: 12, Clients: 4},
+				{Query: "christma tree", Searches: 3, Clients: 3},
+			},
+		},
+		{
+			name: "zero-result queries",
+			query: func() ([]core.QueryCount, error) {
+				return searchLog.ZeroResultQueries(context.Background(), since, 5)
+			},
+			mock: func() {
+				mock.ExpectQuery(`WHERE logged_at >= \$1 AND results = 0 AND query <> '' GROUP BY query`).
+					WithArgs(since, 5).
+					WillReturnRows(sqlxmock.NewRows([]string{"query", "searches", "clients"}).
+						AddRow("unicorn", 2, 1))
+			},
+			want: []core.QueryCount{{Query: "unicorn", Searches: 2, Clients: 1}},
+		},
+		{
+			name: "db error",
+			query: func() ([]core.QueryCount, error) {
+				return searchLog.TopQueries(context.Background(), since, 10)
+			},
+			mock: func() {
+				mock.ExpectQuery(`FROM search_log`).WillReturnError(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := tt.query()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("query error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSearchLog_Latency(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`percentile_cont\(0.5\) WITHIN GROUP \(ORDER BY latency_ms\) AS p50, .* FROM search_log WHERE logged_at >= \$1 GROUP BY mode`).
+		WithArgs(since).
+		WillReturnRows(sqlxmock.NewRows([]string{"mode", "searches", "p50", "p90", "p99"}).
+			AddRow("db", 40, 4.5, 12.0, 30.25).
+			AddRow("index", 10, 0.5, 1.0, 2.0))
+
+	searchLog := newSearchLog(slog.Default(), db, 0)
+	got, err := searchLog.Latency(context.Background(), since)
+	assert.NoError(t, err)
+	assert.Equal(t, []core.LatencyStats{
+		{Mode: core.EngineDB, Searches: 40, P50: 4500 * time.Microsecond, P90: 12 * time.Millisecond, P99: 30250 * time.Microsecond},
+		{Mode: core.EngineIndex, Searches: 10, P50: 500 * time.Microsecond, P90: time.Millisecond, P99: 2 * time.Millisecond},
+	}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
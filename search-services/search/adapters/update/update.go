@@ -0,0 +1,63 @@
+package update
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+	updatepb "yadro.com/course/proto/update"
+)
+
+// retryInterval is the wait before watching again after the stream broke.
+const retryInterval = 5 * time.Second
+
+type Client struct {
+	log    *slog.Logger
+	client updatepb.UpdateClient
+}
+
+func NewClient(address string, log *slog.Logger) (*Client, error) {
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		client: updatepb.NewUpdateClient(conn),
+		log:    log,
+	}, nil
+}
+
+// Watch calls changed with the reason of every change the update service
+// reports until ctx is done. The changes made while the stream is broken
+// are lost, so changed is called on every reconnection too.
+func (c Client) Watch(ctx context.Context, changed func(reason string)) {
+	connected := false
+	for ctx.Err() == nil {
+		stream, err := c.client.Watch(ctx, &emptypb.Empty{})
+		if err == nil {
+			if connected {
+				changed("reconnected")
+			}
+			connected = true
+			for {
+				var change *updatepb.Change
+				if change, err = stream.Recv(); err != nil {
+					break
+				}
+				changed(change.GetReason())
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		c.log.Warn("failed to watch updates, retrying", "error", err)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(retryInterval):
+		}
+	}
+}
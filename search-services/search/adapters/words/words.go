@@ -2,11 +2,13 @@ package words
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	wordspb "yadro.com/course/proto/words"
+	"yadro.com/course/search/core"
 )
 
 type Client struct {
@@ -34,6 +36,31 @@ func (c Client) Norm(ctx context.Context, phrase string) ([]string, error) {
 	return resp.Words, nil
 }
 
+func (c Client) Tokens(ctx context.Context, texts []string) ([][]core.Token, error) {
+	resp, err := c.client.Tokens(ctx, &wordspb.TextsRequest{Texts: texts})
+	if err != nil {
+		c.log.Error("failed to tokenize texts", "error", err)
+		return nil, err
+	}
+	if len(resp.GetTexts()) != len(texts) {
+		return nil, fmt.Errorf("tokenized %d texts of %d", len(resp.GetTexts()), len(texts))
+	}
+
+	out := make([][]core.Token, len(texts))
+	for i, text := range resp.GetTexts() {
+		tokens := make([]core.Token, len(text.GetTokens()))
+		for j, token := range text.GetTokens() {
+			tokens[j] = core.Token{
+				Stem:  token.GetStem(),
+				Start: int(token.GetStart()),
+				End:   int(token.GetEnd()),
+			}
+		}
+		out[i] = tokens
+	}
+	return out, nil
+}
+
 func (c Client) Ping(ctx context.Context) error {
 	_, err := c.client.Ping(ctx, nil)
 	if err != nil {
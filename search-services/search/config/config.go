@@ -7,12 +7,45 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
+// Boosts weigh a keyword match in every field of a comic when ranking.
+type Boosts struct {
+	Title      float64 `yaml:"title" env:"BOOST_TITLE" env-default:"3"`
+	Alt        float64 `yaml:"alt" env:"BOOST_ALT" env-default:"2"`
+	Transcript float64 `yaml:"transcript" env:"BOOST_TRANSCRIPT" env-default:"1"`
+	Tag        float64 `yaml:"tag" env:"BOOST_TAG" env-default:"4"`
+}
+
+// Cache keeps the results of recent searches, zero size disables it.
+type Cache struct {
+	Size int           `yaml:"size" env:"CACHE_SIZE" env-default:"1000"`
+	TTL  time.Duration `yaml:"ttl" env:"CACHE_TTL" env-default:"5m"`
+}
+
 type Config struct {
-	LogLevel     string        `yaml:"log_level" env:"LOG_LEVEL" env-default:"DEBUG"`
-	Address      string        `yaml:"search_address" env:"SEARCH_ADDRESS" env-default:"localhost:83"`
-	DBAddress    string        `yaml:"db_address" env:"DB_ADDRESS" env-default:"localhost:82"`
-	WordsAddress string        `yaml:"words_address" env:"WORDS_ADDRESS" env-default:"localhost:81"`
-	IndexTTL     time.Duration `yaml:"index_ttl" env:"INDEX_TTL"`
+	LogLevel     string `yaml:"log_level" env:"LOG_LEVEL" env-default:"DEBUG"`
+	Address      string `yaml:"search_address" env:"SEARCH_ADDRESS" env-default:"localhost:83"`
+	DBAddress    string `yaml:"db_address" env:"DB_ADDRESS" env-default:"localhost:82"`
+	WordsAddress string `yaml:"words_address" env:"WORDS_ADDRESS" env-default:"localhost:81"`
+	// UpdateAddress is watched for changes of the comics to purge the
+	// cache, empty not to watch.
+	UpdateAddress string        `yaml:"update_address" env:"UPDATE_ADDRESS"`
+	IndexTTL      time.Duration `yaml:"index_ttl" env:"INDEX_TTL"`
+	// IndexShards partition the index by comic ID, searched in parallel.
+	IndexShards int    `yaml:"index_shards" env:"INDEX_SHARDS" env-default:"1"`
+	Boosts      Boosts `yaml:"boosts"`
+	Cache       Cache  `yaml:"cache"`
+	// SemanticDimensions of the semantic space of the index, zero disables
+	// the semantic search.
+	SemanticDimensions int `yaml:"semantic_dimensions" env:"SEMANTIC_DIMENSIONS" env-default:"64"`
+	// HybridTimeout bounds every engine of a hybrid search, the ones late
+	// are left out of it.
+	HybridTimeout time.Duration `yaml:"hybrid_timeout" env:"HYBRID_TIMEOUT" env-default:"500ms"`
+	// SearchLogRetention is how long the searches are kept in search_log,
+	// zero keeps them forever.
+	SearchLogRetention time.Duration `yaml:"search_log_retention" env:"SEARCH_LOG_RETENTION" env-default:"720h"`
+	// CurationReload is how often the curation is reloaded to pick up the
+	// changes made through the other instances.
+	CurationReload time.Duration `yaml:"curation_reload" env:"CURATION_RELOAD" env-default:"1m"`
 }
 
 func MustLoad(configPath string) Config {
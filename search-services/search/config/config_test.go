@@ -16,6 +16,10 @@ search_address: localhost:83
 db_address: localhost:82
 words_address: localhost:81
 index_ttl: 120s
+index_shards: 8
+boosts:
+  title: 5
+  alt: 1.5
 `
 
 	tmpFile, err := os.CreateTemp("", "test_config_*.yaml")
@@ -34,6 +38,8 @@ index_ttl: 120s
 	assert.Equal(t, "localhost:82", cfg.DBAddress)
 	assert.Equal(t, "localhost:81", cfg.WordsAddress)
 	assert.Equal(t, 120*time.Second, cfg.IndexTTL)
+	assert.Equal(t, 8, cfg.IndexShards)
+	assert.Equal(t, Boosts{Title: 5, Alt: 1.5, Transcript: 1, Tag: 4}, cfg.Boosts)
 }
 
 func TestMustLoad_Defaults(t *testing.T) {
@@ -54,6 +60,12 @@ func TestMustLoad_Defaults(t *testing.T) {
 	assert.Equal(t, "localhost:83", cfg.Address)
 	assert.Equal(t, "localhost:82", cfg.DBAddress)
 	assert.Equal(t, "localhost:81", cfg.WordsAddress)
+	assert.Equal(t, Boosts{Title: 3, Alt: 2, Transcript: 1, Tag: 4}, cfg.Boosts)
+	assert.Equal(t, "", cfg.UpdateAddress)
+	assert.Equal(t, 1, cfg.IndexShards)
+	assert.Equal(t, 500*time.Millisecond, cfg.HybridTimeout)
+	assert.Equal(t, 64, cfg.SemanticDimensions)
+	assert.Equal(t, Cache{Size: 1000, TTL: 5 * time.Minute}, cfg.Cache)
 }
 
 func TestMustLoad_EnvVars(t *testing.T) {
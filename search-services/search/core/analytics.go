@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SearchRecord is a search made, as the search log keeps it.
+type SearchRecord struct {
+	Time time.Time
+	// Query is the normalized query, see Query.String.
+	Query   string
+	Mode    Engine
+	Results int
+	Latency time.Duration
+	// Client is a hash identifying who searched, empty if unknown.
+	Client string
+}
+
+// QueryCount is how many searches of a query were made and by how many
+// distinct clients.
+type QueryCount struct {
+	Query    string
+	Searches int
+	Clients  int
+}
+
+// LatencyStats are the latency percentiles of the searches of a mode.
+type LatencyStats struct {
+	Mode     Engine
+	Searches int
+	P50      time.Duration
+	P90      time.Duration
+	P99      time.Duration
+}
+
+// Analytics sum up the searches made since a moment.
+type Analytics struct {
+	Top         []QueryCount
+	ZeroResults []QueryCount
+	Latency     []LatencyStats
+}
+
+// String is the query with its terms sorted, so that the same words typed
+// in any order count as one query. Scoped terms are written as field:word.
+func (q Query) String() string {
+	terms := make([]string, len(q.Terms))
+	for i, term := range q.Terms {
+		terms[i] = term.Word
+		if term.Field != "" {
+			terms[i] = term.Field + ":" + term.Word
+		}
+	}
+	sort.Strings(terms)
+	return strings.Join(terms, " ")
+}
+
+// record passes a successful search to the search log, if there is one.
+func (s Service) record(mode Engine, query string, opts Options, results int, start time.Time) {
+	if s.searchLog == nil {
+		return
+	}
+	s.searchLog.Record(SearchRecord{
+		Time:    start,
+		Query:   query,
+		Mode:    mode,
+		Results: results,
+		Latency: time.Since(start),
+		Client:  opts.Client,
+	})
+}
+
+// Analytics sums up the search log since the given moment, listing up to
+// limit queries in each top.
+func (s Service) Analytics(ctx context.Context, since time.Time, limit int) (Analytics, error) {
+	if s.searchLog == nil {
+		return Analytics{}, nil
+	}
+
+	top, err := s.searchLog.TopQueries(ctx, since, limit)
+	if err != nil {
+		s.log.Error("failed to get top queries", "error", err)
+		return Analytics{}, err
+	}
+
+	zero, err := s.searchLog.ZeroResultQueries(ctx, since, limit)
+	if err != nil {
+		s.log.Error("failed to get zero-result queries", "error", err)
+		return Analytics{}, err
+	}
+
+	latency, err := s.searchLog.Latency(ctx, since)
+	if err != nil {
+		s.log.Error("failed to get search latency", "error", err)
+		return Analytics{}, err
+	}
+
+	return Analytics{Top: top, ZeroResults: zero, Latency: latency}, nil
+}
@@ -0,0 +1,190 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestQuery_String(t *testing.T) {
+	tests := []struct {
+		name  string
+		query Query
+		want  string
+	}{
+		{name: "empty", query: Query{}, want: ""},
+		{name: "sorted", query: unscoped("tree", "christma", "binari"), want: "binari christma tree"},
+		{
+			name:  "scoped",
+			query: Query{Terms: []Term{{Word: "snake"}, {Field: FieldTitle, Word: "python"}}},
+			want:  "snake title:python",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.query.String())
+		})
+	}
+}
+
+func TestService_Record(t *testing.T) {
+	ctx := context.Background()
+	opts := Options{Client: "3f2a"}
+
+	tests := []struct {
+		name      string
+		search    func(Service) error
+		mockSetup func(*MockDB, *MockIndex, *MockFullText, *MockWords)
+		want      *SearchRecord
+	}{
+		{
+			name: "db search",
+			search: func(s Service) error {
+				_, err := s.Search(ctx, 5, "dogs cats", opts)
+				return err
+			},
+			mockSetup: func(db *MockDB, _ *MockIndex, _ *MockFullText, words *MockWords) {
+				words.On("Norm", ctx, "dogs cats").Return([]string{"dog", "cat"}, nil)
+				query := unscoped("dog", "cat")
+				query.Options = opts
+				db.On("SearchComics", ctx, 5, query).Return([]Comics{{ID: 1}}, nil)
+			},
+			want: &SearchRecord{Query: "cat dog", Mode: EngineDB, Results: 1, Client: "3f2a"},
+		},
+		{
+			name: "index search found nothing",
+			search: func(s Service) error {
+				_, err := s.IndexSearch(ctx, 5, "unicorn", opts)
+				return err
+			},
+			mockSetup: func(_ *MockDB, index *MockIndex, _ *MockFullText, words *MockWords) {
+				words.On("Norm", ctx, "unicorn").Return([]string{"unicorn"}, nil)
+				query := unscoped("unicorn")
+				query.Options = opts
+				index.On("SearchByIndex", ctx, 5, query).Return([]Comics{}, nil)
+			},
+			want: &SearchRecord{Query: "unicorn", Mode: EngineIndex, Results: 0, Client: "3f2a"},
+		},
+		{
+			name: "full-text search keeps the phrase it cannot normalize",
+			search: func(s Service) error {
+				_, err := s.FTSSearch(ctx, 5, "cats", opts)
+				return err
+			},
+			mockSetup: func(_ *MockDB, _ *MockIndex, fts *MockFullText, words *MockWords) {
+				fts.On("Search", ctx, 5, "cats", opts).Return([]Comics{{ID: 2}}, nil)
+				words.On("Norm", ctx, "cats").Return([]string(nil), errors.New("words error"))
+			},
+			want: &SearchRecord{Query: "cats", Mode: EngineFTS, Results: 1, Client: "3f2a"},
+		},
+		{
+			name: "failed search is not recorded",
+			search: func(s Service) error {
+				_, err := s.Search(ctx, 5, "cats", opts)
+				return err
+			},
+			mockSetup: func(db *MockDB, _ *MockIndex, _ *MockFullText, words *MockWords) {
+				words.On("Norm", ctx, "cats").Return([]string{"cat"}, nil)
+				query := unscoped("cat")
+				query.Options = opts
+				db.On("SearchComics", ctx, 5, query).Return([]Comics(nil), errors.New("db error"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			mockIndex := new(MockIndex)
+			mockFTS := new(MockFullText)
+			mockWords := new(MockWords)
+			mockSearchLog := new(MockSearchLog)
+			tt.mockSetup(mockDB, mockIndex, mockFTS, mockWords)
+			mockDB.On("GetTexts", ctx, mock.Anything).Return(map[ComicID]Text{}, nil).Maybe()
+
+			var got *SearchRecord
+			mockSearchLog.On("Record", mock.Anything).Run(func(args mock.Arguments) {
+				record := args.Get(0).(SearchRecord)
+				got = &record
+			}).Maybe()
+
+			service := Service{
+				log:       slog.Default(),
+				db:        mockDB,
+				words:     mockWords,
+				index:     mockIndex,
+				fts:       mockFTS,
+				searchLog: mockSearchLog,
+			}
+			err := tt.search(service)
+
+			if tt.want == nil {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.NotNil(t, got) {
+				assert.False(t, got.Time.IsZero())
+				assert.GreaterOrEqual(t, got.Latency, time.Duration(0))
+				got.Time, got.Latency = time.Time{}, 0
+				assert.Equal(t, *tt.want, *got)
+			}
+		})
+	}
+}
+
+func TestService_Analytics(t *testing.T) {
+	ctx := context.Background()
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	top := []QueryCount{{Query: "cat", Searches: 10, Clients: 3}}
+	zero := []QueryCount{{Query: "unicorn", Searches: 2, Clients: 1}}
+	latency := []LatencyStats{{Mode: EngineDB, Searches: 12, P50: 5 * time.Millisecond, P90: 9 * time.Millisecond, P99: 20 * time.Millisecond}}
+
+	tests := []struct {
+		name      string
+		mockSetup func(*MockSearchLog)
+		want      Analytics
+		wantErr   bool
+	}{
+		{
+			name: "all sections",
+			mockSetup: func(m *MockSearchLog) {
+				m.On("TopQueries", ctx, since, 5).Return(top, nil)
+				m.On("ZeroResultQueries", ctx, since, 5).Return(zero, nil)
+				m.On("Latency", ctx, since).Return(latency, nil)
+			},
+			want: Analytics{Top: top, ZeroResults: zero, Latency: latency},
+		},
+		{
+			name: "log error",
+			mockSetup: func(m *MockSearchLog) {
+				m.On("TopQueries", ctx, since, 5).Return(top, nil)
+				m.On("ZeroResultQueries", ctx, since, 5).Return([]QueryCount(nil), errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSearchLog := new(MockSearchLog)
+			tt.mockSetup(mockSearchLog)
+
+			service := Service{log: slog.Default(), searchLog: mockSearchLog}
+			got, err := service.Analytics(ctx, since, 5)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Analytics error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+			mockSearchLog.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CacheStats count the lookups of the result cache since the start.
+type CacheStats struct {
+	Hits      int
+	Misses    int
+	Evictions int
+	// Purges counts the times the whole cache was dropped.
+	Purges   int
+	Size     int
+	Capacity int
+}
+
+// cached returns the comics search finds, looking them up in the cache
+// first. The key is made of the normalized query, the mode, the limit and
+// the filters, so a search is cached whatever the order of its words. The
+// cache is purged when the index gets a new generation, and the generation
+// is a part of the key too, so that a search started before a rebuild
+// cannot fill the cache with stale comics.
+func (s Service) cached(mode Engine, limit int, query string, opts Options, search func() ([]Comics, error)) ([]Comics, error) {
+	key, ok := s.cacheKey(mode, limit, query, opts)
+	if !ok {
+		return search()
+	}
+	if comics, ok := s.cache.Get(key); ok {
+		return comics, nil
+	}
+
+	comics, err := search()
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Put(key, comics)
+	return comics, nil
+}
+
+// cacheKey returns the key of the search in the cache, false if it is not
+// to be cached.
+func (s Service) cacheKey(mode Engine, limit int, query string, opts Options) (string, bool) {
+	if s.cache == nil || opts.NoCache {
+		return "", false
+	}
+
+	generation := s.index.Generation()
+	if s.generation.Swap(generation) != generation {
+		s.cache.Purge()
+		s.log.Debug("search cache purged", "reason", "index rebuilt", "generation", generation)
+	}
+
+	return fmt.Sprintf("%d|%s|%d|%q|%s|%s|%d|%d|%s|%t", generation, mode, limit, query,
+		moment(opts.From), moment(opts.To), opts.MinID, opts.MaxID, opts.Sort, opts.All), true
+}
+
+// moment formats a bound of the options, empty if it is open.
+func moment(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// Invalidate drops the cached searches since the comics changed.
+func (s Service) Invalidate(reason string) {
+	if s.cache == nil {
+		return
+	}
+	s.cache.Purge()
+	s.log.Debug("search cache purged", "reason", reason)
+}
+
+func (s Service) CacheStats(_ context.Context) (CacheStats, error) {
+	if s.cache == nil {
+		return CacheStats{}, nil
+	}
+	return s.cache.Stats(), nil
+}
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestService_Cached(t *testing.T) {
+	found := []Comics{{ID: 1, URL: "url1"}}
+	key := `3|index|5|"cat dog"|||0|0||false`
+
+	tests := []struct {
+		name       string
+		opts       Options
+		generation uint64
+		setupCache func(c *MockCache)
+		searchErr  error
+		wantSearch bool
+		want       []Comics
+		wantErr    bool
+	}{
+		{
+			name:       "hit",
+			generation: 3,
+			setupCache: func(c *MockCache) {
+				c.On("Get", key).Return(found, true)
+			},
+			want: found,
+		},
+		{
+			name:       "miss is cached",
+			generation: 3,
+			setupCache: func(c *MockCache) {
+				c.On("Get", key).Return([]Comics(nil), false)
+				c.On("Put", key, found)
+			},
+			wantSearch: true,
+			want:       found,
+		},
+		{
+			name:       "failed search is not cached",
+			generation: 3,
+			setupCache: func(c *MockCache) {
+				c.On("Get", key).Return([]Comics(nil), false)
+			},
+			searchErr:  errors.New("failed to search"),
+			wantSearch: true,
+			wantErr:    true,
+		},
+		{
+			name:       "new index generation purges",
+			generation: 4,
+			setupCache: func(c *MockCache) {
+				c.On("Purge")
+				c.On("Get", `4|index|5|"cat dog"|||0|0||false`).Return([]Comics(nil), false)
+				c.On("Put", `4|index|5|"cat dog"|||0|0||false`, found)
+			},
+			wantSearch: true,
+			want:       found,
+		},
+		{
+			name:       "filters and sort are a part of the key",
+			opts:       Options{From: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), MinID: 10, Sort: SortNewest, Client: "abc"},
+			generation: 3,
+			setupCache: func(c *MockCache) {
+				c.On("Get", `3|index|5|"cat dog"|2024-01-02T00:00:00Z||10|0|newest|false`).Return(found, true)
+			},
+			want: found,
+		},
+		{
+			name:       "no cache",
+			opts:       Options{NoCache: true},
+			setupCache: func(c *MockCache) {},
+			wantSearch: true,
+			want:       found,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockIndex := new(MockIndex)
+			mockIndex.On("Generation").Return(tt.generation).Maybe()
+			mockCache := new(MockCache)
+			tt.setupCache(mockCache)
+
+			service := &Service{
+				log:        slog.Default(),
+				index:      mockIndex,
+				cache:      mockCache,
+				generation: new(atomic.Uint64),
+			}
+			service.generation.Store(3)
+
+			searched := false
+			got, err := service.cached(EngineIndex, 5, "cat dog", tt.opts, func() ([]Comics, error) {
+				searched = true
+				if tt.searchErr != nil {
+					return nil, tt.searchErr
+				}
+				return found, nil
+			})
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.Equal(t, tt.wantSearch, searched)
+			mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_IndexSearchCached(t *testing.T) {
+	ctx := context.Background()
+	mockWords := new(MockWords)
+	mockIndex := new(MockIndex)
+	mockCache := new(MockCache)
+
+	// words in another order make the same normalized query
+	mockWords.On("Norm", ctx, "dogs cats").Return([]string{"dog", "cat"}, nil)
+	mockIndex.On("Generation").Return(uint64(0))
+	mockCache.On("Get", `0|index|5|"cat dog"|||0|0||false`).Return([]Comics{{ID: 1}}, true)
+
+	service := &Service{
+		log:        slog.Default(),
+		words:      mockWords,
+		index:      mockIndex,
+		cache:      mockCache,
+		generation: new(atomic.Uint64),
+	}
+
+	got, err := service.IndexSearch(ctx, 5, "dogs cats", Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, Found{Comics: []Comics{{ID: 1}}}, got)
+	mockIndex.AssertNotCalled(t, "SearchByIndex", mock.Anything, mock.Anything, mock.Anything)
+	mockCache.AssertExpectations(t)
+}
+
+func TestService_Invalidate(t *testing.T) {
+	mockCache := new(MockCache)
+	mockCache.On("Purge").Once()
+	mockCache.On("Stats").Return(CacheStats{Purges: 1, Capacity: 10})
+
+	service := &Service{log: slog.Default(), cache: mockCache}
+	service.Invalidate("update")
+
+	stats, err := service.CacheStats(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, CacheStats{Purges: 1, Capacity: 10}, stats)
+	mockCache.AssertExpectations(t)
+
+	// without a cache there is nothing to purge
+	service = &Service{log: slog.Default()}
+	service.Invalidate("update")
+	stats, err = service.CacheStats(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, CacheStats{}, stats)
+}
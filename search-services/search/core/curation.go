@@ -0,0 +1,324 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ComicID identifies a comic among the sources.
+type ComicID struct {
+	Source string
+	ID     int
+}
+
+// Pin puts the comics first in the results of the query, in order.
+type Pin struct {
+	// Query is normalized, see Query.String.
+	Query  string
+	Comics []ComicID
+}
+
+// Rewrite makes a search of the query search the phrase instead.
+type Rewrite struct {
+	// Query is normalized, see Query.String.
+	Query  string
+	Phrase string
+}
+
+// Curation is what the editors set the searches up with: the comics pinned
+// for the queries, the queries rewritten and the comics never found.
+type Curation struct {
+	Pins     []Pin
+	Rewrites []Rewrite
+	Blocked  []ComicID
+}
+
+// curated is the curation ready for the searches. It is swapped as a whole
+// on every reload, so a search sees one version of it.
+type curated struct {
+	pins     map[string][]ComicID
+	rewrites map[string]string
+	blocked  map[ComicID]struct{}
+}
+
+func newCurated(curation Curation) *curated {
+	c := &curated{
+		pins:     make(map[string][]ComicID, len(curation.Pins)),
+		rewrites: make(map[string]string, len(curation.Rewrites)),
+		blocked:  make(map[ComicID]struct{}, len(curation.Blocked)),
+	}
+	for _, pin := range curation.Pins {
+		c.pins[pin.Query] = pin.Comics
+	}
+	for _, rewrite := range curation.Rewrites {
+		c.rewrites[rewrite.Query] = rewrite.Phrase
+	}
+	for _, id := range curation.Blocked {
+		c.blocked[id] = struct{}{}
+	}
+	return c
+}
+
+// fetch is how many comics to search for limit of them to be left when the
+// blocked ones are dropped.
+func (c *curated) fetch(limit int) int {
+	return limit + len(c.blocked)
+}
+
+// unblocked drops the blocked comics and cuts the rest to the limit.
+func (c *curated) unblocked(comics []Comics, limit int) []Comics {
+	out := make([]Comics, 0, min(max(limit, 0), len(comics)))
+	for _, comic := range comics {
+		if len(out) == limit {
+			break
+		}
+		if _, ok := c.blocked[ComicID{comic.Source, comic.ID}]; !ok {
+			out = append(out, comic)
+		}
+	}
+	return out
+}
+
+// errNoCurations is returned by the edits of the curation if there is no
+// store for it.
+var errNoCurations = fmt.Errorf("%w: curation is not set up", ErrBadArguments)
+
+// curation returns the curation the searches are to use now, none until it
+// is loaded.
+func (s Service) curation() *curated {
+	if s.curated != nil {
+		if c := s.curated.Load(); c != nil {
+			return c
+		}
+	}
+	return newCurated(Curation{})
+}
+
+// ReloadCuration loads the curation from the store for the searches to use
+// from then on.
+func (s Service) ReloadCuration(ctx context.Context) error {
+	if s.curations == nil {
+		return nil
+	}
+	curation, err := s.curations.Load(ctx)
+	if err != nil {
+		s.log.Error("failed to load curation", "error", err)
+		return err
+	}
+	s.curated.Store(newCurated(curation))
+	s.log.Debug("curation reloaded", "pins", len(curation.Pins), "rewrites", len(curation.Rewrites),
+		"blocked", len(curation.Blocked))
+	return nil
+}
+
+// WatchCuration reloads the curation every interval until the context is
+// done, so that the changes made by the other instances show up too.
+func (s Service) WatchCuration(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = s.ReloadCuration(ctx)
+		}
+	}
+}
+
+// rewrite returns the query of the phrase the query is rewritten to, with
+// the same options, or the query itself if it is not rewritten.
+func (s Service) rewrite(ctx context.Context, c *curated, query Query) (Query, error) {
+	phrase, ok := c.rewrites[query.String()]
+	if !ok {
+		return query, nil
+	}
+	rewritten, err := s.query(ctx, phrase)
+	if err != nil {
+		return Query{}, err
+	}
+	rewritten.Options = query.Options
+	return rewritten, nil
+}
+
+// curate drops the blocked comics found and puts the comics pinned for the
+// query as typed, or else as rewritten, first. The pins are for the
+// searches ranked by relevance only, and not narrowed down: a pinned comic
+// may be out of the bounds.
+func (s Service) curate(ctx context.Context, c *curated, typed, query Query, comics []Comics, limit int) []Comics {
+	opts := query.Options
+	pins, ok := c.pins[typed.String()]
+	if !ok {
+		pins = c.pins[query.String()]
+	}
+	if len(pins) == 0 || opts.Sort != SortRelevance && opts.Sort != "" ||
+		!opts.From.IsZero() || !opts.To.IsZero() || opts.MinID != 0 || opts.MaxID != 0 {
+		return c.unblocked(comics, limit)
+	}
+
+	found := make(map[ComicID]Comics, len(comics))
+	for _, comic := range comics {
+		found[ComicID{comic.Source, comic.ID}] = comic
+	}
+
+	pinned := make([]Comics, 0, len(pins)+len(comics))
+	seen := make(map[ComicID]struct{}, len(pins))
+	for _, id := range pins {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		if comic, ok := found[id]; ok {
+			pinned = append(pinned, comic)
+			continue
+		}
+		url, err := s.db.GetImageURL(ctx, id.Source, id.ID)
+		if err != nil || url == "" {
+			s.log.Warn("pinned comic skipped", "query", query.String(), "source", id.Source, "comic_id", id.ID, "error", err)
+			continue
+		}
+		pinned = append(pinned, s.withSnippets(ctx, []Comics{{ID: id.ID, Source: id.Source, URL: url}}, query)...)
+	}
+	for _, comic := range comics {
+		if _, ok := seen[ComicID{comic.Source, comic.ID}]; !ok {
+			pinned = append(pinned, comic)
+		}
+	}
+	return c.unblocked(pinned, limit)
+}
+
+// Curation returns the curation as the store keeps it.
+func (s Service) Curation(ctx context.Context) (Curation, error) {
+	if s.curations == nil {
+		return Curation{}, nil
+	}
+	curation, err := s.curations.Load(ctx)
+	if err != nil {
+		s.log.Error("failed to load curation", "error", err)
+		return Curation{}, err
+	}
+	return curation, nil
+}
+
+// normalized returns the query of the phrase as the curation keys it.
+func (s Service) normalized(ctx context.Context, phrase string) (string, error) {
+	query, err := s.query(ctx, phrase)
+	if err != nil {
+		s.log.Error("failed to normalize req", "error", err)
+		return "", err
+	}
+	if len(query.Terms) == 0 {
+		return "", fmt.Errorf("%w: %q has no words to search", ErrBadArguments, phrase)
+	}
+	return query.String(), nil
+}
+
+// PutPin pins the comics for the phrase, replacing the comics pinned for it
+// before.
+func (s Service) PutPin(ctx context.Context, phrase string, comics []ComicID) (Pin, error) {
+	if s.curations == nil {
+		return Pin{}, errNoCurations
+	}
+	if len(comics) == 0 {
+		return Pin{}, fmt.Errorf("%w: no comics to pin", ErrBadArguments)
+	}
+	for _, id := range comics {
+		if id.Source == "" || id.ID < 1 {
+			return Pin{}, fmt.Errorf("%w: bad comic %d of %q", ErrBadArguments, id.ID, id.Source)
+		}
+	}
+	query, err := s.normalized(ctx, phrase)
+	if err != nil {
+		return Pin{}, err
+	}
+
+	pin := Pin{Query: query, Comics: comics}
+	if err := s.curations.PutPin(ctx, pin); err != nil {
+		s.log.Error("failed to put pin", "query", query, "error", err)
+		return Pin{}, err
+	}
+	return pin, s.ReloadCuration(ctx)
+}
+
+// DeletePin returns ErrNotFound if nothing is pinned for the phrase.
+func (s Service) DeletePin(ctx context.Context, phrase string) error {
+	if s.curations == nil {
+		return errNoCurations
+	}
+	query, err := s.normalized(ctx, phrase)
+	if err != nil {
+		return err
+	}
+	if err := s.curations.DeletePin(ctx, query); err != nil {
+		return err
+	}
+	return s.ReloadCuration(ctx)
+}
+
+// PutRewrite makes the searches of the phrase search the other phrase. The
+// rewrites are not chained, so the other phrase is searched as is.
+func (s Service) PutRewrite(ctx context.Context, phrase, rewrite string) (Rewrite, error) {
+	if s.curations == nil {
+		return Rewrite{}, errNoCurations
+	}
+	query, err := s.normalized(ctx, phrase)
+	if err != nil {
+		return Rewrite{}, err
+	}
+	target, err := s.normalized(ctx, rewrite)
+	if err != nil {
+		return Rewrite{}, err
+	}
+	if target == query {
+		return Rewrite{}, fmt.Errorf("%w: %q is rewritten to itself", ErrBadArguments, phrase)
+	}
+
+	r := Rewrite{Query: query, Phrase: rewrite}
+	if err := s.curations.PutRewrite(ctx, r); err != nil {
+		s.log.Error("failed to put rewrite", "query", query, "error", err)
+		return Rewrite{}, err
+	}
+	return r, s.ReloadCuration(ctx)
+}
+
+// DeleteRewrite returns ErrNotFound if the phrase is not rewritten.
+func (s Service) DeleteRewrite(ctx context.Context, phrase string) error {
+	if s.curations == nil {
+		return errNoCurations
+	}
+	query, err := s.normalized(ctx, phrase)
+	if err != nil {
+		return err
+	}
+	if err := s.curations.DeleteRewrite(ctx, query); err != nil {
+		return err
+	}
+	return s.ReloadCuration(ctx)
+}
+
+// Block hides the comic from all the searches.
+func (s Service) Block(ctx context.Context, id ComicID) error {
+	if s.curations == nil {
+		return errNoCurations
+	}
+	if id.Source == "" || id.ID < 1 {
+		return fmt.Errorf("%w: bad comic %d of %q", ErrBadArguments, id.ID, id.Source)
+	}
+	if err := s.curations.Block(ctx, id); err != nil {
+		s.log.Error("failed to block comic", "source", id.Source, "comic_id", id.ID, "error", err)
+		return err
+	}
+	return s.ReloadCuration(ctx)
+}
+
+// Unblock returns ErrNotFound if the comic is not blocked.
+func (s Service) Unblock(ctx context.Context, id ComicID) error {
+	if s.curations == nil {
+		return errNoCurations
+	}
+	if err := s.curations.Unblock(ctx, id); err != nil {
+		return err
+	}
+	return s.ReloadCuration(ctx)
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestService_SearchCurated(t *testing.T) {
+	ctx := context.Background()
+	xkcd := func(ids ...int) []Comics {
+		comics := make([]Comics, len(ids))
+		for i, id := range ids {
+			comics[i] = Comics{ID: id, Source: "xkcd"}
+		}
+		return comics
+	}
+	password := Query{Terms: []Term{{Word: "password"}}}
+	pinned := Curation{Pins: []Pin{{Query: "password", Comics: []ComicID{{"xkcd", 936}, {"xkcd", 792}}}}}
+
+	tests := []struct {
+		name     string
+		phrase   string
+		opts     Options
+		curation Curation
+		mockDB   func(*MockDB)
+		want     []int
+	}{
+		{
+			name:     "pinned comics first",
+			phrase:   "password",
+			curation: pinned,
+			mockDB: func(db *MockDB) {
+				db.On("SearchComics", ctx, 3, password).Return(xkcd(1, 792, 2), nil)
+				db.On("GetImageURL", ctx, "xkcd", 936).Return("https://xkcd/936.png", nil)
+			},
+			want: []int{936, 792, 1},
+		},
+		{
+			name:     "pinned comic missing",
+			phrase:   "password",
+			curation: pinned,
+			mockDB: func(db *MockDB) {
+				db.On("SearchComics", ctx, 3, password).Return(xkcd(1), nil)
+				db.On("GetImageURL", ctx, "xkcd", 936).Return("", nil)
+				db.On("GetImageURL", ctx, "xkcd", 792).Return("", errors.New("no comic"))
+			},
+			want: []int{1},
+		},
+		{
+			name:     "pins left out of date sorts",
+			phrase:   "password",
+			opts:     Options{Sort: SortNewest},
+			curation: pinned,
+			mockDB: func(db *MockDB) {
+				db.On("SearchComics", ctx, 3, Query{Terms: password.Terms, Options: Options{Sort: SortNewest}}).
+					Return(xkcd(1, 2), nil)
+			},
+			want: []int{1, 2},
+		},
+		{
+			name:     "blocked comics dropped",
+			phrase:   "password",
+			curation: Curation{Blocked: []ComicID{{"xkcd", 2}, {"other", 3}}},
+			mockDB: func(db *MockDB) {
+				db.On("SearchComics", ctx, 5, password).Return(xkcd(1, 2, 3, 4, 5), nil)
+			},
+			want: []int{1, 3, 4},
+		},
+		{
+			name:     "query rewritten",
+			phrase:   "passwd",
+			curation: Curation{Rewrites: []Rewrite{{Query: "passwd", Phrase: "password"}}},
+			mockDB: func(db *MockDB) {
+				db.On("SearchComics", ctx, 3, password).Return(xkcd(1), nil)
+			},
+			want: []int{1},
+		},
+		{
+			name:   "pins of the rewritten query",
+			phrase: "passwd",
+			curation: Curation{
+				Pins:     pinned.Pins,
+				Rewrites: []Rewrite{{Query: "passwd", Phrase: "password"}},
+			},
+			mockDB: func(db *MockDB) {
+				db.On("SearchComics", ctx, 3, password).Return(xkcd(936, 792), nil)
+			},
+			want: []int{936, 792},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, engine := range []Engine{EngineDB, EngineIndex} {
+			t.Run(tt.name+"/"+string(engine), func(t *testing.T) {
+				mockWords := new(MockWords)
+				mockWords.On("Norm", ctx, "password").Return([]string{"password"}, nil).Maybe()
+				mockWords.On("Norm", ctx, "passwd").Return([]string{"passwd"}, nil).Maybe()
+				mockDB := new(MockDB)
+				mockDB.On("GetTexts", ctx, mock.Anything).Return(map[ComicID]Text{}, nil).Maybe()
+				tt.mockDB(mockDB)
+
+				// the index is searched as the database is, by its mock
+				mockIndex := new(MockIndex)
+				for _, call := range mockDB.ExpectedCalls {
+					if call.Method == "SearchComics" {
+						mockIndex.On("SearchByIndex", call.Arguments...).Return(call.ReturnArguments...)
+					}
+				}
+
+				service := &Service{
+					log:     slog.Default(),
+					db:      mockDB,
+					words:   mockWords,
+					index:   mockIndex,
+					curated: new(atomic.Pointer[curated]),
+				}
+				service.curated.Store(newCurated(tt.curation))
+
+				search := service.Search
+				if engine == EngineIndex {
+					search = service.IndexSearch
+				}
+				found, err := search(ctx, 3, tt.phrase, tt.opts)
+				assert.NoError(t, err)
+				ids := []int{}
+				for _, comic := range found.Comics {
+					ids = append(ids, comic.ID)
+				}
+				assert.Equal(t, tt.want, ids)
+			})
+		}
+	}
+}
+
+func TestService_Curate(t *testing.T) {
+	ctx := context.Background()
+	curations := new(MockCurations)
+	words := new(MockWords)
+	words.On("Norm", ctx, "Passwords!").Return([]string{"password"}, nil)
+	words.On("Norm", ctx, "passwd").Return([]string{"passwd"}, nil)
+	words.On("Norm", ctx, "the").Return([]string{}, nil)
+
+	service := &Service{
+		log:       slog.Default(),
+		words:     words,
+		curations: curations,
+		curated:   new(atomic.Pointer[curated]),
+	}
+
+	comics := []ComicID{{"xkcd", 936}}
+	curation := Curation{Pins: []Pin{{Query: "password", Comics: comics}}}
+	curations.On("PutPin", ctx, Pin{Query: "password", Comics: comics}).Return(nil).Once()
+	curations.On("Load", ctx).Return(curation, nil).Once()
+	pin, err := service.PutPin(ctx, "Passwords!", comics)
+	assert.NoError(t, err)
+	assert.Equal(t, Pin{Query: "password", Comics: comics}, pin)
+	assert.Equal(t, comics, service.curation().pins["password"], "the pin is reloaded")
+
+	_, err = service.PutPin(ctx, "the", comics)
+	assert.ErrorIs(t, err, ErrBadArguments, "a phrase of stop words is no query")
+	_, err = service.PutPin(ctx, "Passwords!", []ComicID{{"xkcd", 0}})
+	assert.ErrorIs(t, err, ErrBadArguments)
+
+	_, err = service.PutRewrite(ctx, "Passwords!", "Passwords!")
+	assert.ErrorIs(t, err, ErrBadArguments, "a query is not rewritten to itself")
+
+	curations.On("DeleteRewrite", ctx, "passwd").Return(ErrNotFound).Once()
+	assert.ErrorIs(t, service.DeleteRewrite(ctx, "passwd"), ErrNotFound)
+
+	curations.On("Block", ctx, ComicID{"xkcd", 404}).Return(nil).Once()
+	curations.On("Load", ctx).Return(Curation{Blocked: []ComicID{{"xkcd", 404}}}, nil).Once()
+	assert.NoError(t, service.Block(ctx, ComicID{"xkcd", 404}))
+	assert.Empty(t, service.curation().pins, "the reload replaces the curation")
+	assert.Contains(t, service.curation().blocked, ComicID{"xkcd", 404})
+
+	curations.AssertExpectations(t)
+
+	unset := &Service{log: slog.Default()}
+	assert.ErrorIs(t, unset.Block(ctx, ComicID{"xkcd", 404}), ErrBadArguments, "no store to edit")
+	assert.NoError(t, unset.ReloadCuration(ctx))
+}
@@ -0,0 +1,186 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// rrfK damps the weight of the top ranks in the reciprocal rank fusion, 60
+// as Cormack et al. suggest.
+const rrfK = 60
+
+// Hybrid is a search fused from all the engines. Degraded lists the engines
+// that failed or timed out, their comics are missing.
+type Hybrid struct {
+	Comics   []Comics
+	Degraded []Engine
+}
+
+// retriever runs the search of an engine.
+type retriever struct {
+	engine Engine
+	search func(ctx context.Context) ([]Comics, error)
+}
+
+// HybridSearch runs the database, index and full-text searches at once and
+// fuses their results by reciprocal rank. An engine failing or running out
+// of the retriever timeout leaves the others' comics, the search is then
+// degraded and not cached; it fails only if all the engines fail. The ranks
+// of the engines are their relevance ones, so it cannot sort by date. The
+// query is curated as in Search, the full-text engine searching the phrase
+// it is rewritten to.
+func (s Service) HybridSearch(ctx context.Context, limit int, phrase string, opts Options) (Hybrid, error) {
+	if opts.Sort != SortRelevance && opts.Sort != "" {
+		return Hybrid{}, fmt.Errorf("%w: hybrid search sorts by relevance only", ErrBadArguments)
+	}
+
+	start := time.Now()
+	query, err := s.query(ctx, phrase)
+	if err != nil {
+		s.log.Error("failed to normalize req", "error", err)
+		return Hybrid{}, err
+	}
+	query.Options = opts
+
+	c := s.curation()
+	typed := query
+	if query, err = s.rewrite(ctx, c, query); err != nil {
+		s.log.Error("failed to normalize rewritten req", "error", err)
+		return Hybrid{}, err
+	}
+	if query.String() != typed.String() {
+		phrase = c.rewrites[typed.String()]
+	}
+
+	fetch := c.fetch(limit)
+	key, cache := s.cacheKey(EngineHybrid, fetch, query.String(), opts)
+	if cache {
+		if comics, ok := s.cache.Get(key); ok {
+			comics = s.curate(ctx, c, typed, query, comics, limit)
+			s.record(EngineHybrid, typed.String(), opts, len(comics), start)
+			return Hybrid{Comics: comics}, nil
+		}
+	}
+
+	retrievers := []retriever{
+		{EngineDB, func(ctx context.Context) ([]Comics, error) { return s.db.SearchComics(ctx, fetch, query) }},
+		{EngineIndex, func(ctx context.Context) ([]Comics, error) { return s.index.SearchByIndex(ctx, fetch, query) }},
+	}
+	if s.fts != nil {
+		retrievers = append(retrievers, retriever{EngineFTS, func(ctx context.Context) ([]Comics, error) {
+			return s.fts.Search(ctx, fetch, phrase, opts)
+		}})
+	}
+
+	lists, errs := s.retrieve(ctx, retrievers)
+	var hybrid Hybrid
+	for n, err := range errs {
+		if err != nil {
+			s.log.Warn("hybrid search degraded", "engine", retrievers[n].engine, "error", err)
+			hybrid.Degraded = append(hybrid.Degraded, retrievers[n].engine)
+			lists[n] = nil
+		}
+	}
+	if len(hybrid.Degraded) == len(retrievers) {
+		s.log.Error("failed to hybrid search comics", "error", errs[0])
+		return Hybrid{}, errs[0]
+	}
+
+	hybrid.Comics = s.withSnippets(ctx, fuse(fetch, lists...), query)
+	if cache && len(hybrid.Degraded) == 0 {
+		s.cache.Put(key, hybrid.Comics)
+	}
+	hybrid.Comics = s.curate(ctx, c, typed, query, hybrid.Comics, limit)
+
+	s.record(EngineHybrid, typed.String(), opts, len(hybrid.Comics), start)
+	return hybrid, nil
+}
+
+// retrieve runs the retrievers concurrently and returns the comics or the
+// error of each. The ones still running when the timeout is over are left
+// behind with the context error.
+func (s Service) retrieve(ctx context.Context, retrievers []retriever) ([][]Comics, []error) {
+	if s.retrieverTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.retrieverTimeout)
+		defer cancel()
+	}
+
+	type retrieved struct {
+		n      int
+		comics []Comics
+		err    error
+	}
+	found := make(chan retrieved, len(retrievers))
+	for n, r := range retrievers {
+		go func() {
+			comics, err := r.search(ctx)
+			found <- retrieved{n, comics, err}
+		}()
+	}
+
+	lists := make([][]Comics, len(retrievers))
+	errs := make([]error, len(retrievers))
+	done := make([]bool, len(retrievers))
+	for range retrievers {
+		select {
+		case r := <-found:
+			lists[r.n], errs[r.n], done[r.n] = r.comics, r.err, true
+		case <-ctx.Done():
+			for n := range retrievers {
+				if !done[n] {
+					errs[n] = ctx.Err()
+				}
+			}
+			return lists, errs
+		}
+	}
+	return lists, errs
+}
+
+// fuse merges the ranked lists by reciprocal rank: a comic scores the sum
+// of 1/(rrfK + rank) over the lists it is in, so the comics most engines
+// rank high come first. Ties go to the newer comic.
+func fuse(limit int, lists ...[]Comics) []Comics {
+	type key struct {
+		source string
+		id     int
+	}
+	type fused struct {
+		comic Comics
+		score float64
+	}
+
+	byKey := make(map[key]*fused)
+	var all []*fused
+	for _, list := range lists {
+		for rank, comic := range list {
+			k := key{comic.Source, comic.ID}
+			f, ok := byKey[k]
+			if !ok {
+				f = &fused{comic: comic}
+				byKey[k] = f
+				all = append(all, f)
+			}
+			f.score += 1 / float64(rrfK+rank+1)
+		}
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].score == all[j].score {
+			if all[i].comic.ID == all[j].comic.ID {
+				return all[i].comic.Source < all[j].comic.Source
+			}
+			return all[i].comic.ID > all[j].comic.ID
+		}
+		return all[i].score > all[j].score
+	})
+
+	comics := make([]Comics, 0, min(max(limit, 0), len(all)))
+	for _, f := range all[:cap(comics)] {
+		comics = append(comics, f.comic)
+	}
+	return comics
+}
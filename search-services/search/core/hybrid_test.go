@@ -0,0 +1,221 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFuse(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		lists [][]Comics
+		want  []Comics
+	}{
+		{
+			name:  "found by more engines first",
+			limit: 10,
+			lists: [][]Comics{
+				{{ID: 1, URL: "db1"}, {ID: 2, URL: "db2"}},
+				{{ID: 3}, {ID: 2}},
+				{{ID: 2}},
+			},
+			want: []Comics{{ID: 2, URL: "db2"}, {ID: 3}, {ID: 1, URL: "db1"}},
+		},
+		{
+			name:  "higher ranks first, ties to the newer",
+			limit: 10,
+			lists: [][]Comics{
+				{{ID: 1}, {ID: 4}},
+				{{ID: 5}, {ID: 1}},
+			},
+			want: []Comics{{ID: 1}, {ID: 5}, {ID: 4}},
+		},
+		{
+			name:  "same ID of another source is another comic",
+			limit: 10,
+			lists: [][]Comics{
+				{{ID: 1, Source: "xkcd"}},
+				{{ID: 1, Source: "smbc"}},
+			},
+			want: []Comics{{ID: 1, Source: "smbc"}, {ID: 1, Source: "xkcd"}},
+		},
+		{
+			name:  "limit",
+			limit: 1,
+			lists: [][]Comics{{{ID: 1}, {ID: 2}}, {{ID: 2}}},
+			want:  []Comics{{ID: 2}},
+		},
+		{
+			name:  "nothing found",
+			limit: 10,
+			lists: [][]Comics{nil, {}},
+			want:  []Comics{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, fuse(tt.limit, tt.lists...))
+		})
+	}
+}
+
+func TestService_HybridSearch(t *testing.T) {
+	ctx := context.Background()
+	query := Query{Terms: []Term{{Word: "cat"}}, Options: Options{Sort: SortRelevance}}
+	failed := errors.New("failed to search")
+
+	tests := []struct {
+		name      string
+		opts      Options
+		noFTS     bool
+		mockSetup func(*MockDB, *MockIndex, *MockFullText)
+		want      Hybrid
+		wantErr   error
+	}{
+		{
+			name: "all engines fused",
+			opts: Options{Sort: SortRelevance},
+			mockSetup: func(db *MockDB, idx *MockIndex, fts *MockFullText) {
+				db.On("SearchComics", mock.Anything, 5, query).Return([]Comics{{ID: 1}, {ID: 2}}, nil)
+				idx.On("SearchByIndex", mock.Anything, 5, query).Return([]Comics{{ID: 2}, {ID: 3}}, nil)
+				fts.On("Search", mock.Anything, 5, "cats", Options{Sort: SortRelevance}).Return([]Comics{{ID: 2}}, nil)
+			},
+			want: Hybrid{Comics: []Comics{{ID: 2}, {ID: 1}, {ID: 3}}},
+		},
+		{
+			name:  "without full-text search",
+			opts:  Options{Sort: SortRelevance},
+			noFTS: true,
+			mockSetup: func(db *MockDB, idx *MockIndex, fts *MockFullText) {
+				db.On("SearchComics", mock.Anything, 5, query).Return([]Comics{{ID: 1}}, nil)
+				idx.On("SearchByIndex", mock.Anything, 5, query).Return([]Comics{{ID: 3}}, nil)
+			},
+			want: Hybrid{Comics: []Comics{{ID: 3}, {ID: 1}}},
+		},
+		{
+			name: "failed engine degrades",
+			opts: Options{Sort: SortRelevance},
+			mockSetup: func(db *MockDB, idx *MockIndex, fts *MockFullText) {
+				db.On("SearchComics", mock.Anything, 5, query).Return([]Comics(nil), failed)
+				idx.On("SearchByIndex", mock.Anything, 5, query).Return([]Comics{{ID: 3}}, nil)
+				fts.On("Search", mock.Anything, 5, "cats", Options{Sort: SortRelevance}).Return([]Comics{{ID: 1}}, nil)
+			},
+			want: Hybrid{Comics: []Comics{{ID: 3}, {ID: 1}}, Degraded: []Engine{EngineDB}},
+		},
+		{
+			name: "late engine degrades",
+			opts: Options{Sort: SortRelevance},
+			mockSetup: func(db *MockDB, idx *MockIndex, fts *MockFullText) {
+				db.On("SearchComics", mock.Anything, 5, query).Return([]Comics{{ID: 1}}, nil)
+				idx.On("SearchByIndex", mock.Anything, 5, query).Return([]Comics{{ID: 3}}, nil)
+				fts.On("Search", mock.Anything, 5, "cats", Options{Sort: SortRelevance}).
+					WaitUntil(time.After(time.Second)).Return([]Comics{{ID: 2}}, nil)
+			},
+			want: Hybrid{Comics: []Comics{{ID: 3}, {ID: 1}}, Degraded: []Engine{EngineFTS}},
+		},
+		{
+			name: "all engines failed",
+			opts: Options{Sort: SortRelevance},
+			mockSetup: func(db *MockDB, idx *MockIndex, fts *MockFullText) {
+				db.On("SearchComics", mock.Anything, 5, query).Return([]Comics(nil), failed)
+				idx.On("SearchByIndex", mock.Anything, 5, query).Return([]Comics(nil), failed)
+				fts.On("Search", mock.Anything, 5, "cats", Options{Sort: SortRelevance}).Return([]Comics(nil), failed)
+			},
+			wantErr: failed,
+		},
+		{
+			name:      "date sort",
+			opts:      Options{Sort: SortNewest},
+			mockSetup: func(db *MockDB, idx *MockIndex, fts *MockFullText) {},
+			wantErr:   ErrBadArguments,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			mockWords := new(MockWords)
+			mockIndex := new(MockIndex)
+			mockFTS := new(MockFullText)
+			mockWords.On("Norm", ctx, "cats").Return([]string{"cat"}, nil).Maybe()
+			mockDB.On("GetTexts", ctx, mock.Anything).Return(map[ComicID]Text{}, nil).Maybe()
+			tt.mockSetup(mockDB, mockIndex, mockFTS)
+
+			service := &Service{
+				log:              slog.Default(),
+				db:               mockDB,
+				words:            mockWords,
+				index:            mockIndex,
+				fts:              mockFTS,
+				retrieverTimeout: 50 * time.Millisecond,
+				generation:       new(atomic.Uint64),
+			}
+			if tt.noFTS {
+				service.fts = nil
+			}
+
+			got, err := service.HybridSearch(ctx, 5, "cats", tt.opts)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+			mockDB.AssertExpectations(t)
+			mockIndex.AssertExpectations(t)
+			mockFTS.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_HybridSearchCached(t *testing.T) {
+	ctx := context.Background()
+	query := Query{Terms: []Term{{Word: "cat"}}, Options: Options{Sort: SortRelevance}}
+	key := `0|hybrid|5|"cat"|||0|0|relevance|false`
+
+	tests := []struct {
+		name     string
+		indexErr error
+		wantPut  bool
+	}{
+		{name: "complete search is cached", wantPut: true},
+		{name: "degraded search is not cached", indexErr: errors.New("failed to search")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			mockWords := new(MockWords)
+			mockIndex := new(MockIndex)
+			mockCache := new(MockCache)
+			mockWords.On("Norm", ctx, "cats").Return([]string{"cat"}, nil)
+			mockDB.On("GetTexts", ctx, mock.Anything).Return(map[ComicID]Text{}, nil)
+			mockDB.On("SearchComics", mock.Anything, 5, query).Return([]Comics{{ID: 1}}, nil)
+			mockIndex.On("Generation").Return(uint64(0))
+			mockIndex.On("SearchByIndex", mock.Anything, 5, query).Return([]Comics{{ID: 1}}, tt.indexErr)
+			mockCache.On("Get", key).Return([]Comics(nil), false)
+			if tt.wantPut {
+				mockCache.On("Put", key, []Comics{{ID: 1}})
+			}
+
+			service := &Service{
+				log:        slog.Default(),
+				db:         mockDB,
+				words:      mockWords,
+				index:      mockIndex,
+				cache:      mockCache,
+				generation: new(atomic.Uint64),
+			}
+
+			got, err := service.HybridSearch(ctx, 5, "cats", Options{Sort: SortRelevance})
+			assert.NoError(t, err)
+			assert.Equal(t, []Comics{{ID: 1}}, got.Comics)
+			mockCache.AssertExpectations(t)
+		})
+	}
+}
@@ -1,13 +1,246 @@
 package core
 
+import (
+	"fmt"
+	"time"
+)
+
 type DbComics struct {
-	ID       int    `db:"comic_id"`
-	URL      string `db:"image_url"`
-	Keywords string `db:"keywords"`
+	ID                 int        `db:"comic_id"`
+	Source             string     `db:"source"`
+	URL                string     `db:"image_url"`
+	Keywords           string     `db:"keywords"`
+	TitleKeywords      string     `db:"title_keywords"`
+	AltKeywords        string     `db:"alt_keywords"`
+	TranscriptKeywords string     `db:"transcript_keywords"`
+	TagKeywords        string     `db:"tag_keywords"`
+	Published          *time.Time `db:"published"`
 }
 
 type Comics struct {
 	ID       int
+	Source   string
 	URL      string
 	Keywords string
+	// keywords of every field, JSON arrays like Keywords
+	TitleKeywords      string
+	AltKeywords        string
+	TranscriptKeywords string
+	// TagKeywords are the words of the tags attached by hand.
+	TagKeywords string
+	// Published is nil if the source does not date its comics.
+	Published *time.Time
+	// Snippet explains the match, its Field is empty if there is none.
+	Snippet Snippet
+	// Explanation is set by explained searches only.
+	Explanation *Explanation
+}
+
+// Text is the searchable text of a comic.
+type Text struct {
+	Title      string `db:"title"`
+	Alt        string `db:"alt"`
+	Transcript string `db:"transcript"`
+}
+
+// Token is a normalized word with its byte offsets in the text.
+type Token struct {
+	Stem  string
+	Start int
+	End   int
+}
+
+// Highlight marks a matched word by its byte offsets in the snippet text.
+type Highlight struct {
+	Start int
+	End   int
+}
+
+// Snippet is a piece of one field of a comic around the matched words.
+type Snippet struct {
+	Field      string
+	Text       string
+	Highlights []Highlight
+}
+
+// Fields a query word may be scoped to with a "field:" prefix.
+const (
+	FieldTitle      = "title"
+	FieldAlt        = "alt"
+	FieldTranscript = "transcript"
+	FieldTag        = "tag"
+)
+
+// Term is a normalized query word. A term without a field matches any.
+type Term struct {
+	Field string
+	Word  string
+}
+
+// Boosts weigh a match in every field. Keywords stored without a field
+// weigh 1.
+type Boosts struct {
+	Title      float64
+	Alt        float64
+	Transcript float64
+	Tag        float64
+}
+
+func (b Boosts) Of(field string) float64 {
+	switch field {
+	case FieldTitle:
+		return b.Title
+	case FieldAlt:
+		return b.Alt
+	case FieldTranscript:
+		return b.Transcript
+	case FieldTag:
+		return b.Tag
+	}
+	return 1
+}
+
+// Query is a normalized search phrase. A comic matches it if it has any of
+// the terms and every scoped one, or every term if the options require all;
+// its rank is the sum of the boosts of the fields the terms are found in.
+type Query struct {
+	Terms   []Term
+	Boosts  Boosts
+	Options Options
+}
+
+// Engine is a way to search: the comic_terms table, the in-memory index,
+// the PostgreSQL full-text search, the first three fused or the semantic
+// space of the index.
+type Engine string
+
+const (
+	EngineDB       Engine = "db"
+	EngineIndex    Engine = "index"
+	EngineFTS      Engine = "fts"
+	EngineHybrid   Engine = "hybrid"
+	EngineSemantic Engine = "semantic"
+)
+
+// Explain is a search run with the explanation of every comic found. The
+// full-text search is analyzed by the database, so it has no Terms but
+// the TSQuery it was turned into.
+type Explain struct {
+	Terms   []Term
+	TSQuery string
+	// Plan is the EXPLAIN output of the SQL query, empty for the index.
+	Plan []string
+	// Formula is how the engine scores the comics, see FormulaTerms and
+	// FormulaFTS.
+	Formula string
+	Comics  []Comics
+}
+
+// The formulas the engines score the comics by. The term rankers weigh
+// neither the rarity of a term nor the length of a field.
+const (
+	FormulaTerms = "score = sum over the matches of tf * boost; no idf, no field length normalization"
+	FormulaFTS   = "score = ts_rank_cd(document, tsquery)"
+)
+
+// Explanation breaks the score of a comic down into the query terms it
+// matched. A full-text search hit has the score of ts_rank_cd only.
+type Explanation struct {
+	Score   float64
+	Matches []Match
+}
+
+// Match is a query term found in a field of a comic. Its score is
+// TF * Boost, the rankers weigh neither the rarity of a term nor the length
+// of a field.
+type Match struct {
+	Term  Term
+	Field string
+	TF    int
+	Boost float64
+	Score float64
+}
+
+// NewMatch scores tf occurrences of the term in the field.
+func NewMatch(term Term, field string, tf int, boost float64) Match {
+	return Match{
+		Term:  term,
+		Field: field,
+		TF:    tf,
+		Boost: boost,
+		Score: float64(tf) * boost,
+	}
+}
+
+// Sort orders the found comics.
+type Sort string
+
+const (
+	SortRelevance Sort = "relevance"
+	SortNewest    Sort = "newest"
+	SortOldest    Sort = "oldest"
+)
+
+// ParseSort accepts the known orders, an empty one meaning relevance.
+func ParseSort(s string) (Sort, error) {
+	switch sort := Sort(s); sort {
+	case "":
+		return SortRelevance, nil
+	case SortRelevance, SortNewest, SortOldest:
+		return sort, nil
+	}
+	return "", fmt.Errorf("%w: unknown sort %q", ErrBadArguments, s)
+}
+
+// Options narrow a search down and order it. Zero bounds are open, the
+// dates are inclusive.
+type Options struct {
+	From  time.Time
+	To    time.Time
+	MinID int
+	MaxID int
+	Sort  Sort
+	// All makes a comic match every term of the query, not just any.
+	All bool
+	// Client is a hash identifying who searched, it only goes to the
+	// search log.
+	Client string
+	// NoCache makes the search skip the result cache both ways.
+	NoCache bool
+}
+
+// Match reports whether the comic is within the bounds. A comic without a
+// publication date is out of any date range.
+func (o Options) Match(id int, published *time.Time) bool {
+	if o.MinID != 0 && id < o.MinID || o.MaxID != 0 && id > o.MaxID {
+		return false
+	}
+	if o.From.IsZero() && o.To.IsZero() {
+		return true
+	}
+	if published == nil {
+		return false
+	}
+	return !published.Before(o.From) && (o.To.IsZero() || !published.After(o.To))
+}
+
+// Scoped returns the number of terms bound to a field.
+func (q Query) Scoped() int {
+	n := 0
+	for _, term := range q.Terms {
+		if term.Field != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Fields and words of the terms, in the same order.
+func (q Query) Columns() ([]string, []string) {
+	fields := make([]string, len(q.Terms))
+	words := make([]string, len(q.Terms))
+	for i, term := range q.Terms {
+		fields[i], words[i] = term.Field, term.Word
+	}
+	return fields, words
 }
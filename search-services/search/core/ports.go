@@ -1,22 +1,106 @@
 package core
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type DB interface {
-	SearchComics(ctx context.Context, limit int, keywords []string) ([]Comics, error)
-	GetImageURL(ctx context.Context, id int) (string, error)
+	SearchComics(ctx context.Context, limit int, query Query) ([]Comics, error)
+	GetImageURL(ctx context.Context, source string, id int) (string, error)
 	GetComics(ctx context.Context) ([]Comics, error)
+	// GetTexts returns the text of every comic found, the others are
+	// missing from the map.
+	GetTexts(ctx context.Context, ids []ComicID) (map[ComicID]Text, error)
+	// ExplainSearch runs SearchComics with the plan and the scores.
+	ExplainSearch(ctx context.Context, limit int, query Query) (Explain, error)
+	// RandomComics returns up to limit comics within the date bounds of
+	// the options in random order, with their publication dates.
+	RandomComics(ctx context.Context, limit int, opts Options) ([]Comics, error)
+	// OnThisDay returns up to limit comics published on the month and day
+	// before the year, newest first, with their publication dates.
+	OnThisDay(ctx context.Context, month time.Month, day, year, limit int) ([]Comics, error)
 }
 
 type Words interface {
 	Norm(ctx context.Context, phrase string) ([]string, error)
+	// Tokens tokenizes every text, in order.
+	Tokens(ctx context.Context, texts []string) ([][]Token, error)
 }
 
 type Searcher interface {
-	Search(ctx context.Context, limit int, phrase string) ([]Comics, error)
-	IndexSearch(ctx context.Context, limit int, phrase string) ([]Comics, error)
+	Search(ctx context.Context, limit int, phrase string, opts Options) (Found, error)
+	IndexSearch(ctx context.Context, limit int, phrase string, opts Options) (Found, error)
+	FTSSearch(ctx context.Context, limit int, phrase string, opts Options) ([]Comics, error)
+	HybridSearch(ctx context.Context, limit int, phrase string, opts Options) (Hybrid, error)
+	SemanticSearch(ctx context.Context, limit int, phrase string, opts Options) ([]Comics, error)
+	Similar(ctx context.Context, source string, id, limit int) ([]Comics, error)
+	Random(ctx context.Context, phrase string, opts Options) (Comics, error)
+	OnThisDay(ctx context.Context, date time.Time, limit int) ([]Comics, error)
+	Explain(ctx context.Context, engine Engine, limit int, phrase string, opts Options) (Explain, error)
+	Analytics(ctx context.Context, since time.Time, limit int) (Analytics, error)
+	CacheStats(ctx context.Context) (CacheStats, error)
+	Curation(ctx context.Context) (Curation, error)
+	PutPin(ctx context.Context, phrase string, comics []ComicID) (Pin, error)
+	DeletePin(ctx context.Context, phrase string) error
+	PutRewrite(ctx context.Context, phrase, rewrite string) (Rewrite, error)
+	DeleteRewrite(ctx context.Context, phrase string) error
+	Block(ctx context.Context, id ComicID) error
+	Unblock(ctx context.Context, id ComicID) error
+}
+
+// Cache keeps the comics found by recent searches. Get misses the expired
+// entries, Purge drops every entry.
+type Cache interface {
+	Get(key string) ([]Comics, bool)
+	Put(key string, comics []Comics)
+	Purge()
+	Stats() CacheStats
+}
+
+// SearchLog keeps the searches made. Record must not hold the search up,
+// the record may be written later or even dropped.
+type SearchLog interface {
+	Record(record SearchRecord)
+	TopQueries(ctx context.Context, since time.Time, limit int) ([]QueryCount, error)
+	ZeroResultQueries(ctx context.Context, since time.Time, limit int) ([]QueryCount, error)
+	Latency(ctx context.Context, since time.Time) ([]LatencyStats, error)
+}
+
+// Curations keep the curation of the searches. The queries are normalized,
+// the deletes return ErrNotFound if there is nothing to delete.
+type Curations interface {
+	Load(ctx context.Context) (Curation, error)
+	// PutPin replaces the comics pinned for the query.
+	PutPin(ctx context.Context, pin Pin) error
+	DeletePin(ctx context.Context, query string) error
+	PutRewrite(ctx context.Context, rewrite Rewrite) error
+	DeleteRewrite(ctx context.Context, query string) error
+	Block(ctx context.Context, id ComicID) error
+	Unblock(ctx context.Context, id ComicID) error
+}
+
+// FullText searches the raw phrase with the database's own text analysis.
+type FullText interface {
+	Search(ctx context.Context, limit int, phrase string, opts Options) ([]Comics, error)
+	// Explain runs Search with the plan and the scores.
+	Explain(ctx context.Context, limit int, phrase string, opts Options) (Explain, error)
 }
 
 type Index interface {
-	SearchByIndex(ctx context.Context, limit int, keywords []string) ([]Comics, error)
+	SearchByIndex(ctx context.Context, limit int, query Query) ([]Comics, error)
+	// ExplainSearch runs SearchByIndex with the scores.
+	ExplainSearch(ctx context.Context, limit int, query Query) ([]Comics, error)
+	// SemanticSearch finds the comics closest in meaning to the query, it
+	// returns ErrBadArguments if the index has no semantic space.
+	SemanticSearch(ctx context.Context, limit int, query Query) ([]Comics, error)
+	// Similar returns ErrNotFound if the comic is not indexed.
+	Similar(ctx context.Context, source string, id, limit int) ([]Comics, error)
+	// DocFreq returns the number of comics with the term.
+	DocFreq(term Term) int
+	// Fuzzy returns the indexed words other than the word within the edit
+	// distance of it, in order.
+	Fuzzy(word string, distance int) []string
+	// Generation is bumped by every build of the index.
+	Generation() uint64
 }
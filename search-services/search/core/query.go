@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"strings"
+)
+
+// scope splits the phrase into free text and words prefixed with a field
+// name, e.g. "title:python" or "tag:romance". Unknown prefixes are left in
+// the text.
+func scope(phrase string) (string, []Term) {
+	var (
+		text   []string
+		scoped []Term
+	)
+	for _, token := range strings.Fields(phrase) {
+		field, word, ok := strings.Cut(token, ":")
+		switch field = strings.ToLower(field); {
+		case ok && word != "" && (field == FieldTitle || field == FieldAlt || field == FieldTranscript ||
+			field == FieldTag):
+			scoped = append(scoped, Term{Field: field, Word: word})
+		default:
+			text = append(text, token)
+		}
+	}
+	return strings.Join(text, " "), scoped
+}
+
+// query normalizes the phrase. Scoped words are normalized one by one, a
+// word that normalizes to nothing (a stop word) is dropped.
+func (s Service) query(ctx context.Context, phrase string) (Query, error) {
+	text, scoped := scope(phrase)
+
+	query := Query{Boosts: s.boosts}
+	seen := make(map[Term]struct{})
+	add := func(term Term) {
+		if _, ok := seen[term]; !ok {
+			seen[term] = struct{}{}
+			query.Terms = append(query.Terms, term)
+		}
+	}
+
+	if text != "" {
+		words, err := s.words.Norm(ctx, text)
+		if err != nil {
+			return Query{}, err
+		}
+		for _, word := range words {
+			add(Term{Word: word})
+		}
+	}
+	for _, term := range scoped {
+		words, err := s.words.Norm(ctx, term.Word)
+		if err != nil {
+			return Query{}, err
+		}
+		for _, word := range words {
+			add(Term{Field: term.Field, Word: word})
+		}
+	}
+	return query, nil
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// luckyPool is how many of the best comics found by a phrase a random one
+// is drawn from, so that it is still about the phrase.
+const luckyPool = 20
+
+// Random draws a comic within the date bounds of the options, from the
+// comics found by the phrase or from all of them if it is empty. The
+// blocked comics are never drawn. The phrase is not logged as searched, the
+// draws would skew the search analytics. It returns ErrNotFound if there is
+// nothing to draw from.
+func (s Service) Random(ctx context.Context, phrase string, opts Options) (Comics, error) {
+	var comics []Comics
+	if strings.TrimSpace(phrase) != "" {
+		found, _, err := s.search(ctx, luckyPool, phrase, opts)
+		if err != nil {
+			return Comics{}, err
+		}
+		comics = found.Comics
+	} else {
+		c := s.curation()
+		drawn, err := s.db.RandomComics(ctx, c.fetch(1), opts)
+		if err != nil {
+			s.log.Error("failed to draw comics", "error", err)
+			return Comics{}, err
+		}
+		comics = c.unblocked(drawn, 1)
+	}
+
+	if len(comics) == 0 {
+		return Comics{}, fmt.Errorf("%w: no comics to draw from", ErrNotFound)
+	}
+	return comics[rand.IntN(len(comics))], nil
+}
+
+// OnThisDay finds the comics published on the month and day of the date in
+// the years before it, newest first, the blocked ones dropped.
+func (s Service) OnThisDay(ctx context.Context, date time.Time, limit int) ([]Comics, error) {
+	if limit < 1 {
+		return []Comics{}, fmt.Errorf("%w: limit must be positive", ErrBadArguments)
+	}
+
+	c := s.curation()
+	comics, err := s.db.OnThisDay(ctx, date.Month(), date.Day(), date.Year(), c.fetch(limit))
+	if err != nil {
+		s.log.Error("failed to find comics of the day", "date", date.Format(time.DateOnly), "error", err)
+		return []Comics{}, err
+	}
+	return c.unblocked(comics, limit), nil
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestService_Random(t *testing.T) {
+	ctx := context.Background()
+	published := time.Date(2011, 2, 14, 0, 0, 0, 0, time.UTC)
+	from := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+	blocked := Curation{Blocked: []ComicID{{"xkcd", 1}}}
+
+	tests := []struct {
+		name      string
+		phrase    string
+		opts      Options
+		curation  Curation
+		mockSetup func(*MockDB, *MockWords)
+		want      []int
+		wantErr   error
+	}{
+		{
+			name:     "any comic, blocked ones skipped",
+			opts:     Options{From: from},
+			curation: blocked,
+			mockSetup: func(db *MockDB, _ *MockWords) {
+				db.On("RandomComics", ctx, 2, Options{From: from}).Return([]Comics{
+					{ID: 1, Source: "xkcd"},
+					{ID: 844, Source: "xkcd", Published: &published},
+				}, nil)
+			},
+			want: []int{844},
+		},
+		{
+			name:   "drawn from the comics found",
+			phrase: "romance",
+			mockSetup: func(db *MockDB, words *MockWords) {
+				words.On("Norm", ctx, "romance").Return([]string{"romanc"}, nil)
+				db.On("SearchComics", ctx, luckyPool, Query{Terms: []Term{{Word: "romanc"}}}).
+					Return([]Comics{{ID: 162, Source: "xkcd"}, {ID: 844, Source: "xkcd"}}, nil)
+				db.On("GetTexts", ctx, mock.Anything).Return(map[ComicID]Text{}, nil)
+			},
+			want: []int{162, 844},
+		},
+		{
+			name:   "nothing found",
+			phrase: "zzz",
+			mockSetup: func(db *MockDB, words *MockWords) {
+				words.On("Norm", ctx, "zzz").Return([]string{"zzz"}, nil)
+				db.On("SearchComics", ctx, luckyPool, Query{Terms: []Term{{Word: "zzz"}}}).Return([]Comics{}, nil)
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name:     "all blocked",
+			curation: blocked,
+			mockSetup: func(db *MockDB, _ *MockWords) {
+				db.On("RandomComics", ctx, 2, Options{}).Return([]Comics{{ID: 1, Source: "xkcd"}}, nil)
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			mockWords := new(MockWords)
+			mockSearchLog := new(MockSearchLog)
+			tt.mockSetup(mockDB, mockWords)
+
+			// the draws are not logged as searches, Record is not expected
+			service := &Service{
+				log:       slog.Default(),
+				db:        mockDB,
+				words:     mockWords,
+				searchLog: mockSearchLog,
+				curated:   new(atomic.Pointer[curated]),
+			}
+			service.curated.Store(newCurated(tt.curation))
+
+			comic, err := service.Random(ctx, tt.phrase, tt.opts)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Contains(t, tt.want, comic.ID)
+			mockDB.AssertExpectations(t)
+			mockSearchLog.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_OnThisDay(t *testing.T) {
+	ctx := context.Background()
+	today := time.Date(2026, 4, 1, 15, 0, 0, 0, time.UTC)
+	published := time.Date(2009, 4, 1, 0, 0, 0, 0, time.UTC)
+	dbErr := errors.New("db error")
+
+	tests := []struct {
+		name      string
+		limit     int
+		mockSetup func(*MockDB)
+		want      []Comics
+		wantErr   error
+	}{
+		{
+			name:  "blocked comics dropped",
+			limit: 1,
+			mockSetup: func(db *MockDB) {
+				db.On("OnThisDay", ctx, time.April, 1, 2026, 2).Return([]Comics{
+					{ID: 1, Source: "xkcd"},
+					{ID: 565, Source: "xkcd", Published: &published},
+				}, nil)
+			},
+			want: []Comics{{ID: 565, Source: "xkcd", Published: &published}},
+		},
+		{
+			name:      "bad limit",
+			limit:     0,
+			mockSetup: func(*MockDB) {},
+			wantErr:   ErrBadArguments,
+		},
+		{
+			name:  "db error",
+			limit: 10,
+			mockSetup: func(db *MockDB) {
+				db.On("OnThisDay", ctx, time.April, 1, 2026, 11).Return([]Comics(nil), dbErr)
+			},
+			wantErr: dbErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			tt.mockSetup(mockDB)
+
+			service := &Service{log: slog.Default(), db: mockDB, curated: new(atomic.Pointer[curated])}
+			service.curated.Store(newCurated(Curation{Blocked: []ComicID{{"xkcd", 1}}}))
+
+			comics, err := service.OnThisDay(ctx, today, tt.limit)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, comics)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package core
+
+import "unicode/utf8"
+
+// Relax is a way to loosen a query requiring all its terms that found
+// nothing. The ways are tried in the order below until one finds comics.
+type Relax string
+
+const (
+	// RelaxDropTerm drops the least informative term, the one found in the
+	// most comics.
+	RelaxDropTerm Relax = "drop_term"
+	// RelaxAnyTerm lets a comic match any of the terms.
+	RelaxAnyTerm Relax = "any_term"
+	// RelaxFuzzy matches any of the terms or the words spelled close to them.
+	RelaxFuzzy Relax = "fuzzy"
+)
+
+// Relaxation tells how a query was loosened and the terms it was searched
+// with then, so that the comics can be shown as found for them.
+type Relaxation struct {
+	Relax Relax
+	Terms []Term
+}
+
+// Found is the comics a search found.
+type Found struct {
+	Comics []Comics
+	// Relaxation is set by the searches found by a loosened query only.
+	Relaxation *Relaxation
+}
+
+// relaxed runs the search of the query and, if it requires all its terms
+// and finds nothing, of the query loosened in every way in turn until one
+// finds comics. Every search is cached on its own, so a relaxed search is
+// cached as a whole.
+func (s Service) relaxed(query Query, search func(Query) ([]Comics, error)) (Found, error) {
+	comics, err := search(query)
+	if err != nil || len(comics) > 0 || !query.Options.All {
+		return Found{Comics: comics}, err
+	}
+
+	for _, relax := range []Relax{RelaxDropTerm, RelaxAnyTerm, RelaxFuzzy} {
+		loose, ok := s.loosen(query, relax)
+		if !ok {
+			continue
+		}
+		found, err := search(loose)
+		if err != nil {
+			return Found{}, err
+		}
+		if len(found) > 0 {
+			s.log.Debug("search relaxed", "query", query.String(), "relax", relax, "terms", loose.String())
+			return Found{Comics: found, Relaxation: &Relaxation{Relax: relax, Terms: loose.Terms}}, nil
+		}
+	}
+	return Found{Comics: comics}, nil
+}
+
+// loosen returns the query loosened the given way, false if it cannot be
+// loosened so. The scoped terms stay required, so they are not spelled
+// fuzzily: all their variants would be required then.
+func (s Service) loosen(query Query, relax Relax) (Query, bool) {
+	if len(query.Terms) == 0 {
+		return Query{}, false
+	}
+
+	loose := query
+	switch relax {
+	case RelaxDropTerm:
+		if len(query.Terms) < 2 {
+			return Query{}, false
+		}
+		drop, most := 0, -1
+		for i, term := range query.Terms {
+			if df := s.index.DocFreq(term); df >= most {
+				drop, most = i, df
+			}
+		}
+		loose.Terms = make([]Term, 0, len(query.Terms)-1)
+		loose.Terms = append(loose.Terms, query.Terms[:drop]...)
+		loose.Terms = append(loose.Terms, query.Terms[drop+1:]...)
+		return loose, true
+
+	case RelaxAnyTerm:
+		if len(query.Terms) < 2 {
+			return Query{}, false
+		}
+		loose.Options.All = false
+		return loose, true
+
+	case RelaxFuzzy:
+		loose.Options.All = false
+		loose.Terms = append([]Term{}, query.Terms...)
+		seen := make(map[Term]struct{}, len(query.Terms))
+		for _, term := range query.Terms {
+			seen[term] = struct{}{}
+		}
+		for _, term := range query.Terms {
+			distance := fuzziness(term.Word)
+			if term.Field != "" || distance == 0 {
+				continue
+			}
+			for _, word := range s.index.Fuzzy(term.Word, distance) {
+				if _, ok := seen[Term{Word: word}]; !ok {
+					seen[Term{Word: word}] = struct{}{}
+					loose.Terms = append(loose.Terms, Term{Word: word})
+				}
+			}
+		}
+		return loose, len(loose.Terms) > len(query.Terms)
+	}
+	return Query{}, false
+}
+
+// fuzziness is the edit distance a word may be misspelled by: none for the
+// shortest words, most words that short being that close to each other, one
+// for the short ones and two for the rest.
+func fuzziness(word string) int {
+	switch n := utf8.RuneCountInString(word); {
+	case n <= 2:
+		return 0
+	case n <= 5:
+		return 1
+	}
+	return 2
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestService_SearchRelaxed(t *testing.T) {
+	ctx := context.Background()
+	all := Options{All: true}
+	loose := Options{}
+	query := func(opts Options, terms ...Term) Query {
+		return Query{Terms: terms, Options: opts}
+	}
+	rocket, banana, rockte := Term{Word: "rocket"}, Term{Word: "banana"}, Term{Word: "rockte"}
+	titleRockte := Term{Field: FieldTitle, Word: "rockte"}
+	failed := errors.New("failed to search")
+
+	tests := []struct {
+		name      string
+		phrase    string
+		norm      map[string][]string
+		opts      Options
+		mockSetup func(*MockDB, *MockIndex)
+		want      Found
+		wantErr   error
+	}{
+		{
+			name:   "found as is",
+			phrase: "rocket banana",
+			norm:   map[string][]string{"rocket banana": {"rocket", "banana"}},
+			opts:   all,
+			mockSetup: func(db *MockDB, idx *MockIndex) {
+				db.On("SearchComics", ctx, 5, query(all, rocket, banana)).Return([]Comics{{ID: 1}}, nil)
+			},
+			want: Found{Comics: []Comics{{ID: 1}}},
+		},
+		{
+			name:   "least informative term dropped",
+			phrase: "rocket banana",
+			norm:   map[string][]string{"rocket banana": {"rocket", "banana"}},
+			opts:   all,
+			mockSetup: func(db *MockDB, idx *MockIndex) {
+				db.On("SearchComics", ctx, 5, query(all, rocket, banana)).Return([]Comics{}, nil)
+				idx.On("DocFreq", rocket).Return(5)
+				idx.On("DocFreq", banana).Return(30)
+				db.On("SearchComics", ctx, 5, query(all, rocket)).Return([]Comics{{ID: 2}}, nil)
+			},
+			want: Found{Comics: []Comics{{ID: 2}}, Relaxation: &Relaxation{Relax: RelaxDropTerm, Terms: []Term{rocket}}},
+		},
+		{
+			name:   "any of the terms",
+			phrase: "rocket banana",
+			norm:   map[string][]string{"rocket banana": {"rocket", "banana"}},
+			opts:   all,
+			mockSetup: func(db *MockDB, idx *MockIndex) {
+				db.On("SearchComics", ctx, 5, query(all, rocket, banana)).Return([]Comics{}, nil)
+				idx.On("DocFreq", rocket).Return(5)
+				idx.On("DocFreq", banana).Return(5)
+				db.On("SearchComics", ctx, 5, query(all, rocket)).Return([]Comics{}, nil)
+				db.On("SearchComics", ctx, 5, query(loose, rocket, banana)).Return([]Comics{{ID: 3}}, nil)
+			},
+			want: Found{Comics: []Comics{{ID: 3}}, Relaxation: &Relaxation{Relax: RelaxAnyTerm, Terms: []Term{rocket, banana}}},
+		},
+		{
+			name:   "fuzzy words",
+			phrase: "rockte",
+			norm:   map[string][]string{"rockte": {"rockte"}},
+			opts:   all,
+			mockSetup: func(db *MockDB, idx *MockIndex) {
+				db.On("SearchComics", ctx, 5, query(all, rockte)).Return([]Comics{}, nil)
+				idx.On("Fuzzy", "rockte", 2).Return([]string{"rocket", "rocks"})
+				db.On("SearchComics", ctx, 5, query(loose, rockte, rocket, Term{Word: "rocks"})).Return([]Comics{{ID: 4}}, nil)
+			},
+			want: Found{Comics: []Comics{{ID: 4}}, Relaxation: &Relaxation{
+				Relax: RelaxFuzzy,
+				Terms: []Term{rockte, rocket, {Word: "rocks"}},
+			}},
+		},
+		{
+			name:   "scoped terms are not fuzzy",
+			phrase: "rocket title:rockte",
+			norm:   map[string][]string{"rocket": {"rocket"}, "rockte": {"rockte"}},
+			opts:   all,
+			mockSetup: func(db *MockDB, idx *MockIndex) {
+				db.On("SearchComics", ctx, 5, query(all, rocket, titleRockte)).Return([]Comics{}, nil)
+				idx.On("DocFreq", rocket).Return(5)
+				idx.On("DocFreq", titleRockte).Return(0)
+				db.On("SearchComics", ctx, 5, query(all, titleRockte)).Return([]Comics{}, nil)
+				db.On("SearchComics", ctx, 5, query(loose, rocket, titleRockte)).Return([]Comics{}, nil)
+				idx.On("Fuzzy", "rocket", 2).Return([]string{})
+			},
+			want: Found{Comics: []Comics{}},
+		},
+		{
+			name:   "any terms not relaxed",
+			phrase: "rocket banana",
+			norm:   map[string][]string{"rocket banana": {"rocket", "banana"}},
+			opts:   loose,
+			mockSetup: func(db *MockDB, idx *MockIndex) {
+				db.On("SearchComics", ctx, 5, query(loose, rocket, banana)).Return([]Comics{}, nil)
+			},
+			want: Found{Comics: []Comics{}},
+		},
+		{
+			name:   "relaxed search failed",
+			phrase: "rocket banana",
+			norm:   map[string][]string{"rocket banana": {"rocket", "banana"}},
+			opts:   all,
+			mockSetup: func(db *MockDB, idx *MockIndex) {
+				db.On("SearchComics", ctx, 5, query(all, rocket, banana)).Return([]Comics{}, nil)
+				idx.On("DocFreq", mock.Anything).Return(1)
+				db.On("SearchComics", ctx, 5, query(all, rocket)).Return([]Comics(nil), failed)
+			},
+			want:    Found{Comics: []Comics{}},
+			wantErr: failed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockWords := new(MockWords)
+			mockDB := new(MockDB)
+			mockIndex := new(MockIndex)
+			for text, words := range tt.norm {
+				mockWords.On("Norm", ctx, text).Return(words, nil)
+			}
+			mockDB.On("GetTexts", ctx, mock.Anything).Return(map[ComicID]Text{}, nil).Maybe()
+			tt.mockSetup(mockDB, mockIndex)
+
+			service := &Service{
+				log:   slog.Default(),
+				db:    mockDB,
+				words: mockWords,
+				index: mockIndex,
+			}
+
+			got, err := service.Search(ctx, 5, tt.phrase, tt.opts)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+			mockDB.AssertExpectations(t)
+			mockIndex.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFuzziness(t *testing.T) {
+	assert.Equal(t, 0, fuzziness("ox"))
+	assert.Equal(t, 1, fuzziness("cat"))
+	assert.Equal(t, 1, fuzziness("горох"))
+	assert.Equal(t, 2, fuzziness("rocket"))
+}
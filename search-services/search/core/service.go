@@ -2,55 +2,253 @@ package core
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"sync/atomic"
+	"time"
 )
 
 type Service struct {
-	log   *slog.Logger
-	db    DB
-	words Words
-	index Index
+	log       *slog.Logger
+	db        DB
+	words     Words
+	index     Index
+	fts       FullText
+	searchLog SearchLog
+	cache     Cache
+	boosts    Boosts
+	// retrieverTimeout bounds every engine of a hybrid search, zero leaves
+	// them to the deadline of the search.
+	retrieverTimeout time.Duration
+	// generation is the index generation the cache was filled with.
+	generation *atomic.Uint64
+	curations  Curations
+	// curated is the curation the searches use, reloaded from curations.
+	curated *atomic.Pointer[curated]
 }
 
-func NewService(log *slog.Logger, db DB, words Words, index Index) (*Service, error) {
+func NewService(log *slog.Logger, db DB, words Words, index Index, fts FullText, searchLog SearchLog, cache Cache, curations Curations, boosts Boosts, retrieverTimeout time.Duration) (*Service, error) {
 	service := &Service{
-		log:   log,
-		db:    db,
-		words: words,
-		index: index,
+		log:              log,
+		db:               db,
+		words:            words,
+		index:            index,
+		fts:              fts,
+		searchLog:        searchLog,
+		cache:            cache,
+		boosts:           boosts,
+		retrieverTimeout: retrieverTimeout,
+		generation:       new(atomic.Uint64),
+		curations:        curations,
+		curated:          new(atomic.Pointer[curated]),
 	}
 
 	return service, nil
 }
 
-func (s Service) Search(ctx context.Context, limit int, phrase string) ([]Comics, error) {
-	normReq, err := s.words.Norm(ctx, phrase)
+// Search finds the comics by the comic_terms table. A query requiring all
+// its terms that finds nothing is relaxed, see relaxed. The query is
+// curated, see curate, and logged as typed.
+func (s Service) Search(ctx context.Context, limit int, phrase string, opts Options) (Found, error) {
+	start := time.Now()
+	found, typed, err := s.search(ctx, limit, phrase, opts)
+	if err != nil {
+		return found, err
+	}
+	s.record(EngineDB, typed.String(), opts, len(found.Comics), start)
+	return found, nil
+}
+
+// search is Search without the search log, for the searches the users did
+// not type. It returns the query as typed too.
+func (s Service) search(ctx context.Context, limit int, phrase string, opts Options) (Found, Query, error) {
+	query, err := s.query(ctx, phrase)
 	if err != nil {
 		s.log.Error("failed to normalize req", "error", err)
-		return []Comics{}, err
+		return Found{Comics: []Comics{}}, Query{}, err
 	}
+	query.Options = opts
+
+	c := s.curation()
+	typed := query
+	if query, err = s.rewrite(ctx, c, query); err != nil {
+		s.log.Error("failed to normalize rewritten req", "error", err)
+		return Found{Comics: []Comics{}}, Query{}, err
+	}
+
+	fetch := c.fetch(limit)
+	found, err := s.relaxed(query, func(query Query) ([]Comics, error) {
+		return s.cached(EngineDB, fetch, query.String(), query.Options, func() ([]Comics, error) {
+			comics, err := s.db.SearchComics(ctx, fetch, query)
+			if err != nil {
+				s.log.Error("failed to search comics in db", "error", err)
+				return nil, err
+			}
+			return s.withSnippets(ctx, comics, query), nil
+		})
+	})
+	if err != nil {
+		return Found{Comics: []Comics{}}, Query{}, err
+	}
+	found.Comics = s.curate(ctx, c, typed, query, found.Comics, limit)
+	return found, typed, nil
+}
+
+// IndexSearch is Search by the index.
+func (s Service) IndexSearch(ctx context.Context, limit int, phrase string, opts Options) (Found, error) {
+	start := time.Now()
+	query, err := s.query(ctx, phrase)
+	if err != nil {
+		s.log.Error("failed to normalize req", "error", err)
+		return Found{Comics: []Comics{}}, err
+	}
+	query.Options = opts
+
+	c := s.curation()
+	typed := query
+	if query, err = s.rewrite(ctx, c, query); err != nil {
+		s.log.Error("failed to normalize rewritten req", "error", err)
+		return Found{Comics: []Comics{}}, err
+	}
+
+	fetch := c.fetch(limit)
+	found, err := s.relaxed(query, func(query Query) ([]Comics, error) {
+		return s.cached(EngineIndex, fetch, query.String(), query.Options, func() ([]Comics, error) {
+			comics, err := s.index.SearchByIndex(ctx, fetch, query)
+			if err != nil {
+				s.log.Error("failed to isearch comics in db", "error", err)
+				return nil, err
+			}
+			return s.withSnippets(ctx, comics, query), nil
+		})
+	})
+	if err != nil {
+		return Found{Comics: []Comics{}}, err
+	}
+	found.Comics = s.curate(ctx, c, typed, query, found.Comics, limit)
+
+	s.record(EngineIndex, typed.String(), opts, len(found.Comics), start)
+	return found, nil
+}
+
+// FTSSearch skips our normalization: the phrase goes to the database as is,
+// so the two analyzers can be compared, and it is cached by the phrase. Our
+// normalization only serves the snippets and the search log, which keeps the
+// phrase as is if it cannot be normalized. The blocked comics are dropped,
+// the pins and the rewrites are for our normalization only.
+func (s Service) FTSSearch(ctx context.Context, limit int, phrase string, opts Options) ([]Comics, error) {
+	start := time.Now()
+	logged := ""
+	c := s.curation()
+	fetch := c.fetch(limit)
+	comics, err := s.cached(EngineFTS, fetch, phrase, opts, func() ([]Comics, error) {
+		comics, err := s.fts.Search(ctx, fetch, phrase, opts)
+		if err != nil {
+			s.log.Error("failed to full-text search comics", "error", err)
+			return nil, err
+		}
 
-	comics, err := s.db.SearchComics(ctx, limit, normReq)
+		query, err := s.query(ctx, phrase)
+		if err != nil {
+			s.log.Error("failed to normalize req", "error", err)
+			logged = phrase
+			return comics, nil
+		}
+		logged = query.String()
+		return s.withSnippets(ctx, comics, query), nil
+	})
 	if err != nil {
-		s.log.Error("failed to search comics in db", "error", err)
 		return []Comics{}, err
 	}
+	comics = c.unblocked(comics, limit)
 
+	// a cached search is normalized for the search log only
+	if logged == "" {
+		logged = phrase
+		if query, err := s.query(ctx, phrase); err == nil {
+			logged = query.String()
+		}
+	}
+	s.record(EngineFTS, logged, opts, len(comics), start)
 	return comics, nil
 }
 
-func (s Service) IndexSearch(ctx context.Context, limit int, phrase string) ([]Comics, error) {
-	normReq, err := s.words.Norm(ctx, phrase)
+// SemanticSearch finds the comics closest in meaning to the phrase, with
+// its words or not. They are ordered by their similarity, so the search
+// cannot sort by date. The blocked comics are dropped.
+func (s Service) SemanticSearch(ctx context.Context, limit int, phrase string, opts Options) ([]Comics, error) {
+	if opts.Sort != SortRelevance && opts.Sort != "" {
+		return []Comics{}, fmt.Errorf("%w: semantic search sorts by relevance only", ErrBadArguments)
+	}
+
+	start := time.Now()
+	query, err := s.query(ctx, phrase)
 	if err != nil {
 		s.log.Error("failed to normalize req", "error", err)
 		return []Comics{}, err
 	}
+	query.Options = opts
 
-	comics, err := s.index.SearchByIndex(ctx, limit, normReq)
+	c := s.curation()
+	fetch := c.fetch(limit)
+	comics, err := s.cached(EngineSemantic, fetch, query.String(), opts, func() ([]Comics, error) {
+		comics, err := s.index.SemanticSearch(ctx, fetch, query)
+		if err != nil {
+			s.log.Error("failed to semantic search comics", "error", err)
+			return nil, err
+		}
+		return s.withSnippets(ctx, comics, query), nil
+	})
 	if err != nil {
-		s.log.Error("failed to isearch comics in db", "error", err)
 		return []Comics{}, err
 	}
+	comics = c.unblocked(comics, limit)
 
+	s.record(EngineSemantic, query.String(), opts, len(comics), start)
 	return comics, nil
 }
+
+// Similar drops the blocked comics.
+func (s Service) Similar(ctx context.Context, source string, id, limit int) ([]Comics, error) {
+	c := s.curation()
+	comics, err := s.index.Similar(ctx, source, id, c.fetch(limit))
+	if err != nil {
+		s.log.Error("failed to find similar comics", "source", source, "comic_id", id, "error", err)
+		return []Comics{}, err
+	}
+
+	return c.unblocked(comics, limit), nil
+}
+
+// Explain runs a search of the engine telling how every comic found was
+// scored. It is meant for the admins puzzled by a ranking.
+func (s Service) Explain(ctx context.Context, engine Engine, limit int, phrase string, opts Options) (Explain, error) {
+	query, err := s.query(ctx, phrase)
+	if err != nil {
+		s.log.Error("failed to normalize req", "error", err)
+		return Explain{}, err
+	}
+	query.Options = opts
+
+	var explain Explain
+	switch engine {
+	case EngineDB:
+		explain, err = s.db.ExplainSearch(ctx, limit, query)
+		explain.Terms, explain.Formula = query.Terms, FormulaTerms
+	case EngineIndex:
+		explain.Comics, err = s.index.ExplainSearch(ctx, limit, query)
+		explain.Terms, explain.Formula = query.Terms, FormulaTerms
+	case EngineFTS:
+		explain, err = s.fts.Explain(ctx, limit, phrase, opts)
+		explain.Formula = FormulaFTS
+	default:
+		return Explain{}, fmt.Errorf("%w: unknown engine %q", ErrBadArguments, engine)
+	}
+	if err != nil {
+		s.log.Error("failed to explain search", "engine", engine, "error", err)
+		return Explain{}, err
+	}
+
+	explain.Comics = s.withSnippets(ctx, explain.Comics, query)
+	return explain, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -22,13 +23,29 @@ type MockIndex struct {
 	mock.Mock
 }
 
-func (m *MockDB) SearchComics(ctx context.Context, limit int, keywords []string) ([]Comics, error) {
-	args := m.Called(ctx, limit, keywords)
+type MockFullText struct {
+	mock.Mock
+}
+
+type MockSearchLog struct {
+	mock.Mock
+}
+
+type MockCache struct {
+	mock.Mock
+}
+
+type MockCurations struct {
+	mock.Mock
+}
+
+func (m *MockDB) SearchComics(ctx context.Context, limit int, query Query) ([]Comics, error) {
+	args := m.Called(ctx, limit, query)
 	return args.Get(0).([]Comics), args.Error(1)
 }
 
-func (m *MockDB) GetImageURL(ctx context.Context, id int) (string, error) {
-	args := m.Called(ctx, id)
+func (m *MockDB) GetImageURL(ctx context.Context, source string, id int) (string, error) {
+	args := m.Called(ctx, source, id)
 	return args.String(0), args.Error(1)
 }
 
@@ -37,16 +54,127 @@ func (m *MockDB) GetComics(ctx context.Context) ([]Comics, error) {
 	return args.Get(0).([]Comics), args.Error(1)
 }
 
+func (m *MockDB) GetTexts(ctx context.Context, ids []ComicID) (map[ComicID]Text, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).(map[ComicID]Text), args.Error(1)
+}
+
+func (m *MockDB) RandomComics(ctx context.Context, limit int, opts Options) ([]Comics, error) {
+	args := m.Called(ctx, limit, opts)
+	return args.Get(0).([]Comics), args.Error(1)
+}
+
+func (m *MockDB) OnThisDay(ctx context.Context, month time.Month, day, year, limit int) ([]Comics, error) {
+	args := m.Called(ctx, month, day, year, limit)
+	return args.Get(0).([]Comics), args.Error(1)
+}
+
+func (m *MockDB) ExplainSearch(ctx context.Context, limit int, query Query) (Explain, error) {
+	args := m.Called(ctx, limit, query)
+	return args.Get(0).(Explain), args.Error(1)
+}
+
+func (m *MockWords) Tokens(ctx context.Context, texts []string) ([][]Token, error) {
+	args := m.Called(ctx, texts)
+	return args.Get(0).([][]Token), args.Error(1)
+}
+
 func (m *MockWords) Norm(ctx context.Context, phrase string) ([]string, error) {
 	args := m.Called(ctx, phrase)
 	return args.Get(0).([]string), args.Error(1)
 }
 
-func (m *MockIndex) SearchByIndex(ctx context.Context, limit int, keywords []string) ([]Comics, error) {
-	args := m.Called(ctx, limit, keywords)
+func (m *MockIndex) SearchByIndex(ctx context.Context, limit int, query Query) ([]Comics, error) {
+	args := m.Called(ctx, limit, query)
+	return args.Get(0).([]Comics), args.Error(1)
+}
+
+func (m *MockIndex) ExplainSearch(ctx context.Context, limit int, query Query) ([]Comics, error) {
+	args := m.Called(ctx, limit, query)
 	return args.Get(0).([]Comics), args.Error(1)
 }
 
+func (m *MockIndex) SemanticSearch(ctx context.Context, limit int, query Query) ([]Comics, error) {
+	args := m.Called(ctx, limit, query)
+	return args.Get(0).([]Comics), args.Error(1)
+}
+
+func (m *MockIndex) Similar(ctx context.Context, source string, id, limit int) ([]Comics, error) {
+	args := m.Called(ctx, source, id, limit)
+	return args.Get(0).([]Comics), args.Error(1)
+}
+
+func (m *MockIndex) DocFreq(term Term) int {
+	args := m.Called(term)
+	return args.Int(0)
+}
+
+func (m *MockIndex) Fuzzy(word string, distance int) []string {
+	args := m.Called(word, distance)
+	return args.Get(0).([]string)
+}
+
+func (m *MockIndex) Generation() uint64 {
+	args := m.Called()
+	return args.Get(0).(uint64)
+}
+
+func (m *MockFullText) Search(ctx context.Context, limit int, phrase string, opts Options) ([]Comics, error) {
+	args := m.Called(ctx, limit, phrase, opts)
+	return args.Get(0).([]Comics), args.Error(1)
+}
+
+func (m *MockFullText) Explain(ctx context.Context, limit int, phrase string, opts Options) (Explain, error) {
+	args := m.Called(ctx, limit, phrase, opts)
+	return args.Get(0).(Explain), args.Error(1)
+}
+
+func (m *MockSearchLog) Record(record SearchRecord) {
+	m.Called(record)
+}
+
+func (m *MockSearchLog) TopQueries(ctx context.Context, since time.Time, limit int) ([]QueryCount, error) {
+	args := m.Called(ctx, since, limit)
+	return args.Get(0).([]QueryCount), args.Error(1)
+}
+
+func (m *MockSearchLog) ZeroResultQueries(ctx context.Context, since time.Time, limit int) ([]QueryCount, error) {
+	args := m.Called(ctx, since, limit)
+	return args.Get(0).([]QueryCount), args.Error(1)
+}
+
+func (m *MockSearchLog) Latency(ctx context.Context, since time.Time) ([]LatencyStats, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).([]LatencyStats), args.Error(1)
+}
+
+func (m *MockCache) Get(key string) ([]Comics, bool) {
+	args := m.Called(key)
+	return args.Get(0).([]Comics), args.Bool(1)
+}
+
+func (m *MockCache) Put(key string, comics []Comics) {
+	m.Called(key, comics)
+}
+
+func (m *MockCache) Purge() {
+	m.Called()
+}
+
+func (m *MockCache) Stats() CacheStats {
+	args := m.Called()
+	return args.Get(0).(CacheStats)
+}
+
+// unscoped makes a query of words matching any field.
+func unscoped(words ...string) Query {
+	query := Query{}
+	for _, word := range words {
+		query.Terms = append(query.Terms, Term{Word: word})
+	}
+	return query
+}
+
 func TestService_Search(t *testing.T) {
 	ctx := context.Background()
 
@@ -54,6 +182,7 @@ func TestService_Search(t *testing.T) {
 		name        string
 		phrase      string
 		limit       int
+		opts        Options
 		mockNorm    []string
 		mockNormErr error
 		mockDBRes   []Comics
@@ -75,6 +204,19 @@ func TestService_Search(t *testing.T) {
 				{ID: 2, URL: "url2"},
 			},
 		},
+		{
+			name:     "options are passed on",
+			phrase:   "python",
+			limit:    5,
+			opts:     Options{MinID: 100, MaxID: 200, Sort: SortNewest},
+			mockNorm: []string{"python"},
+			mockDBRes: []Comics{
+				{ID: 150, URL: "url150"},
+			},
+			want: []Comics{
+				{ID: 150, URL: "url150"},
+			},
+		},
 		{
 			name:        "failed to norm",
 			phrase:      "invalid",
@@ -108,8 +250,11 @@ func TestService_Search(t *testing.T) {
 
 			mockWords.On("Norm", ctx, tt.phrase).Return(tt.mockNorm, tt.mockNormErr)
 			if tt.mockNormErr == nil {
-				mockDB.On("SearchComics", ctx, tt.limit, tt.mockNorm).
+				query := unscoped(tt.mockNorm...)
+				query.Options = tt.opts
+				mockDB.On("SearchComics", ctx, tt.limit, query).
 					Return(tt.mockDBRes, tt.mockDBErr)
+				mockDB.On("GetTexts", ctx, mock.Anything).Return(map[ComicID]Text{}, nil).Maybe()
 			}
 
 			service := &Service{
@@ -119,13 +264,13 @@ func TestService_Search(t *testing.T) {
 				index: mockIndex,
 			}
 
-			got, err := service.Search(ctx, tt.limit, tt.phrase)
+			got, err := service.Search(ctx, tt.limit, tt.phrase, tt.opts)
 
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.want, got)
+				assert.Equal(t, Found{Comics: tt.want}, got)
 			}
 
 			mockWords.AssertExpectations(t)
@@ -189,8 +334,9 @@ func TestService_IndexSearch(t *testing.T) {
 
 			mockWords.On("Norm", ctx, tt.phrase).Return(tt.mockNorm, tt.mockNormErr)
 			if tt.mockNormErr == nil {
-				mockIndex.On("SearchByIndex", ctx, tt.limit, tt.mockNorm).
+				mockIndex.On("SearchByIndex", ctx, tt.limit, unscoped(tt.mockNorm...)).
 					Return(tt.mockIndexRes, tt.mockIndexErr)
+				mockDB.On("GetTexts", ctx, mock.Anything).Return(map[ComicID]Text{}, nil).Maybe()
 			}
 
 			service := &Service{
@@ -200,13 +346,13 @@ func TestService_IndexSearch(t *testing.T) {
 				index: mockIndex,
 			}
 
-			got, err := service.IndexSearch(ctx, tt.limit, tt.phrase)
+			got, err := service.IndexSearch(ctx, tt.limit, tt.phrase, Options{})
 
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.want, got)
+				assert.Equal(t, Found{Comics: tt.want}, got)
 			}
 
 			mockWords.AssertExpectations(t)
@@ -217,16 +363,451 @@ func TestService_IndexSearch(t *testing.T) {
 	}
 }
 
+func TestService_SemanticSearch(t *testing.T) {
+	ctx := context.Background()
+	query := unscoped("cat")
+
+	tests := []struct {
+		name      string
+		opts      Options
+		mockSetup func(*MockIndex)
+		want      []Comics
+		wantErr   error
+	}{
+		{
+			name: "successful semantic search",
+			mockSetup: func(idx *MockIndex) {
+				idx.On("SemanticSearch", ctx, 5, query).Return([]Comics{{ID: 2}, {ID: 7}}, nil)
+			},
+			want: []Comics{{ID: 2}, {ID: 7}},
+		},
+		{
+			name: "semantic search disabled",
+			mockSetup: func(idx *MockIndex) {
+				idx.On("SemanticSearch", ctx, 5, query).Return([]Comics(nil), ErrBadArguments)
+			},
+			want:    []Comics{},
+			wantErr: ErrBadArguments,
+		},
+		{
+			name:      "date sort",
+			opts:      Options{Sort: SortOldest},
+			mockSetup: func(idx *MockIndex) {},
+			want:      []Comics{},
+			wantErr:   ErrBadArguments,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockWords := new(MockWords)
+			mockDB := new(MockDB)
+			mockIndex := new(MockIndex)
+			mockWords.On("Norm", ctx, "cats").Return([]string{"cat"}, nil).Maybe()
+			mockDB.On("GetTexts", ctx, mock.Anything).Return(map[ComicID]Text{}, nil).Maybe()
+			tt.mockSetup(mockIndex)
+
+			service := &Service{
+				log:   slog.Default(),
+				db:    mockDB,
+				words: mockWords,
+				index: mockIndex,
+			}
+
+			got, err := service.SemanticSearch(ctx, 5, "cats", tt.opts)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+			mockIndex.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_FTSSearch(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		phrase     string
+		limit      int
+		mockFTSRes []Comics
+		mockFTSErr error
+		want       []Comics
+		wantErr    bool
+	}{
+		{
+			name:   "phrase is passed as is",
+			phrase: `"funny cats" or dogs`,
+			limit:  5,
+			mockFTSRes: []Comics{
+				{ID: 2, URL: "url2"},
+			},
+			want: []Comics{
+				{ID: 2, URL: "url2"},
+			},
+		},
+		{
+			name:       "fts error",
+			phrase:     "cats",
+			limit:      3,
+			mockFTSErr: errors.New("failed to search"),
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockWords := new(MockWords)
+			mockDB := new(MockDB)
+			mockFTS := new(MockFullText)
+
+			mockFTS.On("Search", ctx, tt.limit, tt.phrase, Options{Sort: SortOldest}).Return(tt.mockFTSRes, tt.mockFTSErr)
+			mockWords.On("Norm", ctx, tt.phrase).Return([]string{"cat"}, nil).Maybe()
+			mockDB.On("GetTexts", ctx, mock.Anything).Return(map[ComicID]Text{}, nil).Maybe()
+
+			service := &Service{
+				log:   slog.Default(),
+				db:    mockDB,
+				words: mockWords,
+				fts:   mockFTS,
+			}
+
+			got, err := service.FTSSearch(ctx, tt.limit, tt.phrase, Options{Sort: SortOldest})
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			mockFTS.AssertExpectations(t)
+			if tt.mockFTSErr != nil {
+				mockWords.AssertNotCalled(t, "Norm", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestService_Similar(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		mockIndexRes []Comics
+		mockIndexErr error
+		want         []Comics
+		wantErr      error
+	}{
+		{
+			name:         "similar comics",
+			mockIndexRes: []Comics{{ID: 2, Source: "xkcd", URL: "url2"}},
+			want:         []Comics{{ID: 2, Source: "xkcd", URL: "url2"}},
+		},
+		{
+			name:         "not indexed",
+			mockIndexRes: []Comics{},
+			mockIndexErr: ErrNotFound,
+			want:         []Comics{},
+			wantErr:      ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockIndex := new(MockIndex)
+			mockIndex.On("Similar", ctx, "xkcd", 1, 5).Return(tt.mockIndexRes, tt.mockIndexErr)
+
+			service := &Service{log: slog.Default(), index: mockIndex}
+			got, err := service.Similar(ctx, "xkcd", 1, 5)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+			mockIndex.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Explain(t *testing.T) {
+	ctx := context.Background()
+	opts := Options{MinID: 100}
+	query := unscoped("cat")
+	query.Options = opts
+	explained := []Comics{{ID: 1, URL: "url1", Explanation: &Explanation{Score: 1, Matches: []Match{
+		NewMatch(Term{Word: "cat"}, "", 1, 1),
+	}}}}
+
+	tests := []struct {
+		name      string
+		engine    Engine
+		mockSetup func(*MockDB, *MockIndex, *MockFullText)
+		want      Explain
+		wantErr   error
+	}{
+		{
+			name:   "db",
+			engine: EngineDB,
+			mockSetup: func(db *MockDB, _ *MockIndex, _ *MockFullText) {
+				db.On("ExplainSearch", ctx, 5, query).Return(Explain{Plan: []string{"Limit"}, Comics: explained}, nil)
+			},
+			want: Explain{Terms: query.Terms, Plan: []string{"Limit"}, Formula: FormulaTerms, Comics: explained},
+		},
+		{
+			name:   "index",
+			engine: EngineIndex,
+			mockSetup: func(_ *MockDB, index *MockIndex, _ *MockFullText) {
+				index.On("ExplainSearch", ctx, 5, query).Return(explained, nil)
+			},
+			want: Explain{Terms: query.Terms, Formula: FormulaTerms, Comics: explained},
+		},
+		{
+			name:   "full-text keeps its own query",
+			engine: EngineFTS,
+			mockSetup: func(_ *MockDB, _ *MockIndex, fts *MockFullText) {
+				fts.On("Explain", ctx, 5, "cats", opts).Return(Explain{TSQuery: "'cat'", Comics: explained}, nil)
+			},
+			want: Explain{TSQuery: "'cat'", Formula: FormulaFTS, Comics: explained},
+		},
+		{
+			name:      "unknown engine",
+			engine:    "grep",
+			mockSetup: func(*MockDB, *MockIndex, *MockFullText) {},
+			wantErr:   ErrBadArguments,
+		},
+		{
+			name:   "engine error",
+			engine: EngineIndex,
+			mockSetup: func(_ *MockDB, index *MockIndex, _ *MockFullText) {
+				index.On("ExplainSearch", ctx, 5, query).Return([]Comics(nil), assert.AnError)
+			},
+			wantErr: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockWords := new(MockWords)
+			mockDB := new(MockDB)
+			mockIndex := new(MockIndex)
+			mockFTS := new(MockFullText)
+
+			mockWords.On("Norm", ctx, "cats").Return([]string{"cat"}, nil)
+			mockDB.On("GetTexts", ctx, mock.Anything).Return(map[ComicID]Text{}, nil).Maybe()
+			tt.mockSetup(mockDB, mockIndex, mockFTS)
+
+			service := &Service{
+				log:   slog.Default(),
+				db:    mockDB,
+				words: mockWords,
+				index: mockIndex,
+				fts:   mockFTS,
+			}
+
+			got, err := service.Explain(ctx, tt.engine, 5, "cats", opts)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			mockDB.AssertExpectations(t)
+			mockIndex.AssertExpectations(t)
+			mockFTS.AssertExpectations(t)
+		})
+	}
+}
+
+func (m *MockCurations) Load(ctx context.Context) (Curation, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(Curation), args.Error(1)
+}
+
+func (m *MockCurations) PutPin(ctx context.Context, pin Pin) error {
+	return m.Called(ctx, pin).Error(0)
+}
+
+func (m *MockCurations) DeletePin(ctx context.Context, query string) error {
+	return m.Called(ctx, query).Error(0)
+}
+
+func (m *MockCurations) PutRewrite(ctx context.Context, rewrite Rewrite) error {
+	return m.Called(ctx, rewrite).Error(0)
+}
+
+func (m *MockCurations) DeleteRewrite(ctx context.Context, query string) error {
+	return m.Called(ctx, query).Error(0)
+}
+
+func (m *MockCurations) Block(ctx context.Context, id ComicID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockCurations) Unblock(ctx context.Context, id ComicID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
 func TestNewService(t *testing.T) {
 	mockDB := new(MockDB)
 	mockWords := new(MockWords)
 	mockIndex := new(MockIndex)
+	mockFTS := new(MockFullText)
+	mockSearchLog := new(MockSearchLog)
+	mockCache := new(MockCache)
+	mockCurations := new(MockCurations)
 
-	service, err := NewService(slog.Default(), mockDB, mockWords, mockIndex)
+	service, err := NewService(slog.Default(), mockDB, mockWords, mockIndex, mockFTS, mockSearchLog, mockCache, mockCurations, Boosts{Title: 3, Alt: 2, Transcript: 1}, time.Second)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, service)
 	assert.Equal(t, mockDB, service.db)
 	assert.Equal(t, mockWords, service.words)
 	assert.Equal(t, mockIndex, service.index)
+	assert.Equal(t, mockFTS, service.fts)
+	assert.Equal(t, mockSearchLog, service.searchLog)
+	assert.Equal(t, mockCache, service.cache)
+	assert.NotNil(t, service.generation)
+	assert.Equal(t, mockCurations, service.curations)
+	assert.NotNil(t, service.curated)
+	assert.Equal(t, Boosts{Title: 3, Alt: 2, Transcript: 1}, service.boosts)
+	assert.Equal(t, time.Second, service.retrieverTimeout)
+}
+
+func TestService_Query(t *testing.T) {
+	ctx := context.Background()
+	boosts := Boosts{Title: 3, Alt: 2, Transcript: 1}
+
+	tests := []struct {
+		name      string
+		phrase    string
+		mockSetup func(*MockWords)
+		want      Query
+		wantErr   bool
+	}{
+		{
+			name:   "free text only",
+			phrase: "funny cats",
+			mockSetup: func(m *MockWords) {
+				m.On("Norm", ctx, "funny cats").Return([]string{"funni", "cat"}, nil)
+			},
+			want: Query{Terms: []Term{{Word: "funni"}, {Word: "cat"}}, Boosts: boosts},
+		},
+		{
+			name:   "scoped words",
+			phrase: "Title:Python snakes alt:pythons transcript:the",
+			mockSetup: func(m *MockWords) {
+				m.On("Norm", ctx, "snakes").Return([]string{"snake"}, nil)
+				m.On("Norm", ctx, "Python").Return([]string{"python"}, nil)
+				m.On("Norm", ctx, "pythons").Return([]string{"python"}, nil)
+				m.On("Norm", ctx, "the").Return([]string{}, nil)
+			},
+			want: Query{Terms: []Term{
+				{Word: "snake"},
+				{Field: FieldTitle, Word: "python"},
+				{Field: FieldAlt, Word: "python"},
+			}, Boosts: boosts},
+		},
+		{
+			name:   "tag",
+			phrase: "Tag:Romance math",
+			mockSetup: func(m *MockWords) {
+				m.On("Norm", ctx, "math").Return([]string{"math"}, nil)
+				m.On("Norm", ctx, "Romance").Return([]string{"romanc"}, nil)
+			},
+			want: Query{Terms: []Term{
+				{Word: "math"},
+				{Field: FieldTag, Word: "romanc"},
+			}, Boosts: boosts},
+		},
+		{
+			name:   "unknown field and empty word stay in text",
+			phrase: "author:randall title: title:python title:python",
+			mockSetup: func(m *MockWords) {
+				m.On("Norm", ctx, "author:randall title:").Return([]string{"author", "randal", "titl"}, nil)
+				m.On("Norm", ctx, "python").Return([]string{"python"}, nil)
+			},
+			want: Query{Terms: []Term{
+				{Word: "author"}, {Word: "randal"}, {Word: "titl"},
+				{Field: FieldTitle, Word: "python"},
+			}, Boosts: boosts},
+		},
+		{
+			name:   "norm error",
+			phrase: "title:python",
+			mockSetup: func(m *MockWords) {
+				m.On("Norm", ctx, "python").Return([]string(nil), errors.New("words error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockWords := new(MockWords)
+			tt.mockSetup(mockWords)
+
+			service := &Service{log: slog.Default(), words: mockWords, boosts: boosts}
+			got, err := service.query(ctx, tt.phrase)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("query error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got)
+			}
+			mockWords.AssertExpectations(t)
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Sort
+		wantErr error
+	}{
+		{in: "", want: SortRelevance},
+		{in: "relevance", want: SortRelevance},
+		{in: "newest", want: SortNewest},
+		{in: "oldest", want: SortOldest},
+		{in: "random", wantErr: ErrBadArguments},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSort(tt.in)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOptions_Match(t *testing.T) {
+	date := func(year, month, day int) *time.Time {
+		d := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+	between := Options{From: *date(2010, 1, 1), To: *date(2012, 12, 31)}
+
+	tests := []struct {
+		name      string
+		opts      Options
+		id        int
+		published *time.Time
+		want      bool
+	}{
+		{name: "no bounds", opts: Options{}, id: 1, want: true},
+		{name: "within ids", opts: Options{MinID: 10, MaxID: 20}, id: 20, want: true},
+		{name: "below min id", opts: Options{MinID: 10}, id: 9, want: false},
+		{name: "above max id", opts: Options{MaxID: 20}, id: 21, want: false},
+		{name: "first day", opts: between, id: 1, published: date(2010, 1, 1), want: true},
+		{name: "last day", opts: between, id: 1, published: date(2012, 12, 31), want: true},
+		{name: "before", opts: between, id: 1, published: date(2009, 12, 31), want: false},
+		{name: "after", opts: Options{To: *date(2012, 12, 31)}, id: 1, published: date(2013, 1, 1), want: false},
+		{name: "undated", opts: Options{From: *date(2010, 1, 1)}, id: 1, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.opts.Match(tt.id, tt.published))
+		})
+	}
 }
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+	// snippetSize is the longest snippet in bytes, ellipses aside.
+	snippetSize = 160
+	// snippetLead is how much text is kept before the first match.
+	snippetLead = 40
+)
+
+// withSnippets adds a snippet to every found comic, picking the field with
+// most matched words, the title winning ties. The texts of all the comics
+// are fetched in one query and tokenized in one call; if either fails the
+// comics are returned without snippets.
+func (s Service) withSnippets(ctx context.Context, comics []Comics, query Query) []Comics {
+	if len(query.Terms) == 0 || len(comics) == 0 {
+		return comics
+	}
+
+	ids := make([]ComicID, len(comics))
+	for i, comic := range comics {
+		ids[i] = ComicID{comic.Source, comic.ID}
+	}
+	texts, err := s.db.GetTexts(ctx, ids)
+	if err != nil {
+		s.log.Error("failed to get texts for snippets", "error", err)
+		return comics
+	}
+
+	type field struct {
+		comic      int
+		name, text string
+	}
+	var fields []field
+	for i, id := range ids {
+		text := texts[id]
+		for _, f := range []field{
+			{i, FieldTitle, text.Title},
+			{i, FieldAlt, text.Alt},
+			{i, FieldTranscript, text.Transcript},
+		} {
+			if f.text != "" {
+				fields = append(fields, f)
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return comics
+	}
+
+	batch := make([]string, len(fields))
+	for i, f := range fields {
+		batch[i] = f.text
+	}
+	tokens, err := s.words.Tokens(ctx, batch)
+	if err != nil {
+		s.log.Error("failed to tokenize texts for snippets", "error", err)
+		return comics
+	}
+
+	for i, f := range fields {
+		snippet := cut(f.name, f.text, tokens[i], func(stem string) bool {
+			for _, term := range query.Terms {
+				if term.Word == stem && (term.Field == "" || term.Field == f.name) {
+					return true
+				}
+			}
+			return false
+		})
+		if len(snippet.Highlights) > len(comics[f.comic].Snippet.Highlights) {
+			comics[f.comic].Snippet = snippet
+		}
+	}
+	return comics
+}
+
+// cut returns up to snippetSize bytes of the text starting a little before
+// the first matched token, on token boundaries.
+func cut(field, text string, tokens []Token, match func(stem string) bool) Snippet {
+	var matched []Token
+	for _, token := range tokens {
+		if match(token.Stem) {
+			matched = append(matched, token)
+		}
+	}
+	if len(matched) == 0 {
+		return Snippet{}
+	}
+
+	begin, end := 0, len(text)
+	if len(text) > snippetSize {
+		if begin = matched[0].Start - snippetLead; begin > 0 {
+			for _, token := range tokens {
+				if token.Start >= begin {
+					begin = token.Start
+					break
+				}
+			}
+		} else {
+			begin = 0
+		}
+		end = matched[0].End
+		for _, token := range tokens {
+			if token.End > begin+snippetSize {
+				break
+			}
+			end = max(end, token.End)
+		}
+	}
+
+	var sb strings.Builder
+	if begin > 0 {
+		sb.WriteString("… ")
+	}
+	shift := sb.Len() - begin
+	sb.WriteString(text[begin:end])
+	if end < len(text) {
+		sb.WriteString(" …")
+	}
+
+	snippet := Snippet{Field: field, Text: sb.String()}
+	for _, token := range matched {
+		if token.Start >= begin && token.End <= end {
+			snippet.Highlights = append(snippet.Highlights, Highlight{Start: token.Start + shift, End: token.End + shift})
+		}
+	}
+	return snippet
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCut(t *testing.T) {
+	long := strings.Repeat("word ", 20) + "python" + strings.Repeat(" word", 40)
+	var longTokens []Token
+	for i := 0; i < 20; i++ {
+		longTokens = append(longTokens, Token{Stem: "word", Start: i * 5, End: i*5 + 4})
+	}
+	longTokens = append(longTokens, Token{Stem: "python", Start: 100, End: 106})
+	for i := 0; i < 40; i++ {
+		longTokens = append(longTokens, Token{Stem: "word", Start: 107 + i*5, End: 111 + i*5})
+	}
+
+	tests := []struct {
+		name   string
+		text   string
+		tokens []Token
+		want   Snippet
+	}{
+		{
+			name: "short text is kept whole",
+			text: "Python snakes",
+			tokens: []Token{
+				{Stem: "python", Start: 0, End: 6},
+				{Stem: "snake", Start: 7, End: 13},
+			},
+			want: Snippet{Field: FieldTitle, Text: "Python snakes", Highlights: []Highlight{{Start: 0, End: 6}}},
+		},
+		{
+			name:   "no match",
+			text:   "Snakes",
+			tokens: []Token{{Stem: "snake", Start: 0, End: 6}},
+			want:   Snippet{},
+		},
+		{
+			name:   "long text is cut around the match",
+			text:   long,
+			tokens: longTokens,
+			want: Snippet{
+				Field:      FieldTitle,
+				Text:       "… " + long[60:216] + " …",
+				Highlights: []Highlight{{Start: 44, End: 50}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cut(FieldTitle, tt.text, tt.tokens, func(stem string) bool { return stem == "python" })
+			assert.Equal(t, tt.want, got)
+			for _, h := range got.Highlights {
+				assert.Equal(t, "python", strings.ToLower(got.Text[h.Start:h.End]))
+			}
+		})
+	}
+}
+
+func TestService_WithSnippets(t *testing.T) {
+	ctx := context.Background()
+	ids := []ComicID{{"xkcd", 1}, {"xkcd", 2}}
+	texts := map[ComicID]Text{
+		{"xkcd", 1}: {Title: "Snakes", Alt: "A python and a python", Transcript: "python"},
+		{"xkcd", 2}: {Title: "Python"},
+	}
+	batch := []string{"Snakes", "A python and a python", "python", "Python"}
+	tokens := [][]Token{
+		{{Stem: "snake", Start: 0, End: 6}},
+		{{Stem: "python", Start: 2, End: 8}, {Stem: "python", Start: 15, End: 21}},
+		{{Stem: "python", Start: 0, End: 6}},
+		{{Stem: "python", Start: 0, End: 6}},
+	}
+
+	tests := []struct {
+		name      string
+		query     Query
+		mockSetup func(*MockDB, *MockWords)
+		want      []Snippet
+	}{
+		{
+			name:      "empty query",
+			query:     Query{},
+			mockSetup: func(*MockDB, *MockWords) {},
+			want:      []Snippet{{}, {}},
+		},
+		{
+			name:  "field with most matches",
+			query: unscoped("python"),
+			mockSetup: func(db *MockDB, words *MockWords) {
+				db.On("GetTexts", ctx, ids).Return(texts, nil).Once()
+				words.On("Tokens", ctx, batch).Return(tokens, nil).Once()
+			},
+			want: []Snippet{
+				{Field: FieldAlt, Text: "A python and a python", Highlights: []Highlight{
+					{Start: 2, End: 8}, {Start: 15, End: 21},
+				}},
+				{Field: FieldTitle, Text: "Python", Highlights: []Highlight{{Start: 0, End: 6}}},
+			},
+		},
+		{
+			name:  "scoped term matches its field only",
+			query: Query{Terms: []Term{{Field: FieldTranscript, Word: "python"}}},
+			mockSetup: func(db *MockDB, words *MockWords) {
+				db.On("GetTexts", ctx, ids).Return(texts, nil).Once()
+				words.On("Tokens", ctx, batch).Return(tokens, nil).Once()
+			},
+			want: []Snippet{
+				{Field: FieldTranscript, Text: "python", Highlights: []Highlight{{Start: 0, End: 6}}},
+				{},
+			},
+		},
+		{
+			name:  "db error",
+			query: unscoped("python"),
+			mockSetup: func(db *MockDB, words *MockWords) {
+				db.On("GetTexts", ctx, ids).Return(map[ComicID]Text(nil), errors.New("db error"))
+			},
+			want: []Snippet{{}, {}},
+		},
+		{
+			name:  "words error",
+			query: unscoped("python"),
+			mockSetup: func(db *MockDB, words *MockWords) {
+				db.On("GetTexts", ctx, ids).Return(texts, nil)
+				words.On("Tokens", ctx, batch).Return([][]Token(nil), errors.New("words error"))
+			},
+			want: []Snippet{{}, {}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			mockWords := new(MockWords)
+			tt.mockSetup(mockDB, mockWords)
+
+			service := &Service{log: slog.Default(), db: mockDB, words: mockWords}
+			comics := service.withSnippets(ctx, []Comics{{ID: 1, Source: "xkcd"}, {ID: 2, Source: "xkcd"}}, tt.query)
+			got := make([]Snippet, len(comics))
+			for i, comic := range comics {
+				got[i] = comic.Snippet
+			}
+			assert.Equal(t, tt.want, got)
+			mockDB.AssertExpectations(t)
+			mockWords.AssertExpectations(t)
+		})
+	}
+}
@@ -12,9 +12,14 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 	searchpb "yadro.com/course/proto/search"
+	"yadro.com/course/search/adapters/cache"
+	"yadro.com/course/search/adapters/curation"
 	"yadro.com/course/search/adapters/db"
+	"yadro.com/course/search/adapters/fts"
 	searchgrpc "yadro.com/course/search/adapters/grpc"
 	"yadro.com/course/search/adapters/index"
+	"yadro.com/course/search/adapters/searchlog"
+	"yadro.com/course/search/adapters/update"
 	"yadro.com/course/search/adapters/words"
 	"yadro.com/course/search/config"
 	"yadro.com/course/search/core"
@@ -40,8 +45,30 @@ func run() error {
 		return err
 	}
 
+	// full-text search adapter
+	fullText, err := fts.New(log, cfg.DBAddress)
+	if err != nil {
+		log.Error("failed to connect to db", "error", err)
+		return err
+	}
+
+	// search log adapter
+	searchLog, err := searchlog.New(log, cfg.DBAddress, cfg.SearchLogRetention)
+	if err != nil {
+		log.Error("failed to connect to db", "error", err)
+		return err
+	}
+	defer searchLog.Close()
+
+	// curation adapter
+	curations, err := curation.New(log, cfg.DBAddress)
+	if err != nil {
+		log.Error("failed to connect to db", "error", err)
+		return err
+	}
+
 	// index adapter
-	index := index.NewIndex(log, storage, cfg.IndexTTL)
+	index := index.NewIndex(log, storage, cfg.IndexTTL, cfg.IndexShards, cfg.SemanticDimensions)
 
 	// words adapter
 	words, err := words.NewClient(cfg.WordsAddress, log)
@@ -50,8 +77,19 @@ func run() error {
 		os.Exit(1)
 	}
 
+	// result cache
+	var results core.Cache
+	if cfg.Cache.Size > 0 {
+		results = cache.New(cfg.Cache.Size, cfg.Cache.TTL)
+	}
+
 	// service
-	searcher, err := core.NewService(log, storage, words, index)
+	searcher, err := core.NewService(log, storage, words, index, fullText, searchLog, results, curations, core.Boosts{
+		Title:      cfg.Boosts.Title,
+		Alt:        cfg.Boosts.Alt,
+		Transcript: cfg.Boosts.Transcript,
+		Tag:        cfg.Boosts.Tag,
+	}, cfg.HybridTimeout)
 	if err != nil {
 		log.Error("failed create Update service", "error", err)
 		return err
@@ -73,6 +111,23 @@ func run() error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
 
+	// curation, reloaded after every edit and periodically; the tables may
+	// not be migrated by the update service yet, the watcher retries then
+	if err := searcher.ReloadCuration(ctx); err != nil {
+		log.Warn("curation is not loaded yet", "error", err)
+	}
+	go searcher.WatchCuration(ctx, cfg.CurationReload)
+
+	// update watcher
+	if cfg.UpdateAddress != "" {
+		updates, err := update.NewClient(cfg.UpdateAddress, log)
+		if err != nil {
+			log.Error("failed create Update client", "error", err)
+			return err
+		}
+		go updates.Watch(ctx, searcher.Invalidate)
+	}
+
 	go func() {
 		<-ctx.Done()
 		log.Debug("shutting down server")
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS comics_analyzer_version_idx;
+
+ALTER TABLE comics
+    DROP COLUMN IF EXISTS title,
+    DROP COLUMN IF EXISTS safe_title,
+    DROP COLUMN IF EXISTS alt,
+    DROP COLUMN IF EXISTS transcript,
+    DROP COLUMN IF EXISTS analyzer_version;
@@ -0,0 +1,8 @@
+ALTER TABLE comics
+    ADD COLUMN title TEXT NOT NULL DEFAULT '',
+    ADD COLUMN safe_title TEXT NOT NULL DEFAULT '',
+    ADD COLUMN alt TEXT NOT NULL DEFAULT '',
+    ADD COLUMN transcript TEXT NOT NULL DEFAULT '',
+    ADD COLUMN analyzer_version INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX comics_analyzer_version_idx ON comics (analyzer_version);
@@ -0,0 +1,6 @@
+DELETE FROM comics WHERE source <> 'xkcd';
+
+ALTER TABLE comics DROP CONSTRAINT IF EXISTS comics_source_comic_id_key;
+ALTER TABLE comics ADD CONSTRAINT comics_comic_id_key UNIQUE (comic_id);
+
+ALTER TABLE comics DROP COLUMN IF EXISTS source;
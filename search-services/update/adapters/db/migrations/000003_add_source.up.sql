@@ -0,0 +1,4 @@
+ALTER TABLE comics ADD COLUMN source TEXT NOT NULL DEFAULT 'xkcd';
+
+ALTER TABLE comics DROP CONSTRAINT comics_comic_id_key;
+ALTER TABLE comics ADD CONSTRAINT comics_source_comic_id_key UNIQUE (source, comic_id);
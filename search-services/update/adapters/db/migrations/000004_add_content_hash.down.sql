@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS comic_versions;
+
+ALTER TABLE comics DROP COLUMN IF EXISTS content_hash;
@@ -0,0 +1,19 @@
+ALTER TABLE comics ADD COLUMN content_hash TEXT NOT NULL DEFAULT '';
+
+UPDATE comics SET content_hash = encode(sha256(convert_to(
+    concat_ws(chr(31), COALESCE(image_url, ''), title, safe_title, alt, transcript), 'UTF8')), 'hex');
+
+CREATE TABLE comic_versions (
+    id BIGSERIAL PRIMARY KEY,
+    source TEXT NOT NULL,
+    comic_id INTEGER NOT NULL,
+    image_url TEXT NOT NULL DEFAULT '',
+    title TEXT NOT NULL DEFAULT '',
+    safe_title TEXT NOT NULL DEFAULT '',
+    alt TEXT NOT NULL DEFAULT '',
+    transcript TEXT NOT NULL DEFAULT '',
+    content_hash TEXT NOT NULL,
+    replaced_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX comic_versions_comic_idx ON comic_versions (source, comic_id, replaced_at);
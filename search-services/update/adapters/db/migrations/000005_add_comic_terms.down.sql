@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS comics_sync_terms ON comics;
+DROP FUNCTION IF EXISTS sync_comic_terms();
+DROP TABLE IF EXISTS comic_terms;
@@ -0,0 +1,32 @@
+CREATE TABLE comic_terms (
+    term TEXT NOT NULL,
+    source TEXT NOT NULL,
+    comic_id INTEGER NOT NULL,
+    tf INTEGER NOT NULL,
+    PRIMARY KEY (term, source, comic_id),
+    FOREIGN KEY (source, comic_id) REFERENCES comics (source, comic_id)
+        ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX comic_terms_comic_idx ON comic_terms (source, comic_id);
+
+-- comic_terms mirrors comics.keywords, so writers of comics stay unaware of it
+CREATE FUNCTION sync_comic_terms() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM comic_terms WHERE source = NEW.source AND comic_id = NEW.comic_id;
+    INSERT INTO comic_terms (term, source, comic_id, tf)
+    SELECT kw, NEW.source, NEW.comic_id, COUNT(*)
+    FROM unnest(NEW.keywords) AS kw
+    GROUP BY kw;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER comics_sync_terms
+    AFTER INSERT OR UPDATE OF keywords ON comics
+    FOR EACH ROW EXECUTE FUNCTION sync_comic_terms();
+
+INSERT INTO comic_terms (term, source, comic_id, tf)
+SELECT kw, c.source, c.comic_id, COUNT(*)
+FROM comics AS c, unnest(c.keywords) AS kw
+GROUP BY kw, c.source, c.comic_id;
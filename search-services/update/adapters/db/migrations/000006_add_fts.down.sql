@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS comics_fts_idx;
+
+ALTER TABLE comics DROP COLUMN IF EXISTS fts;
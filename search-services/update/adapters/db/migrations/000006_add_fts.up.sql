@@ -0,0 +1,7 @@
+ALTER TABLE comics ADD COLUMN fts TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(alt, '')), 'B') ||
+    setweight(to_tsvector('english', COALESCE(transcript, '')), 'C')
+) STORED;
+
+CREATE INDEX comics_fts_idx ON comics USING GIN (fts);
@@ -0,0 +1,29 @@
+CREATE OR REPLACE FUNCTION sync_comic_terms() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM comic_terms WHERE source = NEW.source AND comic_id = NEW.comic_id;
+    INSERT INTO comic_terms (term, source, comic_id, tf)
+    SELECT kw, NEW.source, NEW.comic_id, COUNT(*)
+    FROM unnest(NEW.keywords) AS kw
+    GROUP BY kw;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS comics_sync_terms ON comics;
+CREATE TRIGGER comics_sync_terms
+    AFTER INSERT OR UPDATE OF keywords ON comics
+    FOR EACH ROW EXECUTE FUNCTION sync_comic_terms();
+
+DELETE FROM comic_terms;
+ALTER TABLE comic_terms DROP CONSTRAINT comic_terms_pkey;
+ALTER TABLE comic_terms DROP COLUMN IF EXISTS field;
+ALTER TABLE comic_terms ADD PRIMARY KEY (term, source, comic_id);
+INSERT INTO comic_terms (term, source, comic_id, tf)
+SELECT kw, c.source, c.comic_id, COUNT(*)
+FROM comics AS c, unnest(c.keywords) AS kw
+GROUP BY kw, c.source, c.comic_id;
+
+ALTER TABLE comics
+    DROP COLUMN IF EXISTS title_keywords,
+    DROP COLUMN IF EXISTS alt_keywords,
+    DROP COLUMN IF EXISTS transcript_keywords;
@@ -0,0 +1,36 @@
+ALTER TABLE comics
+    ADD COLUMN title_keywords TEXT[] DEFAULT '{}',
+    ADD COLUMN alt_keywords TEXT[] DEFAULT '{}',
+    ADD COLUMN transcript_keywords TEXT[] DEFAULT '{}';
+
+ALTER TABLE comic_terms ADD COLUMN field TEXT NOT NULL DEFAULT '';
+ALTER TABLE comic_terms DROP CONSTRAINT comic_terms_pkey;
+ALTER TABLE comic_terms ADD PRIMARY KEY (term, field, source, comic_id);
+
+-- terms are kept per field; comics stored before the fields were split have
+-- their keywords indexed under the empty field until they are reindexed
+CREATE OR REPLACE FUNCTION sync_comic_terms() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM comic_terms WHERE source = NEW.source AND comic_id = NEW.comic_id;
+    INSERT INTO comic_terms (term, field, source, comic_id, tf)
+    SELECT kw, f.field, NEW.source, NEW.comic_id, COUNT(*)
+    FROM (
+        SELECT 'title' AS field, unnest(NEW.title_keywords) AS kw
+        UNION ALL SELECT 'alt', unnest(NEW.alt_keywords)
+        UNION ALL SELECT 'transcript', unnest(NEW.transcript_keywords)
+        UNION ALL SELECT '', unnest(NEW.keywords)
+        WHERE COALESCE(cardinality(NEW.title_keywords), 0) + COALESCE(cardinality(NEW.alt_keywords), 0)
+            + COALESCE(cardinality(NEW.transcript_keywords), 0) = 0
+    ) AS f
+    GROUP BY kw, f.field;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER comics_sync_terms ON comics;
+CREATE TRIGGER comics_sync_terms
+    AFTER INSERT OR UPDATE OF keywords, title_keywords, alt_keywords, transcript_keywords ON comics
+    FOR EACH ROW EXECUTE FUNCTION sync_comic_terms();
+
+-- per-field keywords come from the words service, reindex fills them
+UPDATE comics SET analyzer_version = 0;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS comics_published_idx;
+ALTER TABLE comics DROP COLUMN IF EXISTS published;
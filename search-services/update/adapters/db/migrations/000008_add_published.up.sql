@@ -0,0 +1,4 @@
+-- publication dates come from the sources, refresh fills them for comics
+-- stored before
+ALTER TABLE comics ADD COLUMN published DATE;
+CREATE INDEX comics_published_idx ON comics (published);
@@ -0,0 +1,18 @@
+-- search_log is written by the search service, one row per search; old
+-- rows are purged by it after the retention period.
+--
+-- The services share one database with one migration history, run by the
+-- update service only, so the tables of the search service are migrated
+-- here too. The search service owns their rows and relies on the update
+-- service having migrated before it starts; it never changes the schema.
+CREATE TABLE search_log (
+    id BIGSERIAL PRIMARY KEY,
+    logged_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    query TEXT NOT NULL,
+    mode TEXT NOT NULL,
+    results INTEGER NOT NULL,
+    latency_ms DOUBLE PRECISION NOT NULL,
+    client TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX search_log_logged_at_idx ON search_log (logged_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS curation_blocklist;
+DROP TABLE IF EXISTS curation_rewrites;
+DROP TABLE IF EXISTS curation_pins;
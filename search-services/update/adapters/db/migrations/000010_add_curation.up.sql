@@ -0,0 +1,22 @@
+-- the curation of the searches is edited by the admins through the search
+-- service; queries are normalized as the search log keeps them. The search
+-- service owns these tables, they are migrated here with the shared schema
+-- like search_log.
+CREATE TABLE curation_pins (
+    query TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    source TEXT NOT NULL,
+    comic_id INTEGER NOT NULL,
+    PRIMARY KEY (query, position)
+);
+
+CREATE TABLE curation_rewrites (
+    query TEXT PRIMARY KEY,
+    phrase TEXT NOT NULL
+);
+
+CREATE TABLE curation_blocklist (
+    source TEXT NOT NULL,
+    comic_id INTEGER NOT NULL,
+    PRIMARY KEY (source, comic_id)
+);
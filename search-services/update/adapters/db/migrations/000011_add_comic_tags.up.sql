@@ -0,0 +1,62 @@
+-- tags are attached to comics by the admins; keywords are the tag as
+-- normalized by the words service, indexed in comic_terms under the tag field
+CREATE TABLE comic_tags (
+    source TEXT NOT NULL,
+    comic_id INTEGER NOT NULL,
+    tag TEXT NOT NULL,
+    keywords TEXT[] NOT NULL DEFAULT '{}',
+    added_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (source, comic_id, tag),
+    FOREIGN KEY (source, comic_id) REFERENCES comics (source, comic_id)
+        ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX comic_tags_tag_idx ON comic_tags (tag);
+
+-- the terms of the comic fields are rewritten without touching the tag ones
+CREATE OR REPLACE FUNCTION sync_comic_terms() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM comic_terms
+    WHERE source = NEW.source AND comic_id = NEW.comic_id AND field <> 'tag';
+    INSERT INTO comic_terms (term, field, source, comic_id, tf)
+    SELECT kw, f.field, NEW.source, NEW.comic_id, COUNT(*)
+    FROM (
+        SELECT 'title' AS field, unnest(NEW.title_keywords) AS kw
+        UNION ALL SELECT 'alt', unnest(NEW.alt_keywords)
+        UNION ALL SELECT 'transcript', unnest(NEW.transcript_keywords)
+        UNION ALL SELECT '', unnest(NEW.keywords)
+        WHERE COALESCE(cardinality(NEW.title_keywords), 0) + COALESCE(cardinality(NEW.alt_keywords), 0)
+            + COALESCE(cardinality(NEW.transcript_keywords), 0) = 0
+    ) AS f
+    GROUP BY kw, f.field;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- the tag terms of a comic are rebuilt from all its tags on every change;
+-- a comic being deleted has its terms cascaded away, so none are added
+CREATE FUNCTION sync_comic_tag_terms() RETURNS TRIGGER AS $$
+DECLARE
+    tagged RECORD;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        tagged := OLD;
+    ELSE
+        tagged := NEW;
+    END IF;
+
+    DELETE FROM comic_terms
+    WHERE source = tagged.source AND comic_id = tagged.comic_id AND field = 'tag';
+    INSERT INTO comic_terms (term, field, source, comic_id, tf)
+    SELECT kw, 'tag', t.source, t.comic_id, COUNT(*)
+    FROM comic_tags AS t, unnest(t.keywords) AS kw
+    WHERE t.source = tagged.source AND t.comic_id = tagged.comic_id
+        AND EXISTS (SELECT 1 FROM comics AS c WHERE c.source = t.source AND c.comic_id = t.comic_id)
+    GROUP BY kw, t.source, t.comic_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER comic_tags_sync_terms
+    AFTER INSERT OR UPDATE OR DELETE ON comic_tags
+    FOR EACH ROW EXECUTE FUNCTION sync_comic_tag_terms();
@@ -0,0 +1,24 @@
+DELETE FROM comic_tags AS t
+WHERE NOT EXISTS (SELECT 1 FROM comics AS c WHERE c.source = t.source AND c.comic_id = t.comic_id);
+
+ALTER TABLE comic_tags ADD FOREIGN KEY (source, comic_id) REFERENCES comics (source, comic_id)
+    ON DELETE CASCADE ON UPDATE CASCADE;
+
+CREATE OR REPLACE FUNCTION sync_comic_terms() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM comic_terms
+    WHERE source = NEW.source AND comic_id = NEW.comic_id AND field <> 'tag';
+    INSERT INTO comic_terms (term, field, source, comic_id, tf)
+    SELECT kw, f.field, NEW.source, NEW.comic_id, COUNT(*)
+    FROM (
+        SELECT 'title' AS field, unnest(NEW.title_keywords) AS kw
+        UNION ALL SELECT 'alt', unnest(NEW.alt_keywords)
+        UNION ALL SELECT 'transcript', unnest(NEW.transcript_keywords)
+        UNION ALL SELECT '', unnest(NEW.keywords)
+        WHERE COALESCE(cardinality(NEW.title_keywords), 0) + COALESCE(cardinality(NEW.alt_keywords), 0)
+            + COALESCE(cardinality(NEW.transcript_keywords), 0) = 0
+    ) AS f
+    GROUP BY kw, f.field;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,30 @@
+-- the tags are set by the admins and outlive a drop of the comics: they are
+-- no longer bound to the comics, and a comic fetched back gets the terms of
+-- its kept tags
+ALTER TABLE comic_tags DROP CONSTRAINT IF EXISTS comic_tags_source_comic_id_fkey;
+
+CREATE OR REPLACE FUNCTION sync_comic_terms() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM comic_terms
+    WHERE source = NEW.source AND comic_id = NEW.comic_id AND field <> 'tag';
+    INSERT INTO comic_terms (term, field, source, comic_id, tf)
+    SELECT kw, f.field, NEW.source, NEW.comic_id, COUNT(*)
+    FROM (
+        SELECT 'title' AS field, unnest(NEW.title_keywords) AS kw
+        UNION ALL SELECT 'alt', unnest(NEW.alt_keywords)
+        UNION ALL SELECT 'transcript', unnest(NEW.transcript_keywords)
+        UNION ALL SELECT '', unnest(NEW.keywords)
+        WHERE COALESCE(cardinality(NEW.title_keywords), 0) + COALESCE(cardinality(NEW.alt_keywords), 0)
+            + COALESCE(cardinality(NEW.transcript_keywords), 0) = 0
+    ) AS f
+    GROUP BY kw, f.field;
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO comic_terms (term, field, source, comic_id, tf)
+        SELECT kw, 'tag', t.source, t.comic_id, COUNT(*)
+        FROM comic_tags AS t, unnest(t.keywords) AS kw
+        WHERE t.source = NEW.source AND t.comic_id = NEW.comic_id
+        GROUP BY kw, t.source, t.comic_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -2,10 +2,17 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"yadro.com/course/update/core"
 )
 
@@ -28,20 +35,65 @@ func New(log *slog.Logger, address string) (*DB, error) {
 	}, nil
 }
 
-func (db *DB) Add(ctx context.Context, comics core.Comics) error {
-	query := `
-		INSERT INTO comics (comic_id, image_url, keywords)
-		VALUES (:id, :url, :words)
-		ON CONFLICT (comic_id) DO NOTHING;`
+// insertColumns is the number of values Add passes for each comic; it keeps
+// a batch under the limit of 65535 parameters per statement.
+const insertColumns = 14
+
+// Add inserts comics with multi-row statements in one transaction, skipping
+// the ones already stored.
+func (db *DB) Add(ctx context.Context, comics []core.Comics) error {
+	if len(comics) == 0 {
+		return nil
+	}
 
-	_, err := db.conn.NamedExecContext(ctx, query, comics)
+	tx, err := db.conn.BeginTxx(ctx, nil)
 	if err != nil {
-		db.log.Error("failed to insert comic", "error", err, "comic_id", comics.ID)
+		db.log.Error("failed to begin transaction", "error", err)
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	const maxRows = math.MaxUint16 / insertColumns
+	for start := 0; start < len(comics); start += maxRows {
+		query, args := insertQuery(comics[start:min(start+maxRows, len(comics))])
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			db.log.Error("failed to insert comics", "error", err, "comics", len(comics))
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		db.log.Error("failed to commit transaction", "error", err)
 		return err
 	}
 	return nil
 }
 
+func insertQuery(comics []core.Comics) (string, []any) {
+	var sb strings.Builder
+	sb.WriteString(`INSERT INTO comics (comic_id, source, image_url, keywords, title_keywords, alt_keywords, transcript_keywords,
+		title, safe_title, alt, transcript, analyzer_version, content_hash, published) VALUES `)
+
+	args := make([]any, 0, len(comics)*insertColumns)
+	for i, c := range comics {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(")
+		for j := range insertColumns {
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString("$" + strconv.Itoa(i*insertColumns+j+1))
+		}
+		sb.WriteString(")")
+		args = append(args, c.ID, c.Source, c.URL, pq.Array(c.Words), pq.Array(c.TitleWords), pq.Array(c.AltWords),
+			pq.Array(c.TranscriptWords), c.Title, c.SafeTitle, c.Alt, c.Transcript, c.AnalyzerVersion, c.ContentHash, c.Published)
+	}
+	sb.WriteString(` ON CONFLICT (source, comic_id) DO NOTHING;`)
+	return sb.String(), args
+}
+
 func (db *DB) Stats(ctx context.Context) (core.DBStats, error) {
 	var stats core.DBStats
 
@@ -67,9 +119,9 @@ func (db *DB) Stats(ctx context.Context) (core.DBStats, error) {
 	return stats, nil
 }
 
-func (db *DB) IDs(ctx context.Context) ([]int, error) {
+func (db *DB) IDs(ctx context.Context, source string) ([]int, error) {
 	var ids []int
-	err := db.conn.SelectContext(ctx, &ids, `SELECT comic_id FROM comics;`)
+	err := db.conn.SelectContext(ctx, &ids, `SELECT comic_id FROM comics WHERE source = $1;`, source)
 	if err != nil {
 		db.log.Error("failed to query comic IDs", "error", err)
 		return nil, err
@@ -78,11 +130,354 @@ func (db *DB) IDs(ctx context.Context) ([]int, error) {
 	return ids, nil
 }
 
+// Stale pages through comics with a different analyzer version using
+// (source, comic_id) keyset pagination. The comics keep their publication
+// date, Replace writes it back.
+func (db *DB) Stale(ctx context.Context, version int, after core.ComicKey, limit int) ([]core.Comics, error) {
+	query := `
+		SELECT comic_id, source, COALESCE(image_url, '') AS image_url, title, safe_title, alt, transcript, analyzer_version,
+			published
+		FROM comics
+		WHERE analyzer_version <> $1 AND (source, comic_id) > ($2, $3)
+		ORDER BY source, comic_id
+		LIMIT $4;`
+
+	var comics []core.Comics
+	err := db.conn.SelectContext(ctx, &comics, query, version, after.Source, after.ID, limit)
+	if err != nil {
+		db.log.Error("failed to query stale comics", "error", err)
+		return nil, err
+	}
+
+	return comics, nil
+}
+
+func (db *DB) Replace(ctx context.Context, comics []core.Comics) error {
+	query := `
+		UPDATE comics
+		SET image_url = $1, keywords = $2, title_keywords = $3, alt_keywords = $4, transcript_keywords = $5,
+			title = $6, safe_title = $7, alt = $8, transcript = $9, analyzer_version = $10, content_hash = $11,
+			published = COALESCE($14, published)
+		WHERE source = $12 AND comic_id = $13;`
+
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		db.log.Error("failed to begin transaction", "error", err)
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, c := range comics {
+		_, err := tx.ExecContext(ctx, query,
+			c.URL, pq.Array(c.Words), pq.Array(c.TitleWords), pq.Array(c.AltWords), pq.Array(c.TranscriptWords),
+			c.Title, c.SafeTitle, c.Alt, c.Transcript, c.AnalyzerVersion, c.ContentHash, c.Source, c.ID, c.Published)
+		if err != nil {
+			db.log.Error("failed to replace comic", "error", err, "comic_id", c.ID)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		db.log.Error("failed to commit transaction", "error", err)
+		return err
+	}
+	return nil
+}
+
+// List pages through all comics using (source, comic_id) keyset pagination.
+func (db *DB) List(ctx context.Context, after core.ComicKey, limit int) ([]core.Comics, error) {
+	query := `
+		SELECT comic_id, source, COALESCE(image_url, ''), keywords, title_keywords, alt_keywords, transcript_keywords,
+			title, safe_title, alt, transcript, analyzer_version, content_hash, published
+		FROM comics
+		WHERE (source, comic_id) > ($1, $2)
+		ORDER BY source, comic_id
+		LIMIT $3;`
+
+	rows, err := db.conn.QueryContext(ctx, query, after.Source, after.ID, limit)
+	if err != nil {
+		db.log.Error("failed to query comics", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comics []core.Comics
+	for rows.Next() {
+		var c core.Comics
+		err := rows.Scan(&c.ID, &c.Source, &c.URL, pq.Array(&c.Words),
+			pq.Array(&c.TitleWords), pq.Array(&c.AltWords), pq.Array(&c.TranscriptWords), &c.Title, &c.SafeTitle, &c.Alt, &c.Transcript, &c.AnalyzerVersion, &c.ContentHash, &c.Published)
+		if err != nil {
+			db.log.Error("failed to scan comic", "error", err)
+			return nil, err
+		}
+		comics = append(comics, c)
+	}
+	if err := rows.Err(); err != nil {
+		db.log.Error("failed to iterate comics", "error", err)
+		return nil, err
+	}
+
+	return comics, nil
+}
+
+// Upsert inserts comics in one transaction, overwriting existing rows.
+func (db *DB) Upsert(ctx context.Context, comics []core.Comics) error {
+	query := `
+		INSERT INTO comics (comic_id, source, image_url, keywords, title_keywords, alt_keywords, transcript_keywords,
+			title, safe_title, alt, transcript, analyzer_version, content_hash, published)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (source, comic_id) DO UPDATE
+		SET image_url = EXCLUDED.image_url, keywords = EXCLUDED.keywords, title_keywords = EXCLUDED.title_keywords,
+			alt_keywords = EXCLUDED.alt_keywords, transcript_keywords = EXCLUDED.transcript_keywords, title = EXCLUDED.title,
+			safe_title = EXCLUDED.safe_title, alt = EXCLUDED.alt, transcript = EXCLUDED.transcript,
+			analyzer_version = EXCLUDED.analyzer_version, content_hash = EXCLUDED.content_hash, published = EXCLUDED.published;`
+
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		db.log.Error("failed to begin transaction", "error", err)
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, c := range comics {
+		_, err := tx.ExecContext(ctx, query,
+			c.ID, c.Source, c.URL, pq.Array(c.Words), pq.Array(c.TitleWords), pq.Array(c.AltWords), pq.Array(c.TranscriptWords),
+			c.Title, c.SafeTitle, c.Alt, c.Transcript, c.AnalyzerVersion, c.ContentHash, c.Published)
+		if err != nil {
+			db.log.Error("failed to upsert comic", "error", err, "comic_id", c.ID)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		db.log.Error("failed to commit transaction", "error", err)
+		return err
+	}
+	return nil
+}
+
+// Empty returns IDs of comics stored without an image or keywords. The
+// placeholders of the comics the source is missing, with neither an image
+// nor any text, are left out: refetching them would only find them missing
+// again.
+func (db *DB) Empty(ctx context.Context, source string) ([]int, error) {
+	query := `
+		SELECT comic_id FROM comics
+		WHERE source = $1 AND (COALESCE(image_url, '') = '' OR COALESCE(cardinality(keywords), 0) = 0)
+			AND NOT (COALESCE(image_url, '') = '' AND COALESCE(title, '') = '' AND COALESCE(safe_title, '') = ''
+				AND COALESCE(alt, '') = '' AND COALESCE(transcript, '') = '')
+		ORDER BY comic_id;`
+
+	ids := []int{}
+	if err := db.conn.SelectContext(ctx, &ids, query, source); err != nil {
+		db.log.Error("failed to query empty comics", "error", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Revise overwrites comics with their new content in one transaction. The
+// previous content of every changed comic that had any text is moved to
+// comic_versions first. A known publication date is never cleared.
+func (db *DB) Revise(ctx context.Context, comics []core.Comics) error {
+	archive := `
+		INSERT INTO comic_versions (source, comic_id, image_url, title, safe_title, alt, transcript, content_hash)
+		SELECT source, comic_id, COALESCE(image_url, ''), title, safe_title, alt, transcript, content_hash
+		FROM comics
+		WHERE source = $1 AND comic_id = $2 AND content_hash <> $3 AND concat(title, safe_title, alt, transcript) <> '';`
+	update := `
+		UPDATE comics
+		SET image_url = $1, keywords = $2, title_keywords = $3, alt_keywords = $4, transcript_keywords = $5,
+			title = $6, safe_title = $7, alt = $8, transcript = $9, analyzer_version = $10, content_hash = $11,
+			published = COALESCE($12, published)
+		WHERE source = $13 AND comic_id = $14;`
+
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		db.log.Error("failed to begin transaction", "error", err)
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, c := range comics {
+		if _, err := tx.ExecContext(ctx, archive, c.Source, c.ID, c.ContentHash); err != nil {
+			db.log.Error("failed to archive comic", "error", err, "comic_id", c.ID)
+			return err
+		}
+		_, err := tx.ExecContext(ctx, update,
+			c.URL, pq.Array(c.Words), pq.Array(c.TitleWords), pq.Array(c.AltWords), pq.Array(c.TranscriptWords),
+			c.Title, c.SafeTitle, c.Alt, c.Transcript, c.AnalyzerVersion, c.ContentHash, c.Published, c.Source, c.ID)
+		if err != nil {
+			db.log.Error("failed to revise comic", "error", err, "comic_id", c.ID)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		db.log.Error("failed to commit transaction", "error", err)
+		return err
+	}
+	return nil
+}
+
+// History returns previous versions of the comic, newest first.
+func (db *DB) History(ctx context.Context, key core.ComicKey) ([]core.ComicVersion, error) {
+	var exists bool
+	err := db.conn.GetContext(ctx, &exists,
+		`SELECT EXISTS (SELECT 1 FROM comics WHERE source = $1 AND comic_id = $2);`, key.Source, key.ID)
+	if err != nil {
+		db.log.Error("failed to check comic", "error", err)
+		return nil, err
+	}
+	if !exists {
+		return nil, core.ErrNotFound
+	}
+
+	query := `
+		SELECT image_url, title, safe_title, alt, transcript, content_hash, replaced_at
+		FROM comic_versions
+		WHERE source = $1 AND comic_id = $2
+		ORDER BY replaced_at DESC, id DESC;`
+
+	versions := []core.ComicVersion{}
+	if err := db.conn.SelectContext(ctx, &versions, query, key.Source, key.ID); err != nil {
+		db.log.Error("failed to query comic history", "error", err)
+		return nil, err
+	}
+	return versions, nil
+}
+
+// Drop deletes the comics with their versions and terms. The tags are kept:
+// they are set by the admins rather than fetched, and are searched again
+// once their comics are fetched back.
 func (db *DB) Drop(ctx context.Context) error {
-	_, err := db.conn.ExecContext(ctx, `TRUNCATE TABLE comics;`)
+	_, err := db.conn.ExecContext(ctx, `TRUNCATE TABLE comics, comic_versions, comic_terms;`)
 	if err != nil {
 		db.log.Error("failed to drop table", "error", err)
 		return err
 	}
 	return nil
 }
+
+// Tags returns the tags of the comic in the order they were added.
+func (db *DB) Tags(ctx context.Context, key core.ComicKey) ([]core.Tag, error) {
+	var exists bool
+	err := db.conn.GetContext(ctx, &exists,
+		`SELECT EXISTS (SELECT 1 FROM comics WHERE source = $1 AND comic_id = $2);`, key.Source, key.ID)
+	if err != nil {
+		db.log.Error("failed to check comic", "error", err)
+		return nil, err
+	}
+	if !exists {
+		return nil, core.ErrNotFound
+	}
+
+	query := `
+		SELECT tag, keywords, added_at FROM comic_tags
+		WHERE source = $1 AND comic_id = $2
+		ORDER BY added_at, tag;`
+
+	rows, err := db.conn.QueryContext(ctx, query, key.Source, key.ID)
+	if err != nil {
+		db.log.Error("failed to query tags", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []core.Tag{}
+	for rows.Next() {
+		var tag core.Tag
+		if err := rows.Scan(&tag.Name, pq.Array(&tag.Words), &tag.AddedAt); err != nil {
+			db.log.Error("failed to scan tag", "error", err)
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		db.log.Error("failed to iterate tags", "error", err)
+		return nil, err
+	}
+	return tags, nil
+}
+
+// AddTag tags the comic, renormalizing the tag if the comic has it already.
+// It returns when the comic was first tagged with it.
+func (db *DB) AddTag(ctx context.Context, key core.ComicKey, tag core.Tag) (time.Time, error) {
+	var added time.Time
+	err := db.conn.GetContext(ctx, &added, `
+		INSERT INTO comic_tags (source, comic_id, tag, keywords)
+		SELECT source, comic_id, $3, $4 FROM comics WHERE source = $1 AND comic_id = $2
+		ON CONFLICT (source, comic_id, tag) DO UPDATE SET keywords = EXCLUDED.keywords
+		RETURNING added_at;`,
+		key.Source, key.ID, tag.Name, pq.Array(tag.Words))
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, core.ErrNotFound
+	}
+	if err != nil {
+		db.log.Error("failed to add tag", "error", err)
+		return time.Time{}, err
+	}
+	return added, nil
+}
+
+func (db *DB) RemoveTag(ctx context.Context, key core.ComicKey, tag string) error {
+	res, err := db.conn.ExecContext(ctx,
+		`DELETE FROM comic_tags WHERE source = $1 AND comic_id = $2 AND tag = $3;`, key.Source, key.ID, tag)
+	if err != nil {
+		db.log.Error("failed to remove tag", "error", err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return core.ErrNotFound
+	}
+	return nil
+}
+
+// AllTags returns the tags of every tagged comic, the tags kept of the
+// dropped comics aside.
+func (db *DB) AllTags(ctx context.Context) (map[core.ComicKey][]core.Tag, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT t.source, t.comic_id, t.tag, t.keywords, t.added_at FROM comic_tags AS t
+		JOIN comics AS c ON c.source = t.source AND c.comic_id = t.comic_id
+		ORDER BY t.source, t.comic_id, t.added_at, t.tag;`)
+	if err != nil {
+		db.log.Error("failed to query tags", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tagged := make(map[core.ComicKey][]core.Tag)
+	for rows.Next() {
+		var (
+			key core.ComicKey
+			tag core.Tag
+		)
+		if err := rows.Scan(&key.Source, &key.ID, &tag.Name, pq.Array(&tag.Words), &tag.AddedAt); err != nil {
+			db.log.Error("failed to scan tag", "error", err)
+			return nil, err
+		}
+		tagged[key] = append(tagged[key], tag)
+	}
+	if err := rows.Err(); err != nil {
+		db.log.Error("failed to iterate tags", "error", err)
+		return nil, err
+	}
+	return tagged, nil
+}
+
+// TagCounts returns every tag with the number of comics it is attached to,
+// the most used first.
+func (db *DB) TagCounts(ctx context.Context) ([]core.TagCount, error) {
+	query := `
+		SELECT t.tag, COUNT(*) AS comics FROM comic_tags AS t
+		JOIN comics AS c ON c.source = t.source AND c.comic_id = t.comic_id
+		GROUP BY t.tag
+		ORDER BY comics DESC, tag;`
+
+	counts := []core.TagCount{}
+	if err := db.conn.SelectContext(ctx, &counts, query); err != nil {
+		db.log.Error("failed to count tags", "error", err)
+		return nil, err
+	}
+	return counts, nil
+}
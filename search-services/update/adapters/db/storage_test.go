@@ -5,7 +5,9 @@ import (
 	"errors"
 	"log/slog"
 	"testing"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	sqlxmock "github.com/zhashkevych/go-sqlxmock"
 	"yadro.com/course/update/core"
@@ -110,7 +112,8 @@ func TestDB_IDs(t *testing.T) {
 					AddRow(1).
 					AddRow(2).
 					AddRow(3)
-				mock.ExpectQuery(`SELECT comic_id FROM comics`).
+				mock.ExpectQuery(`SELECT comic_id FROM comics WHERE source = \$1`).
+					WithArgs("xkcd").
 					WillReturnRows(rows)
 			},
 			want:    []int{1, 2, 3},
@@ -120,7 +123,8 @@ func TestDB_IDs(t *testing.T) {
 			name: "empty",
 			mock: func() {
 				rows := sqlxmock.NewRows([]string{"comic_id"})
-				mock.ExpectQuery(`SELECT comic_id FROM comics`).
+				mock.ExpectQuery(`SELECT comic_id FROM comics WHERE source = \$1`).
+					WithArgs("xkcd").
 					WillReturnRows(rows)
 			},
 			want:    nil,
@@ -129,7 +133,8 @@ func TestDB_IDs(t *testing.T) {
 		{
 			name: "Db error",
 			mock: func() {
-				mock.ExpectQuery(`SELECT comic_id FROM comics`).
+				mock.ExpectQuery(`SELECT comic_id FROM comics WHERE source = \$1`).
+					WithArgs("xkcd").
 					WillReturnError(errors.New("db error"))
 			},
 			want:    nil,
@@ -140,7 +145,7 @@ func TestDB_IDs(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			got, err := storage.IDs(context.Background())
+			got, err := storage.IDs(context.Background(), "xkcd")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("IDs error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -150,6 +155,514 @@ func TestDB_IDs(t *testing.T) {
 	}
 }
 
+func TestDB_Stale(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &DB{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	published := time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"comic_id", "source", "image_url", "title", "safe_title", "alt", "transcript", "analyzer_version", "published"}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []core.Comics
+		wantErr bool
+	}{
+		{
+			name: "successful",
+			mock: func() {
+				rows := sqlxmock.NewRows(columns).
+					AddRow(1, "xkcd", "url1", "Barrel - Part 1", "Barrel - Part 1", "Don't we all.", "", 0, published).
+					AddRow(2, "xkcd", "url2", "", "", "", "", 0, nil)
+				mock.ExpectQuery(`SELECT comic_id, .* FROM comics WHERE analyzer_version <> \$1 AND \(source, comic_id\) > \(\$2, \$3\) ORDER BY source, comic_id LIMIT \$4`).
+					WithArgs(2, "", 0, 100).
+					WillReturnRows(rows)
+			},
+			want: []core.Comics{
+				{ID: 1, Source: "xkcd", URL: "url1", Title: "Barrel - Part 1", SafeTitle: "Barrel - Part 1", Alt: "Don't we all.",
+					Published: &published},
+				{ID: 2, Source: "xkcd", URL: "url2"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Db error",
+			mock: func() {
+				mock.ExpectQuery(`SELECT comic_id, .* FROM comics WHERE analyzer_version <> \$1`).
+					WithArgs(2, "", 0, 100).
+					WillReturnError(errors.New("db error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := storage.Stale(context.Background(), 2, core.ComicKey{}, 100)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Stale error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDB_Replace(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &DB{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	published := time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC)
+	comics := []core.Comics{
+		{ID: 1, Source: "xkcd", URL: "url1", Title: "Barrel", Words: []string{"barrel"}, AnalyzerVersion: 2, Published: &published},
+		{ID: 2, Source: "xkcd", URL: "url2", Title: "Trees", Words: []string{"tree"}, AnalyzerVersion: 2},
+	}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr bool
+	}{
+		{
+			name: "successful",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE comics SET .* published = COALESCE\(\$14, published\) WHERE source = \$12 AND comic_id = \$13`).
+					WithArgs(sqlxmock.AnyArg(), sqlxmock.AnyArg(), sqlxmock.AnyArg(), sqlxmock.AnyArg(), sqlxmock.AnyArg(),
+						"Barrel", "", "", "", 2, "", "xkcd", 1, &published).
+					WillReturnResult(sqlxmock.NewResult(0, 1))
+				mock.ExpectExec(`UPDATE comics SET .* WHERE source = \$12 AND comic_id = \$13`).
+					WillReturnResult(sqlxmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+		{
+			name: "Db error rolls back",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE comics SET .* WHERE source = \$12 AND comic_id = \$13`).
+					WillReturnError(errors.New("db error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := storage.Replace(context.Background(), comics)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Replace error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDB_List(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &DB{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	columns := []string{"comic_id", "source", "image_url", "keywords", "title_keywords", "alt_keywords", "transcript_keywords",
+		"title", "safe_title", "alt", "transcript", "analyzer_version", "content_hash", "published"}
+	published := time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []core.Comics
+		wantErr bool
+	}{
+		{
+			name: "successful",
+			mock: func() {
+				rows := sqlxmock.NewRows(columns).
+					AddRow(1, "xkcd", "url1", "{barrel,boy,us}", "{barrel}", "{us}", "{barrel,boy}",
+						"Barrel - Part 1", "Barrel - Part 1", "Don't we all.", "boy in a barrel", 1, "abc", published).
+					AddRow(2, "xkcd", "", nil, nil, nil, nil, "", "", "", "", 1, "", nil)
+				mock.ExpectQuery(`SELECT comic_id, .* FROM comics WHERE \(source, comic_id\) > \(\$1, \$2\) ORDER BY source, comic_id LIMIT \$3`).
+					WithArgs("", 0, 100).
+					WillReturnRows(rows)
+			},
+			want: []core.Comics{
+				{ID: 1, Source: "xkcd", URL: "url1", Words: []string{"barrel", "boy", "us"},
+					TitleWords: []string{"barrel"}, AltWords: []string{"us"}, TranscriptWords: []string{"barrel", "boy"},
+					Title: "Barrel - Part 1", SafeTitle: "Barrel - Part 1", Alt: "Don't we all.", Transcript: "boy in a barrel",
+					AnalyzerVersion: 1, ContentHash: "abc", Published: &published},
+				{ID: 2, Source: "xkcd", AnalyzerVersion: 1},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Db error",
+			mock: func() {
+				mock.ExpectQuery(`SELECT comic_id, .* FROM comics`).
+					WithArgs("", 0, 100).
+					WillReturnError(errors.New("db error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := storage.List(context.Background(), core.ComicKey{}, 100)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("List error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDB_Add(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &DB{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	published := time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC)
+	comics := []core.Comics{
+		{ID: 1, Source: "xkcd", URL: "url1", Title: "Barrel", Words: []string{"barrel"}, TitleWords: []string{"barrel"},
+			AltWords: []string{}, TranscriptWords: []string{}, AnalyzerVersion: 1, ContentHash: "h1", Published: &published},
+		{ID: 2, Source: "xkcd", URL: "url2", Title: "Trees", Words: []string{"tree"}, TitleWords: []string{"tree"},
+			AltWords: []string{}, TranscriptWords: []string{}, AnalyzerVersion: 1, ContentHash: "h2"},
+	}
+
+	tests := []struct {
+		name    string
+		comics  []core.Comics
+		mock    func()
+		wantErr bool
+	}{
+		{
+			name:   "one statement per batch",
+			comics: comics,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(`INSERT INTO comics .* VALUES \(\$1, .*, \$14\), \(\$15, .*, \$28\) ON CONFLICT \(source, comic_id\) DO NOTHING`).
+					WithArgs(1, "xkcd", "url1", pq.Array([]string{"barrel"}), pq.Array([]string{"barrel"}), pq.Array([]string{}),
+						pq.Array([]string{}), "Barrel", "", "", "", 1, "h1", published,
+						2, "xkcd", "url2", pq.Array([]string{"tree"}), pq.Array([]string{"tree"}), pq.Array([]string{}),
+						pq.Array([]string{}), "Trees", "", "", "", 1, "h2", nil).
+					WillReturnResult(sqlxmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+		{
+			name:    "empty batch",
+			comics:  nil,
+			mock:    func() {},
+			wantErr: false,
+		},
+		{
+			name:   "Db error rolls back",
+			comics: comics,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(`INSERT INTO comics`).
+					WillReturnError(errors.New("db error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := storage.Add(context.Background(), tt.comics)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Add error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDB_Upsert(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &DB{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	comics := []core.Comics{
+		{ID: 1, Source: "xkcd", URL: "url1", Title: "Barrel", Words: []string{"barrel"}, AnalyzerVersion: 1},
+		{ID: 2, Source: "xkcd", URL: "url2", Title: "Trees", Words: []string{"tree"}, AnalyzerVersion: 1},
+	}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr bool
+	}{
+		{
+			name: "successful",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(`INSERT INTO comics .* ON CONFLICT \(source, comic_id\) DO UPDATE`).
+					WillReturnResult(sqlxmock.NewResult(0, 1))
+				mock.ExpectExec(`INSERT INTO comics .* ON CONFLICT \(source, comic_id\) DO UPDATE`).
+					WillReturnResult(sqlxmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+		{
+			name: "Db error rolls back",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(`INSERT INTO comics .* ON CONFLICT \(source, comic_id\) DO UPDATE`).
+					WillReturnError(errors.New("db error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := storage.Upsert(context.Background(), comics)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Upsert error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDB_Empty(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &DB{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []int
+		wantErr bool
+	}{
+		{
+			name: "successful",
+			mock: func() {
+				rows := sqlxmock.NewRows([]string{"comic_id"}).AddRow(7).AddRow(404)
+				mock.ExpectQuery(`SELECT comic_id FROM comics WHERE source = \$1 AND \(COALESCE\(image_url, ''\) = '' OR COALESCE\(cardinality\(keywords\), 0\) = 0\) ` +
+					`AND NOT \(COALESCE\(image_url, ''\) = '' AND COALESCE\(title, ''\) = ''`).
+					WithArgs("xkcd").
+					WillReturnRows(rows)
+			},
+			want:    []int{7, 404},
+			wantErr: false,
+		},
+		{
+			name: "none",
+			mock: func() {
+				mock.ExpectQuery(`SELECT comic_id FROM comics WHERE source = \$1 AND`).
+					WithArgs("xkcd").
+					WillReturnRows(sqlxmock.NewRows([]string{"comic_id"}))
+			},
+			want:    []int{},
+			wantErr: false,
+		},
+		{
+			name: "Db error",
+			mock: func() {
+				mock.ExpectQuery(`SELECT comic_id FROM comics WHERE source = \$1 AND`).
+					WithArgs("xkcd").
+					WillReturnError(errors.New("db error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := storage.Empty(context.Background(), "xkcd")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Empty error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDB_Revise(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &DB{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	comics := []core.Comics{
+		{ID: 1, Source: "xkcd", URL: "url1", Title: "Barrel", Transcript: "fixed", Words: []string{"barrel"}, ContentHash: "new"},
+	}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr bool
+	}{
+		{
+			name: "successful",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(`INSERT INTO comic_versions .* SELECT .* FROM comics WHERE source = \$1 AND comic_id = \$2 AND content_hash <> \$3`).
+					WithArgs("xkcd", 1, "new").
+					WillReturnResult(sqlxmock.NewResult(0, 1))
+				mock.ExpectExec(`UPDATE comics SET .* title_keywords = \$3, .* content_hash = \$11, published = COALESCE\(\$12, published\) WHERE source = \$13 AND comic_id = \$14`).
+					WillReturnResult(sqlxmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+		{
+			name: "Db error rolls back",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(`INSERT INTO comic_versions`).
+					WithArgs("xkcd", 1, "new").
+					WillReturnError(errors.New("db error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := storage.Revise(context.Background(), comics)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Revise error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDB_History(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &DB{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	replaced := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"image_url", "title", "safe_title", "alt", "transcript", "content_hash", "replaced_at"}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []core.ComicVersion
+		wantErr error
+	}{
+		{
+			name: "successful",
+			mock: func() {
+				mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM comics WHERE source = \$1 AND comic_id = \$2\)`).
+					WithArgs("xkcd", 1).
+					WillReturnRows(sqlxmock.NewRows([]string{"exists"}).AddRow(true))
+				rows := sqlxmock.NewRows(columns).AddRow("url1", "Barrel", "Barrel", "", "old", "abc", replaced)
+				mock.ExpectQuery(`SELECT image_url, .* FROM comic_versions WHERE source = \$1 AND comic_id = \$2 ORDER BY replaced_at DESC`).
+					WithArgs("xkcd", 1).
+					WillReturnRows(rows)
+			},
+			want: []core.ComicVersion{
+				{URL: "url1", Title: "Barrel", SafeTitle: "Barrel", Transcript: "old", ContentHash: "abc", ReplacedAt: replaced},
+			},
+		},
+		{
+			name: "unknown comic",
+			mock: func() {
+				mock.ExpectQuery(`SELECT EXISTS`).
+					WithArgs("xkcd", 1).
+					WillReturnRows(sqlxmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			want:    nil,
+			wantErr: core.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := storage.History(context.Background(), core.ComicKey{Source: "xkcd", ID: 1})
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 func TestDB_Drop(t *testing.T) {
 	db, mock, err := sqlxmock.Newx()
 	if err != nil {
@@ -170,7 +683,7 @@ func TestDB_Drop(t *testing.T) {
 		{
 			name: "successful",
 			mock: func() {
-				mock.ExpectExec(`TRUNCATE TABLE comics`).
+				mock.ExpectExec(`TRUNCATE TABLE comics, comic_versions, comic_terms;`).
 					WillReturnResult(sqlxmock.NewResult(0, 0))
 			},
 			wantErr: false,
@@ -196,3 +709,89 @@ func TestDB_Drop(t *testing.T) {
 		})
 	}
 }
+
+func TestDB_Tags(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &DB{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	added := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM comics WHERE source = \$1 AND comic_id = \$2\)`).
+		WithArgs("xkcd", 1).
+		WillReturnRows(sqlxmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`SELECT tag, keywords, added_at FROM comic_tags WHERE source = \$1 AND comic_id = \$2`).
+		WithArgs("xkcd", 1).
+		WillReturnRows(sqlxmock.NewRows([]string{"tag", "keywords", "added_at"}).
+			AddRow("black hat", "{black,hat}", added))
+
+	got, err := storage.Tags(context.Background(), core.ComicKey{Source: "xkcd", ID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []core.Tag{{Name: "black hat", Words: []string{"black", "hat"}, AddedAt: added}}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_AddTag(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &DB{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	added := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		rows    *sqlxmock.Rows
+		want    time.Time
+		wantErr error
+	}{
+		{name: "tagged", rows: sqlxmock.NewRows([]string{"added_at"}).AddRow(added), want: added},
+		{name: "unknown comic", rows: sqlxmock.NewRows([]string{"added_at"}), wantErr: core.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectQuery(`INSERT INTO comic_tags \(source, comic_id, tag, keywords\) SELECT source, comic_id, \$3, \$4 FROM comics .* RETURNING added_at`).
+				WithArgs("xkcd", 1, "black hat", pq.Array([]string{"black", "hat"})).
+				WillReturnRows(tt.rows)
+
+			got, err := storage.AddTag(context.Background(), core.ComicKey{Source: "xkcd", ID: 1},
+				core.Tag{Name: "black hat", Words: []string{"black", "hat"}})
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDB_RemoveTag(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to mock db")
+	}
+	defer db.Close()
+
+	storage := &DB{
+		log:  slog.Default(),
+		conn: db,
+	}
+
+	mock.ExpectExec(`DELETE FROM comic_tags WHERE source = \$1 AND comic_id = \$2 AND tag = \$3`).
+		WithArgs("xkcd", 1, "romance").
+		WillReturnResult(sqlxmock.NewResult(0, 0))
+
+	err = storage.RemoveTag(context.Background(), core.ComicKey{Source: "xkcd", ID: 1}, "romance")
+	assert.ErrorIs(t, err, core.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
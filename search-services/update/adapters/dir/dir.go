@@ -0,0 +1,153 @@
+package dir
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"yadro.com/course/update/core"
+)
+
+var metadataExts = []string{".json", ".yaml", ".yml"}
+var imageExts = []string{".png", ".jpg", ".jpeg", ".gif", ".webp"}
+
+// Source reads comics from a local directory. Every comic is described by
+// a {id}.json, {id}.yaml or {id}.yml metadata file, optionally accompanied
+// by an {id}.png (.jpg, .gif, ...) image.
+type Source struct {
+	log      *slog.Logger
+	name     string
+	path     string
+	imageURL string
+}
+
+type metadata struct {
+	Title      string `json:"title" yaml:"title"`
+	SafeTitle  string `json:"safe_title" yaml:"safe_title"`
+	Alt        string `json:"alt" yaml:"alt"`
+	Transcript string `json:"transcript" yaml:"transcript"`
+	Image      string `json:"image" yaml:"image"`
+}
+
+// New creates a directory source. Relative image paths are resolved
+// against imageURL, or served as file:// URLs when imageURL is empty.
+func New(name, path, imageURL string, log *slog.Logger) (*Source, error) {
+	if name == "" {
+		return nil, fmt.Errorf("empty source name specified")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%q is not a directory", path)
+	}
+	return &Source{
+		log:      log,
+		name:     name,
+		path:     path,
+		imageURL: imageURL,
+	}, nil
+}
+
+func (s Source) Name() string {
+	return s.name
+}
+
+func (s Source) List(ctx context.Context) ([]int, error) {
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ext := filepath.Ext(entry.Name())
+		if !slices.Contains(metadataExts, ext) {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ext))
+		if err != nil {
+			s.log.Debug("skipping file without comic id", "file", entry.Name())
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+	return slices.Compact(ids), nil
+}
+
+// Get reads the metadata of the comic, core.Err404Comics if it has none: a
+// gap in the directory is stored as a placeholder, like a missing xkcd.
+func (s Source) Get(ctx context.Context, id int) (core.ComicInfo, error) {
+	for _, ext := range metadataExts {
+		data, err := os.ReadFile(filepath.Join(s.path, strconv.Itoa(id)+ext))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return core.ComicInfo{}, err
+		}
+
+		var meta metadata
+		if ext == ".json" {
+			err = json.Unmarshal(data, &meta)
+		} else {
+			err = yaml.Unmarshal(data, &meta)
+		}
+		if err != nil {
+			return core.ComicInfo{}, fmt.Errorf("failed to decode %d%s: %w", id, ext, err)
+		}
+
+		return core.ComicInfo{
+			ID:         id,
+			URL:        s.image(id, meta.Image),
+			Title:      meta.Title,
+			Alt:        meta.Alt,
+			Transcript: meta.Transcript,
+			SafeTitle:  meta.SafeTitle,
+		}, nil
+	}
+	return core.ComicInfo{}, fmt.Errorf("%w: comic %d in %q", core.Err404Comics, id, s.path)
+}
+
+func (s Source) image(id int, image string) string {
+	if image == "" {
+		for _, ext := range imageExts {
+			name := strconv.Itoa(id) + ext
+			if _, err := os.Stat(filepath.Join(s.path, name)); err == nil {
+				image = name
+				break
+			}
+		}
+		if image == "" {
+			return ""
+		}
+	}
+
+	if u, err := url.Parse(image); err == nil && u.Scheme != "" {
+		return image
+	}
+	if s.imageURL != "" {
+		return strings.TrimSuffix(s.imageURL, "/") + "/" + strings.TrimPrefix(image, "/")
+	}
+	abs, err := filepath.Abs(filepath.Join(s.path, image))
+	if err != nil {
+		return ""
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+}
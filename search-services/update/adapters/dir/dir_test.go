@@ -0,0 +1,130 @@
+package dir
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"yadro.com/course/update/core"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+
+	tests := []struct {
+		name    string
+		source  string
+		path    string
+		wantErr bool
+	}{
+		{name: "success", source: "local", path: dir, wantErr: false},
+		{name: "empty name", source: "", path: dir, wantErr: true},
+		{name: "missing directory", source: "local", path: filepath.Join(dir, "missing"), wantErr: true},
+		{name: "not a directory", source: "local", path: file, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.source, tt.path, "", slog.Default())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSource_List(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"2.yaml":     "title: Two",
+		"10.json":    `{"title": "Ten"}`,
+		"1.yml":      "title: One",
+		"1.png":      "",
+		"notes.txt":  "",
+		"cover.json": `{"title": "Cover"}`,
+	})
+
+	source, err := New("local", dir, "", slog.Default())
+	require.NoError(t, err)
+
+	got, err := source.List(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 10}, got)
+}
+
+func TestSource_Get(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"1.json": `{"title": "Barrel", "alt": "Don't we all.", "image": "barrel.png"}`,
+		"2.yaml": "title: Trees\ntranscript: Two trees\nimage: https://example.com/trees.png\n",
+		"3.yml":  "title: Sibling image\n",
+		"3.jpg":  "",
+		"4.json": "invalid json",
+	})
+
+	tests := []struct {
+		name     string
+		imageURL string
+		id       int
+		want     core.ComicInfo
+		wantErr  error
+	}{
+		{
+			name:     "json with relative image",
+			imageURL: "http://static/comics/",
+			id:       1,
+			want:     core.ComicInfo{ID: 1, Title: "Barrel", Alt: "Don't we all.", URL: "http://static/comics/barrel.png"},
+		},
+		{
+			name: "yaml with absolute image",
+			id:   2,
+			want: core.ComicInfo{ID: 2, Title: "Trees", Transcript: "Two trees", URL: "https://example.com/trees.png"},
+		},
+		{
+			name: "sibling image served from file",
+			id:   3,
+			want: core.ComicInfo{ID: 3, Title: "Sibling image", URL: "file://" + filepath.ToSlash(filepath.Join(dir, "3.jpg"))},
+		},
+		{
+			name:    "missing comic",
+			id:      5,
+			wantErr: core.Err404Comics,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := New("local", dir, tt.imageURL, slog.Default())
+			require.NoError(t, err)
+
+			got, err := source.Get(context.Background(), tt.id)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		source, err := New("local", dir, "", slog.Default())
+		require.NoError(t, err)
+
+		_, err = source.Get(context.Background(), 4)
+		assert.Error(t, err)
+	})
+}
@@ -2,8 +2,14 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	updatepb "yadro.com/course/proto/update"
 	"yadro.com/course/update/core"
 )
@@ -51,11 +57,22 @@ func (s *Server) Stats(ctx context.Context, _ *emptypb.Empty) (*updatepb.StatsRe
 	if err != nil {
 		return nil, err
 	}
+	pipeline := make([]*updatepb.StageMetrics, 0, len(stats.Pipeline))
+	for _, m := range stats.Pipeline {
+		pipeline = append(pipeline, &updatepb.StageMetrics{
+			Stage:     m.Stage,
+			Items:     int64(m.Items),
+			Errors:    int64(m.Errors),
+			Seconds:   m.Duration.Seconds(),
+			PerSecond: m.Rate(),
+		})
+	}
 	return &updatepb.StatsReply{
 		WordsTotal:    int64(stats.WordsTotal),
 		WordsUnique:   int64(stats.WordsUnique),
 		ComicsTotal:   int64(stats.ComicsTotal),
 		ComicsFetched: int64(stats.ComicsFetched),
+		Pipeline:      pipeline,
 	}, nil
 }
 
@@ -66,3 +83,218 @@ func (s *Server) Drop(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, er
 	}
 	return &emptypb.Empty{}, nil
 }
+
+func (s *Server) Reindex(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	err := s.service.Reindex(ctx)
+	if err != nil {
+		if errors.Is(err, core.ErrAlreadyExists) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) Export(_ *emptypb.Empty, stream grpc.ServerStreamingServer[updatepb.Comic]) error {
+	return s.service.Export(stream.Context(), func(c core.Comics) error {
+		return stream.Send(&updatepb.Comic{
+			Source:             c.Source,
+			Id:                 int64(c.ID),
+			Url:                c.URL,
+			Keywords:           c.Words,
+			TitleKeywords:      c.TitleWords,
+			AltKeywords:        c.AltWords,
+			TranscriptKeywords: c.TranscriptWords,
+			Title:              c.Title,
+			SafeTitle:          c.SafeTitle,
+			Alt:                c.Alt,
+			Transcript:         c.Transcript,
+			AnalyzerVersion:    int64(c.AnalyzerVersion),
+			Published:          timestamp(c.Published),
+		})
+	})
+}
+
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func date(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func (s *Server) Import(stream grpc.ClientStreamingServer[updatepb.Comic, updatepb.ImportReply]) error {
+	imported, err := s.service.Import(stream.Context(), func() (core.Comics, error) {
+		c, err := stream.Recv()
+		if err != nil {
+			return core.Comics{}, err
+		}
+		return core.Comics{
+			ID:              int(c.GetId()),
+			Source:          c.GetSource(),
+			URL:             c.GetUrl(),
+			Words:           c.GetKeywords(),
+			TitleWords:      c.GetTitleKeywords(),
+			AltWords:        c.GetAltKeywords(),
+			TranscriptWords: c.GetTranscriptKeywords(),
+			Title:           c.GetTitle(),
+			SafeTitle:       c.GetSafeTitle(),
+			Alt:             c.GetAlt(),
+			Transcript:      c.GetTranscript(),
+			AnalyzerVersion: int(c.GetAnalyzerVersion()),
+			Published:       date(c.GetPublished()),
+		}, nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, core.ErrBadArguments):
+			return status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, core.ErrAlreadyExists):
+			return status.Error(codes.AlreadyExists, err.Error())
+		}
+		return err
+	}
+	return stream.SendAndClose(&updatepb.ImportReply{Imported: int64(imported)})
+}
+
+func (s *Server) Verify(ctx context.Context, in *updatepb.VerifyRequest) (*updatepb.VerifyReply, error) {
+	reports, err := s.service.Verify(ctx, in.GetRepair())
+	if err != nil {
+		if errors.Is(err, core.ErrAlreadyExists) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		return nil, err
+	}
+
+	reply := &updatepb.VerifyReply{Sources: make([]*updatepb.SourceReport, 0, len(reports))}
+	for _, r := range reports {
+		reply.Sources = append(reply.Sources, &updatepb.SourceReport{
+			Source:   r.Source,
+			Total:    int64(r.Total),
+			Missing:  toInt64(r.Missing),
+			Empty:    toInt64(r.Empty),
+			Repaired: int64(r.Repaired),
+		})
+	}
+	return reply, nil
+}
+
+func (s *Server) Refresh(ctx context.Context, _ *emptypb.Empty) (*updatepb.RefreshReply, error) {
+	changed, err := s.service.Refresh(ctx)
+	if err != nil {
+		if errors.Is(err, core.ErrAlreadyExists) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		return nil, err
+	}
+	return &updatepb.RefreshReply{Changed: int64(changed)}, nil
+}
+
+func (s *Server) History(ctx context.Context, in *updatepb.HistoryRequest) (*updatepb.HistoryReply, error) {
+	versions, err := s.service.History(ctx, core.ComicKey{Source: in.GetSource(), ID: int(in.GetId())})
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+
+	reply := &updatepb.HistoryReply{Versions: make([]*updatepb.ComicVersion, 0, len(versions))}
+	for _, v := range versions {
+		reply.Versions = append(reply.Versions, &updatepb.ComicVersion{
+			Url:         v.URL,
+			Title:       v.Title,
+			SafeTitle:   v.SafeTitle,
+			Alt:         v.Alt,
+			Transcript:  v.Transcript,
+			ContentHash: v.ContentHash,
+			ReplacedAt:  timestamppb.New(v.ReplacedAt),
+		})
+	}
+	return reply, nil
+}
+
+func (s *Server) Tags(ctx context.Context, in *updatepb.TagsRequest) (*updatepb.TagsReply, error) {
+	tags, err := s.service.Tags(ctx, core.ComicKey{Source: in.GetSource(), ID: int(in.GetId())})
+	if err != nil {
+		return nil, tagError(err)
+	}
+
+	reply := &updatepb.TagsReply{Tags: make([]*updatepb.Tag, 0, len(tags))}
+	for _, tag := range tags {
+		reply.Tags = append(reply.Tags, tagReply(tag))
+	}
+	return reply, nil
+}
+
+func (s *Server) AddTag(ctx context.Context, in *updatepb.TagRequest) (*updatepb.Tag, error) {
+	tag, err := s.service.AddTag(ctx, core.ComicKey{Source: in.GetSource(), ID: int(in.GetId())}, in.GetTag())
+	if err != nil {
+		return nil, tagError(err)
+	}
+	return tagReply(tag), nil
+}
+
+func (s *Server) RemoveTag(ctx context.Context, in *updatepb.TagRequest) (*emptypb.Empty, error) {
+	err := s.service.RemoveTag(ctx, core.ComicKey{Source: in.GetSource(), ID: int(in.GetId())}, in.GetTag())
+	if err != nil {
+		return nil, tagError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) TagCounts(ctx context.Context, _ *emptypb.Empty) (*updatepb.TagCountsReply, error) {
+	counts, err := s.service.TagCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &updatepb.TagCountsReply{Tags: make([]*updatepb.TagCount, 0, len(counts))}
+	for _, c := range counts {
+		reply.Tags = append(reply.Tags, &updatepb.TagCount{Tag: c.Tag, Comics: int64(c.Comics)})
+	}
+	return reply, nil
+}
+
+func tagReply(tag core.Tag) *updatepb.Tag {
+	reply := &updatepb.Tag{Name: tag.Name, Words: tag.Words}
+	if !tag.AddedAt.IsZero() {
+		reply.AddedAt = timestamppb.New(tag.AddedAt)
+	}
+	return reply
+}
+
+// tagError maps the errors of the tag requests to the statuses.
+func tagError(err error) error {
+	switch {
+	case errors.Is(err, core.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, core.ErrBadArguments):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return err
+}
+
+func (s *Server) Watch(_ *emptypb.Empty, stream grpc.ServerStreamingServer[updatepb.Change]) error {
+	for change := range s.service.Watch(stream.Context()) {
+		if err := stream.Send(&updatepb.Change{Reason: change.Reason, Comics: int64(change.Comics)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toInt64(ids []int) []int64 {
+	out := make([]int64, len(ids))
+	for i, id := range ids {
+		out[i] = int64(id)
+	}
+	return out
+}
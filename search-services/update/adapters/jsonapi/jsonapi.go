@@ -0,0 +1,218 @@
+package jsonapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"yadro.com/course/update/core"
+)
+
+// Mapping holds dot-separated paths (e.g. "data.attributes.title") to the
+// comic fields inside an API item. Array elements are addressed by index.
+type Mapping struct {
+	ID         string
+	Title      string
+	SafeTitle  string
+	Alt        string
+	Transcript string
+	Image      string
+}
+
+// Config describes a generic JSON API. ListURL returns an array, found at
+// ListPath, of either comic IDs or whole items. When ItemURL is set, every
+// comic is fetched from it with {id} replaced by the comic ID.
+type Config struct {
+	ListURL  string
+	ListPath string
+	ItemURL  string
+	Mapping  Mapping
+}
+
+type Client struct {
+	log    *slog.Logger
+	client http.Client
+	name   string
+	cfg    Config
+
+	mu    sync.Mutex
+	items map[int]any
+}
+
+func NewClient(name string, cfg Config, timeout time.Duration, log *slog.Logger) (*Client, error) {
+	if name == "" {
+		return nil, fmt.Errorf("empty source name specified")
+	}
+	if cfg.ListURL == "" {
+		return nil, fmt.Errorf("empty list url specified")
+	}
+	if cfg.ItemURL != "" && !strings.Contains(cfg.ItemURL, "{id}") {
+		return nil, fmt.Errorf("item url %q has no {id} placeholder", cfg.ItemURL)
+	}
+	if cfg.Mapping.ID == "" {
+		cfg.Mapping.ID = "id"
+	}
+	return &Client{
+		log:    log,
+		client: http.Client{Timeout: timeout},
+		name:   name,
+		cfg:    cfg,
+		items:  make(map[int]any),
+	}, nil
+}
+
+func (c *Client) Name() string {
+	return c.name
+}
+
+func (c *Client) List(ctx context.Context) ([]int, error) {
+	var body any
+	if err := c.fetch(ctx, c.cfg.ListURL, &body); err != nil {
+		return nil, err
+	}
+
+	list, ok := lookup(body, c.cfg.ListPath)
+	if !ok {
+		return nil, fmt.Errorf("no %q in list response", c.cfg.ListPath)
+	}
+	elems, ok := list.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%q in list response is not an array", c.cfg.ListPath)
+	}
+
+	ids := make([]int, 0, len(elems))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, elem := range elems {
+		if _, isItem := elem.(map[string]any); !isItem {
+			id, ok := toInt(elem)
+			if !ok {
+				c.log.Warn("skipping list element without comic id", "source", c.name, "element", elem)
+				continue
+			}
+			ids = append(ids, id)
+			continue
+		}
+
+		value, _ := lookup(elem, c.cfg.Mapping.ID)
+		id, ok := toInt(value)
+		if !ok {
+			c.log.Warn("skipping list item without comic id", "source", c.name, "path", c.cfg.Mapping.ID)
+			continue
+		}
+		c.items[id] = elem
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+	return slices.Compact(ids), nil
+}
+
+func (c *Client) Get(ctx context.Context, id int) (core.ComicInfo, error) {
+	var item any
+	if c.cfg.ItemURL != "" {
+		url := strings.ReplaceAll(c.cfg.ItemURL, "{id}", strconv.Itoa(id))
+		if err := c.fetch(ctx, url, &item); err != nil {
+			return core.ComicInfo{}, err
+		}
+	} else {
+		c.mu.Lock()
+		cached, ok := c.items[id]
+		c.mu.Unlock()
+		if !ok {
+			return core.ComicInfo{}, fmt.Errorf("%w: comic %d is not listed by %q", core.ErrNotFound, id, c.cfg.ListURL)
+		}
+		item = cached
+	}
+
+	m := c.cfg.Mapping
+	return core.ComicInfo{
+		ID:         id,
+		URL:        str(item, m.Image),
+		Title:      str(item, m.Title),
+		Alt:        str(item, m.Alt),
+		Transcript: str(item, m.Transcript),
+		SafeTitle:  str(item, m.SafeTitle),
+	}, nil
+}
+
+func (c *Client) fetch(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send req: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", core.ErrNotFound, url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	return nil
+}
+
+func lookup(v any, path string) (any, bool) {
+	if path == "" {
+		return v, true
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func str(v any, path string) string {
+	if path == "" {
+		return ""
+	}
+	value, ok := lookup(v, path)
+	if !ok || value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
+
+func toInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), n == float64(int(n))
+	case string:
+		id, err := strconv.Atoi(n)
+		return id, err == nil
+	}
+	return 0, false
+}
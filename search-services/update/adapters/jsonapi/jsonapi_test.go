@@ -0,0 +1,128 @@
+package jsonapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"yadro.com/course/update/core"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ids":
+			_, _ = w.Write([]byte(`{"data": {"ids": [3, "1", 2, "x"]}}`))
+		case "/comics/1":
+			_, _ = w.Write([]byte(`{"data": {"name": "One", "text": {"alt": "first"}, "images": ["https://example.com/1.png"]}}`))
+		case "/items":
+			_, _ = w.Write([]byte(`[{"num": 7, "name": "Seven", "img": "https://example.com/7.png"}, {"name": "no id"}]`))
+		case "/object":
+			_, _ = w.Write([]byte(`{"data": {}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "success", source: "api", cfg: Config{ListURL: "http://api/ids", ItemURL: "http://api/{id}"}, wantErr: false},
+		{name: "empty name", source: "", cfg: Config{ListURL: "http://api/ids"}, wantErr: true},
+		{name: "empty list URL", source: "api", cfg: Config{}, wantErr: true},
+		{name: "no placeholder", source: "api", cfg: Config{ListURL: "http://api/ids", ItemURL: "http://api/item"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(tt.source, tt.cfg, time.Second, slog.Default())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewClient error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_ItemURL(t *testing.T) {
+	server := newServer(t)
+	client, err := NewClient("api", Config{
+		ListURL:  server.URL + "/ids",
+		ListPath: "data.ids",
+		ItemURL:  server.URL + "/comics/{id}",
+		Mapping: Mapping{
+			Title: "data.name",
+			Alt:   "data.text.alt",
+			Image: "data.images.0",
+		},
+	}, time.Second, slog.Default())
+	require.NoError(t, err)
+
+	ids, err := client.List(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, ids)
+
+	got, err := client.Get(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, core.ComicInfo{ID: 1, Title: "One", Alt: "first", URL: "https://example.com/1.png"}, got)
+
+	_, err = client.Get(context.Background(), 2)
+	assert.True(t, errors.Is(err, core.ErrNotFound))
+}
+
+func TestClient_ListedItems(t *testing.T) {
+	server := newServer(t)
+	client, err := NewClient("api", Config{
+		ListURL: server.URL + "/items",
+		Mapping: Mapping{ID: "num", Title: "name", Image: "img"},
+	}, time.Second, slog.Default())
+	require.NoError(t, err)
+
+	ids, err := client.List(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []int{7}, ids)
+
+	got, err := client.Get(context.Background(), 7)
+	assert.NoError(t, err)
+	assert.Equal(t, core.ComicInfo{ID: 7, Title: "Seven", URL: "https://example.com/7.png"}, got)
+
+	_, err = client.Get(context.Background(), 8)
+	assert.True(t, errors.Is(err, core.ErrNotFound))
+}
+
+func TestClient_ListErrors(t *testing.T) {
+	server := newServer(t)
+
+	tests := []struct {
+		name     string
+		url      string
+		listPath string
+		wantErr  string
+	}{
+		{name: "server err", url: server.URL + "/missing", wantErr: "resource is not found"},
+		{name: "missing path", url: server.URL + "/object", listPath: "data.ids", wantErr: "no \"data.ids\""},
+		{name: "not an array", url: server.URL + "/object", listPath: "data", wantErr: "is not an array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient("api", Config{ListURL: tt.url, ListPath: tt.listPath}, time.Second, slog.Default())
+			require.NoError(t, err)
+
+			_, err = client.List(context.Background())
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package rss
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"html"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"yadro.com/course/update/core"
+)
+
+// DefaultIDPattern takes the last number of an item link, e.g. 3000 from
+// https://xkcd.com/3000/.
+const DefaultIDPattern = `(\d+)\D*$`
+
+var (
+	imgTag  = regexp.MustCompile(`(?is)<img\s[^>]*>`)
+	imgAttr = regexp.MustCompile(`(?is)\b(src|title|alt)\s*=\s*"([^"]*)"`)
+	anyTag  = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// Client reads comics from an RSS 2.0 or Atom feed. Feeds only carry the
+// latest entries, so items that leave the feed stay in the database as they
+// were fetched.
+type Client struct {
+	log       *slog.Logger
+	client    http.Client
+	name      string
+	url       string
+	idPattern *regexp.Regexp
+
+	mu    sync.Mutex
+	items map[int]core.ComicInfo
+}
+
+type feed struct {
+	Items   []item  `xml:"channel>item"`
+	Entries []entry `xml:"entry"`
+}
+
+type item struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description"`
+	Enclosure   struct {
+		URL  string `xml:"url,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"enclosure"`
+}
+
+type entry struct {
+	Title string `xml:"title"`
+	ID    string `xml:"id"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"link"`
+	Summary string `xml:"summary"`
+	Content string `xml:"content"`
+}
+
+func NewClient(name, url, idPattern string, timeout time.Duration, log *slog.Logger) (*Client, error) {
+	if name == "" {
+		return nil, fmt.Errorf("empty source name specified")
+	}
+	if url == "" {
+		return nil, fmt.Errorf("empty feed url specified")
+	}
+	if idPattern == "" {
+		idPattern = DefaultIDPattern
+	}
+	pattern, err := regexp.Compile(idPattern)
+	if err != nil {
+		return nil, fmt.Errorf("bad id pattern: %w", err)
+	}
+	return &Client{
+		log:       log,
+		client:    http.Client{Timeout: timeout},
+		name:      name,
+		url:       url,
+		idPattern: pattern,
+		items:     make(map[int]core.ComicInfo),
+	}, nil
+}
+
+func (c *Client) Name() string {
+	return c.name
+}
+
+func (c *Client) List(ctx context.Context) ([]int, error) {
+	items, err := c.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(items))
+	for _, info := range items {
+		ids = append(ids, info.ID)
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids), nil
+}
+
+func (c *Client) Get(ctx context.Context, id int) (core.ComicInfo, error) {
+	c.mu.Lock()
+	info, ok := c.items[id]
+	c.mu.Unlock()
+	if ok {
+		return info, nil
+	}
+
+	items, err := c.fetch(ctx)
+	if err != nil {
+		return core.ComicInfo{}, err
+	}
+	for _, info := range items {
+		if info.ID == id {
+			return info, nil
+		}
+	}
+	return core.ComicInfo{}, fmt.Errorf("%w: comic %d is not in feed %q", core.ErrNotFound, id, c.url)
+}
+
+func (c *Client) fetch(ctx context.Context) ([]core.ComicInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send req: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var f feed
+	if err := xml.NewDecoder(resp.Body).Decode(&f); err != nil {
+		return nil, fmt.Errorf("failed to decode feed: %w", err)
+	}
+
+	items := make([]core.ComicInfo, 0, len(f.Items)+len(f.Entries))
+	for _, it := range f.Items {
+		image := ""
+		if strings.HasPrefix(it.Enclosure.Type, "image/") {
+			image = it.Enclosure.URL
+		}
+		items = append(items, c.info(it.Title, it.Link, it.GUID, it.Description, image))
+	}
+	for _, e := range f.Entries {
+		link, image := "", ""
+		for _, l := range e.Links {
+			switch {
+			case l.Rel == "enclosure" && strings.HasPrefix(l.Type, "image/"):
+				image = l.Href
+			case l.Rel == "" || l.Rel == "alternate":
+				link = l.Href
+			}
+		}
+		body := e.Content
+		if body == "" {
+			body = e.Summary
+		}
+		items = append(items, c.info(e.Title, link, e.ID, body, image))
+	}
+
+	c.mu.Lock()
+	for _, info := range items {
+		c.items[info.ID] = info
+	}
+	c.mu.Unlock()
+
+	return items, nil
+}
+
+func (c *Client) info(title, link, guid, body, image string) core.ComicInfo {
+	info := core.ComicInfo{
+		ID:        c.id(link, guid),
+		URL:       image,
+		Title:     strings.TrimSpace(title),
+		SafeTitle: strings.TrimSpace(title),
+	}
+
+	if tag := imgTag.FindString(body); tag != "" {
+		for _, attr := range imgAttr.FindAllStringSubmatch(tag, -1) {
+			value := html.UnescapeString(attr[2])
+			switch strings.ToLower(attr[1]) {
+			case "src":
+				if info.URL == "" {
+					info.URL = value
+				}
+			case "title":
+				info.Alt = value
+			case "alt":
+				if info.Alt == "" {
+					info.Alt = value
+				}
+			}
+		}
+	}
+	info.Transcript = strings.Join(strings.Fields(html.UnescapeString(anyTag.ReplaceAllString(body, " "))), " ")
+	return info
+}
+
+// id extracts the comic ID from the item link or guid. Items without a
+// number get a stable ID derived from their guid.
+func (c *Client) id(link, guid string) int {
+	for _, s := range []string{link, guid} {
+		if m := c.idPattern.FindStringSubmatch(s); len(m) > 1 {
+			if id, err := strconv.Atoi(m[1]); err == nil {
+				return id
+			}
+		}
+	}
+	key := guid
+	if key == "" {
+		key = link
+	}
+	return int(crc32.ChecksumIEEE([]byte(key)) & 0x7fffffff)
+}
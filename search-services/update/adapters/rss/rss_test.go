@@ -0,0 +1,153 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"yadro.com/course/update/core"
+)
+
+const rssFeed = `<?xml version="1.0" encoding="utf-8"?>
+<rss version="2.0"><channel>
+<title>xkcd.com</title>
+<item>
+<title>Barrel - Part 1</title>
+<link>https://xkcd.com/1/</link>
+<description>&lt;img src="https://imgs.xkcd.com/comics/barrel_cropped_(1).jpg" title="Don&amp;#39;t we all." alt="Barrel" /&gt;</description>
+<guid>https://xkcd.com/1/</guid>
+</item>
+<item>
+<title>Petit Trees (sketch)</title>
+<link>https://xkcd.com/2/</link>
+<description>Two trees are growing on opposite sides of a sphere.</description>
+<enclosure url="https://imgs.xkcd.com/comics/tree_cropped_(1).jpg" type="image/jpeg" />
+</item>
+</channel></rss>`
+
+const atomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+<title>Some comic</title>
+<entry>
+<title>Hello</title>
+<link href="https://example.com/comics/hello" rel="alternate" />
+<id>tag:example.com,2024:hello</id>
+<summary type="html">&lt;p&gt;Hello &lt;b&gt;world&lt;/b&gt;&lt;/p&gt;&lt;img src="https://example.com/hello.png" alt="Waving" /&gt;</summary>
+</entry>
+</feed>`
+
+func newServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rss.xml":
+			_, _ = w.Write([]byte(rssFeed))
+		case "/atom.xml":
+			_, _ = w.Write([]byte(atomFeed))
+		case "/broken.xml":
+			_, _ = w.Write([]byte("<rss><channel>"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		url       string
+		idPattern string
+		wantErr   bool
+	}{
+		{name: "success", source: "feed", url: "https://xkcd.com/rss.xml", wantErr: false},
+		{name: "empty name", source: "", url: "https://xkcd.com/rss.xml", wantErr: true},
+		{name: "empty URL", source: "feed", url: "", wantErr: true},
+		{name: "bad pattern", source: "feed", url: "https://xkcd.com/rss.xml", idPattern: "(", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(tt.source, tt.url, tt.idPattern, time.Second, slog.Default())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewClient error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_RSS(t *testing.T) {
+	server := newServer(t)
+	client, err := NewClient("xkcd-rss", server.URL+"/rss.xml", "", time.Second, slog.Default())
+	require.NoError(t, err)
+
+	ids, err := client.List(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, ids)
+
+	got, err := client.Get(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, core.ComicInfo{
+		ID:         1,
+		URL:        "https://imgs.xkcd.com/comics/barrel_cropped_(1).jpg",
+		Title:      "Barrel - Part 1",
+		SafeTitle:  "Barrel - Part 1",
+		Alt:        "Don't we all.",
+		Transcript: "",
+	}, got)
+
+	got, err = client.Get(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://imgs.xkcd.com/comics/tree_cropped_(1).jpg", got.URL)
+	assert.Equal(t, "Two trees are growing on opposite sides of a sphere.", got.Transcript)
+
+	_, err = client.Get(context.Background(), 3)
+	assert.True(t, errors.Is(err, core.ErrNotFound))
+}
+
+func TestClient_Atom(t *testing.T) {
+	server := newServer(t)
+	client, err := NewClient("atom", server.URL+"/atom.xml", "", time.Second, slog.Default())
+	require.NoError(t, err)
+
+	ids, err := client.List(context.Background())
+	assert.NoError(t, err)
+	require.Len(t, ids, 1)
+
+	got, err := client.Get(context.Background(), ids[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello", got.Title)
+	assert.Equal(t, "https://example.com/hello.png", got.URL)
+	assert.Equal(t, "Waving", got.Alt)
+	assert.Equal(t, "Hello world", got.Transcript)
+}
+
+func TestClient_Errors(t *testing.T) {
+	server := newServer(t)
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr string
+	}{
+		{name: "server err", url: server.URL + "/missing.xml", wantErr: "unexpected status code"},
+		{name: "invalid feed", url: server.URL + "/broken.xml", wantErr: "failed to decode feed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient("feed", tt.url, "", time.Second, slog.Default())
+			require.NoError(t, err)
+
+			_, err = client.List(context.Background())
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
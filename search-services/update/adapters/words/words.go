@@ -6,6 +6,7 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
 	wordspb "yadro.com/course/proto/words"
 )
 
@@ -34,6 +35,15 @@ func (c Client) Norm(ctx context.Context, phrase string) ([]string, error) {
 	return resp.Words, nil
 }
 
+func (c Client) Version(ctx context.Context) (int, error) {
+	resp, err := c.client.Version(ctx, &emptypb.Empty{})
+	if err != nil {
+		c.log.Error("failed to get analyzer version", "error", err)
+		return 0, err
+	}
+	return int(resp.Version), nil
+}
+
 func (c Client) Ping(ctx context.Context) error {
 	_, err := c.client.Ping(ctx, nil)
 	if err != nil {
@@ -0,0 +1,139 @@
+package xkcd_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"yadro.com/course/fakexkcd/corpus"
+	"yadro.com/course/update/adapters/xkcd"
+	"yadro.com/course/update/core"
+)
+
+type memDB struct {
+	mu     sync.Mutex
+	comics map[core.ComicKey]core.Comics
+	adds   int
+}
+
+func (db *memDB) Add(_ context.Context, batch []core.Comics) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, c := range batch {
+		db.adds++
+		if _, ok := db.comics[c.Key()]; !ok {
+			db.comics[c.Key()] = c
+		}
+	}
+	return nil
+}
+
+func (db *memDB) IDs(_ context.Context, source string) ([]int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var ids []int
+	for key := range db.comics {
+		if key.Source == source {
+			ids = append(ids, key.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (db *memDB) Stats(context.Context) (core.DBStats, error)  { return core.DBStats{}, nil }
+func (db *memDB) Drop(context.Context) error                   { return nil }
+func (db *memDB) Replace(context.Context, []core.Comics) error { return nil }
+func (db *memDB) Upsert(context.Context, []core.Comics) error  { return nil }
+func (db *memDB) Stale(context.Context, int, core.ComicKey, int) ([]core.Comics, error) {
+	return nil, nil
+}
+func (db *memDB) List(context.Context, core.ComicKey, int) ([]core.Comics, error) {
+	return nil, nil
+}
+func (db *memDB) Empty(_ context.Context, source string) ([]int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	ids := []int{}
+	for key, c := range db.comics {
+		if key.Source == source && (c.URL == "" || len(c.Words) == 0) {
+			ids = append(ids, key.ID)
+		}
+	}
+	return ids, nil
+}
+func (db *memDB) Revise(context.Context, []core.Comics) error { return nil }
+func (db *memDB) History(context.Context, core.ComicKey) ([]core.ComicVersion, error) {
+	return nil, nil
+}
+func (db *memDB) Tags(context.Context, core.ComicKey) ([]core.Tag, error) { return nil, nil }
+func (db *memDB) AddTag(context.Context, core.ComicKey, core.Tag) (time.Time, error) {
+	return time.Time{}, nil
+}
+func (db *memDB) RemoveTag(context.Context, core.ComicKey, string) error        { return nil }
+func (db *memDB) TagCounts(context.Context) ([]core.TagCount, error)            { return nil, nil }
+func (db *memDB) AllTags(context.Context) (map[core.ComicKey][]core.Tag, error) { return nil, nil }
+
+type splitWords struct{}
+
+func (splitWords) Norm(_ context.Context, phrase string) ([]string, error) {
+	return strings.Fields(strings.ToLower(phrase)), nil
+}
+
+func (splitWords) Version(context.Context) (int, error) {
+	return 1, nil
+}
+
+// TestUpdate_FakeXKCD crawls the bundled offline corpus through the real
+// client, the way the stack does with XKCD_URL pointing at fakexkcd.
+func TestUpdate_FakeXKCD(t *testing.T) {
+	comics, err := corpus.Bundled()
+	require.NoError(t, err)
+	server := httptest.NewServer(comics.Handler())
+	defer server.Close()
+
+	client, err := xkcd.NewClient(server.URL, time.Second, slog.Default())
+	require.NoError(t, err)
+
+	db := &memDB{comics: make(map[core.ComicKey]core.Comics)}
+	service, err := core.NewService(slog.Default(), db, []core.Source{client}, splitWords{},
+		core.Pipeline{Fetchers: 2, Normalizers: 2, Persisters: 1, BatchSize: 2, Buffer: 1})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, service.Update(ctx))
+
+	ids, err := db.IDs(ctx, xkcd.SourceName)
+	require.NoError(t, err)
+	sort.Ints(ids)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ids)
+
+	barrel := db.comics[core.ComicKey{Source: xkcd.SourceName, ID: 1}]
+	assert.Equal(t, "Barrel - Part 1", barrel.Title)
+	assert.Equal(t, "https://imgs.xkcd.com/comics/barrel_cropped_(1).jpg", barrel.URL)
+	assert.Contains(t, barrel.Words, "barrel")
+	assert.Contains(t, barrel.TitleWords, "barrel")
+	assert.NotContains(t, barrel.AltWords, "barrel")
+	assert.Equal(t, 1, barrel.AnalyzerVersion)
+
+	stats, err := service.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, stats.ComicsTotal)
+	assert.Equal(t, core.StagePersist, stats.Pipeline[2].Stage)
+	assert.Equal(t, 5, stats.Pipeline[2].Items)
+
+	require.NoError(t, service.Update(ctx))
+	assert.Equal(t, 5, db.adds, "second update must not refetch stored comics")
+
+	reports, err := service.Verify(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, []core.VerifyReport{
+		{Source: xkcd.SourceName, Total: 5, Missing: []int{}, Empty: []int{}},
+	}, reports)
+}
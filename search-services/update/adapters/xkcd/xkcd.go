@@ -11,6 +11,8 @@ import (
 	"yadro.com/course/update/core"
 )
 
+const SourceName = "xkcd"
+
 type Client struct {
 	log    *slog.Logger
 	client http.Client
@@ -28,28 +30,55 @@ func NewClient(url string, timeout time.Duration, log *slog.Logger) (*Client, er
 	}, nil
 }
 
-func (c Client) Get(ctx context.Context, id int) (core.XKCDInfo, error) {
+func (c Client) Name() string {
+	return SourceName
+}
+
+// List returns every xkcd ID up to the latest one, as xkcd numbers its
+// comics sequentially.
+func (c Client) List(ctx context.Context) ([]int, error) {
+	last, err := c.LastID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, last)
+	for id := 1; id <= last; id++ {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (c Client) Get(ctx context.Context, id int) (core.ComicInfo, error) {
 	url := fmt.Sprintf("%s/%d/info.0.json", c.url, id)
 	resp, err := http.Get(url)
 	if err != nil {
 		c.log.Error("failed to send req", "info", err)
-		return core.XKCDInfo{}, err
+		return core.ComicInfo{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		if id == 404 {
-			return core.XKCDInfo{}, core.Err404Comics
+			return core.ComicInfo{}, core.Err404Comics
 		}
-		return core.XKCDInfo{}, err
+		return core.ComicInfo{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
 	var jsonInfo core.JsonXKCDInfo
 	if err := json.NewDecoder(resp.Body).Decode(&jsonInfo); err != nil {
-		return core.XKCDInfo{}, err
+		return core.ComicInfo{}, err
 	}
 
-	info := core.XKCDInfo(jsonInfo)
+	info := core.ComicInfo{
+		ID:         jsonInfo.ID,
+		URL:        jsonInfo.URL,
+		Title:      jsonInfo.Title,
+		Alt:        jsonInfo.Alt,
+		Transcript: jsonInfo.Transcript,
+		SafeTitle:  jsonInfo.SafeTitle,
+		Published:  jsonInfo.Published(),
+	}
 
 	return info, nil
 }
@@ -55,6 +55,9 @@ func TestClient_Get(t *testing.T) {
 				Title: "Pore Strips",
 				URL:   "https://imgs.xkcd.com/comics/pore_strips.png",
 				Alt:   "I'm sure they're a harmful tool of the cosmetics-industrial complex and all, but my goodness do those strips ever work to pull gunk out of your pores. I was shocked, disgusted, and vaguely fascinated by the result.",
+				Year:  "2010",
+				Month: "8",
+				Day:   "20",
 			}
 			w.WriteHeader(http.StatusOK)
 			if err := json.NewEncoder(w).Encode(info); err != nil {
@@ -71,28 +74,30 @@ func TestClient_Get(t *testing.T) {
 
 	client, err := NewClient(server.URL, time.Second, slog.Default())
 	assert.NoError(t, err)
+	published := time.Date(2010, 8, 20, 0, 0, 0, 0, time.UTC)
 
 	tests := []struct {
 		name    string
 		id      int
-		want    core.XKCDInfo
+		want    core.ComicInfo
 		wantErr error
 	}{
 		{
 			name: "success get 777 comic",
 			id:   777,
-			want: core.XKCDInfo{
-				ID:    777,
-				Title: "Pore Strips",
-				URL:   "https://imgs.xkcd.com/comics/pore_strips.png",
-				Alt:   "I'm sure they're a harmful tool of the cosmetics-industrial complex and all, but my goodness do those strips ever work to pull gunk out of your pores. I was shocked, disgusted, and vaguely fascinated by the result.",
+			want: core.ComicInfo{
+				ID:        777,
+				Title:     "Pore Strips",
+				URL:       "https://imgs.xkcd.com/comics/pore_strips.png",
+				Alt:       "I'm sure they're a harmful tool of the cosmetics-industrial complex and all, but my goodness do those strips ever work to pull gunk out of your pores. I was shocked, disgusted, and vaguely fascinated by the result.",
+				Published: &published,
 			},
 			wantErr: nil,
 		},
 		{
 			name:    "404 comic",
 			id:      404,
-			want:    core.XKCDInfo{},
+			want:    core.ComicInfo{},
 			wantErr: core.Err404Comics,
 		},
 	}
@@ -177,3 +182,31 @@ func TestClient_LastID(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_List(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/info.0.json":
+			if err := json.NewEncoder(w).Encode(core.JsonXKCDInfo{ID: 3}); err != nil {
+				log.Printf("failed to encode")
+			}
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, time.Second, slog.Default())
+	assert.NoError(t, err)
+	assert.Equal(t, SourceName, client.Name())
+
+	got, err := client.List(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, got)
+
+	client, err = NewClient(server.URL+"/error", time.Second, slog.Default())
+	assert.NoError(t, err)
+
+	_, err = client.List(context.Background())
+	assert.ErrorContains(t, err, "unexpected status code")
+}
@@ -14,12 +14,55 @@ type XKCD struct {
 	CheckPeriod time.Duration `yaml:"check_period" env:"XKCD_CHECK_PERIOD" env-default:"1h"`
 }
 
+// Pipeline sets the concurrency of every update stage. Zero fetchers means
+// the xkcd concurrency.
+type Pipeline struct {
+	Fetchers    int `yaml:"fetchers" env:"PIPELINE_FETCHERS" env-default:"0"`
+	Normalizers int `yaml:"normalizers" env:"PIPELINE_NORMALIZERS" env-default:"4"`
+	Persisters  int `yaml:"persisters" env:"PIPELINE_PERSISTERS" env-default:"1"`
+	BatchSize   int `yaml:"batch_size" env:"PIPELINE_BATCH_SIZE" env-default:"100"`
+	Buffer      int `yaml:"buffer" env:"PIPELINE_BUFFER" env-default:"100"`
+}
+
+const (
+	SourceDir  = "dir"
+	SourceRSS  = "rss"
+	SourceJSON = "json"
+)
+
+type FieldMapping struct {
+	ID         string `yaml:"id"`
+	Title      string `yaml:"title"`
+	SafeTitle  string `yaml:"safe_title"`
+	Alt        string `yaml:"alt"`
+	Transcript string `yaml:"transcript"`
+	Image      string `yaml:"image"`
+}
+
+// Source is a webcomic crawled in addition to xkcd. Type selects which of
+// the other fields are used: path and image_url for dir, url and id_pattern
+// for rss, url, list_path, item_url and mapping for json.
+type Source struct {
+	Name      string        `yaml:"name"`
+	Type      string        `yaml:"type"`
+	URL       string        `yaml:"url"`
+	Path      string        `yaml:"path"`
+	ImageURL  string        `yaml:"image_url"`
+	IDPattern string        `yaml:"id_pattern"`
+	ListPath  string        `yaml:"list_path"`
+	ItemURL   string        `yaml:"item_url"`
+	Mapping   FieldMapping  `yaml:"mapping"`
+	Timeout   time.Duration `yaml:"timeout"`
+}
+
 type Config struct {
-	LogLevel     string `yaml:"log_level" env:"LOG_LEVEL" env-default:"DEBUG"`
-	Address      string `yaml:"update_address" env:"UPDATE_ADDRESS" env-default:"localhost:83"`
-	XKCD         XKCD   `yaml:"xkcd"`
-	DBAddress    string `yaml:"db_address" env:"DB_ADDRESS" env-default:"localhost:82"`
-	WordsAddress string `yaml:"words_address" env:"WORDS_ADDRESS" env-default:"localhost:81"`
+	LogLevel     string   `yaml:"log_level" env:"LOG_LEVEL" env-default:"DEBUG"`
+	Address      string   `yaml:"update_address" env:"UPDATE_ADDRESS" env-default:"localhost:83"`
+	XKCD         XKCD     `yaml:"xkcd"`
+	Pipeline     Pipeline `yaml:"pipeline"`
+	Sources      []Source `yaml:"sources"`
+	DBAddress    string   `yaml:"db_address" env:"DB_ADDRESS" env-default:"localhost:82"`
+	WordsAddress string   `yaml:"words_address" env:"WORDS_ADDRESS" env-default:"localhost:81"`
 }
 
 func MustLoad(configPath string) Config {
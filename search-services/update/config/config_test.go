@@ -18,6 +18,28 @@ xkcd:
   concurrency: 10
   timeout: 10s
   check_period: 1h
+pipeline:
+  fetchers: 8
+  normalizers: 2
+  batch_size: 500
+sources:
+  - name: local
+    type: dir
+    path: /comics
+    image_url: http://static/comics
+  - name: smbc
+    type: rss
+    url: https://www.smbc-comics.com/comic/rss
+    timeout: 5s
+  - name: api
+    type: json
+    url: https://example.com/comics
+    list_path: data
+    item_url: https://example.com/comics/{id}
+    mapping:
+      id: num
+      title: name
+      image: img
 db_address: localhost:82
 words_address: localhost:81
 `
@@ -41,6 +63,21 @@ words_address: localhost:81
 	assert.Equal(t, 10*time.Second, cfg.XKCD.Timeout)
 	assert.Equal(t, 1*time.Hour, cfg.XKCD.CheckPeriod)
 
+	assert.Equal(t, Pipeline{Fetchers: 8, Normalizers: 2, Persisters: 1, BatchSize: 500, Buffer: 100}, cfg.Pipeline)
+
+	assert.Equal(t, []Source{
+		{Name: "local", Type: SourceDir, Path: "/comics", ImageURL: "http://static/comics"},
+		{Name: "smbc", Type: SourceRSS, URL: "https://www.smbc-comics.com/comic/rss", Timeout: 5 * time.Second},
+		{
+			Name:     "api",
+			Type:     SourceJSON,
+			URL:      "https://example.com/comics",
+			ListPath: "data",
+			ItemURL:  "https://example.com/comics/{id}",
+			Mapping:  FieldMapping{ID: "num", Title: "name", Image: "img"},
+		},
+	}, cfg.Sources)
+
 	assert.Equal(t, "localhost:82", cfg.DBAddress)
 	assert.Equal(t, "localhost:81", cfg.WordsAddress)
 }
@@ -63,6 +100,7 @@ func TestMustLoad_Defaults(t *testing.T) {
 	assert.Equal(t, "localhost:83", cfg.Address)
 	assert.Equal(t, "localhost:82", cfg.DBAddress)
 	assert.Equal(t, "localhost:81", cfg.WordsAddress)
+	assert.Equal(t, Pipeline{Fetchers: 0, Normalizers: 4, Persisters: 1, BatchSize: 100, Buffer: 100}, cfg.Pipeline)
 }
 
 func TestMustLoad_EnvVars(t *testing.T) {
@@ -0,0 +1,63 @@
+package core
+
+import "context"
+
+// Change reasons.
+const (
+	ChangeUpdate  = "update"
+	ChangeDrop    = "drop"
+	ChangeReindex = "reindex"
+	ChangeImport  = "import"
+	ChangeRepair  = "repair"
+	ChangeRefresh = "refresh"
+	ChangeTag     = "tag"
+)
+
+// Change tells the watchers that the stored comics changed.
+type Change struct {
+	Reason string
+	// Comics is how many comics were written, zero if not counted.
+	Comics int
+}
+
+// Watch sends the changes made until ctx is done, then closes the channel.
+// A watcher slow to receive misses the changes made meanwhile but the
+// latest, which is enough to know the comics changed.
+func (s *Service) Watch(ctx context.Context) <-chan Change {
+	ch := make(chan Change, 1)
+
+	s.watchMu.Lock()
+	if s.watchers == nil {
+		s.watchers = make(map[chan Change]struct{})
+	}
+	s.watchers[ch] = struct{}{}
+	s.watchMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.watchMu.Lock()
+		delete(s.watchers, ch)
+		close(ch)
+		s.watchMu.Unlock()
+	}()
+	return ch
+}
+
+// notify passes the change to every watcher without waiting for them.
+func (s *Service) notify(change Change) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+
+	for ch := range s.watchers {
+		select {
+		case ch <- change:
+		default:
+			// drop the pending change for the latest one
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- change
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestService_Watch(t *testing.T) {
+	db := &MockDB{}
+	db.On("Drop", mock.Anything).Return(nil)
+	service := &Service{log: slog.Default(), db: db, idsExists: map[string]map[int]struct{}{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := service.Watch(ctx)
+
+	assert.NoError(t, service.Drop(context.Background()))
+	assert.Equal(t, Change{Reason: ChangeDrop}, <-changes)
+
+	// a watcher not receiving gets the latest change only
+	service.notify(Change{Reason: ChangeImport, Comics: 1})
+	service.notify(Change{Reason: ChangeRefresh, Comics: 2})
+	assert.Equal(t, Change{Reason: ChangeRefresh, Comics: 2}, <-changes)
+
+	cancel()
+	select {
+	case _, ok := <-changes:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("changes are not closed")
+	}
+	service.notify(Change{Reason: ChangeUpdate})
+	db.AssertExpectations(t)
+}
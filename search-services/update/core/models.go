@@ -1,5 +1,13 @@
 package core
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type ServiceStatus string
 
 const (
@@ -16,12 +24,104 @@ type DBStats struct {
 type ServiceStats struct {
 	DBStats
 	ComicsTotal int
+	// Pipeline holds the metrics of the last update, if any.
+	Pipeline []StageMetrics
+}
+
+// VerifyReport lists the stored comics of a source that need attention.
+// Total is the number of comics the source has.
+type VerifyReport struct {
+	Source   string
+	Total    int
+	Missing  []int
+	Empty    []int
+	Repaired int
+}
+
+type ComicKey struct {
+	Source string
+	ID     int
 }
 
 type Comics struct {
-	ID    int
-	URL   string
-	Words []string
+	ID              int      `db:"comic_id"`
+	Source          string   `db:"source"`
+	URL             string   `db:"image_url"`
+	Words           []string `db:"keywords"`
+	TitleWords      []string `db:"title_keywords"`
+	AltWords        []string `db:"alt_keywords"`
+	TranscriptWords []string `db:"transcript_keywords"`
+	Title           string   `db:"title"`
+	SafeTitle       string   `db:"safe_title"`
+	Alt             string   `db:"alt"`
+	Transcript      string   `db:"transcript"`
+	AnalyzerVersion int      `db:"analyzer_version"`
+	ContentHash     string   `db:"content_hash"`
+	// Published is nil if the source does not date its comics.
+	Published *time.Time `db:"published"`
+}
+
+func (c Comics) Key() ComicKey {
+	return ComicKey{Source: c.Source, ID: c.ID}
+}
+
+// field is the text of one searchable field with its keywords.
+type field struct {
+	text  string
+	words *[]string
+}
+
+// fields lists the searchable fields of the comics. The safe title belongs
+// to the title field.
+func (c *Comics) fields() []field {
+	return []field{
+		{strings.TrimSpace(c.Title + " " + c.SafeTitle), &c.TitleWords},
+		{c.Alt, &c.AltWords},
+		{c.Transcript, &c.TranscriptWords},
+	}
+}
+
+func (c Comics) HasText() bool {
+	return c.Title != "" || c.Transcript != "" || c.SafeTitle != "" || c.Alt != ""
+}
+
+// placeholder reports whether the comic stands in for one the source is
+// missing.
+func (c Comics) placeholder() bool {
+	return c.URL == "" && !c.HasText()
+}
+
+// Hash identifies the published content of the comic. It must stay in sync
+// with the backfill in the add_content_hash migration.
+func (c Comics) Hash() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{c.URL, c.Title, c.SafeTitle, c.Alt, c.Transcript}, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
+
+// ComicVersion is the content a comic had before it was changed at the
+// source.
+type ComicVersion struct {
+	URL         string    `db:"image_url"`
+	Title       string    `db:"title"`
+	SafeTitle   string    `db:"safe_title"`
+	Alt         string    `db:"alt"`
+	Transcript  string    `db:"transcript"`
+	ContentHash string    `db:"content_hash"`
+	ReplacedAt  time.Time `db:"replaced_at"`
+}
+
+// Tag is a theme attached to a comic by hand. Words are the tag normalized,
+// searched as the tag field of the comic.
+type Tag struct {
+	Name    string
+	Words   []string
+	AddedAt time.Time
+}
+
+// TagCount is a tag with the number of comics it is attached to.
+type TagCount struct {
+	Tag    string `db:"tag"`
+	Comics int    `db:"comics"`
 }
 
 type JsonXKCDInfo struct {
@@ -31,13 +131,33 @@ type JsonXKCDInfo struct {
 	Alt        string `json:"alt"`
 	Transcript string `json:"transcript"`
 	SafeTitle  string `json:"safe_title"`
+	Year       string `json:"year"`
+	Month      string `json:"month"`
+	Day        string `json:"day"`
+}
+
+// Published parses the date xkcd gives as separate strings, nil if it is
+// missing or invalid.
+func (j JsonXKCDInfo) Published() *time.Time {
+	year, errYear := strconv.Atoi(j.Year)
+	month, errMonth := strconv.Atoi(j.Month)
+	day, errDay := strconv.Atoi(j.Day)
+	if errYear != nil || errMonth != nil || errDay != nil || month < 1 || month > 12 || day < 1 || day > 31 {
+		return nil
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day {
+		return nil
+	}
+	return &date
 }
 
-type XKCDInfo struct {
+type ComicInfo struct {
 	ID         int
 	URL        string
 	Title      string
 	Alt        string
 	Transcript string
 	SafeTitle  string
+	Published  *time.Time
 }
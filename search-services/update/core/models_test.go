@@ -0,0 +1,45 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonXKCDInfo_Published(t *testing.T) {
+	date := time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		info JsonXKCDInfo
+		want *time.Time
+	}{
+		{
+			name: "valid date",
+			info: JsonXKCDInfo{Year: "2006", Month: "1", Day: "1"},
+			want: &date,
+		},
+		{
+			name: "missing date",
+			info: JsonXKCDInfo{},
+			want: nil,
+		},
+		{
+			name: "no such day",
+			info: JsonXKCDInfo{Year: "2006", Month: "2", Day: "30"},
+			want: nil,
+		},
+		{
+			name: "not a number",
+			info: JsonXKCDInfo{Year: "2006", Month: "Jan", Day: "1"},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.info.Published())
+		})
+	}
+}
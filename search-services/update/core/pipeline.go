@@ -0,0 +1,235 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Pipeline configures the stages of Update: fetch from the sources,
+// normalize, persist. Every stage runs its own pool of workers, stages are
+// connected by channels holding up to Buffer comics. Persist workers write
+// BatchSize comics at a time.
+type Pipeline struct {
+	Fetchers    int
+	Normalizers int
+	Persisters  int
+	BatchSize   int
+	Buffer      int
+}
+
+func (p Pipeline) validate() error {
+	if p.Fetchers < 1 || p.Normalizers < 1 || p.Persisters < 1 {
+		return fmt.Errorf("wrong pipeline concurrency specified: %d/%d/%d", p.Fetchers, p.Normalizers, p.Persisters)
+	}
+	if p.BatchSize < 1 {
+		return fmt.Errorf("wrong batch size specified: %d", p.BatchSize)
+	}
+	if p.Buffer < 0 {
+		return fmt.Errorf("wrong buffer size specified: %d", p.Buffer)
+	}
+	return nil
+}
+
+const (
+	StageFetch     = "fetch"
+	StageNormalize = "normalize"
+	StagePersist   = "persist"
+)
+
+// StageMetrics describes one stage of the last update. Duration runs from
+// the start of the update to the moment the last worker of the stage quit.
+type StageMetrics struct {
+	Stage    string
+	Items    int
+	Errors   int
+	Duration time.Duration
+}
+
+// Rate is the number of comics the stage passed on per second.
+func (m StageMetrics) Rate() float64 {
+	if m.Duration <= 0 {
+		return 0
+	}
+	return float64(m.Items) / m.Duration.Seconds()
+}
+
+type stage struct {
+	name   string
+	items  atomic.Int64
+	errors atomic.Int64
+	end    time.Time
+}
+
+func (st *stage) metrics(start time.Time) StageMetrics {
+	return StageMetrics{
+		Stage:    st.name,
+		Items:    int(st.items.Load()),
+		Errors:   int(st.errors.Load()),
+		Duration: st.end.Sub(start),
+	}
+}
+
+// run starts n workers and returns a channel closed after all of them
+// returned and done was called.
+func (st *stage) run(n int, work func(), done func()) <-chan struct{} {
+	finished := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for range n {
+		go func() {
+			defer wg.Done()
+			work()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		st.end = time.Now()
+		done()
+		close(finished)
+	}()
+	return finished
+}
+
+type job struct {
+	source Source
+	id     int
+}
+
+// runPipeline fetches, normalizes and stores every comic of the sources that
+// is not stored yet. Failed comics are counted and skipped.
+func (s *Service) runPipeline(ctx context.Context, version int) []StageMetrics {
+	p := s.pipeline
+	start := time.Now()
+
+	jobs := make(chan job, p.Buffer)
+	fetched := make(chan Comics, p.Buffer)
+	normalized := make(chan Comics, p.Buffer)
+
+	go func() {
+		defer close(jobs)
+		for _, source := range s.sources {
+			for _, id := range s.missing(ctx, source) {
+				select {
+				case jobs <- job{source: source, id: id}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	fetch := &stage{name: StageFetch}
+	fetch.run(p.Fetchers, func() {
+		for j := range jobs {
+			comics, err := s.get(ctx, j.source, j.id)
+			if err != nil {
+				fetch.errors.Add(1)
+				continue
+			}
+			select {
+			case fetched <- comics:
+				fetch.items.Add(1)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}, func() { close(fetched) })
+
+	normalize := &stage{name: StageNormalize}
+	normalize.run(p.Normalizers, func() {
+		for comics := range fetched {
+			// placeholders of missing comics have nothing to normalize
+			if !comics.placeholder() {
+				if err := s.normalize(ctx, &comics); err != nil {
+					s.log.Error("failed to normalize words", "source", comics.Source, "comic_id", comics.ID, "error", err)
+					normalize.errors.Add(1)
+					continue
+				}
+			}
+			comics.AnalyzerVersion = version
+			select {
+			case normalized <- comics:
+				normalize.items.Add(1)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}, func() { close(normalized) })
+
+	persist := &stage{name: StagePersist}
+	save := func(batch []Comics) {
+		if err := s.db.Add(ctx, batch); err != nil {
+			s.log.Error("failed to save comics", "comics", len(batch), "error", err)
+			persist.errors.Add(int64(len(batch)))
+			return
+		}
+		persist.items.Add(int64(len(batch)))
+	}
+	<-persist.run(p.Persisters, func() {
+		batch := make([]Comics, 0, p.BatchSize)
+		for comics := range normalized {
+			batch = append(batch, comics)
+			if len(batch) == p.BatchSize {
+				save(batch)
+				batch = batch[:0]
+			}
+		}
+		if len(batch) > 0 {
+			save(batch)
+		}
+	}, func() {})
+
+	// the fetch and normalize stages ended before their output was closed
+	return []StageMetrics{fetch.metrics(start), normalize.metrics(start), persist.metrics(start)}
+}
+
+// missing lists IDs of the source that are not stored yet.
+func (s *Service) missing(ctx context.Context, source Source) []int {
+	name := source.Name()
+	ids, err := source.List(ctx)
+	if err != nil {
+		s.log.Error("failed to list comics", "source", name, "error", err)
+		return nil
+	}
+
+	stored, err := s.db.IDs(ctx, name)
+	if err != nil {
+		s.log.Error("failed to get ids from db", "source", name, "error", err)
+	}
+
+	exists, ok := s.idsExists[name]
+	if !ok {
+		exists = make(map[int]struct{})
+		s.idsExists[name] = exists
+	}
+	for _, id := range stored {
+		exists[id] = struct{}{}
+	}
+
+	var out []int
+	for _, id := range ids {
+		if _, ok := exists[id]; !ok {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// get fetches a comic without normalizing it. A comic the source reports as
+// missing becomes an empty placeholder, so it is stored and not requested
+// again.
+func (s *Service) get(ctx context.Context, source Source, id int) (Comics, error) {
+	info, err := source.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, Err404Comics) {
+			return newComics(source.Name(), ComicInfo{ID: id}), nil
+		}
+		s.log.Error("failed to get comics", "source", source.Name(), "comic_id", id, "error", err)
+		return Comics{}, err
+	}
+	return newComics(source.Name(), info), nil
+}
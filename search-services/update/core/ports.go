@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"time"
 )
 
 type Updater interface {
@@ -9,20 +10,51 @@ type Updater interface {
 	Stats(context.Context) (ServiceStats, error)
 	Status(context.Context) ServiceStatus
 	Drop(context.Context) error
+	Reindex(context.Context) error
+	Export(ctx context.Context, send func(Comics) error) error
+	Import(ctx context.Context, recv func() (Comics, error)) (int, error)
+	Verify(ctx context.Context, repair bool) ([]VerifyReport, error)
+	Refresh(context.Context) (int, error)
+	History(context.Context, ComicKey) ([]ComicVersion, error)
+	Watch(context.Context) <-chan Change
+	Tags(context.Context, ComicKey) ([]Tag, error)
+	AddTag(ctx context.Context, key ComicKey, tag string) (Tag, error)
+	RemoveTag(ctx context.Context, key ComicKey, tag string) error
+	TagCounts(context.Context) ([]TagCount, error)
 }
 
 type DB interface {
-	Add(context.Context, Comics) error
+	Add(context.Context, []Comics) error
 	Stats(context.Context) (DBStats, error)
 	Drop(context.Context) error
-	IDs(context.Context) ([]int, error)
+	IDs(ctx context.Context, source string) ([]int, error)
+	Stale(ctx context.Context, version int, after ComicKey, limit int) ([]Comics, error)
+	Replace(context.Context, []Comics) error
+	List(ctx context.Context, after ComicKey, limit int) ([]Comics, error)
+	Upsert(context.Context, []Comics) error
+	Empty(ctx context.Context, source string) ([]int, error)
+	Revise(context.Context, []Comics) error
+	History(context.Context, ComicKey) ([]ComicVersion, error)
+	// Tags, AddTag and RemoveTag return ErrNotFound if there is no such
+	// comic or, to remove, no such tag of it. AddTag returns when the comic
+	// was first tagged with the tag.
+	Tags(context.Context, ComicKey) ([]Tag, error)
+	AddTag(ctx context.Context, key ComicKey, tag Tag) (time.Time, error)
+	RemoveTag(ctx context.Context, key ComicKey, tag string) error
+	TagCounts(context.Context) ([]TagCount, error)
+	// AllTags returns the tags of every comic to renormalize them.
+	AllTags(context.Context) (map[ComicKey][]Tag, error)
 }
 
-type XKCD interface {
-	Get(context.Context, int) (XKCDInfo, error)
-	LastID(context.Context) (int, error)
+// Source is a webcomic the service can crawl. Comic IDs are unique only
+// within the source named by Name.
+type Source interface {
+	Name() string
+	List(context.Context) ([]int, error)
+	Get(context.Context, int) (ComicInfo, error)
 }
 
 type Words interface {
 	Norm(ctx context.Context, phrase string) ([]string, error)
+	Version(ctx context.Context) (int, error)
 }
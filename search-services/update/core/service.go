@@ -4,33 +4,52 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"sync"
 )
 
+const batchSize = 100
+
 type Service struct {
-	log         *slog.Logger
-	db          DB
-	xkcd        XKCD
-	words       Words
-	concurrency int
-	idsExists   map[int]struct{}
-	mu          sync.Mutex
+	log       *slog.Logger
+	db        DB
+	sources   []Source
+	words     Words
+	pipeline  Pipeline
+	idsExists map[string]map[int]struct{}
+	mu        sync.Mutex
+
+	metricsMu sync.Mutex
+	metrics   []StageMetrics
+
+	watchMu  sync.Mutex
+	watchers map[chan Change]struct{}
 }
 
 func NewService(
-	log *slog.Logger, db DB, xkcd XKCD, words Words, concurrency int,
+	log *slog.Logger, db DB, sources []Source, words Words, pipeline Pipeline,
 ) (*Service, error) {
-	if concurrency < 1 {
-		return nil, fmt.Errorf("wrong concurrency specified: %d", concurrency)
+	if err := pipeline.validate(); err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no comic sources specified")
+	}
+	names := make(map[string]struct{}, len(sources))
+	for _, source := range sources {
+		if _, ok := names[source.Name()]; ok {
+			return nil, fmt.Errorf("duplicate comic source: %q", source.Name())
+		}
+		names[source.Name()] = struct{}{}
 	}
 	return &Service{
-		log:         log,
-		db:          db,
-		xkcd:        xkcd,
-		words:       words,
-		concurrency: concurrency,
-		idsExists:   make(map[int]struct{}),
+		log:       log,
+		db:        db,
+		sources:   sources,
+		words:     words,
+		pipeline:  pipeline,
+		idsExists: make(map[string]map[int]struct{}),
 	}, nil
 }
 
@@ -40,70 +59,41 @@ func (s *Service) Update(ctx context.Context) (err error) {
 	}
 
 	defer s.mu.Unlock()
-	var wg sync.WaitGroup
-	id, err := s.xkcd.LastID(ctx)
+	version, err := s.words.Version(ctx)
 	if err != nil {
-		s.log.Error("failed to get LastID", "error", err)
+		s.log.Error("failed to get analyzer version", "error", err)
+		return err
 	}
 
-	ids, err := s.db.IDs(ctx)
-	if err != nil {
-		s.log.Error("failed to get ids from db", "error", err)
+	metrics := s.runPipeline(ctx, version)
+	for _, m := range metrics {
+		s.log.Info("update stage finished",
+			"stage", m.Stage, "items", m.Items, "errors", m.Errors, "duration", m.Duration, "per_second", m.Rate())
 	}
 
-	for i := range ids {
-		if _, ok := s.idsExists[i]; !ok {
-			s.idsExists[i] = struct{}{}
-		}
-	}
+	s.metricsMu.Lock()
+	s.metrics = metrics
+	s.metricsMu.Unlock()
 
-	sema := make(chan struct{}, s.concurrency)
-	for i := 1; i <= id; i++ {
-		if _, ok := s.idsExists[i]; ok {
-			continue
-		}
-		wg.Add(1)
-		sema <- struct{}{}
-		go func() {
-			defer func() {
-				<-sema
-			}()
-			defer wg.Done()
-
-			info, err := s.xkcd.Get(ctx, i)
-			if err != nil {
-				if errors.Is(err, Err404Comics) {
-					err = s.db.Add(ctx, Comics{ID: 404})
-					if err != nil {
-						s.log.Error("failed to save comics", "error", err)
-						return
-					}
-					return
-				}
-				s.log.Error("failed to get comics", "error", err)
-				return
-			}
+	s.notify(Change{Reason: ChangeUpdate})
+	return nil
+}
 
-			phrase := info.Title + " " + info.Transcript + " " + info.SafeTitle + " " + info.Alt
-			words, err := s.words.Norm(ctx, phrase)
-			if err != nil {
-				s.log.Error("failed to normalize words for ", "error", err)
-				return
-			}
+// fetch gets a comic from the source and normalizes its text.
+func (s *Service) fetch(ctx context.Context, source Source, id, version int) (Comics, error) {
+	comics, err := s.get(ctx, source, id)
+	if err != nil {
+		return Comics{}, err
+	}
 
-			comics := Comics{
-				ID:    info.ID,
-				URL:   info.URL,
-				Words: words,
-			}
-			err = s.db.Add(ctx, comics)
-			if err != nil {
-				s.log.Error("failed to save comics", "error", err)
-			}
-		}()
+	if !comics.placeholder() {
+		if err := s.normalize(ctx, &comics); err != nil {
+			s.log.Error("failed to normalize words", "source", source.Name(), "comic_id", id, "error", err)
+			return Comics{}, err
+		}
 	}
-	wg.Wait()
-	return nil
+	comics.AnalyzerVersion = version
+	return comics, nil
 }
 
 func (s *Service) Stats(ctx context.Context) (ServiceStats, error) {
@@ -113,15 +103,24 @@ func (s *Service) Stats(ctx context.Context) (ServiceStats, error) {
 		return ServiceStats{}, err
 	}
 
-	comicsTotal, err := s.xkcd.LastID(ctx)
-	if err != nil {
-		s.log.Error("failed to get lastID", "error", err)
-		return ServiceStats{}, err
+	comicsTotal := 0
+	for _, source := range s.sources {
+		ids, err := source.List(ctx)
+		if err != nil {
+			s.log.Error("failed to list comics", "source", source.Name(), "error", err)
+			return ServiceStats{}, err
+		}
+		comicsTotal += len(ids)
 	}
 
+	s.metricsMu.Lock()
+	pipeline := s.metrics
+	s.metricsMu.Unlock()
+
 	return ServiceStats{
 		DBStats:     stats,
 		ComicsTotal: comicsTotal,
+		Pipeline:    pipeline,
 	}, nil
 }
 
@@ -144,6 +143,454 @@ func (s *Service) Drop(ctx context.Context) error {
 		s.log.Error("failed to drop", "error", err)
 	}
 
-	s.idsExists = make(map[int]struct{})
+	s.idsExists = make(map[string]map[int]struct{})
+	s.notify(Change{Reason: ChangeDrop})
 	return nil
 }
+
+// Reindex renormalizes the stored text of every comic stamped with an
+// analyzer version other than the one the words service reports now.
+// Comics are rewritten in batches, so search keeps working meanwhile.
+func (s *Service) Reindex(ctx context.Context) error {
+	if !s.mu.TryLock() {
+		return ErrAlreadyExists
+	}
+	defer s.mu.Unlock()
+
+	version, err := s.words.Version(ctx)
+	if err != nil {
+		s.log.Error("failed to get analyzer version", "error", err)
+		return err
+	}
+
+	s.log.Info("reindexing comics", "analyzer_version", version)
+	reindexed, after := 0, ComicKey{}
+	defer func() {
+		if reindexed > 0 {
+			s.notify(Change{Reason: ChangeReindex, Comics: reindexed})
+		}
+	}()
+	for {
+		batch, err := s.db.Stale(ctx, version, after, batchSize)
+		if err != nil {
+			s.log.Error("failed to get stale comics", "error", err)
+			return err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		after = batch[len(batch)-1].Key()
+
+		done := make([]Comics, 0, len(batch))
+		for _, comics := range batch {
+			comics, err := s.renormalize(ctx, comics)
+			if err != nil {
+				s.log.Error("failed to reindex comics", "source", comics.Source, "comic_id", comics.ID, "error", err)
+				continue
+			}
+			comics.AnalyzerVersion = version
+			comics.ContentHash = comics.Hash()
+			done = append(done, comics)
+		}
+
+		if len(done) == 0 {
+			continue
+		}
+		if err := s.db.Replace(ctx, done); err != nil {
+			s.log.Error("failed to save reindexed comics", "error", err)
+			return err
+		}
+		reindexed += len(done)
+	}
+
+	if reindexed > 0 {
+		if err := s.retag(ctx); err != nil {
+			s.log.Error("failed to renormalize tags", "error", err)
+			return err
+		}
+	}
+
+	s.log.Info("reindex finished", "analyzer_version", version, "comics", reindexed)
+	return nil
+}
+
+// renormalize recomputes keywords of the comics. Rows stored before the text
+// was persisted have nothing to normalize, so they are fetched again.
+func (s *Service) renormalize(ctx context.Context, comics Comics) (Comics, error) {
+	if !comics.HasText() {
+		source, err := s.source(comics.Source)
+		if err != nil {
+			return comics, err
+		}
+		info, err := source.Get(ctx, comics.ID)
+		if err != nil {
+			if errors.Is(err, Err404Comics) {
+				return comics, nil
+			}
+			return comics, err
+		}
+		comics = newComics(comics.Source, info)
+	}
+
+	if err := s.normalize(ctx, &comics); err != nil {
+		return comics, err
+	}
+	return comics, nil
+}
+
+// normalize computes keywords of every field of the comics separately;
+// Words holds all of them.
+func (s *Service) normalize(ctx context.Context, comics *Comics) error {
+	all := []string{}
+	seen := make(map[string]struct{})
+	for _, field := range comics.fields() {
+		*field.words = []string{}
+		if field.text == "" {
+			continue
+		}
+		words, err := s.words.Norm(ctx, field.text)
+		if err != nil {
+			return err
+		}
+		*field.words = words
+		for _, word := range words {
+			if _, ok := seen[word]; !ok {
+				seen[word] = struct{}{}
+				all = append(all, word)
+			}
+		}
+	}
+	comics.Words = all
+	return nil
+}
+
+// Export passes every stored comic to send, ordered by source and ID.
+func (s *Service) Export(ctx context.Context, send func(Comics) error) error {
+	after := ComicKey{}
+	for {
+		batch, err := s.db.List(ctx, after, batchSize)
+		if err != nil {
+			s.log.Error("failed to list comics", "error", err)
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		for _, comics := range batch {
+			if err := send(comics); err != nil {
+				return err
+			}
+		}
+		after = batch[len(batch)-1].Key()
+	}
+}
+
+// Import stores comics returned by recv until it reports io.EOF, replacing
+// the ones already stored under the same key. Batches saved before an error
+// stay in the database.
+func (s *Service) Import(ctx context.Context, recv func() (Comics, error)) (int, error) {
+	if !s.mu.TryLock() {
+		return 0, ErrAlreadyExists
+	}
+	defer s.mu.Unlock()
+
+	imported := 0
+	defer func() {
+		if imported > 0 {
+			s.notify(Change{Reason: ChangeImport, Comics: imported})
+		}
+	}()
+	batch := make([]Comics, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.db.Upsert(ctx, batch); err != nil {
+			s.log.Error("failed to save imported comics", "error", err)
+			return err
+		}
+		imported += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		comics, err := recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return imported, err
+		}
+		if comics.Source == "" || comics.ID < 1 {
+			return imported, fmt.Errorf("%w: comic %q/%d", ErrBadArguments, comics.Source, comics.ID)
+		}
+		comics.ContentHash = comics.Hash()
+		batch = append(batch, comics)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return imported, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return imported, err
+	}
+	s.log.Info("import finished", "comics", imported)
+	return imported, nil
+}
+
+// Verify compares stored comics of every source with the comics the source
+// lists. With repair set, missing and empty rows are fetched again and
+// rewritten; other rows are not touched.
+func (s *Service) Verify(ctx context.Context, repair bool) ([]VerifyReport, error) {
+	var version int
+	if repair {
+		if !s.mu.TryLock() {
+			return nil, ErrAlreadyExists
+		}
+		defer s.mu.Unlock()
+
+		var err error
+		version, err = s.words.Version(ctx)
+		if err != nil {
+			s.log.Error("failed to get analyzer version", "error", err)
+			return nil, err
+		}
+	}
+
+	reports := make([]VerifyReport, 0, len(s.sources))
+	for _, source := range s.sources {
+		report, err := s.verifySource(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		if repair {
+			report.Repaired, err = s.repair(ctx, source, report, version)
+			if err != nil {
+				return nil, err
+			}
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func (s *Service) verifySource(ctx context.Context, source Source) (VerifyReport, error) {
+	name := source.Name()
+	report := VerifyReport{Source: name, Missing: []int{}}
+
+	ids, err := source.List(ctx)
+	if err != nil {
+		s.log.Error("failed to list comics", "source", name, "error", err)
+		return VerifyReport{}, err
+	}
+	report.Total = len(ids)
+
+	stored, err := s.db.IDs(ctx, name)
+	if err != nil {
+		s.log.Error("failed to get ids from db", "source", name, "error", err)
+		return VerifyReport{}, err
+	}
+	exists := make(map[int]struct{}, len(stored))
+	for _, id := range stored {
+		exists[id] = struct{}{}
+	}
+	for _, id := range ids {
+		if _, ok := exists[id]; !ok {
+			report.Missing = append(report.Missing, id)
+		}
+	}
+
+	if report.Empty, err = s.db.Empty(ctx, name); err != nil {
+		s.log.Error("failed to get empty comics", "source", name, "error", err)
+		return VerifyReport{}, err
+	}
+	return report, nil
+}
+
+// repair refetches the bad entries of the report and returns how many of
+// them now have an image. Entries that cannot be fetched stay as they are,
+// the ones the source reports as missing are saved as placeholders.
+func (s *Service) repair(ctx context.Context, source Source, report VerifyReport, version int) (int, error) {
+	bad := make(map[int]struct{})
+	for _, ids := range [][]int{report.Missing, report.Empty} {
+		for _, id := range ids {
+			bad[id] = struct{}{}
+		}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		fixed    []Comics
+		repaired int
+	)
+	sema := make(chan struct{}, s.pipeline.Fetchers)
+	for id := range bad {
+		wg.Add(1)
+		sema <- struct{}{}
+		go func() {
+			defer func() {
+				<-sema
+			}()
+			defer wg.Done()
+
+			comics, err := s.fetch(ctx, source, id, version)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			fixed = append(fixed, comics)
+			if comics.URL != "" {
+				repaired++
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for start := 0; start < len(fixed); start += batchSize {
+		end := min(start+batchSize, len(fixed))
+		if err := s.db.Upsert(ctx, fixed[start:end]); err != nil {
+			s.log.Error("failed to save repaired comics", "source", source.Name(), "error", err)
+			return 0, err
+		}
+	}
+	if len(bad) > 0 {
+		s.notify(Change{Reason: ChangeRepair, Comics: len(fixed)})
+	}
+	s.log.Info("repair finished", "source", source.Name(), "bad", len(bad), "repaired", repaired)
+	return repaired, nil
+}
+
+// Refresh fetches every stored comic again and saves the ones whose content
+// changed at the source, keeping the previous content in their history.
+// It returns the number of changed comics.
+func (s *Service) Refresh(ctx context.Context) (int, error) {
+	if !s.mu.TryLock() {
+		return 0, ErrAlreadyExists
+	}
+	defer s.mu.Unlock()
+
+	version, err := s.words.Version(ctx)
+	if err != nil {
+		s.log.Error("failed to get analyzer version", "error", err)
+		return 0, err
+	}
+
+	changed, after := 0, ComicKey{}
+	defer func() {
+		if changed > 0 {
+			s.notify(Change{Reason: ChangeRefresh, Comics: changed})
+		}
+	}()
+	for {
+		batch, err := s.db.List(ctx, after, batchSize)
+		if err != nil {
+			s.log.Error("failed to list comics", "error", err)
+			return changed, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		after = batch[len(batch)-1].Key()
+
+		revised := s.changed(ctx, batch, version)
+		if len(revised) == 0 {
+			continue
+		}
+		if err := s.db.Revise(ctx, revised); err != nil {
+			s.log.Error("failed to save refreshed comics", "error", err)
+			return changed, err
+		}
+		changed += len(revised)
+	}
+
+	s.log.Info("refresh finished", "changed", changed)
+	return changed, nil
+}
+
+// changed refetches the stored comics and returns the ones with new content
+// or a publication date they were stored without, normalized with the given
+// analyzer version. Comics that cannot be fetched
+// are left as they are.
+func (s *Service) changed(ctx context.Context, stored []Comics, version int) []Comics {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		revised []Comics
+	)
+	sema := make(chan struct{}, s.pipeline.Fetchers)
+	for _, old := range stored {
+		source, err := s.source(old.Source)
+		if err != nil {
+			continue
+		}
+		wg.Add(1)
+		sema <- struct{}{}
+		go func() {
+			defer func() {
+				<-sema
+			}()
+			defer wg.Done()
+
+			info, err := source.Get(ctx, old.ID)
+			if err != nil {
+				if !errors.Is(err, Err404Comics) {
+					s.log.Error("failed to get comics", "source", old.Source, "comic_id", old.ID, "error", err)
+				}
+				return
+			}
+			comics := newComics(old.Source, info)
+			if comics.ContentHash == old.ContentHash && (old.Published != nil || comics.Published == nil) {
+				return
+			}
+			if err := s.normalize(ctx, &comics); err != nil {
+				s.log.Error("failed to normalize words", "source", old.Source, "comic_id", old.ID, "error", err)
+				return
+			}
+			comics.AnalyzerVersion = version
+
+			mu.Lock()
+			revised = append(revised, comics)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return revised
+}
+
+func (s *Service) History(ctx context.Context, key ComicKey) ([]ComicVersion, error) {
+	versions, err := s.db.History(ctx, key)
+	if err != nil {
+		s.log.Error("failed to get comics history", "source", key.Source, "comic_id", key.ID, "error", err)
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (s *Service) source(name string) (Source, error) {
+	for _, source := range s.sources {
+		if source.Name() == name {
+			return source, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: comic source %q", ErrNotFound, name)
+}
+
+func newComics(source string, info ComicInfo) Comics {
+	comics := Comics{
+		ID:         info.ID,
+		Source:     source,
+		URL:        info.URL,
+		Title:      info.Title,
+		SafeTitle:  info.SafeTitle,
+		Alt:        info.Alt,
+		Transcript: info.Transcript,
+		Published:  info.Published,
+	}
+	comics.ContentHash = comics.Hash()
+	return comics
+}
@@ -3,9 +3,11 @@ package core
 import (
 	"context"
 	"errors"
+	"io"
 	"log/slog"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -15,7 +17,7 @@ type MockDB struct {
 	mock.Mock
 }
 
-func (m *MockDB) Add(ctx context.Context, comics Comics) error {
+func (m *MockDB) Add(ctx context.Context, comics []Comics) error {
 	args := m.Called(ctx, comics)
 	return args.Error(0)
 }
@@ -25,8 +27,8 @@ func (m *MockDB) Stats(ctx context.Context) (DBStats, error) {
 	return args.Get(0).(DBStats), args.Error(1)
 }
 
-func (m *MockDB) IDs(ctx context.Context) ([]int, error) {
-	args := m.Called(ctx)
+func (m *MockDB) IDs(ctx context.Context, source string) ([]int, error) {
+	args := m.Called(ctx, source)
 	return args.Get(0).([]int), args.Error(1)
 }
 
@@ -35,18 +37,95 @@ func (m *MockDB) Drop(ctx context.Context) error {
 	return args.Error(0)
 }
 
-type MockXKCD struct {
+func (m *MockDB) Stale(ctx context.Context, version int, after ComicKey, limit int) ([]Comics, error) {
+	args := m.Called(ctx, version, after, limit)
+	return args.Get(0).([]Comics), args.Error(1)
+}
+
+func (m *MockDB) Replace(ctx context.Context, comics []Comics) error {
+	args := m.Called(ctx, comics)
+	return args.Error(0)
+}
+
+func (m *MockDB) List(ctx context.Context, after ComicKey, limit int) ([]Comics, error) {
+	args := m.Called(ctx, after, limit)
+	return args.Get(0).([]Comics), args.Error(1)
+}
+
+func (m *MockDB) Upsert(ctx context.Context, comics []Comics) error {
+	args := m.Called(ctx, comics)
+	return args.Error(0)
+}
+
+func (m *MockDB) Empty(ctx context.Context, source string) ([]int, error) {
+	args := m.Called(ctx, source)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockDB) Revise(ctx context.Context, comics []Comics) error {
+	args := m.Called(ctx, comics)
+	return args.Error(0)
+}
+
+func (m *MockDB) History(ctx context.Context, key ComicKey) ([]ComicVersion, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).([]ComicVersion), args.Error(1)
+}
+
+func (m *MockDB) Tags(ctx context.Context, key ComicKey) ([]Tag, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).([]Tag), args.Error(1)
+}
+
+func (m *MockDB) AddTag(ctx context.Context, key ComicKey, tag Tag) (time.Time, error) {
+	args := m.Called(ctx, key, tag)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockDB) RemoveTag(ctx context.Context, key ComicKey, tag string) error {
+	return m.Called(ctx, key, tag).Error(0)
+}
+
+func (m *MockDB) TagCounts(ctx context.Context) ([]TagCount, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]TagCount), args.Error(1)
+}
+
+func (m *MockDB) AllTags(ctx context.Context) (map[ComicKey][]Tag, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(map[ComicKey][]Tag), args.Error(1)
+}
+
+type MockSource struct {
 	mock.Mock
+	name string
 }
 
-func (m *MockXKCD) Get(ctx context.Context, id int) (XKCDInfo, error) {
-	args := m.Called(ctx, id)
-	return args.Get(0).(XKCDInfo), args.Error(1)
+func (m *MockSource) Name() string {
+	return m.name
 }
 
-func (m *MockXKCD) LastID(ctx context.Context) (int, error) {
+func (m *MockSource) List(ctx context.Context) ([]int, error) {
 	args := m.Called(ctx)
-	return args.Int(0), args.Error(1)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockSource) Get(ctx context.Context, id int) (ComicInfo, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(ComicInfo), args.Error(1)
+}
+
+func hashed(c Comics) Comics {
+	c.ContentHash = c.Hash()
+	return c
+}
+
+func seq(n int) []int {
+	ids := make([]int, n)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	return ids
 }
 
 type MockWords struct {
@@ -58,21 +137,27 @@ func (m *MockWords) Norm(ctx context.Context, phrase string) ([]string, error) {
 	return args.Get(0).([]string), args.Error(1)
 }
 
+func (m *MockWords) Version(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
 func TestService_Update(t *testing.T) {
 	tests := []struct {
-		name        string
-		setupMocks  func(db *MockDB, xkcd *MockXKCD, words *MockWords)
-		wantErr     bool
-		expectLock  bool
-		concurrency int
+		name       string
+		setupMocks func(db *MockDB, xkcd *MockSource, words *MockWords)
+		wantErr    bool
+		expectLock bool
+		pipeline   Pipeline
 	}{
 		{
 			name: "successful update",
-			setupMocks: func(db *MockDB, xkcd *MockXKCD, words *MockWords) {
-				xkcd.On("LastID", mock.Anything).Return(2, nil)
-				db.On("IDs", mock.Anything).Return([]int{}, nil)
+			setupMocks: func(db *MockDB, xkcd *MockSource, words *MockWords) {
+				words.On("Version", mock.Anything).Return(1, nil)
+				xkcd.On("List", mock.Anything).Return(seq(2), nil)
+				db.On("IDs", mock.Anything, "xkcd").Return([]int{}, nil)
 
-				xkcd.On("Get", mock.Anything, 1).Return(XKCDInfo{
+				xkcd.On("Get", mock.Anything, 1).Return(ComicInfo{
 					ID:         1,
 					Title:      "Barrel - Part 1",
 					URL:        "https://imgs.xkcd.com/comics/barrel_cropped_(1).jpg",
@@ -80,7 +165,7 @@ func TestService_Update(t *testing.T) {
 					SafeTitle:  "Barrel - Part 1",
 					Alt:        "Don't we all.",
 				}, nil)
-				xkcd.On("Get", mock.Anything, 2).Return(XKCDInfo{
+				xkcd.On("Get", mock.Anything, 2).Return(ComicInfo{
 					ID:         2,
 					Title:      "Petit Trees (sketch)",
 					URL:        "https://imgs.xkcd.com/comics/tree_cropped_(1).jpg",
@@ -89,50 +174,53 @@ func TestService_Update(t *testing.T) {
 					Alt:        "'Petit' being a reference to Le Petit Prince, which I only thought about halfway through the sketch",
 				}, nil)
 
-				words.On("Norm", mock.Anything, mock.Anything).Return([]string{"word1", "word2"}, nil).Twice()
-				db.On("Add", mock.Anything, mock.Anything).Return(nil).Twice()
+				words.On("Norm", mock.Anything, mock.Anything).Return([]string{"word1", "word2"}, nil).Times(6)
+				db.On("Add", mock.Anything, mock.MatchedBy(func(batch []Comics) bool {
+					return len(batch) == 2
+				})).Return(nil).Once()
 			},
-			wantErr:     false,
-			expectLock:  true,
-			concurrency: 2,
+			wantErr:    false,
+			expectLock: true,
+			pipeline:   Pipeline{Fetchers: 2, Normalizers: 2, Persisters: 1, BatchSize: 2},
 		},
 		{
-			name:        "Already running",
-			setupMocks:  func(db *MockDB, xkcd *MockXKCD, words *MockWords) {},
-			wantErr:     true,
-			expectLock:  false,
-			concurrency: 1,
+			name:       "Already running",
+			setupMocks: func(db *MockDB, xkcd *MockSource, words *MockWords) {},
+			wantErr:    true,
+			expectLock: false,
+			pipeline:   Pipeline{Fetchers: 1, Normalizers: 1, Persisters: 1, BatchSize: 1},
 		},
 		{
 			name: "404 comic",
-			setupMocks: func(db *MockDB, xkcd *MockXKCD, words *MockWords) {
-				xkcd.On("LastID", mock.Anything).Return(1, nil)
-				db.On("IDs", mock.Anything).Return([]int{}, nil)
-				xkcd.On("Get", mock.Anything, 1).Return(XKCDInfo{}, Err404Comics)
-				db.On("Add", mock.Anything, Comics{ID: 404}).Return(nil)
+			setupMocks: func(db *MockDB, xkcd *MockSource, words *MockWords) {
+				words.On("Version", mock.Anything).Return(1, nil)
+				xkcd.On("List", mock.Anything).Return([]int{404}, nil)
+				db.On("IDs", mock.Anything, "xkcd").Return([]int{}, nil)
+				xkcd.On("Get", mock.Anything, 404).Return(ComicInfo{}, Err404Comics)
+				db.On("Add", mock.Anything, []Comics{hashed(Comics{ID: 404, Source: "xkcd", AnalyzerVersion: 1})}).Return(nil)
 			},
-			wantErr:     false,
-			expectLock:  true,
-			concurrency: 1,
+			wantErr:    false,
+			expectLock: true,
+			pipeline:   Pipeline{Fetchers: 1, Normalizers: 1, Persisters: 1, BatchSize: 10},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			db := &MockDB{}
-			xkcd := &MockXKCD{}
+			xkcd := &MockSource{name: "xkcd"}
 			words := &MockWords{}
 
 			tt.setupMocks(db, xkcd, words)
 
 			service := &Service{
-				log:         slog.Default(),
-				db:          db,
-				xkcd:        xkcd,
-				words:       words,
-				concurrency: tt.concurrency,
-				idsExists:   make(map[int]struct{}),
-				mu:          sync.Mutex{},
+				log:       slog.Default(),
+				db:        db,
+				sources:   []Source{xkcd},
+				words:     words,
+				pipeline:  tt.pipeline,
+				idsExists: make(map[string]map[int]struct{}),
+				mu:        sync.Mutex{},
 			}
 
 			if !tt.expectLock {
@@ -159,22 +247,618 @@ func TestService_Update(t *testing.T) {
 	}
 }
 
+func TestService_UpdateSources(t *testing.T) {
+	db := &MockDB{}
+	xkcd := &MockSource{name: "xkcd"}
+	local := &MockSource{name: "local"}
+	words := &MockWords{}
+
+	words.On("Version", mock.Anything).Return(1, nil)
+	words.On("Norm", mock.Anything, mock.Anything).Return([]string{"word"}, nil)
+
+	xkcd.On("List", mock.Anything).Return([]int{1, 2}, nil)
+	db.On("IDs", mock.Anything, "xkcd").Return([]int{1}, nil)
+	xkcd.On("Get", mock.Anything, 2).Return(ComicInfo{ID: 2, Title: "Two"}, nil)
+	db.On("Add", mock.Anything, []Comics{hashed(Comics{ID: 2, Source: "xkcd", Title: "Two", Words: []string{"word"},
+		TitleWords: []string{"word"}, AltWords: []string{}, TranscriptWords: []string{}, AnalyzerVersion: 1})}).Return(nil)
+
+	local.On("List", mock.Anything).Return([]int{1}, nil)
+	db.On("IDs", mock.Anything, "local").Return([]int{}, nil)
+	local.On("Get", mock.Anything, 1).Return(ComicInfo{ID: 1, Title: "One"}, nil)
+	db.On("Add", mock.Anything, []Comics{hashed(Comics{ID: 1, Source: "local", Title: "One", Words: []string{"word"},
+		TitleWords: []string{"word"}, AltWords: []string{}, TranscriptWords: []string{}, AnalyzerVersion: 1})}).Return(nil)
+
+	service, err := NewService(slog.Default(), db, []Source{xkcd, local}, words,
+		Pipeline{Fetchers: 2, Normalizers: 1, Persisters: 1, BatchSize: 1})
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.Update(context.Background()))
+
+	db.AssertExpectations(t)
+	xkcd.AssertExpectations(t)
+	local.AssertExpectations(t)
+	words.AssertExpectations(t)
+}
+
+func TestService_UpdatePipeline(t *testing.T) {
+	db := &MockDB{}
+	xkcd := &MockSource{name: "xkcd"}
+	words := &MockWords{}
+
+	words.On("Version", mock.Anything).Return(1, nil)
+	xkcd.On("List", mock.Anything).Return(seq(6), nil)
+	db.On("IDs", mock.Anything, "xkcd").Return([]int{}, nil)
+	for id := 1; id <= 5; id++ {
+		xkcd.On("Get", mock.Anything, id).Return(ComicInfo{ID: id, Title: "title"}, nil)
+	}
+	xkcd.On("Get", mock.Anything, 6).Return(ComicInfo{}, errors.New("xkcd error"))
+	words.On("Norm", mock.Anything, "title").Return([]string{"titl"}, nil)
+
+	var mu sync.Mutex
+	var sizes []int
+	db.On("Add", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		mu.Lock()
+		sizes = append(sizes, len(args.Get(1).([]Comics)))
+		mu.Unlock()
+	}).Return(nil)
+	db.On("Stats", mock.Anything).Return(DBStats{}, nil)
+
+	service, err := NewService(slog.Default(), db, []Source{xkcd}, words,
+		Pipeline{Fetchers: 3, Normalizers: 2, Persisters: 1, BatchSize: 2, Buffer: 1})
+	assert.NoError(t, err)
+	assert.NoError(t, service.Update(context.Background()))
+
+	assert.ElementsMatch(t, []int{2, 2, 1}, sizes)
+
+	stats, err := service.Stats(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, stats.Pipeline, 3)
+	for i, want := range []StageMetrics{
+		{Stage: StageFetch, Items: 5, Errors: 1},
+		{Stage: StageNormalize, Items: 5},
+		{Stage: StagePersist, Items: 5},
+	} {
+		got := stats.Pipeline[i]
+		assert.Equal(t, want.Stage, got.Stage)
+		assert.Equal(t, want.Items, got.Items)
+		assert.Equal(t, want.Errors, got.Errors)
+		assert.Positive(t, got.Duration)
+	}
+}
+
+func TestNewService(t *testing.T) {
+	tests := []struct {
+		name     string
+		sources  []Source
+		pipeline Pipeline
+		wantErr  bool
+	}{
+		{
+			name:     "success",
+			sources:  []Source{&MockSource{name: "xkcd"}, &MockSource{name: "local"}},
+			pipeline: Pipeline{Fetchers: 1, Normalizers: 1, Persisters: 1, BatchSize: 1},
+			wantErr:  false,
+		},
+		{
+			name:     "wrong concurrency",
+			sources:  []Source{&MockSource{name: "xkcd"}},
+			pipeline: Pipeline{Fetchers: 0, Normalizers: 1, Persisters: 1, BatchSize: 1},
+			wantErr:  true,
+		},
+		{
+			name:     "wrong batch size",
+			sources:  []Source{&MockSource{name: "xkcd"}},
+			pipeline: Pipeline{Fetchers: 1, Normalizers: 1, Persisters: 1, BatchSize: 0},
+			wantErr:  true,
+		},
+		{
+			name:     "no sources",
+			sources:  nil,
+			pipeline: Pipeline{Fetchers: 1, Normalizers: 1, Persisters: 1, BatchSize: 1},
+			wantErr:  true,
+		},
+		{
+			name:     "duplicate sources",
+			sources:  []Source{&MockSource{name: "xkcd"}, &MockSource{name: "xkcd"}},
+			pipeline: Pipeline{Fetchers: 1, Normalizers: 1, Persisters: 1, BatchSize: 1},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewService(slog.Default(), &MockDB{}, tt.sources, &MockWords{}, tt.pipeline)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewService error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestService_Reindex(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMocks func(db *MockDB, xkcd *MockSource, words *MockWords)
+		wantErr    bool
+	}{
+		{
+			name: "renormalize stored text",
+			setupMocks: func(db *MockDB, xkcd *MockSource, words *MockWords) {
+				words.On("Version", mock.Anything).Return(2, nil)
+				db.On("Stale", mock.Anything, 2, ComicKey{}, batchSize).Return([]Comics{
+					{ID: 1, Source: "xkcd", URL: "url1", Title: "Barrel", AnalyzerVersion: 1},
+				}, nil)
+				db.On("Stale", mock.Anything, 2, ComicKey{Source: "xkcd", ID: 1}, batchSize).Return([]Comics{}, nil)
+				words.On("Norm", mock.Anything, "Barrel").Return([]string{"barrel"}, nil)
+				db.On("Replace", mock.Anything, []Comics{
+					hashed(Comics{ID: 1, Source: "xkcd", URL: "url1", Title: "Barrel", Words: []string{"barrel"},
+						TitleWords: []string{"barrel"}, AltWords: []string{}, TranscriptWords: []string{}, AnalyzerVersion: 2}),
+				}).Return(nil)
+				key := ComicKey{Source: "xkcd", ID: 1}
+				db.On("AllTags", mock.Anything).Return(map[ComicKey][]Tag{key: {{Name: "barrels", Words: []string{"barrels"}}}}, nil)
+				words.On("Norm", mock.Anything, "barrels").Return([]string{"barrel"}, nil)
+				db.On("AddTag", mock.Anything, key, Tag{Name: "barrels", Words: []string{"barrel"}}).Return(time.Time{}, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "stored date is kept",
+			setupMocks: func(db *MockDB, xkcd *MockSource, words *MockWords) {
+				published := time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC)
+				words.On("Version", mock.Anything).Return(2, nil)
+				db.On("Stale", mock.Anything, 2, ComicKey{}, batchSize).Return([]Comics{
+					{ID: 1, Source: "xkcd", URL: "url1", Title: "Barrel", AnalyzerVersion: 1, Published: &published},
+				}, nil)
+				db.On("Stale", mock.Anything, 2, ComicKey{Source: "xkcd", ID: 1}, batchSize).Return([]Comics{}, nil)
+				words.On("Norm", mock.Anything, "Barrel").Return([]string{"barrel"}, nil)
+				db.On("Replace", mock.Anything, []Comics{
+					hashed(Comics{ID: 1, Source: "xkcd", URL: "url1", Title: "Barrel", Words: []string{"barrel"},
+						TitleWords: []string{"barrel"}, AltWords: []string{}, TranscriptWords: []string{}, AnalyzerVersion: 2,
+						Published: &published}),
+				}).Return(nil)
+				db.On("AllTags", mock.Anything).Return(map[ComicKey][]Tag{}, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "refetch comics without stored text",
+			setupMocks: func(db *MockDB, xkcd *MockSource, words *MockWords) {
+				words.On("Version", mock.Anything).Return(1, nil)
+				db.On("Stale", mock.Anything, 1, ComicKey{}, batchSize).Return([]Comics{
+					{ID: 1, Source: "xkcd", URL: "url1"},
+					{ID: 404, Source: "xkcd"},
+				}, nil)
+				db.On("Stale", mock.Anything, 1, ComicKey{Source: "xkcd", ID: 404}, batchSize).Return([]Comics{}, nil)
+				xkcd.On("Get", mock.Anything, 1).Return(ComicInfo{ID: 1, URL: "url1", Alt: "Don't we all."}, nil)
+				xkcd.On("Get", mock.Anything, 404).Return(ComicInfo{}, Err404Comics)
+				words.On("Norm", mock.Anything, "Don't we all.").Return([]string{"us"}, nil)
+				db.On("Replace", mock.Anything, []Comics{
+					hashed(Comics{ID: 1, Source: "xkcd", URL: "url1", Alt: "Don't we all.", Words: []string{"us"},
+						TitleWords: []string{}, AltWords: []string{"us"}, TranscriptWords: []string{}, AnalyzerVersion: 1}),
+					hashed(Comics{ID: 404, Source: "xkcd", AnalyzerVersion: 1}),
+				}).Return(nil)
+				db.On("AllTags", mock.Anything).Return(map[ComicKey][]Tag{}, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "failed comics are left stale",
+			setupMocks: func(db *MockDB, xkcd *MockSource, words *MockWords) {
+				words.On("Version", mock.Anything).Return(1, nil)
+				db.On("Stale", mock.Anything, 1, ComicKey{}, batchSize).Return([]Comics{
+					{ID: 1, Source: "xkcd", Title: "Barrel"},
+				}, nil)
+				db.On("Stale", mock.Anything, 1, ComicKey{Source: "xkcd", ID: 1}, batchSize).Return([]Comics{}, nil)
+				words.On("Norm", mock.Anything, "Barrel").Return([]string{}, errors.New("words error"))
+			},
+			wantErr: false,
+		},
+		{
+			name: "words version error",
+			setupMocks: func(db *MockDB, xkcd *MockSource, words *MockWords) {
+				words.On("Version", mock.Anything).Return(0, errors.New("words error"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "DB error",
+			setupMocks: func(db *MockDB, xkcd *MockSource, words *MockWords) {
+				words.On("Version", mock.Anything).Return(1, nil)
+				db.On("Stale", mock.Anything, 1, ComicKey{}, batchSize).Return([]Comics{}, errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &MockDB{}
+			xkcd := &MockSource{name: "xkcd"}
+			words := &MockWords{}
+			tt.setupMocks(db, xkcd, words)
+
+			service := &Service{
+				log:     slog.Default(),
+				db:      db,
+				sources: []Source{xkcd},
+				words:   words,
+			}
+
+			err := service.Reindex(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			db.AssertExpectations(t)
+			xkcd.AssertExpectations(t)
+			words.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Export(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMocks func(db *MockDB)
+		sendErr    error
+		want       []Comics
+		wantErr    bool
+	}{
+		{
+			name: "all pages",
+			setupMocks: func(db *MockDB) {
+				db.On("List", mock.Anything, ComicKey{}, batchSize).Return([]Comics{
+					{ID: 1, Source: "smbc"},
+					{ID: 2, Source: "xkcd", Words: []string{"tree"}},
+				}, nil)
+				db.On("List", mock.Anything, ComicKey{Source: "xkcd", ID: 2}, batchSize).Return([]Comics{}, nil)
+			},
+			want: []Comics{
+				{ID: 1, Source: "smbc"},
+				{ID: 2, Source: "xkcd", Words: []string{"tree"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "send error",
+			setupMocks: func(db *MockDB) {
+				db.On("List", mock.Anything, ComicKey{}, batchSize).Return([]Comics{{ID: 1, Source: "xkcd"}}, nil)
+			},
+			sendErr: errors.New("client gone"),
+			want:    []Comics{{ID: 1, Source: "xkcd"}},
+			wantErr: true,
+		},
+		{
+			name: "DB error",
+			setupMocks: func(db *MockDB) {
+				db.On("List", mock.Anything, ComicKey{}, batchSize).Return([]Comics{}, errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &MockDB{}
+			tt.setupMocks(db)
+
+			service := &Service{
+				log: slog.Default(),
+				db:  db,
+			}
+
+			var got []Comics
+			err := service.Export(context.Background(), func(c Comics) error {
+				got = append(got, c)
+				return tt.sendErr
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+			db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Import(t *testing.T) {
+	many := make([]Comics, batchSize+1)
+	for i := range many {
+		many[i] = hashed(Comics{ID: i + 1, Source: "xkcd"})
+	}
+
+	tests := []struct {
+		name       string
+		input      []Comics
+		recvErr    error
+		setupMocks func(db *MockDB)
+		want       int
+		wantErr    bool
+	}{
+		{
+			name:  "batches",
+			input: many,
+			setupMocks: func(db *MockDB) {
+				db.On("Upsert", mock.Anything, many[:batchSize]).Return(nil).Once()
+				db.On("Upsert", mock.Anything, many[batchSize:]).Return(nil).Once()
+			},
+			want:    batchSize + 1,
+			wantErr: false,
+		},
+		{
+			name:       "empty input",
+			setupMocks: func(db *MockDB) {},
+			want:       0,
+			wantErr:    false,
+		},
+		{
+			name:       "comics without source",
+			input:      []Comics{{ID: 1}},
+			setupMocks: func(db *MockDB) {},
+			want:       0,
+			wantErr:    true,
+		},
+		{
+			name:       "recv error",
+			input:      []Comics{{ID: 1, Source: "xkcd"}},
+			recvErr:    errors.New("broken stream"),
+			setupMocks: func(db *MockDB) {},
+			want:       0,
+			wantErr:    true,
+		},
+		{
+			name:  "DB error",
+			input: []Comics{{ID: 1, Source: "xkcd"}},
+			setupMocks: func(db *MockDB) {
+				db.On("Upsert", mock.Anything, []Comics{hashed(Comics{ID: 1, Source: "xkcd"})}).Return(errors.New("db error"))
+			},
+			want:    0,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &MockDB{}
+			tt.setupMocks(db)
+
+			service := &Service{
+				log: slog.Default(),
+				db:  db,
+			}
+
+			input := tt.input
+			got, err := service.Import(context.Background(), func() (Comics, error) {
+				if len(input) == 0 {
+					if tt.recvErr != nil {
+						return Comics{}, tt.recvErr
+					}
+					return Comics{}, io.EOF
+				}
+				c := input[0]
+				input = input[1:]
+				return c, nil
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+			db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Verify(t *testing.T) {
+	tests := []struct {
+		name       string
+		repair     bool
+		setupMocks func(db *MockDB, xkcd *MockSource, words *MockWords)
+		want       []VerifyReport
+		wantErr    bool
+	}{
+		{
+			name:   "report only",
+			repair: false,
+			setupMocks: func(db *MockDB, xkcd *MockSource, words *MockWords) {
+				xkcd.On("List", mock.Anything).Return(seq(5), nil)
+				db.On("IDs", mock.Anything, "xkcd").Return([]int{1, 2, 4}, nil)
+				db.On("Empty", mock.Anything, "xkcd").Return([]int{4}, nil)
+			},
+			want: []VerifyReport{
+				{Source: "xkcd", Total: 5, Missing: []int{3, 5}, Empty: []int{4}},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "repair refetches only bad entries",
+			repair: true,
+			setupMocks: func(db *MockDB, xkcd *MockSource, words *MockWords) {
+				words.On("Version", mock.Anything).Return(1, nil)
+				xkcd.On("List", mock.Anything).Return(seq(3), nil)
+				db.On("IDs", mock.Anything, "xkcd").Return([]int{1, 2}, nil)
+				db.On("Empty", mock.Anything, "xkcd").Return([]int{2}, nil)
+				xkcd.On("Get", mock.Anything, 2).Return(ComicInfo{ID: 2, URL: "url2", Title: "Trees"}, nil)
+				xkcd.On("Get", mock.Anything, 3).Return(ComicInfo{}, errors.New("xkcd error"))
+				words.On("Norm", mock.Anything, "Trees").Return([]string{"tree"}, nil)
+				db.On("Upsert", mock.Anything, []Comics{
+					hashed(Comics{ID: 2, Source: "xkcd", URL: "url2", Title: "Trees", Words: []string{"tree"},
+						TitleWords: []string{"tree"}, AltWords: []string{}, TranscriptWords: []string{}, AnalyzerVersion: 1}),
+				}).Return(nil)
+			},
+			want: []VerifyReport{
+				{Source: "xkcd", Total: 3, Missing: []int{3}, Empty: []int{2}, Repaired: 1},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "DB error",
+			repair: false,
+			setupMocks: func(db *MockDB, xkcd *MockSource, words *MockWords) {
+				xkcd.On("List", mock.Anything).Return(seq(1), nil)
+				db.On("IDs", mock.Anything, "xkcd").Return([]int{}, errors.New("db error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &MockDB{}
+			xkcd := &MockSource{name: "xkcd"}
+			words := &MockWords{}
+			tt.setupMocks(db, xkcd, words)
+
+			service := &Service{
+				log:      slog.Default(),
+				db:       db,
+				sources:  []Source{xkcd},
+				words:    words,
+				pipeline: Pipeline{Fetchers: 2},
+			}
+
+			got, err := service.Verify(context.Background(), tt.repair)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+			db.AssertExpectations(t)
+			xkcd.AssertExpectations(t)
+			words.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_VerifyRepairRunning(t *testing.T) {
+	service := &Service{log: slog.Default()}
+	service.mu.Lock()
+
+	_, err := service.Verify(context.Background(), true)
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+}
+
+func TestService_Refresh(t *testing.T) {
+	barrel := hashed(Comics{ID: 1, Source: "xkcd", URL: "url1", Title: "Barrel", Alt: "Don't we all.", AnalyzerVersion: 1})
+	published := time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		setupMocks func(db *MockDB, xkcd *MockSource, words *MockWords)
+		want       int
+		wantErr    bool
+	}{
+		{
+			name: "only changed comics are revised",
+			setupMocks: func(db *MockDB, xkcd *MockSource, words *MockWords) {
+				words.On("Version", mock.Anything).Return(2, nil)
+				db.On("List", mock.Anything, ComicKey{}, batchSize).Return([]Comics{
+					barrel,
+					hashed(Comics{ID: 2, Source: "xkcd", URL: "url2", Title: "Trees"}),
+					{ID: 3, Source: "gone"},
+					{ID: 404, Source: "xkcd"},
+				}, nil)
+				db.On("List", mock.Anything, ComicKey{Source: "xkcd", ID: 404}, batchSize).Return([]Comics{}, nil)
+				xkcd.On("Get", mock.Anything, 1).Return(ComicInfo{ID: 1, URL: "url1", Title: "Barrel", Alt: "Don't we all."}, nil)
+				xkcd.On("Get", mock.Anything, 2).Return(ComicInfo{ID: 2, URL: "url2", Title: "Trees", Transcript: "fixed"}, nil)
+				xkcd.On("Get", mock.Anything, 404).Return(ComicInfo{}, Err404Comics)
+				words.On("Norm", mock.Anything, "Trees").Return([]string{"tree"}, nil)
+				words.On("Norm", mock.Anything, "fixed").Return([]string{"fix"}, nil)
+				db.On("Revise", mock.Anything, []Comics{
+					hashed(Comics{ID: 2, Source: "xkcd", URL: "url2", Title: "Trees", Transcript: "fixed",
+						Words: []string{"tree", "fix"}, TitleWords: []string{"tree"}, AltWords: []string{},
+						TranscriptWords: []string{"fix"}, AnalyzerVersion: 2}),
+				}).Return(nil)
+			},
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name: "missing publication date is filled",
+			setupMocks: func(db *MockDB, xkcd *MockSource, words *MockWords) {
+				words.On("Version", mock.Anything).Return(1, nil)
+				db.On("List", mock.Anything, ComicKey{}, batchSize).Return([]Comics{barrel}, nil)
+				db.On("List", mock.Anything, ComicKey{Source: "xkcd", ID: 1}, batchSize).Return([]Comics{}, nil)
+				xkcd.On("Get", mock.Anything, 1).Return(ComicInfo{ID: 1, URL: "url1", Title: "Barrel", Alt: "Don't we all.",
+					Published: &published}, nil)
+				words.On("Norm", mock.Anything, "Barrel").Return([]string{"barrel"}, nil)
+				words.On("Norm", mock.Anything, "Don't we all.").Return([]string{"us"}, nil)
+				db.On("Revise", mock.Anything, []Comics{
+					hashed(Comics{ID: 1, Source: "xkcd", URL: "url1", Title: "Barrel", Alt: "Don't we all.",
+						Words: []string{"barrel", "us"}, TitleWords: []string{"barrel"}, AltWords: []string{"us"},
+						TranscriptWords: []string{}, AnalyzerVersion: 1, Published: &published}),
+				}).Return(nil)
+			},
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name: "DB error",
+			setupMocks: func(db *MockDB, xkcd *MockSource, words *MockWords) {
+				words.On("Version", mock.Anything).Return(1, nil)
+				db.On("List", mock.Anything, ComicKey{}, batchSize).Return([]Comics{}, errors.New("db error"))
+			},
+			want:    0,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &MockDB{}
+			xkcd := &MockSource{name: "xkcd"}
+			words := &MockWords{}
+			tt.setupMocks(db, xkcd, words)
+
+			service := &Service{
+				log:      slog.Default(),
+				db:       db,
+				sources:  []Source{xkcd},
+				words:    words,
+				pipeline: Pipeline{Fetchers: 1},
+			}
+
+			got, err := service.Refresh(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+			db.AssertExpectations(t)
+			xkcd.AssertExpectations(t)
+			words.AssertExpectations(t)
+		})
+	}
+}
+
 func TestService_Stats(t *testing.T) {
 	tests := []struct {
 		name       string
-		setupMocks func(db *MockDB, xkcd *MockXKCD)
+		setupMocks func(db *MockDB, xkcd *MockSource)
 		want       ServiceStats
 		wantErr    bool
 	}{
 		{
 			name: "successful get stats",
-			setupMocks: func(db *MockDB, xkcd *MockXKCD) {
+			setupMocks: func(db *MockDB, xkcd *MockSource) {
 				db.On("Stats", mock.Anything).Return(DBStats{
 					ComicsFetched: 111,
 					WordsTotal:    666,
 					WordsUnique:   333,
 				}, nil)
-				xkcd.On("LastID", mock.Anything).Return(3076, nil)
+				xkcd.On("List", mock.Anything).Return(seq(3076), nil)
 			},
 			want: ServiceStats{
 				DBStats: DBStats{
@@ -188,7 +872,7 @@ func TestService_Stats(t *testing.T) {
 		},
 		{
 			name: "DB error",
-			setupMocks: func(db *MockDB, xkcd *MockXKCD) {
+			setupMocks: func(db *MockDB, xkcd *MockSource) {
 				db.On("Stats", mock.Anything).Return(DBStats{}, errors.New("db error"))
 			},
 			want:    ServiceStats{},
@@ -196,13 +880,13 @@ func TestService_Stats(t *testing.T) {
 		},
 		{
 			name: "XKCD error",
-			setupMocks: func(db *MockDB, xkcd *MockXKCD) {
+			setupMocks: func(db *MockDB, xkcd *MockSource) {
 				db.On("Stats", mock.Anything).Return(DBStats{
 					ComicsFetched: 111,
 					WordsTotal:    666,
 					WordsUnique:   333,
 				}, nil)
-				xkcd.On("LastID", mock.Anything).Return(0, errors.New("xkcd error"))
+				xkcd.On("List", mock.Anything).Return([]int{}, errors.New("xkcd error"))
 			},
 			want:    ServiceStats{},
 			wantErr: true,
@@ -212,13 +896,13 @@ func TestService_Stats(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			db := &MockDB{}
-			xkcd := &MockXKCD{}
+			xkcd := &MockSource{name: "xkcd"}
 			tt.setupMocks(db, xkcd)
 
 			service := &Service{
-				log:  slog.Default(),
-				db:   db,
-				xkcd: xkcd,
+				log:     slog.Default(),
+				db:      db,
+				sources: []Source{xkcd},
 			}
 
 			got, err := service.Stats(context.Background())
@@ -271,7 +955,7 @@ func TestService_Drop(t *testing.T) {
 			service := &Service{
 				log:       slog.Default(),
 				db:        db,
-				idsExists: map[int]struct{}{1: {}},
+				idsExists: map[string]map[int]struct{}{"xkcd": {1: {}}},
 			}
 
 			err := service.Drop(context.Background())
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTagLength is the longest tag in runes.
+const maxTagLength = 64
+
+// tagName is the tag as stored: lower case with single spaces, so that
+// "Black  Hat" and "black hat" are the same tag.
+func tagName(tag string) (string, error) {
+	name := strings.Join(strings.Fields(strings.ToLower(tag)), " ")
+	if name == "" {
+		return "", fmt.Errorf("%w: empty tag", ErrBadArguments)
+	}
+	if utf8.RuneCountInString(name) > maxTagLength {
+		return "", fmt.Errorf("%w: tag is longer than %d characters", ErrBadArguments, maxTagLength)
+	}
+	return name, nil
+}
+
+func (s *Service) Tags(ctx context.Context, key ComicKey) ([]Tag, error) {
+	tags, err := s.db.Tags(ctx, key)
+	if err != nil {
+		s.log.Error("failed to get tags", "source", key.Source, "comic_id", key.ID, "error", err)
+		return nil, err
+	}
+	return tags, nil
+}
+
+// AddTag attaches the tag to the comic. The tag is normalized by the words
+// service like the text of the comics, a tag of stop words only is refused.
+func (s *Service) AddTag(ctx context.Context, key ComicKey, tag string) (Tag, error) {
+	name, err := tagName(tag)
+	if err != nil {
+		return Tag{}, err
+	}
+	if _, err := s.source(key.Source); err != nil {
+		return Tag{}, err
+	}
+	words, err := s.words.Norm(ctx, name)
+	if err != nil {
+		s.log.Error("failed to normalize tag", "tag", name, "error", err)
+		return Tag{}, err
+	}
+	if len(words) == 0 {
+		return Tag{}, fmt.Errorf("%w: tag %q has no words to search", ErrBadArguments, name)
+	}
+
+	added := Tag{Name: name, Words: words}
+	if added.AddedAt, err = s.db.AddTag(ctx, key, added); err != nil {
+		s.log.Error("failed to add tag", "source", key.Source, "comic_id", key.ID, "tag", name, "error", err)
+		return Tag{}, err
+	}
+	s.notify(Change{Reason: ChangeTag, Comics: 1})
+	return added, nil
+}
+
+func (s *Service) RemoveTag(ctx context.Context, key ComicKey, tag string) error {
+	name, err := tagName(tag)
+	if err != nil {
+		return err
+	}
+	if err := s.db.RemoveTag(ctx, key, name); err != nil {
+		s.log.Error("failed to remove tag", "source", key.Source, "comic_id", key.ID, "tag", name, "error", err)
+		return err
+	}
+	s.notify(Change{Reason: ChangeTag, Comics: 1})
+	return nil
+}
+
+func (s *Service) TagCounts(ctx context.Context) ([]TagCount, error) {
+	counts, err := s.db.TagCounts(ctx)
+	if err != nil {
+		s.log.Error("failed to count tags", "error", err)
+		return nil, err
+	}
+	return counts, nil
+}
+
+// retag renormalizes all the tags, for them to be searched by the words of
+// the current analyzer like the comics reindexed.
+func (s *Service) retag(ctx context.Context) error {
+	tagged, err := s.db.AllTags(ctx)
+	if err != nil {
+		return err
+	}
+	for key, tags := range tagged {
+		for _, tag := range tags {
+			words, err := s.words.Norm(ctx, tag.Name)
+			if err != nil {
+				return err
+			}
+			if len(words) == 0 {
+				// the analyzer takes the tag for stop words now, it keeps
+				// the old words rather than being lost
+				continue
+			}
+			tag.Words = words
+			if _, err := s.db.AddTag(ctx, key, tag); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestService_AddTag(t *testing.T) {
+	key := ComicKey{Source: "xkcd", ID: 1}
+	added := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		key        ComicKey
+		tag        string
+		setupMocks func(db *MockDB, words *MockWords)
+		want       Tag
+		wantErr    error
+	}{
+		{
+			name: "tagged",
+			key:  key,
+			tag:  "  Black   Hat ",
+			setupMocks: func(db *MockDB, words *MockWords) {
+				words.On("Norm", mock.Anything, "black hat").Return([]string{"black", "hat"}, nil)
+				db.On("AddTag", mock.Anything, key, Tag{Name: "black hat", Words: []string{"black", "hat"}}).Return(added, nil)
+			},
+			want: Tag{Name: "black hat", Words: []string{"black", "hat"}, AddedAt: added},
+		},
+		{
+			name:       "empty tag",
+			key:        key,
+			tag:        "   ",
+			setupMocks: func(db *MockDB, words *MockWords) {},
+			wantErr:    ErrBadArguments,
+		},
+		{
+			name:       "too long tag",
+			key:        key,
+			tag:        strings.Repeat("a", maxTagLength+1),
+			setupMocks: func(db *MockDB, words *MockWords) {},
+			wantErr:    ErrBadArguments,
+		},
+		{
+			name: "stop words only",
+			key:  key,
+			tag:  "the",
+			setupMocks: func(db *MockDB, words *MockWords) {
+				words.On("Norm", mock.Anything, "the").Return([]string{}, nil)
+			},
+			wantErr: ErrBadArguments,
+		},
+		{
+			name:       "unknown source",
+			key:        ComicKey{Source: "dilbert", ID: 1},
+			tag:        "math",
+			setupMocks: func(db *MockDB, words *MockWords) {},
+			wantErr:    ErrNotFound,
+		},
+		{
+			name: "unknown comic",
+			key:  ComicKey{Source: "xkcd", ID: 99999},
+			tag:  "math",
+			setupMocks: func(db *MockDB, words *MockWords) {
+				words.On("Norm", mock.Anything, "math").Return([]string{"math"}, nil)
+				db.On("AddTag", mock.Anything, ComicKey{Source: "xkcd", ID: 99999}, Tag{Name: "math", Words: []string{"math"}}).
+					Return(time.Time{}, ErrNotFound)
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &MockDB{}
+			words := &MockWords{}
+			tt.setupMocks(db, words)
+			service := &Service{
+				log:     slog.Default(),
+				db:      db,
+				sources: []Source{&MockSource{name: "xkcd"}},
+				words:   words,
+			}
+
+			got, err := service.AddTag(context.Background(), tt.key, tt.tag)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+			db.AssertExpectations(t)
+			words.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_RemoveTag(t *testing.T) {
+	key := ComicKey{Source: "xkcd", ID: 1}
+	db := &MockDB{}
+	db.On("RemoveTag", mock.Anything, key, "black hat").Return(nil)
+	service := &Service{log: slog.Default(), db: db}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := service.Watch(ctx)
+
+	assert.NoError(t, service.RemoveTag(context.Background(), key, "Black Hat"))
+	assert.Equal(t, Change{Reason: ChangeTag, Comics: 1}, <-changes, "the watchers learn of the tag")
+	assert.ErrorIs(t, service.RemoveTag(context.Background(), key, ""), ErrBadArguments)
+	db.AssertExpectations(t)
+}
@@ -3,16 +3,21 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log/slog"
 	"net"
 	"os"
 	"os/signal"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 	updatepb "yadro.com/course/proto/update"
 	"yadro.com/course/update/adapters/db"
+	"yadro.com/course/update/adapters/dir"
 	updategrpc "yadro.com/course/update/adapters/grpc"
+	"yadro.com/course/update/adapters/jsonapi"
+	"yadro.com/course/update/adapters/rss"
 	"yadro.com/course/update/adapters/words"
 	"yadro.com/course/update/adapters/xkcd"
 	"yadro.com/course/update/config"
@@ -44,12 +49,21 @@ func run() error {
 		return err
 	}
 
-	// xkcd adapter
+	// source adapters
 	xkcd, err := xkcd.NewClient(cfg.XKCD.URL, cfg.XKCD.Timeout, log)
 	if err != nil {
 		log.Error("failed create XKCD client", "error", err)
 		return err
 	}
+	sources := []core.Source{xkcd}
+	for _, sourceCfg := range cfg.Sources {
+		source, err := newSource(sourceCfg, cfg.XKCD.Timeout, log)
+		if err != nil {
+			log.Error("failed create comic source", "source", sourceCfg.Name, "error", err)
+			return err
+		}
+		sources = append(sources, source)
+	}
 
 	// words adapter
 	words, err := words.NewClient(cfg.WordsAddress, log)
@@ -59,7 +73,17 @@ func run() error {
 	}
 
 	// service
-	updater, err := core.NewService(log, storage, xkcd, words, cfg.XKCD.Concurrency)
+	fetchers := cfg.Pipeline.Fetchers
+	if fetchers == 0 {
+		fetchers = cfg.XKCD.Concurrency
+	}
+	updater, err := core.NewService(log, storage, sources, words, core.Pipeline{
+		Fetchers:    fetchers,
+		Normalizers: cfg.Pipeline.Normalizers,
+		Persisters:  cfg.Pipeline.Persisters,
+		BatchSize:   cfg.Pipeline.BatchSize,
+		Buffer:      cfg.Pipeline.Buffer,
+	})
 	if err != nil {
 		log.Error("failed create Update service", "error", err)
 		return err
@@ -100,6 +124,27 @@ func main() {
 	}
 }
 
+func newSource(cfg config.Source, timeout time.Duration, log *slog.Logger) (core.Source, error) {
+	if cfg.Timeout != 0 {
+		timeout = cfg.Timeout
+	}
+	switch cfg.Type {
+	case config.SourceDir:
+		return dir.New(cfg.Name, cfg.Path, cfg.ImageURL, log)
+	case config.SourceRSS:
+		return rss.NewClient(cfg.Name, cfg.URL, cfg.IDPattern, timeout, log)
+	case config.SourceJSON:
+		return jsonapi.NewClient(cfg.Name, jsonapi.Config{
+			ListURL:  cfg.URL,
+			ListPath: cfg.ListPath,
+			ItemURL:  cfg.ItemURL,
+			Mapping:  jsonapi.Mapping(cfg.Mapping),
+		}, timeout, log)
+	default:
+		return nil, fmt.Errorf("unknown source type: %q", cfg.Type)
+	}
+}
+
 func mustMakeLogger(logLevel string) *slog.Logger {
 	var level slog.Level
 	switch logLevel {
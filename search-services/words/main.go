@@ -33,6 +33,33 @@ func (s *server) Norm(_ context.Context, in *wordspb.WordsRequest) (*wordspb.Wor
 	}, nil
 }
 
+func (s *server) Tokens(_ context.Context, in *wordspb.TextsRequest) (*wordspb.TextsTokensReply, error) {
+	reply := &wordspb.TextsTokensReply{
+		Texts: make([]*wordspb.TokensReply, 0, len(in.GetTexts())),
+	}
+	for _, text := range in.GetTexts() {
+		tokens := words.Tokens(text)
+		out := &wordspb.TokensReply{
+			Tokens: make([]*wordspb.Token, 0, len(tokens)),
+		}
+		for _, token := range tokens {
+			out.Tokens = append(out.Tokens, &wordspb.Token{
+				Stem:  token.Stem,
+				Start: int64(token.Start),
+				End:   int64(token.End),
+			})
+		}
+		reply.Texts = append(reply.Texts, out)
+	}
+	return reply, nil
+}
+
+func (s *server) Version(_ context.Context, _ *emptypb.Empty) (*wordspb.VersionReply, error) {
+	return &wordspb.VersionReply{
+		Version: words.AnalyzerVersion,
+	}, nil
+}
+
 func main() {
 	var cfg config
 	configPath := flag.String("config", "", "path to config file")
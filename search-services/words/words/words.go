@@ -7,6 +7,10 @@ import (
 	"github.com/kljensen/snowball"
 )
 
+// AnalyzerVersion must be bumped whenever the stemmer or the stop-word list
+// changes, so that stored keywords can be reindexed.
+const AnalyzerVersion = 1
+
 func IsStopWord(word string) bool {
 	switch word {
 	case "a", "about", "above", "after", "again", "against", "all", "am", "an",
@@ -58,3 +62,35 @@ func NormalizedString(word string) []string {
 
 	return out
 }
+
+// Token is a normalized word with its byte offsets in the original text.
+type Token struct {
+	Stem  string
+	Start int
+	End   int
+}
+
+// Tokens normalizes every word of the text like NormalizedString does, but
+// keeps repeated words and where each of them was found. Stop words are
+// skipped.
+func Tokens(text string) []Token {
+	out := []Token{}
+	start := -1
+	for i, r := range text + " " {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start < 0 {
+			continue
+		}
+		word := text[start:i]
+		if stem, err := snowball.Stem(word, "english", false); err == nil && !IsStopWord(stem) {
+			out = append(out, Token{Stem: stem, Start: start, End: i})
+		}
+		start = -1
+	}
+	return out
+}
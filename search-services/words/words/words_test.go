@@ -86,3 +86,38 @@ func TestNormalizedString_ErrorHandling(t *testing.T) {
 	result := NormalizedString("test")
 	assert.NotEmpty(t, result, "Should return not empty slice")
 }
+
+func TestTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Token
+	}{
+		{
+			"offsets",
+			"Cats, and dogs!",
+			[]Token{{Stem: "cat", Start: 0, End: 4}, {Stem: "dog", Start: 10, End: 14}},
+		},
+		{
+			"repeated words are kept",
+			"cat cats",
+			[]Token{{Stem: "cat", Start: 0, End: 3}, {Stem: "cat", Start: 4, End: 8}},
+		},
+		{
+			"multibyte text",
+			"café — tree",
+			[]Token{{Stem: "café", Start: 0, End: 5}, {Stem: "tree", Start: 10, End: 14}},
+		},
+		{
+			"empty string",
+			"",
+			[]Token{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Tokens(tt.input))
+		})
+	}
+}